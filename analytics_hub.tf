@@ -0,0 +1,79 @@
+/**
+ * Copyright 2023 Google LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+# Optional data sharing: an Analytics Hub exchange with a listing that
+# publishes the curated dataset built by Dataform. Subscribers get a read-only
+# linked dataset in their own project.
+locals {
+  enable_analytics_hub = var.enable_dataform && var.enable_analytics_hub
+}
+
+resource "google_bigquery_analytics_hub_data_exchange" "lakehouse" {
+  count    = local.enable_analytics_hub ? 1 : 0
+  provider = google-beta
+
+  project          = module.project-services.project_id
+  location         = var.region
+  data_exchange_id = "lakehouse_exchange"
+  display_name     = "Lakehouse exchange"
+  description      = "Data shared from the analytics lakehouse"
+}
+
+resource "google_bigquery_analytics_hub_listing" "curated" {
+  count    = local.enable_analytics_hub ? 1 : 0
+  provider = google-beta
+
+  project          = module.project-services.project_id
+  location         = var.region
+  data_exchange_id = google_bigquery_analytics_hub_data_exchange.lakehouse[0].data_exchange_id
+  listing_id       = "lakehouse_curated"
+  display_name     = "Lakehouse curated layer"
+  description      = "Order details and daily sales curated from the thelook ecommerce data"
+
+  bigquery_dataset {
+    dataset = google_bigquery_dataset.gcp_lakehouse_curated[0].id
+  }
+}
+
+# # Set up the subscriber, who can only reach the curated data through the listing
+resource "google_service_account" "hub_subscriber" {
+  count = local.enable_analytics_hub ? 1 : 0
+
+  project      = module.project-services.project_id
+  account_id   = "user-subscriber-sa-${random_id.id.hex}"
+  display_name = "Service Account for Analytics Hub subscriber"
+}
+
+# Subscribing creates the linked dataset and querying it runs jobs
+resource "google_project_iam_member" "hub_subscriber_bigquery_user" {
+  count = local.enable_analytics_hub ? 1 : 0
+
+  project = module.project-services.project_id
+  role    = "roles/bigquery.user"
+  member  = "serviceAccount:${google_service_account.hub_subscriber[0].email}"
+}
+
+resource "google_bigquery_analytics_hub_listing_iam_member" "hub_subscriber" {
+  count    = local.enable_analytics_hub ? 1 : 0
+  provider = google-beta
+
+  project          = module.project-services.project_id
+  location         = var.region
+  data_exchange_id = google_bigquery_analytics_hub_listing.curated[0].data_exchange_id
+  listing_id       = google_bigquery_analytics_hub_listing.curated[0].listing_id
+  role             = "roles/analyticshub.subscriber"
+  member           = "serviceAccount:${google_service_account.hub_subscriber[0].email}"
+}
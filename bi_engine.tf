@@ -0,0 +1,46 @@
+/**
+ * Copyright 2023 Google LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+# Optional BI Engine reservation. A project has a single reservation per
+# location; listing the curated tables as preferred keeps the capacity for
+# the dashboards built on them.
+locals {
+  enable_bi_engine = var.enable_dataform && var.bi_engine_reservation_gb > 0
+
+  # One curated table per Dataform model
+  bi_engine_tables = [
+    for f in fileset("${path.module}/src/dataform", "definitions/curated/*.sqlx") : trimsuffix(basename(f), ".sqlx")
+  ]
+}
+
+resource "google_bigquery_bi_reservation" "curated" {
+  count = local.enable_bi_engine ? 1 : 0
+
+  project  = module.project-services.project_id
+  location = var.region
+  size     = var.bi_engine_reservation_gb * 1024 * 1024 * 1024
+
+  dynamic "preferred_tables" {
+    for_each = local.bi_engine_tables
+    content {
+      project_id = module.project-services.project_id
+      dataset_id = google_bigquery_dataset.gcp_lakehouse_curated[0].dataset_id
+      table_id   = preferred_tables.value
+    }
+  }
+
+  depends_on = [time_sleep.wait_after_apis_activate]
+}
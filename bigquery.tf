@@ -16,7 +16,15 @@
 
 # Set up BigQuery resources
 # # Create the BigQuery dataset
+locals {
+  # The SQL and workflows refer to the dataset by name, so an existing one
+  # must have the same name
+  lakehouse_dataset_id = var.create_lakehouse_dataset ? one(google_bigquery_dataset.gcp_lakehouse_ds[*].dataset_id) : "gcp_lakehouse_ds"
+}
+
 resource "google_bigquery_dataset" "gcp_lakehouse_ds" {
+  count = var.create_lakehouse_dataset ? 1 : 0
+
   project                    = module.project-services.project_id
   dataset_id                 = "gcp_lakehouse_ds"
   friendly_name              = "My gcp_lakehouse Dataset"
@@ -24,6 +32,18 @@ resource "google_bigquery_dataset" "gcp_lakehouse_ds" {
   location                   = var.region
   labels                     = var.labels
   delete_contents_on_destroy = var.force_destroy
+
+  dynamic "default_encryption_configuration" {
+    for_each = local.kms_key_name == null ? [] : [local.kms_key_name]
+    content {
+      kms_key_name = default_encryption_configuration.value
+    }
+  }
+}
+
+moved {
+  from = google_bigquery_dataset.gcp_lakehouse_ds
+  to   = google_bigquery_dataset.gcp_lakehouse_ds[0]
 }
 
 # # Create a BigQuery connection
@@ -37,18 +57,156 @@ resource "google_bigquery_connection" "gcp_lakehouse_connection" {
 
 
 
-## This grants permissions to the service account of the connection created in the last step.
-resource "google_project_iam_member" "connectionPermissionGrant" {
-  project = module.project-services.project_id
-  role    = "roles/storage.objectViewer"
-  member  = format("serviceAccount:%s", google_bigquery_connection.gcp_lakehouse_connection.cloud_resource[0].service_account_id)
+## This grants the connection's service account read access to the data buckets only.
+resource "google_storage_bucket_iam_member" "connectionPermissionGrant" {
+  for_each = {
+    tables         = local.tables_bucket
+    ga4_images     = google_storage_bucket.ga4_images_bucket.name
+    textocr_images = google_storage_bucket.textocr_images_bucket.name
+  }
+
+  bucket = each.value
+  role   = "roles/storage.objectViewer"
+  member = format("serviceAccount:%s", google_bigquery_connection.gcp_lakehouse_connection.cloud_resource[0].service_account_id)
 }
 
 resource "google_bigquery_routine" "create_view_ecommerce" {
   project         = module.project-services.project_id
-  dataset_id      = google_bigquery_dataset.gcp_lakehouse_ds.dataset_id
+  dataset_id      = local.lakehouse_dataset_id
   routine_id      = "create_view_ecommerce"
   routine_type    = "PROCEDURE"
   language        = "SQL"
   definition_body = file("${path.module}/src/sql/view_ecommerce.sql")
 }
+
+# Deduplicating upsert the workflows use to load the native lakehouse tables,
+# so rerunning a load never duplicates keys.
+resource "google_bigquery_routine" "upsert_table" {
+  project         = module.project-services.project_id
+  dataset_id      = local.lakehouse_dataset_id
+  routine_id      = "upsert_table"
+  routine_type    = "PROCEDURE"
+  language        = "SQL"
+  definition_body = file("${path.module}/src/sql/upsert_table.sql")
+
+  arguments {
+    name      = "source_table"
+    data_type = jsonencode({ typeKind = "STRING" })
+  }
+
+  arguments {
+    name      = "target_table"
+    data_type = jsonencode({ typeKind = "STRING" })
+  }
+
+  arguments {
+    name      = "key_columns"
+    data_type = jsonencode({ typeKind = "ARRAY", arrayElementType = { typeKind = "STRING" } })
+  }
+}
+
+# Attribute-based access: the marketing user can read only the lakehouse
+# dataset, and only for 30 days after apply.
+resource "time_offset" "conditional_access_expiry" {
+  count = var.enable_conditional_access ? 1 : 0
+
+  offset_days = 30
+}
+
+resource "google_project_iam_member" "marketing_user_conditional_viewer" {
+  count = var.enable_conditional_access ? 1 : 0
+
+  project = module.project-services.project_id
+  role    = "roles/bigquery.dataViewer"
+  member  = "serviceAccount:${google_service_account.marketing_user.email}"
+
+  condition {
+    title       = "lakehouse-dataset-time-bound"
+    description = "Read access to the lakehouse dataset until ${time_offset.conditional_access_expiry[0].rfc3339}"
+    expression  = "resource.name.startsWith(\"projects/${module.project-services.project_id}/datasets/${local.lakehouse_dataset_id}\") && request.time < timestamp(\"${time_offset.conditional_access_expiry[0].rfc3339}\")"
+  }
+}
+
+# Access layer: curated views consumers can query without access to the
+# staging tables behind them.
+resource "google_bigquery_dataset" "gcp_lakehouse_access" {
+  count = var.enable_access_layer ? 1 : 0
+
+  project                    = module.project-services.project_id
+  dataset_id                 = "gcp_lakehouse_access"
+  friendly_name              = "Lakehouse access layer"
+  description                = "Curated views over the lakehouse staging tables"
+  location                   = var.region
+  labels                     = var.labels
+  delete_contents_on_destroy = var.force_destroy
+
+  dynamic "default_encryption_configuration" {
+    for_each = local.kms_key_name == null ? [] : [local.kms_key_name]
+    content {
+      kms_key_name = default_encryption_configuration.value
+    }
+  }
+}
+
+resource "google_bigquery_routine" "create_access_views" {
+  count = var.enable_access_layer ? 1 : 0
+
+  project         = module.project-services.project_id
+  dataset_id      = google_bigquery_dataset.gcp_lakehouse_access[0].dataset_id
+  routine_id      = "create_access_views"
+  routine_type    = "PROCEDURE"
+  language        = "SQL"
+  definition_body = file("${path.module}/src/sql/access_views.sql")
+}
+
+# # Authorize every view in the access layer on the Dataplex staging dataset
+resource "google_bigquery_dataset_access" "staging_authorized_access" {
+  count = var.enable_access_layer ? 1 : 0
+
+  project    = module.project-services.project_id
+  dataset_id = replace(google_dataplex_zone.gcp_primary_staging.name, "-", "_")
+
+  dataset {
+    dataset {
+      project_id = module.project-services.project_id
+      dataset_id = google_bigquery_dataset.gcp_lakehouse_access[0].dataset_id
+    }
+    target_types = ["VIEWS"]
+  }
+}
+
+# Allow the workflows service account to create the access layer views
+resource "google_bigquery_dataset_iam_member" "workflows_sa_access_views" {
+  count = var.enable_access_layer ? 1 : 0
+
+  project    = module.project-services.project_id
+  dataset_id = google_bigquery_dataset.gcp_lakehouse_access[0].dataset_id
+  role       = "roles/bigquery.dataEditor"
+  member     = "serviceAccount:${google_service_account.workflows_sa.email}"
+}
+
+# # Set up the access layer consumer, who can only read the curated views
+resource "google_service_account" "access_consumer" {
+  count = var.enable_access_layer ? 1 : 0
+
+  project      = module.project-services.project_id
+  account_id   = "user-consumer-sa-${random_id.id.hex}"
+  display_name = "Service Account for access layer consumer"
+}
+
+resource "google_project_iam_member" "access_consumer_job_user" {
+  count = var.enable_access_layer ? 1 : 0
+
+  project = module.project-services.project_id
+  role    = "roles/bigquery.jobUser"
+  member  = "serviceAccount:${google_service_account.access_consumer[0].email}"
+}
+
+resource "google_bigquery_dataset_iam_member" "access_consumer_viewer" {
+  count = var.enable_access_layer ? 1 : 0
+
+  project    = module.project-services.project_id
+  dataset_id = google_bigquery_dataset.gcp_lakehouse_access[0].dataset_id
+  role       = "roles/bigquery.dataViewer"
+  member     = "serviceAccount:${google_service_account.access_consumer[0].email}"
+}
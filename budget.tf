@@ -0,0 +1,80 @@
+/**
+ * Copyright 2023 Google LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+# Optional monthly budget on the billing account, scoped to the project and
+# to resources carrying the blueprint's analytics-lakehouse label, that emails
+# the budget_alert_emails through Cloud Monitoring as spend and forecasted
+# spend cross its thresholds.
+locals {
+  enable_budget = var.budget_billing_account != ""
+  budget_label  = "analytics-lakehouse"
+}
+
+resource "google_monitoring_notification_channel" "budget" {
+  for_each = local.enable_budget ? toset(var.budget_alert_emails) : toset([])
+
+  project      = module.project-services.project_id
+  display_name = "Lakehouse budget ${each.key}"
+  type         = "email"
+  labels = {
+    email_address = each.key
+  }
+  user_labels = var.labels
+
+  depends_on = [time_sleep.wait_after_apis_activate]
+}
+
+resource "google_billing_budget" "lakehouse" {
+  count = local.enable_budget ? 1 : 0
+
+  billing_account = var.budget_billing_account
+  display_name    = "gcp-${var.use_case_short}-${random_id.id.hex}"
+
+  budget_filter {
+    projects               = ["projects/${data.google_project.project.number}"]
+    labels                 = { (local.budget_label) = lookup(var.labels, local.budget_label, "true") }
+    credit_types_treatment = "INCLUDE_ALL_CREDITS"
+    calendar_period        = "MONTH"
+  }
+
+  amount {
+    specified_amount {
+      currency_code = "USD"
+      units         = tostring(var.budget_amount)
+    }
+  }
+
+  threshold_rules {
+    threshold_percent = 0.5
+  }
+  threshold_rules {
+    threshold_percent = 0.9
+  }
+  threshold_rules {
+    threshold_percent = 1.0
+  }
+  threshold_rules {
+    threshold_percent = 1.0
+    spend_basis       = "FORECASTED_SPEND"
+  }
+
+  all_updates_rule {
+    monitoring_notification_channels = [for c in google_monitoring_notification_channel.budget : c.id]
+    disable_default_iam_recipients   = length(var.budget_alert_emails) > 0
+  }
+
+  depends_on = [time_sleep.wait_after_apis_activate]
+}
@@ -0,0 +1,118 @@
+/**
+ * Copyright 2023 Google LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+# Optional BigQuery Data Transfer Service configs, running as the data-plane
+# service account: a scheduled query that merges the orders into daily
+# aggregates, and a Cloud Storage transfer that loads the raw orders files
+# without the workflows. The transfer creates its destination table's schema
+# from the files, which only Parquet describes.
+locals {
+  enable_transfer_load = var.enable_transfer_load && var.raw_data_format == "PARQUET"
+  enable_data_transfer = var.enable_scheduled_queries || local.enable_transfer_load
+}
+
+resource "google_project_service_identity" "data_transfer" {
+  count    = local.enable_data_transfer ? 1 : 0
+  provider = google-beta
+
+  project = module.project-services.project_id
+  service = "bigquerydatatransfer.googleapis.com"
+}
+
+# The transfer service mints tokens for the service account its runs use
+resource "google_service_account_iam_member" "data_transfer_token_creator" {
+  count = local.enable_data_transfer ? 1 : 0
+
+  service_account_id = google_service_account.dataproc_service_account.name
+  role               = "roles/iam.serviceAccountTokenCreator"
+  member             = "serviceAccount:${google_project_service_identity.data_transfer[0].email}"
+}
+
+resource "google_bigquery_table" "daily_order_aggregates" {
+  count = var.enable_scheduled_queries ? 1 : 0
+
+  project             = module.project-services.project_id
+  dataset_id          = local.lakehouse_dataset_id
+  table_id            = "daily_order_aggregates"
+  description         = "Orders and items per day and status, maintained by the daily-order-aggregates scheduled query"
+  labels              = var.labels
+  deletion_protection = !var.force_destroy
+
+  time_partitioning {
+    type  = "DAY"
+    field = "order_date"
+  }
+
+  schema = jsonencode([
+    { name = "order_date", type = "DATE", mode = "REQUIRED" },
+    { name = "status", type = "STRING", mode = "NULLABLE" },
+    { name = "orders", type = "INTEGER", mode = "NULLABLE" },
+    { name = "items", type = "INTEGER", mode = "NULLABLE" },
+    { name = "updated_at", type = "TIMESTAMP", mode = "NULLABLE" },
+  ])
+}
+
+resource "google_bigquery_data_transfer_config" "daily_order_aggregates" {
+  count = var.enable_scheduled_queries ? 1 : 0
+
+  project              = module.project-services.project_id
+  location             = var.region
+  display_name         = "daily-order-aggregates"
+  data_source_id       = "scheduled_query"
+  schedule             = "every day 03:00"
+  service_account_name = google_service_account.dataproc_service_account.email
+  params = {
+    query = file("${path.module}/src/sql/daily_order_aggregates.sql")
+  }
+
+  depends_on = [
+    google_bigquery_table.daily_order_aggregates,
+    google_service_account_iam_member.data_transfer_token_creator
+  ]
+}
+
+# Cloud Storage transfers need an existing destination table. The Parquet files
+# describe themselves, so the schema is set by the first run.
+resource "google_bigquery_table" "orders_transfer" {
+  count = local.enable_transfer_load ? 1 : 0
+
+  project             = module.project-services.project_id
+  dataset_id          = local.lakehouse_dataset_id
+  table_id            = "thelook_ecommerce_orders_transfer"
+  description         = "thelook orders loaded from the tables bucket by the orders-transfer Cloud Storage transfer"
+  labels              = var.labels
+  deletion_protection = !var.force_destroy
+}
+
+resource "google_bigquery_data_transfer_config" "orders_transfer" {
+  count = local.enable_transfer_load ? 1 : 0
+
+  project                = module.project-services.project_id
+  location               = var.region
+  display_name           = "orders-transfer"
+  data_source_id         = "google_cloud_storage"
+  destination_dataset_id = local.lakehouse_dataset_id
+  schedule               = "every day 04:00"
+  service_account_name   = google_service_account.dataproc_service_account.email
+  params = {
+    destination_table_name_template = google_bigquery_table.orders_transfer[0].table_id
+    data_path_template              = "gs://${local.tables_bucket}/thelook_ecommerce/orders/*"
+    file_format                     = "PARQUET"
+    write_disposition               = "MIRROR"
+  }
+
+  depends_on = [google_service_account_iam_member.data_transfer_token_creator]
+}
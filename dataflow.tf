@@ -0,0 +1,30 @@
+/**
+ * Copyright 2023 Google LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+# Optional Dataflow load path: the project-setup workflow launches the
+# Google-provided GCS_Text_to_BigQuery_Flex template, transforming the
+# distribution centers CSV with a JavaScript UDF on the way in.
+resource "google_storage_bucket_object" "dataflow_files" {
+  for_each = toset(var.enable_dataflow_load ? [
+    "distribution_centers.csv",
+    "distribution_centers.js",
+    "distribution_centers_schema.json",
+  ] : [])
+
+  bucket = google_storage_bucket.provisioning_bucket.name
+  name   = "dataflow/${each.key}"
+  source = "${path.module}/src/dataflow/${each.key}"
+}
@@ -0,0 +1,110 @@
+/**
+ * Copyright 2023 Google LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+# Optional transformation layer: a Dataform repository whose SQLX models in
+# src/dataform build the curated tables from the thelook staging tables. The
+# project-setup workflow writes the models into a workspace, compiles them and
+# invokes the compilation result.
+resource "google_project_service_identity" "dataform" {
+  count    = var.enable_dataform ? 1 : 0
+  provider = google-beta
+
+  project = module.project-services.project_id
+  service = "dataform.googleapis.com"
+}
+
+resource "google_dataform_repository" "lakehouse" {
+  count    = var.enable_dataform ? 1 : 0
+  provider = google-beta
+
+  project = module.project-services.project_id
+  region  = var.region
+  name    = "lakehouse-curated"
+}
+
+resource "google_bigquery_dataset" "gcp_lakehouse_curated" {
+  count = var.enable_dataform ? 1 : 0
+
+  project                    = module.project-services.project_id
+  dataset_id                 = "gcp_lakehouse_curated"
+  friendly_name              = "Lakehouse curated layer"
+  description                = "Curated tables built by the lakehouse-curated Dataform repository"
+  location                   = var.region
+  labels                     = var.labels
+  delete_contents_on_destroy = var.force_destroy
+
+  dynamic "default_encryption_configuration" {
+    for_each = local.kms_key_name == null ? [] : [local.kms_key_name]
+    content {
+      kms_key_name = default_encryption_configuration.value
+    }
+  }
+}
+
+# # Dataform runs as its service agent: it reads staging and writes curated
+resource "google_project_iam_member" "dataform_job_user" {
+  count = var.enable_dataform ? 1 : 0
+
+  project = module.project-services.project_id
+  role    = "roles/bigquery.jobUser"
+  member  = "serviceAccount:${google_project_service_identity.dataform[0].email}"
+}
+
+resource "google_bigquery_dataset_iam_member" "dataform_staging_viewer" {
+  count = var.enable_dataform ? 1 : 0
+
+  project    = module.project-services.project_id
+  dataset_id = replace(google_dataplex_zone.gcp_primary_staging.name, "-", "_")
+  role       = "roles/bigquery.dataViewer"
+  member     = "serviceAccount:${google_project_service_identity.dataform[0].email}"
+}
+
+resource "google_bigquery_dataset_iam_member" "dataform_curated_editor" {
+  count = var.enable_dataform ? 1 : 0
+
+  project    = module.project-services.project_id
+  dataset_id = google_bigquery_dataset.gcp_lakehouse_curated[0].dataset_id
+  role       = "roles/bigquery.dataEditor"
+  member     = "serviceAccount:${google_project_service_identity.dataform[0].email}"
+}
+
+locals {
+  # Workspace file contents keyed by path, base64-encoded for writeFile
+  dataform_files = var.enable_dataform ? merge(
+    {
+      for f in fileset("${path.module}/src/dataform", "definitions/**/*.sqlx") :
+      f => filebase64("${path.module}/src/dataform/${f}")
+    },
+    {
+      "workflow_settings.yaml" = base64encode(templatefile("${path.module}/src/dataform/workflow_settings.yaml", {
+        project_id = module.project-services.project_id
+        region     = var.region
+      }))
+    }
+  ) : {}
+}
+
+# # Allow the workflows service account to write, compile and run the models
+resource "google_dataform_repository_iam_member" "workflows_sa_editor" {
+  count    = var.enable_dataform ? 1 : 0
+  provider = google-beta
+
+  project    = module.project-services.project_id
+  region     = var.region
+  repository = google_dataform_repository.lakehouse[0].name
+  role       = "roles/dataform.editor"
+  member     = "serviceAccount:${google_service_account.workflows_sa.email}"
+}
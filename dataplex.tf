@@ -33,9 +33,9 @@ resource "google_dataplex_lake" "gcp_primary" {
   description  = "gcp primary lake"
   display_name = "gcp primary lake"
 
-  labels = {
+  labels = merge(var.labels, {
     gcp-lake = "exists"
-  }
+  })
 
   project = module.project-services.project_id
 
@@ -45,10 +45,42 @@ resource "google_dataplex_lake" "gcp_primary" {
 
 }
 
+# Let the data analyst discover and read lake data without project-level roles
+resource "google_dataplex_lake_iam_member" "data_analyst_lake_roles" {
+  for_each = toset([
+    "roles/dataplex.dataReader",
+    "roles/dataplex.metadataReader",
+  ])
+
+  project  = module.project-services.project_id
+  location = var.region
+  lake     = google_dataplex_lake.gcp_primary.name
+  role     = each.key
+  member   = "serviceAccount:${google_service_account.data_analyst_user.email}"
+}
+
+# Discovery settings shared by every zone. Iceberg metadata directories are
+# excluded so discovery does not publish them as duplicate entities.
+locals {
+  discovery_schedule         = "0 */6 * * *"
+  discovery_exclude_patterns = ["**/metadata/**"]
+}
+
 #zone - raw
 resource "google_dataplex_zone" "gcp_primary_raw" {
   discovery_spec {
-    enabled = true
+    enabled          = true
+    schedule         = local.discovery_schedule
+    exclude_patterns = local.discovery_exclude_patterns
+
+    csv_options {
+      header_rows = 1
+      delimiter   = ","
+    }
+
+    json_options {
+      encoding = "UTF-8"
+    }
   }
 
   lake     = google_dataplex_lake.gcp_primary.name
@@ -62,7 +94,7 @@ resource "google_dataplex_zone" "gcp_primary_raw" {
   type         = "RAW"
   description  = "Zone for thelook_ecommerce image data"
   display_name = "images"
-  labels       = {}
+  labels       = var.labels
   project      = module.project-services.project_id
 
 
@@ -71,7 +103,18 @@ resource "google_dataplex_zone" "gcp_primary_raw" {
 #zone - curated, for staging the data
 resource "google_dataplex_zone" "gcp_primary_staging" {
   discovery_spec {
-    enabled = true
+    enabled          = true
+    schedule         = local.discovery_schedule
+    exclude_patterns = local.discovery_exclude_patterns
+
+    csv_options {
+      header_rows = 1
+      delimiter   = ","
+    }
+
+    json_options {
+      encoding = "UTF-8"
+    }
   }
 
   lake     = google_dataplex_lake.gcp_primary.name
@@ -85,14 +128,25 @@ resource "google_dataplex_zone" "gcp_primary_staging" {
   type         = "CURATED"
   description  = "Zone for thelook_ecommerce tabular data"
   display_name = "staging"
-  labels       = {}
+  labels       = var.labels
   project      = module.project-services.project_id
 }
 
 #zone - curated, for BI
 resource "google_dataplex_zone" "gcp_primary_curated_bi" {
   discovery_spec {
-    enabled = true
+    enabled          = true
+    schedule         = local.discovery_schedule
+    exclude_patterns = local.discovery_exclude_patterns
+
+    csv_options {
+      header_rows = 1
+      delimiter   = ","
+    }
+
+    json_options {
+      encoding = "UTF-8"
+    }
   }
 
   lake     = google_dataplex_lake.gcp_primary.name
@@ -106,7 +160,7 @@ resource "google_dataplex_zone" "gcp_primary_curated_bi" {
   type         = "CURATED"
   description  = "Zone for thelook_ecommerce tabular data"
   display_name = "business_intelligence"
-  labels       = {}
+  labels       = var.labels
   project      = module.project-services.project_id
 }
 
@@ -130,6 +184,7 @@ resource "google_dataplex_asset" "gcp_primary_textocr" {
     read_access_mode = "MANAGED"
   }
 
+  labels     = var.labels
   project    = module.project-services.project_id
   depends_on = [time_sleep.wait_after_copy_data]
 
@@ -153,6 +208,7 @@ resource "google_dataplex_asset" "gcp_primary_ga4_obfuscated_sample_ecommerce" {
     read_access_mode = "MANAGED"
   }
 
+  labels     = var.labels
   project    = module.project-services.project_id
   depends_on = [time_sleep.wait_after_copy_data]
 
@@ -171,11 +227,12 @@ resource "google_dataplex_asset" "gcp_primary_tables" {
   }
 
   resource_spec {
-    name             = "projects/${module.project-services.project_id}/buckets/${google_storage_bucket.tables_bucket.name}"
+    name             = "projects/${module.project-services.project_id}/buckets/${local.tables_bucket}"
     type             = "STORAGE_BUCKET"
     read_access_mode = "MANAGED"
   }
 
+  labels     = var.labels
   project    = module.project-services.project_id
   depends_on = [time_sleep.wait_after_copy_data]
 }
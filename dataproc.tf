@@ -15,8 +15,27 @@
  */
 
 #ICEBERG setup
-# Set up networking
+# Set up networking, unless Dataproc attaches to an existing or Shared VPC subnet
+locals {
+  use_shared_vpc  = var.shared_vpc_subnetwork != null
+  use_byo_network = var.subnetwork_self_link != null
+  create_network  = !local.use_shared_vpc && !local.use_byo_network
+
+  # Network tag on Dataproc cluster nodes and serverless batches
+  dataproc_network_tag = "dataproc"
+
+  # Network in the project that NAT and Private Service Connect attach to
+  network    = local.create_network ? one(google_compute_network.default_network[*].id) : var.network_self_link
+  subnetwork = (
+    local.use_shared_vpc ? var.shared_vpc_subnetwork :
+    local.use_byo_network ? var.subnetwork_self_link :
+    google_compute_subnetwork.subnet[0].self_link
+  )
+}
+
 resource "google_compute_network" "default_network" {
+  count = local.create_network ? 1 : 0
+
   project                 = module.project-services.project_id
   name                    = "vpc-${var.use_case_short}"
   description             = "Default network"
@@ -24,20 +43,34 @@ resource "google_compute_network" "default_network" {
   mtu                     = 1460
 }
 
+moved {
+  from = google_compute_network.default_network
+  to   = google_compute_network.default_network[0]
+}
+
 resource "google_compute_subnetwork" "subnet" {
+  count = local.create_network ? 1 : 0
+
   project                  = module.project-services.project_id
   name                     = "dataproc-subnet"
   ip_cidr_range            = "10.3.0.0/16"
   region                   = var.region
-  network                  = google_compute_network.default_network.id
+  network                  = google_compute_network.default_network[0].id
   private_ip_google_access = true
 }
 
+moved {
+  from = google_compute_subnetwork.subnet
+  to   = google_compute_subnetwork.subnet[0]
+}
+
 # Firewall rule for dataproc cluster
 resource "google_compute_firewall" "subnet_firewall_rule" {
+  count = local.create_network ? 1 : 0
+
   project = module.project-services.project_id
   name    = "dataproc-firewall"
-  network = google_compute_network.default_network.id
+  network = google_compute_network.default_network[0].id
 
   allow {
     protocol = "icmp"
@@ -51,15 +84,173 @@ resource "google_compute_firewall" "subnet_firewall_rule" {
     protocol = "udp"
   }
   source_ranges = ["10.3.0.0/16"]
+  target_tags   = [local.dataproc_network_tag]
 
   depends_on = [
     google_compute_subnetwork.subnet
   ]
 }
 
+moved {
+  from = google_compute_firewall.subnet_firewall_rule
+  to   = google_compute_firewall.subnet_firewall_rule[0]
+}
+
+# Optional internet egress for the internal-only Dataproc nodes
+resource "google_compute_router" "nat_router" {
+  count = var.enable_nat && !local.use_shared_vpc ? 1 : 0
+
+  project = module.project-services.project_id
+  name    = "dataproc-router"
+  region  = var.region
+  network = local.network
+}
+
+resource "google_compute_router_nat" "nat" {
+  count = var.enable_nat && !local.use_shared_vpc ? 1 : 0
+
+  project                            = module.project-services.project_id
+  name                               = "dataproc-nat"
+  router                             = google_compute_router.nat_router[0].name
+  region                             = var.region
+  nat_ip_allocate_option             = "AUTO_ONLY"
+  source_subnetwork_ip_ranges_to_nat = "ALL_SUBNETWORKS_ALL_IP_RANGES"
+
+  log_config {
+    enable = true
+    filter = "ERRORS_ONLY"
+  }
+}
+
+# Optional Serverless VPC Access connector for serverless integrations
+resource "google_vpc_access_connector" "connector" {
+  count = var.enable_vpc_connector && !local.use_shared_vpc ? 1 : 0
+
+  project       = module.project-services.project_id
+  name          = "lakehouse-connector"
+  region        = var.region
+  network       = local.network
+  ip_cidr_range = "10.8.0.0/28"
+  machine_type  = "e2-micro"
+  min_instances = 2
+  max_instances = 3
+
+  depends_on = [time_sleep.wait_after_apis_activate]
+}
+
+# Optional private routing for Google APIs, through either a Private Service
+# Connect endpoint or the restricted.googleapis.com VIP. The private
+# googleapis.com zone points every API hostname at the chosen addresses.
+locals {
+  enable_psc             = var.enable_private_service_connect && !local.use_shared_vpc
+  enable_restricted_apis = var.enable_restricted_api_access && !local.use_shared_vpc && !local.enable_psc
+  psc_address            = "10.10.0.5"
+  restricted_api_range   = "199.36.153.4/30"
+
+  googleapis_domain    = local.enable_psc ? "private.googleapis.com." : "restricted.googleapis.com."
+  googleapis_addresses = local.enable_psc ? google_compute_global_forwarding_rule.psc_apis[*].ip_address : [for i in range(4) : cidrhost(local.restricted_api_range, i)]
+}
+
+resource "google_compute_global_address" "psc_apis" {
+  count = local.enable_psc ? 1 : 0
+
+  project      = module.project-services.project_id
+  name         = "psc-googleapis"
+  purpose      = "PRIVATE_SERVICE_CONNECT"
+  address_type = "INTERNAL"
+  address      = local.psc_address
+  network      = local.network
+}
+
+resource "google_compute_global_forwarding_rule" "psc_apis" {
+  count = local.enable_psc ? 1 : 0
+
+  project               = module.project-services.project_id
+  name                  = "lakehouseapis"
+  target                = "all-apis"
+  network               = local.network
+  ip_address            = google_compute_global_address.psc_apis[0].id
+  load_balancing_scheme = ""
+}
 
-# Set up Dataproc service account for the Cloud Function to execute as
-# # Set up the Dataproc service account
+# The restricted VIP is reached through the default internet gateway, even
+# if the network's default route is removed.
+resource "google_compute_route" "restricted_apis" {
+  count = local.enable_restricted_apis ? 1 : 0
+
+  project          = module.project-services.project_id
+  name             = "restricted-googleapis"
+  dest_range       = local.restricted_api_range
+  network          = local.network
+  next_hop_gateway = "default-internet-gateway"
+}
+
+resource "google_dns_managed_zone" "googleapis" {
+  count = local.enable_psc || local.enable_restricted_apis ? 1 : 0
+
+  project     = module.project-services.project_id
+  name        = "googleapis"
+  dns_name    = "googleapis.com."
+  description = "Resolves Google APIs to ${trimsuffix(local.googleapis_domain, ".")}"
+  visibility  = "private"
+
+  private_visibility_config {
+    networks {
+      network_url = local.network
+    }
+  }
+
+  depends_on = [time_sleep.wait_after_apis_activate]
+}
+
+resource "google_dns_record_set" "googleapis_a" {
+  count = local.enable_psc || local.enable_restricted_apis ? 1 : 0
+
+  project      = module.project-services.project_id
+  managed_zone = google_dns_managed_zone.googleapis[0].name
+  name         = local.googleapis_domain
+  type         = "A"
+  ttl          = 300
+  rrdatas      = local.googleapis_addresses
+}
+
+resource "google_dns_record_set" "googleapis_cname" {
+  count = local.enable_psc || local.enable_restricted_apis ? 1 : 0
+
+  project      = module.project-services.project_id
+  managed_zone = google_dns_managed_zone.googleapis[0].name
+  name         = "*.googleapis.com."
+  type         = "CNAME"
+  ttl          = 300
+  rrdatas      = [google_dns_record_set.googleapis_a[0].name]
+}
+
+# # Let the Dataproc service agents create VMs on the Shared VPC subnet
+data "google_compute_subnetwork" "shared" {
+  count = local.use_shared_vpc ? 1 : 0
+
+  project   = var.shared_vpc_host_project_id
+  self_link = var.shared_vpc_subnetwork
+}
+
+resource "google_compute_subnetwork_iam_member" "shared_vpc_network_users" {
+  for_each = toset(local.use_shared_vpc ? [
+    "serviceAccount:service-${data.google_project.project.number}@dataproc-accounts.iam.gserviceaccount.com",
+    "serviceAccount:${data.google_project.project.number}@cloudservices.gserviceaccount.com",
+  ] : [])
+
+  project    = data.google_compute_subnetwork.shared[0].project
+  region     = data.google_compute_subnetwork.shared[0].region
+  subnetwork = data.google_compute_subnetwork.shared[0].name
+  role       = "roles/compute.networkUser"
+  member     = each.key
+
+  depends_on = [time_sleep.wait_after_apis_activate]
+}
+
+
+# Set up the Dataproc service account. This is the lakehouse data-plane
+# identity: it owns every data write, including the copy-data workflow.
 resource "google_service_account" "dataproc_service_account" {
   project      = module.project-services.project_id
   account_id   = "dataproc-sa-${random_id.id.hex}"
@@ -67,14 +258,16 @@ resource "google_service_account" "dataproc_service_account" {
 }
 
 resource "google_project_iam_member" "dataproc_sa_roles" {
-  for_each = toset([
+  for_each = toset(concat([
     "roles/storage.objectAdmin",
     "roles/bigquery.connectionAdmin",
     "roles/biglake.admin",
     "roles/bigquery.dataOwner",
     "roles/bigquery.user",
     "roles/dataproc.worker",
-  ])
+    "roles/workflows.viewer",
+    "roles/logging.logWriter",
+  ], var.enable_dataflow_load ? ["roles/dataflow.worker"] : [], var.enable_serving_export ? ["roles/cloudsql.admin"] : [], var.enable_firestore_export ? ["roles/datastore.user"] : [], var.enable_dlp_deidentify ? ["roles/dlp.user", "roles/dlp.deidentifyTemplatesReader"] : []))
 
   project = module.project-services.project_id
   role    = each.key
@@ -90,11 +283,11 @@ resource "google_bigquery_connection" "ds_connection" {
   cloud_resource {}
 }
 
-# # Grant IAM access to the BigQuery Connection account for Cloud Storage
-resource "google_project_iam_member" "bq_connection_iam_object_viewer" {
-  project = module.project-services.project_id
-  role    = "roles/storage.objectViewer"
-  member  = "serviceAccount:${google_bigquery_connection.ds_connection.cloud_resource[0].service_account_id}"
+# # Grant IAM access to the BigQuery Connection account for the Iceberg warehouse
+resource "google_storage_bucket_iam_member" "bq_connection_iam_object_viewer" {
+  bucket = local.warehouse_bucket
+  role   = "roles/storage.objectViewer"
+  member = "serviceAccount:${google_bigquery_connection.ds_connection.cloud_resource[0].service_account_id}"
 }
 
 # # Grant IAM access to the BigQuery Connection account for BigLake Metastore
@@ -104,16 +297,34 @@ resource "google_project_iam_member" "bq_connection_iam_biglake" {
   member  = "serviceAccount:${google_bigquery_connection.ds_connection.cloud_resource[0].service_account_id}"
 }
 
+locals {
+  # The Delta Lake table is written by the batch that creates the Iceberg tables
+  enable_delta_lake = var.enable_dataproc && var.enable_delta_lake
+}
+
 resource "google_dataproc_cluster" "phs" {
+  count = var.enable_dataproc ? 1 : 0
+
   name    = "gcp-${var.use_case_short}-phs-${random_id.id.hex}"
   project = module.project-services.project_id
   region  = var.region
+  labels  = var.labels
   cluster_config {
     staging_bucket = google_storage_bucket.phs-staging-bucket.name
     temp_bucket    = google_storage_bucket.phs-temp-bucket.name
+    # Nodes never get external IPs, so the blueprint deploys under
+    # constraints/compute.vmExternalIpAccess. Google APIs are reached through
+    # Private Google Access, as they are by the serverless Spark batches.
     gce_cluster_config {
-      service_account = google_service_account.dataproc_service_account.email
-      subnetwork      = google_compute_subnetwork.subnet.name
+      service_account  = google_service_account.dataproc_service_account.email
+      subnetwork       = local.subnetwork
+      internal_ip_only = true
+      tags             = [local.dataproc_network_tag]
+      shielded_instance_config {
+        enable_secure_boot          = true
+        enable_vtpm                 = true
+        enable_integrity_monitoring = true
+      }
     }
     software_config {
       override_properties = {
@@ -124,9 +335,21 @@ resource "google_dataproc_cluster" "phs" {
     endpoint_config {
       enable_http_port_access = "true"
     }
+    dynamic "encryption_config" {
+      for_each = local.kms_key_name == null ? [] : [local.kms_key_name]
+      content {
+        kms_key_name = encryption_config.value
+      }
+    }
   }
 
   depends_on = [
-    google_project_iam_member.dataproc_sa_roles
+    google_project_iam_member.dataproc_sa_roles,
+    google_compute_subnetwork_iam_member.shared_vpc_network_users
   ]
 }
+
+moved {
+  from = google_dataproc_cluster.phs
+  to   = google_dataproc_cluster.phs[0]
+}
@@ -0,0 +1,167 @@
+/**
+ * Copyright 2023 Google LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+# Optional Sensitive Data Protection (DLP) inspection of the thelook users and
+# events. Inspection jobs cannot read the external staging tables, so the
+# project-setup workflow upserts them into native tables in the lakehouse
+# dataset and activates one job trigger per table. The triggers rescan weekly
+# and save their findings into a dlp_findings table next to them.
+locals {
+  dlp_scan_tables    = var.enable_dlp_scan ? toset(["thelook_users", "thelook_events"]) : toset([])
+  dlp_findings_table = "dlp_findings"
+}
+
+resource "google_data_loss_prevention_inspect_template" "lakehouse" {
+  count = var.enable_dlp_scan ? 1 : 0
+
+  parent       = "projects/${module.project-services.project_id}/locations/${var.region}"
+  template_id  = "lakehouse-pii"
+  display_name = "lakehouse-pii"
+  description  = "Personal data expected in the thelook users and events"
+
+  inspect_config {
+    min_likelihood = "LIKELY"
+    include_quote  = false
+
+    info_types {
+      name = "EMAIL_ADDRESS"
+    }
+    info_types {
+      name = "PERSON_NAME"
+    }
+    info_types {
+      name = "STREET_ADDRESS"
+    }
+    info_types {
+      name = "IP_ADDRESS"
+    }
+  }
+
+  depends_on = [time_sleep.wait_after_apis_activate]
+}
+
+# Jobs run as the DLP service agent, whose role already reads and writes
+# BigQuery tables in the project, so the findings table needs no extra grant.
+resource "google_data_loss_prevention_job_trigger" "lakehouse" {
+  for_each = local.dlp_scan_tables
+
+  parent       = "projects/${module.project-services.project_id}/locations/${var.region}"
+  trigger_id   = "lakehouse-pii-${replace(each.key, "_", "-")}"
+  display_name = "lakehouse-pii-${replace(each.key, "_", "-")}"
+  description  = "Inspects ${local.lakehouse_dataset_id}.${each.key} for personal data"
+
+  triggers {
+    schedule {
+      recurrence_period_duration = "604800s"
+    }
+  }
+
+  inspect_job {
+    inspect_template_name = google_data_loss_prevention_inspect_template.lakehouse[0].id
+
+    storage_config {
+      big_query_options {
+        table_reference {
+          project_id = module.project-services.project_id
+          dataset_id = local.lakehouse_dataset_id
+          table_id   = each.key
+        }
+        rows_limit    = 100000
+        sample_method = "RANDOM_START"
+      }
+    }
+
+    actions {
+      save_findings {
+        output_config {
+          table {
+            project_id = module.project-services.project_id
+            dataset_id = local.lakehouse_dataset_id
+            table_id   = local.dlp_findings_table
+          }
+        }
+      }
+    }
+  }
+}
+
+# Optional de-identified copy of the thelook users for analysts who must not
+# see personal data. A serverless Spark batch started by the project-setup
+# workflow sends the personal columns through the lakehouse-users-deid
+# template and writes the result into a thelook_users_deidentified table.
+locals {
+  dlp_deidentified_users_table = "thelook_users_deidentified"
+}
+
+resource "google_data_loss_prevention_deidentify_template" "users" {
+  count = var.enable_dlp_deidentify ? 1 : 0
+
+  parent       = "projects/${module.project-services.project_id}/locations/${var.region}"
+  template_id  = "lakehouse-users-deid"
+  display_name = "lakehouse-users-deid"
+  description  = "Tokenizes the names and masks the email and street address of the thelook users"
+
+  deidentify_config {
+    record_transformations {
+      # A transient key is discarded after each request, so tokens cannot be
+      # reversed or joined across batches of rows.
+      field_transformations {
+        fields {
+          name = "first_name"
+        }
+        fields {
+          name = "last_name"
+        }
+        primitive_transformation {
+          crypto_hash_config {
+            crypto_key {
+              transient {
+                name = "lakehouse-users-deid"
+              }
+            }
+          }
+        }
+      }
+
+      field_transformations {
+        fields {
+          name = "email"
+        }
+        fields {
+          name = "street_address"
+        }
+        primitive_transformation {
+          character_mask_config {
+            masking_character = "#"
+            characters_to_ignore {
+              characters_to_skip = "@. "
+            }
+          }
+        }
+      }
+    }
+  }
+
+  depends_on = [time_sleep.wait_after_apis_activate]
+}
+
+resource "google_storage_bucket_object" "deidentify_users_file" {
+  count = var.enable_dlp_deidentify ? 1 : 0
+
+  bucket = google_storage_bucket.provisioning_bucket.name
+  name   = "deidentify_users.py"
+  source = "${path.module}/src/deidentify_users.py"
+}
@@ -21,4 +21,6 @@ module "analytics_lakehouse" {
   region        = "us-central1"
   force_destroy = true
 
+  raw_data_format = var.raw_data_format
+  labels          = var.labels
 }
@@ -29,7 +29,187 @@ output "lakehouse_colab_url" {
   description = "The URL to launch the Colab instance"
 }
 
+output "neos_tutorial_url" {
+  value       = module.analytics_lakehouse.neos_tutorial_url
+  description = "The URL to launch the in-console tutorial"
+}
+
 output "region" {
-  value       = "us-central"
+  value       = module.analytics_lakehouse.region
   description = "The Compute region where resources are created"
 }
+
+output "lakehouse_dataset_id" {
+  value       = module.analytics_lakehouse.lakehouse_dataset_id
+  description = "The ID of the lakehouse BigQuery dataset"
+}
+
+output "tables_bucket" {
+  value       = module.analytics_lakehouse.tables_bucket
+  description = "The name of the tabular data bucket"
+}
+
+output "textocr_images_bucket" {
+  value       = module.analytics_lakehouse.textocr_images_bucket
+  description = "The name of the TextOCR images bucket"
+}
+
+output "ga4_images_bucket" {
+  value       = module.analytics_lakehouse.ga4_images_bucket
+  description = "The name of the GA4 images bucket"
+}
+
+output "data_analyst_service_account" {
+  value       = module.analytics_lakehouse.data_analyst_service_account
+  description = "The email of the data analyst service account"
+}
+
+output "warehouse_bucket" {
+  value       = module.analytics_lakehouse.warehouse_bucket
+  description = "The name of the Iceberg warehouse bucket"
+}
+
+output "workflows_service_account" {
+  value       = module.analytics_lakehouse.workflows_service_account
+  description = "The email of the orchestration service account"
+}
+
+output "dataproc_service_account" {
+  value       = module.analytics_lakehouse.dataproc_service_account
+  description = "The email of the data-plane service account"
+}
+
+output "ops_dataset_id" {
+  value       = module.analytics_lakehouse.ops_dataset_id
+  description = "The ID of the operations logs BigQuery dataset"
+}
+
+output "access_consumer_service_account" {
+  value       = module.analytics_lakehouse.access_consumer_service_account
+  description = "The email of the access layer consumer service account"
+}
+
+output "dataproc_subnetwork" {
+  value       = module.analytics_lakehouse.dataproc_subnetwork
+  description = "The self link of the subnet Dataproc runs on"
+}
+
+output "vpc_connector" {
+  value       = module.analytics_lakehouse.vpc_connector
+  description = "The ID of the Serverless VPC Access connector"
+}
+
+output "streaming_topic" {
+  value       = module.analytics_lakehouse.streaming_topic
+  description = "The ID of the Pub/Sub streaming topic"
+}
+
+output "delta_lake_uri" {
+  value       = module.analytics_lakehouse.delta_lake_uri
+  description = "The Cloud Storage path of the Delta Lake table"
+}
+
+output "iceberg_maintenance_workflow" {
+  value       = module.analytics_lakehouse.iceberg_maintenance_workflow
+  description = "The name of the Iceberg maintenance workflow"
+}
+
+output "dlp_findings_table" {
+  value       = module.analytics_lakehouse.dlp_findings_table
+  description = "The BigQuery table holding the DLP findings"
+}
+
+output "dlp_deidentified_users_table" {
+  value       = module.analytics_lakehouse.dlp_deidentified_users_table
+  description = "The BigQuery table holding the de-identified users"
+}
+
+output "retention_workflow" {
+  value       = module.analytics_lakehouse.retention_workflow
+  description = "The name of the data retention workflow"
+}
+
+output "archive_bucket" {
+  value       = module.analytics_lakehouse.archive_bucket
+  description = "The name of the archive bucket"
+}
+
+output "dataform_repository" {
+  value       = module.analytics_lakehouse.dataform_repository
+  description = "The ID of the Dataform repository"
+}
+
+output "notebook_gcs_uri" {
+  value       = module.analytics_lakehouse.notebook_gcs_uri
+  description = "The Cloud Storage URI of the sample lakehouse notebook"
+}
+
+output "notebook_runtime_template" {
+  value       = module.analytics_lakehouse.notebook_runtime_template
+  description = "The resource name of the Colab Enterprise runtime template"
+}
+
+output "scheduled_query_transfer_config" {
+  value       = module.analytics_lakehouse.scheduled_query_transfer_config
+  description = "The resource name of the scheduled query transfer config"
+}
+
+output "raw_data_format" {
+  value       = module.analytics_lakehouse.raw_data_format
+  description = "The file format of the raw thelook tables"
+}
+
+output "transfer_load_config" {
+  value       = module.analytics_lakehouse.transfer_load_config
+  description = "The resource name of the orders Cloud Storage transfer config"
+}
+
+output "analytics_hub_listing" {
+  value       = module.analytics_lakehouse.analytics_hub_listing
+  description = "The resource name of the Analytics Hub listing"
+}
+
+output "analytics_hub_subscriber_service_account" {
+  value       = module.analytics_lakehouse.analytics_hub_subscriber_service_account
+  description = "The email of the Analytics Hub subscriber service account"
+}
+
+output "serving_instance" {
+  value       = module.analytics_lakehouse.serving_instance
+  description = "The name of the Cloud SQL serving instance"
+}
+
+output "serving_database" {
+  value       = module.analytics_lakehouse.serving_database
+  description = "The name of the Cloud SQL serving database"
+}
+
+output "serving_bucket" {
+  value       = module.analytics_lakehouse.serving_bucket
+  description = "The bucket staging the serving export files"
+}
+
+output "firestore_database" {
+  value       = module.analytics_lakehouse.firestore_database
+  description = "The ID of the Firestore serving database"
+}
+
+output "bi_engine_reservation" {
+  value       = module.analytics_lakehouse.bi_engine_reservation
+  description = "The ID of the BI Engine reservation"
+}
+
+output "slot_reservation" {
+  value       = module.analytics_lakehouse.slot_reservation
+  description = "The ID of the query slot reservation"
+}
+
+output "budget" {
+  value       = module.analytics_lakehouse.budget
+  description = "The resource name of the billing budget"
+}
+
+output "budget_notification_channels" {
+  value       = module.analytics_lakehouse.budget_notification_channels
+  description = "The notification channels the budget alerts are sent to"
+}
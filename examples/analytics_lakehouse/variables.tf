@@ -18,3 +18,15 @@ variable "project_id" {
   description = "The ID of the project in which to provision resources."
   type        = string
 }
+
+variable "raw_data_format" {
+  description = "File format of the raw thelook tables, one of PARQUET, CSV or JSON."
+  type        = string
+  default     = "PARQUET"
+}
+
+variable "labels" {
+  description = "Labels to apply to the blueprint's resources, such as a label identifying a test run."
+  type        = map(string)
+  default     = { "analytics-lakehouse" = "true" }
+}
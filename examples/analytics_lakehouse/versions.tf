@@ -41,5 +41,5 @@ terraform {
       version = ">= 3.2.1"
     }
   }
-  required_version = ">= 0.13"
+  required_version = ">= 1.3"
 }
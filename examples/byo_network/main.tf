@@ -0,0 +1,68 @@
+/**
+ * Copyright 2023 Google LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+locals {
+  region = "us-central1"
+}
+
+# Network managed outside the blueprint
+resource "google_compute_network" "byo" {
+  project                 = var.project_id
+  name                    = "vpc-byo"
+  auto_create_subnetworks = false
+}
+
+resource "google_compute_subnetwork" "byo" {
+  project                  = var.project_id
+  name                     = "byo-subnet"
+  ip_cidr_range            = "10.5.0.0/16"
+  region                   = local.region
+  network                  = google_compute_network.byo.id
+  private_ip_google_access = true
+}
+
+resource "google_compute_firewall" "byo_internal" {
+  project = var.project_id
+  name    = "byo-internal"
+  network = google_compute_network.byo.id
+
+  allow {
+    protocol = "icmp"
+  }
+
+  allow {
+    protocol = "tcp"
+  }
+
+  allow {
+    protocol = "udp"
+  }
+  source_ranges = [google_compute_subnetwork.byo.ip_cidr_range]
+}
+
+module "analytics_lakehouse" {
+  source = "../.."
+
+  project_id           = var.project_id
+  region               = local.region
+  force_destroy        = true
+  network_self_link    = google_compute_network.byo.self_link
+  subnetwork_self_link = google_compute_subnetwork.byo.self_link
+
+  enable_restricted_api_access = true
+
+  depends_on = [google_compute_firewall.byo_internal]
+}
@@ -0,0 +1,25 @@
+/**
+ * Copyright 2023 Google LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+output "dataproc_subnetwork" {
+  value       = module.analytics_lakehouse.dataproc_subnetwork
+  description = "The self link of the subnet Dataproc runs on"
+}
+
+output "subnetwork_self_link" {
+  value       = google_compute_subnetwork.byo.self_link
+  description = "The self link of the subnet created outside the blueprint"
+}
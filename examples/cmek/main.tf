@@ -0,0 +1,44 @@
+/**
+ * Copyright 2023 Google LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+locals {
+  region = "us-central1"
+}
+
+resource "random_id" "keyring" {
+  byte_length = 4
+}
+
+# Key rings cannot be deleted, so each run creates a uniquely named one.
+module "kms" {
+  source  = "terraform-google-modules/kms/google"
+  version = "~> 2.0"
+
+  project_id      = var.project_id
+  location        = local.region
+  keyring         = "lakehouse-keyring-${random_id.keyring.hex}"
+  keys            = ["lakehouse"]
+  prevent_destroy = false
+}
+
+module "analytics_lakehouse" {
+  source = "../.."
+
+  project_id    = var.project_id
+  region        = local.region
+  force_destroy = true
+  kms_key_name  = module.kms.keys["lakehouse"]
+}
@@ -0,0 +1,35 @@
+/**
+ * Copyright 2023 Google LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+output "kms_key_name" {
+  value       = module.kms.keys["lakehouse"]
+  description = "The Cloud KMS key encrypting the lakehouse"
+}
+
+output "lakehouse_dataset_id" {
+  value       = module.analytics_lakehouse.lakehouse_dataset_id
+  description = "The ID of the lakehouse BigQuery dataset"
+}
+
+output "warehouse_bucket" {
+  value       = module.analytics_lakehouse.warehouse_bucket
+  description = "The name of the Iceberg warehouse bucket"
+}
+
+output "buckets" {
+  value       = module.analytics_lakehouse.buckets
+  description = "The names of the Cloud Storage buckets the blueprint created"
+}
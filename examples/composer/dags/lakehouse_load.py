@@ -0,0 +1,60 @@
+# Copyright 2023 Google LLC
+#
+# Licensed under the Apache License, Version 2.0 (the "License");
+# you may not use this file except in compliance with the License.
+# You may obtain a copy of the License at
+#
+#      http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+
+"""Runs the lakehouse load: copy-data, then project-setup once it succeeds."""
+
+import datetime
+import os
+
+from airflow import models
+from airflow.providers.google.cloud.operators.workflows import (
+    WorkflowsCreateExecutionOperator,
+)
+from airflow.providers.google.cloud.sensors.workflows import (
+    WorkflowExecutionSensor,
+)
+
+PROJECT_ID = os.environ["LAKEHOUSE_PROJECT_ID"]
+REGION = os.environ["LAKEHOUSE_REGION"]
+
+with models.DAG(
+    "lakehouse_load",
+    schedule_interval=None,
+    start_date=datetime.datetime(2023, 1, 1),
+    catchup=False,
+) as dag:
+    previous = None
+    for workflow in ["copy-data", "project-setup"]:
+        task_id = workflow.replace("-", "_")
+        execute = WorkflowsCreateExecutionOperator(
+            task_id=f"execute_{task_id}",
+            project_id=PROJECT_ID,
+            location=REGION,
+            workflow_id=workflow,
+            execution={},
+        )
+        wait = WorkflowExecutionSensor(
+            task_id=f"wait_{task_id}",
+            project_id=PROJECT_ID,
+            location=REGION,
+            workflow_id=workflow,
+            execution_id=execute.output["execution_id"],
+            mode="reschedule",
+            poke_interval=60,
+            timeout=60 * 60,
+        )
+        if previous:
+            previous >> execute
+        execute >> wait
+        previous = wait
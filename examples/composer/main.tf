@@ -0,0 +1,147 @@
+/**
+ * Copyright 2023 Google LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+locals {
+  region = "us-central1"
+}
+
+data "google_project" "project" {
+  project_id = var.project_id
+}
+
+resource "google_project_service" "composer" {
+  project            = var.project_id
+  service            = "composer.googleapis.com"
+  disable_on_destroy = false
+}
+
+# Network shared by Dataproc and the Composer GKE nodes
+resource "google_compute_network" "composer" {
+  project                 = var.project_id
+  name                    = "vpc-composer"
+  auto_create_subnetworks = false
+}
+
+resource "google_compute_subnetwork" "composer" {
+  project                  = var.project_id
+  name                     = "composer-subnet"
+  ip_cidr_range            = "10.6.0.0/16"
+  region                   = local.region
+  network                  = google_compute_network.composer.id
+  private_ip_google_access = true
+}
+
+resource "google_compute_firewall" "composer_internal" {
+  project = var.project_id
+  name    = "composer-internal"
+  network = google_compute_network.composer.id
+
+  allow {
+    protocol = "icmp"
+  }
+
+  allow {
+    protocol = "tcp"
+  }
+
+  allow {
+    protocol = "udp"
+  }
+  source_ranges = [google_compute_subnetwork.composer.ip_cidr_range]
+}
+
+# The workflows are run by the DAG below instead of on apply
+module "analytics_lakehouse" {
+  source = "../.."
+
+  project_id           = var.project_id
+  region               = local.region
+  force_destroy        = true
+  network_self_link    = google_compute_network.composer.self_link
+  subnetwork_self_link = google_compute_subnetwork.composer.self_link
+  execute_workflows    = false
+
+  depends_on = [google_compute_firewall.composer_internal]
+}
+
+resource "google_service_account" "composer" {
+  project      = var.project_id
+  account_id   = "lakehouse-composer"
+  display_name = "Service Account for the Composer environment"
+}
+
+resource "google_project_iam_member" "composer_roles" {
+  for_each = toset([
+    "roles/composer.worker",
+    "roles/workflows.invoker",
+    "roles/workflows.viewer",
+  ])
+
+  project = var.project_id
+  role    = each.key
+  member  = "serviceAccount:${google_service_account.composer.email}"
+}
+
+# Composer 2 needs its service agent to manage the environment's service account
+resource "google_service_account_iam_member" "composer_agent" {
+  service_account_id = google_service_account.composer.name
+  role               = "roles/composer.ServiceAgentV2Ext"
+  member             = "serviceAccount:service-${data.google_project.project.number}@cloudcomposer-accounts.iam.gserviceaccount.com"
+
+  depends_on = [google_project_service.composer]
+}
+
+resource "google_composer_environment" "lakehouse" {
+  project = var.project_id
+  name    = "lakehouse-composer"
+  region  = local.region
+
+  config {
+    environment_size = "ENVIRONMENT_SIZE_SMALL"
+
+    software_config {
+      image_version = "composer-2-airflow-2"
+      env_variables = {
+        LAKEHOUSE_PROJECT_ID = var.project_id
+        LAKEHOUSE_REGION     = local.region
+      }
+    }
+
+    node_config {
+      network         = google_compute_network.composer.id
+      subnetwork      = google_compute_subnetwork.composer.id
+      service_account = google_service_account.composer.email
+    }
+
+    # Nodes have internal IPs only
+    private_environment_config {
+      enable_private_endpoint = false
+    }
+  }
+
+  depends_on = [
+    google_project_iam_member.composer_roles,
+    google_service_account_iam_member.composer_agent
+  ]
+}
+
+resource "google_storage_bucket_object" "lakehouse_dag" {
+  bucket = split("/", trimprefix(google_composer_environment.lakehouse.config[0].dag_gcs_prefix, "gs://"))[0]
+  name   = "dags/lakehouse_load.py"
+  source = "${path.module}/dags/lakehouse_load.py"
+
+  depends_on = [module.analytics_lakehouse]
+}
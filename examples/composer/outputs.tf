@@ -0,0 +1,30 @@
+/**
+ * Copyright 2023 Google LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+output "composer_environment" {
+  value       = google_composer_environment.lakehouse.name
+  description = "The name of the Composer environment"
+}
+
+output "composer_dag" {
+  value       = "lakehouse_load"
+  description = "The ID of the DAG that runs the lakehouse workflows"
+}
+
+output "airflow_uri" {
+  value       = google_composer_environment.lakehouse.config[0].airflow_uri
+  description = "The URI of the Airflow web interface"
+}
@@ -0,0 +1,180 @@
+/**
+ * Copyright 2023 Google LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+locals {
+  region   = "us-central1"
+  database = "lakehouse_demo"
+}
+
+module "analytics_lakehouse" {
+  source = "../.."
+
+  project_id    = var.project_id
+  region        = local.region
+  force_destroy = true
+}
+
+resource "google_project_service" "datastream" {
+  for_each = toset([
+    "datastream.googleapis.com",
+    "sqladmin.googleapis.com",
+  ])
+
+  project            = var.project_id
+  service            = each.key
+  disable_on_destroy = false
+}
+
+# Demo MySQL source. Datastream reads its binary log over the public IP, which
+# only accepts Datastream's regional addresses.
+data "google_datastream_static_ips" "datastream" {
+  project  = var.project_id
+  location = local.region
+
+  depends_on = [google_project_service.datastream]
+}
+
+resource "google_sql_database_instance" "source" {
+  project             = var.project_id
+  name                = "lakehouse-mysql"
+  region              = local.region
+  database_version    = "MYSQL_8_0"
+  deletion_protection = false
+
+  settings {
+    tier = "db-custom-1-3840"
+
+    backup_configuration {
+      enabled            = true
+      binary_log_enabled = true
+    }
+
+    ip_configuration {
+      ipv4_enabled = true
+
+      dynamic "authorized_networks" {
+        for_each = data.google_datastream_static_ips.datastream.static_ips
+        content {
+          name  = "datastream-${authorized_networks.key}"
+          value = authorized_networks.value
+        }
+      }
+    }
+  }
+
+  depends_on = [google_project_service.datastream]
+}
+
+resource "google_sql_database" "demo" {
+  project  = var.project_id
+  instance = google_sql_database_instance.source.name
+  name     = local.database
+}
+
+resource "random_password" "datastream" {
+  length  = 24
+  special = false
+}
+
+resource "google_sql_user" "datastream" {
+  project  = var.project_id
+  instance = google_sql_database_instance.source.name
+  name     = "datastream"
+  host     = "%"
+  password = random_password.datastream.result
+}
+
+# Demo data is imported into the source from here
+resource "google_storage_bucket" "source_data" {
+  project                     = var.project_id
+  name                        = "datastream-source-${var.project_id}"
+  location                    = local.region
+  uniform_bucket_level_access = true
+  force_destroy               = true
+}
+
+resource "google_storage_bucket_object" "orders" {
+  bucket = google_storage_bucket.source_data.name
+  name   = "orders.sql"
+  source = "${path.module}/sql/orders.sql"
+}
+
+resource "google_storage_bucket_iam_member" "source_import" {
+  bucket = google_storage_bucket.source_data.name
+  role   = "roles/storage.objectViewer"
+  member = "serviceAccount:${google_sql_database_instance.source.service_account_email_address}"
+}
+
+resource "google_datastream_connection_profile" "source" {
+  project               = var.project_id
+  location              = local.region
+  connection_profile_id = "lakehouse-mysql"
+  display_name          = "Lakehouse demo MySQL"
+
+  mysql_profile {
+    hostname = google_sql_database_instance.source.public_ip_address
+    username = google_sql_user.datastream.name
+    password = google_sql_user.datastream.password
+  }
+}
+
+resource "google_datastream_connection_profile" "destination" {
+  project               = var.project_id
+  location              = local.region
+  connection_profile_id = "lakehouse-bigquery"
+  display_name          = "Lakehouse BigQuery"
+
+  bigquery_profile {}
+
+  depends_on = [google_project_service.datastream]
+}
+
+# Changes land in the raw zone's dataset, which Dataplex creates with the zone
+resource "google_datastream_stream" "cdc" {
+  project       = var.project_id
+  location      = local.region
+  stream_id     = "lakehouse-cdc"
+  display_name  = "Lakehouse CDC"
+  desired_state = "RUNNING"
+
+  source_config {
+    source_connection_profile = google_datastream_connection_profile.source.id
+
+    mysql_source_config {
+      include_objects {
+        mysql_databases {
+          database = google_sql_database.demo.name
+        }
+      }
+    }
+  }
+
+  destination_config {
+    destination_connection_profile = google_datastream_connection_profile.destination.id
+
+    bigquery_destination_config {
+      data_freshness = "60s"
+
+      single_target_dataset {
+        dataset_id = "projects/${var.project_id}/datasets/gcp_primary_raw"
+      }
+    }
+  }
+
+  backfill_all {}
+
+  depends_on = [module.analytics_lakehouse]
+}
@@ -0,0 +1,40 @@
+/**
+ * Copyright 2023 Google LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+output "source_instance" {
+  value       = google_sql_database_instance.source.name
+  description = "The name of the Cloud SQL for MySQL source instance"
+}
+
+output "source_database" {
+  value       = google_sql_database.demo.name
+  description = "The name of the source database Datastream replicates"
+}
+
+output "source_data_uri" {
+  value       = "gs://${google_storage_bucket_object.orders.bucket}/${google_storage_bucket_object.orders.name}"
+  description = "The Cloud Storage URI of the demo orders to import into the source"
+}
+
+output "stream_id" {
+  value       = google_datastream_stream.cdc.stream_id
+  description = "The ID of the Datastream stream"
+}
+
+output "cdc_table" {
+  value       = "gcp_primary_raw.${google_sql_database.demo.name}_orders"
+  description = "The BigQuery table the demo orders are replicated into"
+}
@@ -0,0 +1,30 @@
+-- Copyright 2023 Google LLC
+--
+-- Licensed under the Apache License, Version 2.0 (the "License");
+-- you may not use this file except in compliance with the License.
+-- You may obtain a copy of the License at
+--
+--      http://www.apache.org/licenses/LICENSE-2.0
+--
+-- Unless required by applicable law or agreed to in writing, software
+-- distributed under the License is distributed on an "AS IS" BASIS,
+-- WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+-- See the License for the specific language governing permissions and
+-- limitations under the License.
+
+
+-- Demo orders the test imports into the source after the stream is running,
+-- so every row reaches BigQuery as a change event.
+CREATE TABLE IF NOT EXISTS orders (
+  order_id INT PRIMARY KEY,
+  user_id INT NOT NULL,
+  status VARCHAR(20) NOT NULL,
+  created_at TIMESTAMP NOT NULL
+);
+
+INSERT IGNORE INTO orders VALUES
+  (1, 101, 'Processing', '2023-06-01 09:15:00'),
+  (2, 102, 'Shipped', '2023-06-01 10:42:00'),
+  (3, 101, 'Complete', '2023-06-02 14:03:00'),
+  (4, 103, 'Cancelled', '2023-06-03 08:27:00'),
+  (5, 104, 'Returned', '2023-06-04 17:51:00');
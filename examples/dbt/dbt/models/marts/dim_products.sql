@@ -0,0 +1,10 @@
+SELECT
+  product_id,
+  name,
+  brand,
+  category,
+  department,
+  cost,
+  retail_price
+FROM
+  {{ ref('stg_products') }}
@@ -0,0 +1,19 @@
+-- One row per order with its item count and revenue
+SELECT
+  o.order_id,
+  o.user_id,
+  o.status,
+  o.created_at,
+  COUNT(i.order_item_id) AS items,
+  SUM(i.sale_price) AS revenue
+FROM
+  {{ ref('stg_orders') }} o
+INNER JOIN
+  {{ ref('stg_order_items') }} i
+ON
+  o.order_id = i.order_id
+GROUP BY
+  o.order_id,
+  o.user_id,
+  o.status,
+  o.created_at
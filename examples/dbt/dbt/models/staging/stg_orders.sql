@@ -0,0 +1,7 @@
+SELECT
+  order_id,
+  user_id,
+  status,
+  created_at
+FROM
+  {{ source('thelook', 'orders') }}
@@ -0,0 +1,10 @@
+SELECT
+  id AS product_id,
+  name,
+  brand,
+  category,
+  department,
+  cost,
+  retail_price
+FROM
+  {{ source('thelook', 'products') }}
@@ -0,0 +1,87 @@
+/**
+ * Copyright 2023 Google LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+locals {
+  region = "us-central1"
+}
+
+module "analytics_lakehouse" {
+  source = "../.."
+
+  project_id    = var.project_id
+  region        = local.region
+  force_destroy = true
+}
+
+# Identity the dbt Cloud Build job runs as. It reads the staging tables and
+# owns the marts dataset the dbt models build.
+resource "google_service_account" "dbt" {
+  project      = var.project_id
+  account_id   = "lakehouse-dbt"
+  display_name = "Service Account for dbt builds"
+}
+
+resource "google_project_iam_member" "dbt_roles" {
+  for_each = toset([
+    "roles/bigquery.jobUser",
+    "roles/logging.logWriter",
+  ])
+
+  project = var.project_id
+  role    = each.key
+  member  = "serviceAccount:${google_service_account.dbt.email}"
+}
+
+resource "google_bigquery_dataset" "marts" {
+  project                    = var.project_id
+  dataset_id                 = "gcp_lakehouse_marts"
+  friendly_name              = "Lakehouse marts"
+  description                = "Marts built by the dbt project in this example"
+  location                   = local.region
+  delete_contents_on_destroy = true
+}
+
+resource "google_bigquery_dataset_iam_member" "dbt_marts_editor" {
+  project    = var.project_id
+  dataset_id = google_bigquery_dataset.marts.dataset_id
+  role       = "roles/bigquery.dataEditor"
+  member     = "serviceAccount:${google_service_account.dbt.email}"
+}
+
+# The staging dataset is created by Dataplex discovery
+resource "google_bigquery_dataset_iam_member" "dbt_staging_viewer" {
+  project    = var.project_id
+  dataset_id = "gcp_primary_staging"
+  role       = "roles/bigquery.dataViewer"
+  member     = "serviceAccount:${google_service_account.dbt.email}"
+
+  depends_on = [module.analytics_lakehouse]
+}
+
+# dbt run results are copied here after every build
+resource "google_storage_bucket" "dbt_artifacts" {
+  project                     = var.project_id
+  name                        = "dbt-artifacts-${var.project_id}"
+  location                    = local.region
+  uniform_bucket_level_access = true
+  force_destroy               = true
+}
+
+resource "google_storage_bucket_iam_member" "dbt_artifacts_writer" {
+  bucket = google_storage_bucket.dbt_artifacts.name
+  role   = "roles/storage.objectAdmin"
+  member = "serviceAccount:${google_service_account.dbt.email}"
+}
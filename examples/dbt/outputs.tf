@@ -0,0 +1,35 @@
+/**
+ * Copyright 2023 Google LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+output "dbt_service_account" {
+  value       = google_service_account.dbt.email
+  description = "The email of the service account dbt builds run as"
+}
+
+output "marts_dataset_id" {
+  value       = google_bigquery_dataset.marts.dataset_id
+  description = "The ID of the BigQuery dataset the dbt models build"
+}
+
+output "dbt_artifacts_bucket" {
+  value       = google_storage_bucket.dbt_artifacts.name
+  description = "The name of the bucket dbt run results are copied to"
+}
+
+output "dbt_build_command" {
+  value       = "gcloud builds submit dbt --config=dbt/cloudbuild.yaml --project=${var.project_id} --region=${local.region} --service-account=${google_service_account.dbt.id} --substitutions=_ARTIFACTS_BUCKET=${google_storage_bucket.dbt_artifacts.name}"
+  description = "The command to run the dbt project with Cloud Build from this directory"
+}
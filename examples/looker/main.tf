@@ -0,0 +1,68 @@
+/**
+ * Copyright 2023 Google LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+locals {
+  region = "us-central1"
+}
+
+resource "google_project_service" "looker" {
+  project            = var.project_id
+  service            = "looker.googleapis.com"
+  disable_on_destroy = false
+}
+
+module "analytics_lakehouse" {
+  source = "../.."
+
+  project_id    = var.project_id
+  region        = local.region
+  force_destroy = true
+}
+
+resource "google_looker_instance" "lakehouse" {
+  project          = var.project_id
+  name             = "lakehouse-looker"
+  region           = local.region
+  platform_edition = "LOOKER_CORE_STANDARD"
+
+  oauth_config {
+    client_id     = var.looker_oauth_client_id
+    client_secret = var.looker_oauth_client_secret
+  }
+
+  depends_on = [google_project_service.looker]
+}
+
+# Looker connects to BigQuery as its service agent
+resource "google_project_service_identity" "looker" {
+  provider = google-beta
+
+  project = var.project_id
+  service = "looker.googleapis.com"
+}
+
+resource "google_project_iam_member" "looker_job_user" {
+  project = var.project_id
+  role    = "roles/bigquery.jobUser"
+  member  = "serviceAccount:${google_project_service_identity.looker.email}"
+}
+
+resource "google_bigquery_dataset_iam_member" "looker_viewer" {
+  project    = var.project_id
+  dataset_id = module.analytics_lakehouse.lakehouse_dataset_id
+  role       = "roles/bigquery.dataViewer"
+  member     = "serviceAccount:${google_project_service_identity.looker.email}"
+}
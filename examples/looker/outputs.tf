@@ -0,0 +1,35 @@
+/**
+ * Copyright 2023 Google LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+output "looker_instance" {
+  value       = google_looker_instance.lakehouse.name
+  description = "The name of the Looker instance"
+}
+
+output "looker_uri" {
+  value       = google_looker_instance.lakehouse.looker_uri
+  description = "The URI of the Looker instance"
+}
+
+output "looker_service_agent" {
+  value       = google_project_service_identity.looker.email
+  description = "The email of the Looker service agent that queries BigQuery"
+}
+
+output "lakehouse_dataset_id" {
+  value       = module.analytics_lakehouse.lakehouse_dataset_id
+  description = "The ID of the BigQuery dataset Looker connects to"
+}
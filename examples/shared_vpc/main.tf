@@ -0,0 +1,25 @@
+/**
+ * Copyright 2023 Google LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+module "analytics_lakehouse" {
+  source = "../.."
+
+  project_id                 = var.project_id
+  region                     = "us-central1"
+  force_destroy              = true
+  shared_vpc_host_project_id = var.shared_vpc_host_project_id
+  shared_vpc_subnetwork      = var.shared_vpc_subnetwork
+}
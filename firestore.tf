@@ -0,0 +1,51 @@
+/**
+ * Copyright 2023 Google LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+# Optional Firestore serving: the firestore-export workflow writes the most
+# active users from agg_events_iceberg as documents keyed by user ID, so
+# applications can look them up without querying BigQuery. A named database
+# keeps the project's (default) database free for other uses.
+resource "google_firestore_database" "serving" {
+  count = var.enable_firestore_export ? 1 : 0
+
+  project         = module.project-services.project_id
+  name            = "lakehouse-serving-${random_id.id.hex}"
+  location_id     = var.region
+  type            = "FIRESTORE_NATIVE"
+  deletion_policy = var.force_destroy ? "DELETE" : "ABANDON"
+
+  depends_on = [time_sleep.wait_after_apis_activate]
+}
+
+# The export writes data, so it runs as the data-plane service account.
+resource "google_workflows_workflow" "firestore_export" {
+  count = var.enable_firestore_export ? 1 : 0
+
+  name            = "firestore-export"
+  project         = module.project-services.project_id
+  region          = var.region
+  description     = "Writes the top users by event count to Firestore"
+  service_account = google_service_account.dataproc_service_account.email
+  labels          = var.labels
+  source_contents = templatefile("${path.module}/src/yaml/firestore-export.yaml", {
+    firestore_database = google_firestore_database.serving[0].name,
+    top_users_sql      = jsonencode(file("${path.module}/src/sql/firestore_top_users.sql"))
+  })
+
+  depends_on = [
+    google_project_iam_member.dataproc_sa_roles
+  ]
+}
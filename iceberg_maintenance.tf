@@ -0,0 +1,72 @@
+/**
+ * Copyright 2023 Google LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+# Optional Iceberg table maintenance: Cloud Scheduler executes a workflow that
+# compacts agg_events_iceberg and expires its old snapshots with a serverless
+# Spark batch.
+resource "google_storage_bucket_object" "iceberg_maintenance_file" {
+  count = var.enable_iceberg_maintenance ? 1 : 0
+
+  bucket = google_storage_bucket.provisioning_bucket.name
+  name   = "iceberg_maintenance.py"
+  source = "${path.module}/src/iceberg_maintenance.py"
+}
+
+# The workflow only starts the batch, so it runs as the workflows service
+# account like project-setup.
+resource "google_workflows_workflow" "iceberg_maintenance" {
+  count = var.enable_iceberg_maintenance ? 1 : 0
+
+  name            = "iceberg-maintenance"
+  project         = module.project-services.project_id
+  region          = var.region
+  description     = "Compacts agg_events_iceberg and expires its old snapshots"
+  service_account = google_service_account.workflows_sa.email
+  labels          = var.labels
+  source_contents = templatefile("${path.module}/src/yaml/iceberg-maintenance.yaml", {
+    dataproc_service_account = google_service_account.dataproc_service_account.email,
+    subnetwork               = local.subnetwork,
+    dataproc_network_tag     = local.dataproc_network_tag,
+    provisioner_bucket       = google_storage_bucket.provisioning_bucket.name,
+    warehouse_bucket         = local.warehouse_bucket
+  })
+
+  depends_on = [
+    google_project_iam_member.workflows_sa_roles,
+    google_service_account_iam_member.workflows_sa_dataproc_user,
+    google_storage_bucket_object.iceberg_maintenance_file
+  ]
+}
+
+resource "google_cloud_scheduler_job" "iceberg_maintenance" {
+  count = var.enable_iceberg_maintenance ? 1 : 0
+
+  project     = module.project-services.project_id
+  region      = var.region
+  name        = "iceberg-maintenance"
+  description = "Executes the iceberg-maintenance workflow"
+  schedule    = var.iceberg_maintenance_schedule
+  time_zone   = "Etc/UTC"
+
+  http_target {
+    http_method = "POST"
+    uri         = "https://workflowexecutions.googleapis.com/v1/${google_workflows_workflow.iceberg_maintenance[0].id}/executions"
+
+    oauth_token {
+      service_account_email = google_service_account.workflows_sa.email
+    }
+  }
+}
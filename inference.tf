@@ -0,0 +1,67 @@
+/**
+ * Copyright 2023 Google LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+# Optional Vertex AI features. With image inference, the project-setup
+# workflow creates an object table over the images bucket, a Gemini remote
+# model and a table of generated descriptions. With vector search, it embeds
+# the product catalog, indexes the embeddings and creates a search function.
+locals {
+  enable_vertex_connection = var.enable_image_inference || var.enable_vector_search
+}
+
+resource "google_bigquery_connection" "vertex_connection" {
+  count = local.enable_vertex_connection ? 1 : 0
+
+  project       = module.project-services.project_id
+  connection_id = "gcp_vertex_connection"
+  location      = var.region
+  friendly_name = "Vertex AI remote model connection"
+  cloud_resource {}
+}
+
+resource "google_project_iam_member" "vertex_connection_user" {
+  count = local.enable_vertex_connection ? 1 : 0
+
+  project = module.project-services.project_id
+  role    = "roles/aiplatform.user"
+  member  = "serviceAccount:${google_bigquery_connection.vertex_connection[0].cloud_resource[0].service_account_id}"
+}
+
+# # Allow the workflows service account to create the object table and models
+resource "google_bigquery_connection_iam_member" "workflows_sa_inference_connections" {
+  for_each = merge(
+    var.enable_image_inference ? { lakehouse = google_bigquery_connection.gcp_lakehouse_connection.connection_id } : {},
+    local.enable_vertex_connection ? { vertex = google_bigquery_connection.vertex_connection[0].connection_id } : {},
+  )
+
+  project       = module.project-services.project_id
+  location      = var.region
+  connection_id = each.value
+  role          = "roles/bigquery.connectionAdmin"
+  member        = "serviceAccount:${google_service_account.workflows_sa.email}"
+}
+
+locals {
+  image_inference_sql = var.enable_image_inference ? templatefile("${path.module}/src/sql/image_inference.sql", {
+    lakehouse_connection  = "${module.project-services.project_id}.${var.region}.${google_bigquery_connection.gcp_lakehouse_connection.connection_id}"
+    vertex_connection     = "${module.project-services.project_id}.${var.region}.${google_bigquery_connection.vertex_connection[0].connection_id}"
+    textocr_images_bucket = google_storage_bucket.textocr_images_bucket.name
+  }) : ""
+
+  vector_search_sql = var.enable_vector_search ? templatefile("${path.module}/src/sql/vector_search.sql", {
+    vertex_connection = "${module.project-services.project_id}.${var.region}.${google_bigquery_connection.vertex_connection[0].connection_id}"
+  }) : ""
+}
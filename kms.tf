@@ -0,0 +1,47 @@
+/**
+ * Copyright 2023 Google LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+# Customer-managed encryption keys (CMEK)
+data "google_project" "project" {
+  project_id = module.project-services.project_id
+}
+
+data "google_bigquery_default_service_account" "bq_account" {
+  project = module.project-services.project_id
+}
+
+# # Service agents that encrypt data at rest on behalf of the blueprint
+locals {
+  kms_service_agents = var.kms_key_name == null ? [] : [
+    "serviceAccount:${data.google_storage_project_service_account.gcs_account.email_address}",
+    "serviceAccount:${data.google_bigquery_default_service_account.bq_account.email}",
+    "serviceAccount:service-${data.google_project.project.number}@compute-system.iam.gserviceaccount.com",
+    "serviceAccount:service-${data.google_project.project.number}@dataproc-accounts.iam.gserviceaccount.com",
+  ]
+
+  # Taking the key from the grants makes encrypted resources wait for them.
+  kms_key_name = one(distinct([for grant in google_kms_crypto_key_iam_member.service_agents : grant.crypto_key_id]))
+}
+
+resource "google_kms_crypto_key_iam_member" "service_agents" {
+  for_each = toset(local.kms_service_agents)
+
+  crypto_key_id = var.kms_key_name
+  role          = "roles/cloudkms.cryptoKeyEncrypterDecrypter"
+  member        = each.key
+
+  depends_on = [time_sleep.wait_after_apis_activate]
+}
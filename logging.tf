@@ -0,0 +1,77 @@
+/**
+ * Copyright 2023 Google LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+# Record who read and wrote lakehouse data
+resource "google_project_iam_audit_config" "data_access" {
+  for_each = toset(var.enable_data_access_audit_logs ? [
+    "bigquery.googleapis.com",
+    "storage.googleapis.com",
+  ] : [])
+
+  project = module.project-services.project_id
+  service = each.key
+
+  audit_log_config {
+    log_type = "DATA_READ"
+  }
+  audit_log_config {
+    log_type = "DATA_WRITE"
+  }
+}
+
+# Route orchestration and processing logs into the lakehouse for analysis
+resource "google_bigquery_dataset" "ops" {
+  count = var.enable_log_sink ? 1 : 0
+
+  project                    = module.project-services.project_id
+  dataset_id                 = "gcp_lakehouse_ops"
+  friendly_name              = "Lakehouse operations logs"
+  description                = "Workflows and Dataproc logs routed from the lakehouse project"
+  location                   = var.region
+  labels                     = var.labels
+  delete_contents_on_destroy = var.force_destroy
+
+  dynamic "default_encryption_configuration" {
+    for_each = local.kms_key_name == null ? [] : [local.kms_key_name]
+    content {
+      kms_key_name = default_encryption_configuration.value
+    }
+  }
+}
+
+resource "google_logging_project_sink" "ops" {
+  count = var.enable_log_sink ? 1 : 0
+
+  project     = module.project-services.project_id
+  name        = "lakehouse-ops-sink"
+  destination = "bigquery.googleapis.com/projects/${module.project-services.project_id}/datasets/${google_bigquery_dataset.ops[0].dataset_id}"
+  filter      = "resource.type=(\"workflows.googleapis.com/Workflow\" OR \"cloud_dataproc_cluster\" OR \"cloud_dataproc_batch\")"
+
+  unique_writer_identity = true
+
+  bigquery_options {
+    use_partitioned_tables = true
+  }
+}
+
+resource "google_bigquery_dataset_iam_member" "ops_sink_writer" {
+  count = var.enable_log_sink ? 1 : 0
+
+  project    = module.project-services.project_id
+  dataset_id = google_bigquery_dataset.ops[0].dataset_id
+  role       = "roles/bigquery.dataEditor"
+  member     = google_logging_project_sink.ops[0].writer_identity
+}
@@ -22,7 +22,8 @@ module "project-services" {
   project_id  = var.project_id
   enable_apis = var.enable_apis
 
-  activate_apis = [
+  # APIs of the optional features are only activated when they are enabled
+  activate_apis = concat([
     "artifactregistry.googleapis.com",
     "biglake.googleapis.com",
     "bigquery.googleapis.com",
@@ -35,6 +36,7 @@ module "project-services" {
     "cloudapis.googleapis.com",
     "cloudbuild.googleapis.com",
     "cloudfunctions.googleapis.com",
+    "cloudresourcemanager.googleapis.com",
     "compute.googleapis.com",
     "config.googleapis.com",
     "datacatalog.googleapis.com",
@@ -46,12 +48,51 @@ module "project-services" {
     "storage-api.googleapis.com",
     "storage.googleapis.com",
     "workflows.googleapis.com",
-  ]
+  ],
+    var.enable_image_inference || var.enable_vector_search || var.enable_notebook ? ["aiplatform.googleapis.com"] : [],
+    var.enable_analytics_hub ? ["analyticshub.googleapis.com"] : [],
+    var.budget_billing_account != "" ? ["billingbudgets.googleapis.com", "monitoring.googleapis.com"] : [],
+    var.enable_iceberg_maintenance || var.enable_retention ? ["cloudscheduler.googleapis.com"] : [],
+    var.enable_dataflow_load ? ["dataflow.googleapis.com"] : [],
+    var.enable_dataform ? ["dataform.googleapis.com"] : [],
+    var.enable_dlp_scan || var.enable_dlp_deidentify ? ["dlp.googleapis.com"] : [],
+    var.enable_private_service_connect || var.enable_restricted_api_access ? ["dns.googleapis.com"] : [],
+    var.enable_firestore_export ? ["firestore.googleapis.com"] : [],
+    var.enable_streaming ? ["pubsub.googleapis.com"] : [],
+    var.enable_remote_function ? ["run.googleapis.com"] : [],
+    var.enable_serving_export ? ["sqladmin.googleapis.com"] : [],
+    var.enable_vpc_connector ? ["vpcaccess.googleapis.com"] : [],
+  )
 }
 
 resource "time_sleep" "wait_after_apis_activate" {
   depends_on      = [module.project-services]
   create_duration = "30s"
+
+  # Everything waits on the APIs, so inputs spanning several variables are
+  # checked here, ahead of any other resource
+  lifecycle {
+    precondition {
+      condition     = (var.network_self_link == null) == (var.subnetwork_self_link == null)
+      error_message = "The network_self_link and subnetwork_self_link must be set together."
+    }
+    precondition {
+      condition     = !var.enable_continuous_query || var.enable_streaming
+      error_message = "The enable_continuous_query requires enable_streaming."
+    }
+    precondition {
+      condition     = !var.enable_analytics_hub || var.enable_dataform
+      error_message = "The enable_analytics_hub requires enable_dataform."
+    }
+    precondition {
+      condition     = var.bi_engine_reservation_gb == 0 || var.enable_dataform
+      error_message = "The bi_engine_reservation_gb requires enable_dataform."
+    }
+    precondition {
+      condition     = !var.enable_delta_lake || var.enable_dataproc
+      error_message = "The enable_delta_lake requires enable_dataproc."
+    }
+  }
 }
 
 # Set up service accounts fine grain sec.
@@ -94,19 +135,72 @@ resource "google_storage_bucket" "raw_bucket" {
   location                    = var.region
   uniform_bucket_level_access = true
   force_destroy               = var.force_destroy
+  labels                      = var.labels
+
+  dynamic "encryption" {
+    for_each = local.kms_key_name == null ? [] : [local.kms_key_name]
+    content {
+      default_kms_key_name = encryption.value
+    }
+  }
 
   # public_access_prevention = "enforced" # need to validate if this is a hard requirement
 }
 
-# # Set up the warehouse storage bucket
+# # Set up the warehouse storage bucket, optionally as a configurable dual-region
+locals {
+  create_warehouse_bucket = var.warehouse_bucket_name == null
+  create_tables_bucket    = var.tables_bucket_name == null
+
+  warehouse_bucket = local.create_warehouse_bucket ? one(google_storage_bucket.warehouse_bucket[*].name) : var.warehouse_bucket_name
+  tables_bucket    = local.create_tables_bucket ? one(google_storage_bucket.tables_bucket[*].name) : var.tables_bucket_name
+
+  warehouse_dual_region = length(var.warehouse_dual_region) > 0
+  warehouse_location = (
+    local.warehouse_dual_region
+    ? lookup({ us = "US", europe = "EU", asia = "ASIA" }, split("-", var.warehouse_dual_region[0])[0])
+    : var.region
+  )
+}
+
 resource "google_storage_bucket" "warehouse_bucket" {
+  count = local.create_warehouse_bucket ? 1 : 0
+
   name                        = "gcp-${var.use_case_short}-warehouse-${random_id.id.hex}"
   project                     = module.project-services.project_id
-  location                    = var.region
+  location                    = local.warehouse_location
   uniform_bucket_level_access = true
   force_destroy               = var.force_destroy
+  labels                      = var.labels
+  rpo                         = local.warehouse_dual_region ? (var.warehouse_turbo_replication ? "ASYNC_TURBO" : "DEFAULT") : null
+
+  dynamic "custom_placement_config" {
+    for_each = local.warehouse_dual_region ? [var.warehouse_dual_region] : []
+    content {
+      data_locations = [for location in custom_placement_config.value : upper(location)]
+    }
+  }
+
+  dynamic "encryption" {
+    for_each = local.kms_key_name == null ? [] : [local.kms_key_name]
+    content {
+      default_kms_key_name = encryption.value
+    }
+  }
 
   # public_access_prevention = "enforced" # need to validate if this is a hard requirement
+
+  lifecycle {
+    precondition {
+      condition     = !local.warehouse_dual_region || contains(var.warehouse_dual_region, var.region)
+      error_message = "The warehouse_dual_region must include the region."
+    }
+  }
+}
+
+moved {
+  from = google_storage_bucket.warehouse_bucket
+  to   = google_storage_bucket.warehouse_bucket[0]
 }
 
 # # Set up the provisioning bucketstorage bucket
@@ -116,6 +210,14 @@ resource "google_storage_bucket" "provisioning_bucket" {
   location                    = var.region
   uniform_bucket_level_access = true
   force_destroy               = var.force_destroy
+  labels                      = var.labels
+
+  dynamic "encryption" {
+    for_each = local.kms_key_name == null ? [] : [local.kms_key_name]
+    content {
+      default_kms_key_name = encryption.value
+    }
+  }
 
 }
 
@@ -125,6 +227,14 @@ resource "google_storage_bucket" "ga4_images_bucket" {
   location                    = var.region
   uniform_bucket_level_access = true
   force_destroy               = var.force_destroy
+  labels                      = var.labels
+
+  dynamic "encryption" {
+    for_each = local.kms_key_name == null ? [] : [local.kms_key_name]
+    content {
+      default_kms_key_name = encryption.value
+    }
+  }
 }
 
 resource "google_storage_bucket" "textocr_images_bucket" {
@@ -133,14 +243,37 @@ resource "google_storage_bucket" "textocr_images_bucket" {
   location                    = var.region
   uniform_bucket_level_access = true
   force_destroy               = var.force_destroy
+  labels                      = var.labels
+
+  dynamic "encryption" {
+    for_each = local.kms_key_name == null ? [] : [local.kms_key_name]
+    content {
+      default_kms_key_name = encryption.value
+    }
+  }
 }
 
 resource "google_storage_bucket" "tables_bucket" {
+  count = local.create_tables_bucket ? 1 : 0
+
   name                        = "gcp-${var.use_case_short}-tables-${random_id.id.hex}"
   project                     = module.project-services.project_id
   location                    = var.region
   uniform_bucket_level_access = true
   force_destroy               = var.force_destroy
+  labels                      = var.labels
+
+  dynamic "encryption" {
+    for_each = local.kms_key_name == null ? [] : [local.kms_key_name]
+    content {
+      default_kms_key_name = encryption.value
+    }
+  }
+}
+
+moved {
+  from = google_storage_bucket.tables_bucket
+  to   = google_storage_bucket.tables_bucket[0]
 }
 
 # Bucket used to store BI data in Dataplex
@@ -150,6 +283,14 @@ resource "google_storage_bucket" "dataplex_bucket" {
   location                    = var.region
   uniform_bucket_level_access = true
   force_destroy               = var.force_destroy
+  labels                      = var.labels
+
+  dynamic "encryption" {
+    for_each = local.kms_key_name == null ? [] : [local.kms_key_name]
+    content {
+      default_kms_key_name = encryption.value
+    }
+  }
 }
 
 resource "google_storage_bucket_object" "pyspark_file" {
@@ -163,12 +304,38 @@ resource "google_storage_bucket_object" "pyspark_file" {
 
 }
 
+# Batch that installs a PyPI package, used to check egress through Cloud NAT
+resource "google_storage_bucket_object" "egress_check_file" {
+  count = var.enable_nat ? 1 : 0
+
+  bucket = google_storage_bucket.provisioning_bucket.name
+  name   = "egress_check.py"
+  source = "${path.module}/src/egress_check.py"
+}
+
+# Batch that checks Google APIs resolve to the Private Service Connect endpoint
+resource "google_storage_bucket_object" "psc_check_file" {
+  count = var.enable_private_service_connect ? 1 : 0
+
+  bucket = google_storage_bucket.provisioning_bucket.name
+  name   = "psc_check.py"
+  source = "${path.module}/src/psc_check.py"
+}
+
 resource "google_storage_bucket" "spark-log-directory" {
   name                        = "gcp-${var.use_case_short}-spark-log-directory-${random_id.id.hex}"
   project                     = module.project-services.project_id
   location                    = var.region
   uniform_bucket_level_access = true
   force_destroy               = var.force_destroy
+  labels                      = var.labels
+
+  dynamic "encryption" {
+    for_each = local.kms_key_name == null ? [] : [local.kms_key_name]
+    content {
+      default_kms_key_name = encryption.value
+    }
+  }
 }
 
 resource "google_storage_bucket" "phs-staging-bucket" {
@@ -177,6 +344,14 @@ resource "google_storage_bucket" "phs-staging-bucket" {
   location                    = var.region
   uniform_bucket_level_access = true
   force_destroy               = var.force_destroy
+  labels                      = var.labels
+
+  dynamic "encryption" {
+    for_each = local.kms_key_name == null ? [] : [local.kms_key_name]
+    content {
+      default_kms_key_name = encryption.value
+    }
+  }
 }
 
 resource "google_storage_bucket" "phs-temp-bucket" {
@@ -185,4 +360,12 @@ resource "google_storage_bucket" "phs-temp-bucket" {
   location                    = var.region
   uniform_bucket_level_access = true
   force_destroy               = var.force_destroy
+  labels                      = var.labels
+
+  dynamic "encryption" {
+    for_each = local.kms_key_name == null ? [] : [local.kms_key_name]
+    content {
+      default_kms_key_name = encryption.value
+    }
+  }
 }
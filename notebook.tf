@@ -0,0 +1,31 @@
+/**
+ * Copyright 2023 Google LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+# Optional notebook: a sample lakehouse notebook in the provisioning bucket and
+# a Colab Enterprise runtime template to run it on. The project-setup workflow
+# creates the template, since the supported provider versions have no resource
+# for it.
+locals {
+  notebook_runtime_template_id = "lakehouse-runtime"
+}
+
+resource "google_storage_bucket_object" "quickstart_notebook" {
+  count = var.enable_notebook ? 1 : 0
+
+  bucket = google_storage_bucket.provisioning_bucket.name
+  name   = "notebooks/lakehouse-quickstart.ipynb"
+  source = "${path.module}/src/ipynb/lakehouse-quickstart.ipynb"
+}
@@ -16,7 +16,7 @@
 
 output "workflow_return_project_setup" {
   description = "Output of the project setup workflow"
-  value       = data.http.call_workflows_project_setup.response_body
+  value       = one(data.http.call_workflows_project_setup[*].response_body)
 }
 
 output "lookerstudio_report_url" {
@@ -30,7 +30,7 @@ output "bigquery_editor_url" {
 }
 
 output "neos_tutorial_url" {
-  value       = "http://console.cloud.google.com/products/solutions/deployments?walkthrough_id=panels--sic--analytics-lakehouse_toc"
+  value       = "https://console.cloud.google.com/products/solutions/deployments?walkthrough_id=panels--sic--analytics-lakehouse_toc"
   description = "The URL to launch the in-console tutorial for the Analytics Lakehouse solution"
 }
 
@@ -43,3 +43,183 @@ output "region" {
   value       = var.region
   description = "The Compute region where resources are created."
 }
+
+output "lakehouse_dataset_id" {
+  value       = local.lakehouse_dataset_id
+  description = "The ID of the BigQuery dataset holding the lakehouse tables and views."
+}
+
+output "tables_bucket" {
+  value       = local.tables_bucket
+  description = "The name of the bucket holding the tabular data registered with Dataplex."
+}
+
+output "textocr_images_bucket" {
+  value       = google_storage_bucket.textocr_images_bucket.name
+  description = "The name of the bucket holding the TextOCR images registered with Dataplex."
+}
+
+output "ga4_images_bucket" {
+  value       = google_storage_bucket.ga4_images_bucket.name
+  description = "The name of the bucket holding the GA4 images registered with Dataplex."
+}
+
+output "data_analyst_service_account" {
+  value       = google_service_account.data_analyst_user.email
+  description = "The email of the data analyst service account, which only holds lake-level read roles."
+}
+
+output "warehouse_bucket" {
+  value       = local.warehouse_bucket
+  description = "The name of the bucket holding the Iceberg warehouse registered in BigLake Metastore."
+}
+
+output "workflows_service_account" {
+  value       = google_service_account.workflows_sa.email
+  description = "The email of the orchestration service account the project-setup workflow runs as."
+}
+
+output "dataproc_service_account" {
+  value       = google_service_account.dataproc_service_account.email
+  description = "The email of the data-plane service account that owns data writes."
+}
+
+output "ops_dataset_id" {
+  value       = var.enable_log_sink ? google_bigquery_dataset.ops[0].dataset_id : null
+  description = "The ID of the BigQuery dataset receiving Workflows and Dataproc logs, when the log sink is enabled."
+}
+
+output "access_consumer_service_account" {
+  value       = var.enable_access_layer ? google_service_account.access_consumer[0].email : null
+  description = "The email of the access layer consumer service account, which can only query the curated views, when the access layer is enabled."
+}
+
+output "dataproc_subnetwork" {
+  value       = local.subnetwork
+  description = "The self link of the subnet the Dataproc cluster and serverless Spark batches run on."
+}
+
+output "vpc_connector" {
+  value       = one(google_vpc_access_connector.connector[*].id)
+  description = "The ID of the Serverless VPC Access connector for serverless integrations, when the connector is enabled."
+}
+
+output "streaming_topic" {
+  value       = one(google_pubsub_topic.events[*].id)
+  description = "The ID of the Pub/Sub topic streaming events into the raw zone, when streaming is enabled."
+}
+
+output "delta_lake_uri" {
+  value       = local.enable_delta_lake ? local.delta_lake_uri : null
+  description = "The Cloud Storage path of the Delta Lake table agg_events_delta reads, when Delta Lake is enabled."
+}
+
+output "iceberg_maintenance_workflow" {
+  value       = one(google_workflows_workflow.iceberg_maintenance[*].name)
+  description = "The name of the workflow Cloud Scheduler executes to compact agg_events_iceberg and expire its old snapshots, when Iceberg maintenance is enabled."
+}
+
+output "dlp_findings_table" {
+  value       = var.enable_dlp_scan ? "${local.lakehouse_dataset_id}.${local.dlp_findings_table}" : null
+  description = "The BigQuery table, as dataset.table, the DLP job triggers save their findings into, when the DLP scan is enabled."
+}
+
+output "dlp_deidentified_users_table" {
+  value       = var.enable_dlp_deidentify ? "${local.lakehouse_dataset_id}.${local.dlp_deidentified_users_table}" : null
+  description = "The BigQuery table, as dataset.table, holding the de-identified copy of the thelook users, when DLP de-identification is enabled."
+}
+
+output "retention_workflow" {
+  value       = one(google_workflows_workflow.retention[*].name)
+  description = "The name of the workflow Cloud Scheduler executes to move aged order partitions to the archive bucket, when retention is enabled."
+}
+
+output "archive_bucket" {
+  value       = one(google_storage_bucket.archive_bucket[*].name)
+  description = "The name of the Coldline bucket aged order partitions are archived to, when retention is enabled."
+}
+
+output "buckets" {
+  value       = concat(local.tagged_buckets, google_storage_bucket.archive_bucket[*].name)
+  description = "The names of the Cloud Storage buckets the blueprint created."
+}
+
+output "dataform_repository" {
+  value       = one(google_dataform_repository.lakehouse[*].id)
+  description = "The ID of the Dataform repository building the curated layer, when Dataform is enabled."
+}
+
+output "notebook_gcs_uri" {
+  value       = var.enable_notebook ? "gs://${google_storage_bucket_object.quickstart_notebook[0].bucket}/${google_storage_bucket_object.quickstart_notebook[0].name}" : null
+  description = "The Cloud Storage URI of the sample lakehouse notebook, when the notebook is enabled."
+}
+
+output "notebook_runtime_template" {
+  value       = var.enable_notebook ? "projects/${module.project-services.project_id}/locations/${var.region}/notebookRuntimeTemplates/${local.notebook_runtime_template_id}" : null
+  description = "The resource name of the Colab Enterprise runtime template the project-setup workflow creates for the sample notebook, when the notebook is enabled."
+}
+
+output "scheduled_query_transfer_config" {
+  value       = one(google_bigquery_data_transfer_config.daily_order_aggregates[*].name)
+  description = "The resource name of the Data Transfer Service config for the daily aggregates scheduled query, when scheduled queries are enabled."
+}
+
+output "raw_data_format" {
+  value       = var.raw_data_format
+  description = "The file format of the thelook tables in the tables bucket."
+}
+
+output "transfer_load_config" {
+  value       = one(google_bigquery_data_transfer_config.orders_transfer[*].name)
+  description = "The resource name of the Data Transfer Service config loading the raw orders files, when the transfer load is enabled."
+}
+
+output "analytics_hub_listing" {
+  value       = one(google_bigquery_analytics_hub_listing.curated[*].name)
+  description = "The resource name of the Analytics Hub listing sharing the curated dataset, when Analytics Hub is enabled."
+}
+
+output "analytics_hub_subscriber_service_account" {
+  value       = one(google_service_account.hub_subscriber[*].email)
+  description = "The email of the service account allowed to subscribe to the curated listing, when Analytics Hub is enabled."
+}
+
+output "serving_instance" {
+  value       = one(google_sql_database_instance.serving[*].name)
+  description = "The name of the Cloud SQL instance the serving-export workflow loads the aggregated events into, when the serving export is enabled."
+}
+
+output "serving_database" {
+  value       = one(google_sql_database.serving[*].name)
+  description = "The Cloud SQL database holding the exported agg_events table, when the serving export is enabled."
+}
+
+output "serving_bucket" {
+  value       = one(google_storage_bucket.serving_bucket[*].name)
+  description = "The bucket staging the CSV files the serving-export workflow imports into Cloud SQL, when the serving export is enabled."
+}
+
+output "firestore_database" {
+  value       = one(google_firestore_database.serving[*].name)
+  description = "The ID of the Firestore database the firestore-export workflow writes the top users into, when the Firestore export is enabled."
+}
+
+output "bi_engine_reservation" {
+  value       = one(google_bigquery_bi_reservation.curated[*].id)
+  description = "The ID of the BI Engine reservation accelerating the curated tables, when a reservation size is set."
+}
+
+output "slot_reservation" {
+  value       = one(google_bigquery_reservation.queries[*].id)
+  description = "The ID of the Enterprise edition reservation the project's query jobs are assigned to, when the slot reservation is enabled."
+}
+
+output "budget" {
+  value       = one(google_billing_budget.lakehouse[*].name)
+  description = "The resource name of the monthly billing budget, when a budget billing account is set."
+}
+
+output "budget_notification_channels" {
+  value       = [for c in google_monitoring_notification_channel.budget : c.name]
+  description = "The Cloud Monitoring notification channels the budget alerts are sent to."
+}
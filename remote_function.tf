@@ -0,0 +1,114 @@
+/**
+ * Copyright 2023 Google LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+# Optional BigQuery remote function: score_event calls a Cloud Function
+# through its own connection. The project-setup workflow creates the routine.
+resource "google_service_account" "function_sa" {
+  count = var.enable_remote_function ? 1 : 0
+
+  project      = module.project-services.project_id
+  account_id   = "function-sa-${random_id.id.hex}"
+  display_name = "Service Account for the remote function"
+}
+
+data "archive_file" "score_event" {
+  count = var.enable_remote_function ? 1 : 0
+
+  type        = "zip"
+  source_dir  = "${path.module}/src/functions/score_event"
+  output_path = "${path.module}/src/functions/score_event.zip"
+}
+
+resource "google_storage_bucket_object" "score_event_source" {
+  count = var.enable_remote_function ? 1 : 0
+
+  bucket = google_storage_bucket.provisioning_bucket.name
+  name   = "functions/score_event-${data.archive_file.score_event[0].output_md5}.zip"
+  source = data.archive_file.score_event[0].output_path
+}
+
+resource "google_cloudfunctions2_function" "score_event" {
+  count = var.enable_remote_function ? 1 : 0
+
+  project     = module.project-services.project_id
+  location    = var.region
+  name        = "score-event"
+  description = "Scores order items for the score_event BigQuery remote function"
+  labels      = var.labels
+
+  build_config {
+    runtime     = "python311"
+    entry_point = "score_event"
+    source {
+      storage_source {
+        bucket = google_storage_bucket_object.score_event_source[0].bucket
+        object = google_storage_bucket_object.score_event_source[0].name
+      }
+    }
+  }
+
+  service_config {
+    max_instance_count    = 3
+    available_memory      = "256M"
+    timeout_seconds       = 60
+    service_account_email = google_service_account.function_sa[0].email
+
+    # Scoring needs no internet access; keep any egress on the VPC
+    vpc_connector                 = one(google_vpc_access_connector.connector[*].id)
+    vpc_connector_egress_settings = var.enable_vpc_connector && !local.use_shared_vpc ? "ALL_TRAFFIC" : null
+  }
+
+  depends_on = [time_sleep.wait_after_apis_activate]
+}
+
+resource "google_bigquery_connection" "function_connection" {
+  count = var.enable_remote_function ? 1 : 0
+
+  project       = module.project-services.project_id
+  connection_id = "gcp_function_connection"
+  location      = var.region
+  friendly_name = "Remote function connection"
+  cloud_resource {}
+}
+
+# # Only the connection can invoke the function
+resource "google_cloud_run_service_iam_member" "function_invoker" {
+  count = var.enable_remote_function ? 1 : 0
+
+  project  = module.project-services.project_id
+  location = var.region
+  service  = google_cloudfunctions2_function.score_event[0].name
+  role     = "roles/run.invoker"
+  member   = "serviceAccount:${google_bigquery_connection.function_connection[0].cloud_resource[0].service_account_id}"
+}
+
+# # Allow the workflows service account to create the routine on the connection
+resource "google_bigquery_connection_iam_member" "workflows_sa_function_connection" {
+  count = var.enable_remote_function ? 1 : 0
+
+  project       = module.project-services.project_id
+  location      = var.region
+  connection_id = google_bigquery_connection.function_connection[0].connection_id
+  role          = "roles/bigquery.connectionAdmin"
+  member        = "serviceAccount:${google_service_account.workflows_sa.email}"
+}
+
+locals {
+  remote_function_ddl = var.enable_remote_function ? templatefile("${path.module}/src/sql/remote_function.sql", {
+    connection = "${module.project-services.project_id}.${var.region}.${google_bigquery_connection.function_connection[0].connection_id}"
+    endpoint   = google_cloudfunctions2_function.score_event[0].service_config[0].uri
+  }) : ""
+}
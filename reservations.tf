@@ -0,0 +1,57 @@
+/**
+ * Copyright 2023 Google LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+# Optional capacity-based pricing: the project's query jobs run on an
+# Enterprise edition reservation instead of on-demand. Continuous queries keep
+# their own reservation in streaming.tf.
+resource "google_bigquery_reservation" "queries" {
+  count = var.enable_slot_reservation ? 1 : 0
+
+  project           = module.project-services.project_id
+  location          = var.region
+  name              = "lakehouse-queries"
+  edition           = "ENTERPRISE"
+  slot_capacity     = var.reservation_baseline_slots
+  ignore_idle_slots = false
+
+  autoscale {
+    max_slots = var.reservation_autoscale_max_slots
+  }
+
+  depends_on = [time_sleep.wait_after_apis_activate]
+}
+
+resource "google_bigquery_reservation_assignment" "queries" {
+  count = var.enable_slot_reservation ? 1 : 0
+
+  project     = module.project-services.project_id
+  location    = var.region
+  reservation = google_bigquery_reservation.queries[0].id
+  assignee    = "projects/${module.project-services.project_id}"
+  job_type    = "QUERY"
+}
+
+# Search indexes on tables under 10 GB are only built by index management jobs
+# running on a reservation.
+resource "google_bigquery_reservation_assignment" "background" {
+  count = var.enable_slot_reservation && var.enable_search_index ? 1 : 0
+
+  project     = module.project-services.project_id
+  location    = var.region
+  reservation = google_bigquery_reservation.queries[0].id
+  assignee    = "projects/${module.project-services.project_id}"
+  job_type    = "BACKGROUND"
+}
@@ -0,0 +1,100 @@
+/**
+ * Copyright 2023 Google LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+# Optional data retention: Cloud Scheduler executes a daily workflow that
+# moves the orders older than retention_days from the hot thelook_orders_hot
+# table to a Coldline archive bucket, where an external table in the archive
+# dataset keeps them queryable.
+resource "google_storage_bucket" "archive_bucket" {
+  count = var.enable_retention ? 1 : 0
+
+  name                        = "gcp-${var.use_case_short}-archive-${random_id.id.hex}"
+  project                     = module.project-services.project_id
+  location                    = var.region
+  storage_class               = "COLDLINE"
+  uniform_bucket_level_access = true
+  force_destroy               = var.force_destroy
+  labels                      = var.labels
+
+  dynamic "encryption" {
+    for_each = local.kms_key_name == null ? [] : [local.kms_key_name]
+    content {
+      default_kms_key_name = encryption.value
+    }
+  }
+}
+
+resource "google_bigquery_dataset" "gcp_lakehouse_archive" {
+  count = var.enable_retention ? 1 : 0
+
+  project                    = module.project-services.project_id
+  dataset_id                 = "gcp_lakehouse_archive"
+  friendly_name              = "Lakehouse archive"
+  description                = "Tables over the lakehouse data moved to the archive bucket"
+  location                   = var.region
+  labels                     = var.labels
+  delete_contents_on_destroy = var.force_destroy
+
+  dynamic "default_encryption_configuration" {
+    for_each = local.kms_key_name == null ? [] : [local.kms_key_name]
+    content {
+      kms_key_name = default_encryption_configuration.value
+    }
+  }
+}
+
+# The workflow moves data, so it runs as the data-plane service account
+resource "google_workflows_workflow" "retention" {
+  count = var.enable_retention ? 1 : 0
+
+  name            = "data-retention"
+  project         = module.project-services.project_id
+  region          = var.region
+  description     = "Moves aged order partitions to the archive bucket"
+  service_account = google_service_account.dataproc_service_account.email
+  labels          = var.labels
+  source_contents = templatefile("${path.module}/src/yaml/retention.yaml", {
+    retention_sql = jsonencode(templatefile("${path.module}/src/sql/retention.sql", {
+      retention_days = var.retention_days
+      archive_bucket = google_storage_bucket.archive_bucket[0].name
+    }))
+  })
+
+  depends_on = [
+    google_project_iam_member.dataproc_sa_roles,
+    google_bigquery_dataset.gcp_lakehouse_archive
+  ]
+}
+
+resource "google_cloud_scheduler_job" "retention" {
+  count = var.enable_retention ? 1 : 0
+
+  project     = module.project-services.project_id
+  region      = var.region
+  name        = "data-retention"
+  description = "Executes the data-retention workflow"
+  schedule    = "0 4 * * *"
+  time_zone   = "Etc/UTC"
+
+  http_target {
+    http_method = "POST"
+    uri         = "https://workflowexecutions.googleapis.com/v1/${google_workflows_workflow.retention[0].id}/executions"
+
+    oauth_token {
+      service_account_email = google_service_account.workflows_sa.email
+    }
+  }
+}
@@ -0,0 +1,111 @@
+/**
+ * Copyright 2023 Google LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+# Optional serving layer: the serving-export workflow exports the aggregated
+# events to CSV and imports them into a small Cloud SQL instance that
+# applications can query. Cloud SQL only imports from Cloud Storage, so the
+# files are staged in a dedicated bucket.
+resource "google_storage_bucket" "serving_bucket" {
+  count = var.enable_serving_export ? 1 : 0
+
+  name                        = "gcp-${var.use_case_short}-serving-${random_id.id.hex}"
+  project                     = module.project-services.project_id
+  location                    = var.region
+  uniform_bucket_level_access = true
+  force_destroy               = var.force_destroy
+  labels                      = var.labels
+
+  dynamic "encryption" {
+    for_each = local.kms_key_name == null ? [] : [local.kms_key_name]
+    content {
+      default_kms_key_name = encryption.value
+    }
+  }
+}
+
+resource "google_storage_bucket_object" "serving_tables" {
+  count = var.enable_serving_export ? 1 : 0
+
+  bucket = google_storage_bucket.serving_bucket[0].name
+  name   = "serving_tables.sql"
+  source = "${path.module}/src/sql/serving_tables.sql"
+}
+
+resource "google_sql_database_instance" "serving" {
+  count = var.enable_serving_export ? 1 : 0
+
+  project             = module.project-services.project_id
+  name                = "lakehouse-serving-${random_id.id.hex}"
+  region              = var.region
+  database_version    = "MYSQL_8_0"
+  deletion_protection = !var.force_destroy
+
+  settings {
+    tier        = "db-f1-micro"
+    edition     = "ENTERPRISE"
+    user_labels = var.labels
+
+    # Imports and exports go through the Admin API, so no network is authorized
+    ip_configuration {
+      ipv4_enabled = true
+    }
+  }
+
+  depends_on = [time_sleep.wait_after_apis_activate]
+}
+
+resource "google_sql_database" "serving" {
+  count = var.enable_serving_export ? 1 : 0
+
+  project  = module.project-services.project_id
+  instance = google_sql_database_instance.serving[0].name
+  name     = "lakehouse_serving"
+}
+
+# # Allow the instance to read the staged files and write verification exports
+resource "google_storage_bucket_iam_member" "serving_instance_objects" {
+  count = var.enable_serving_export ? 1 : 0
+
+  bucket = google_storage_bucket.serving_bucket[0].name
+  role   = "roles/storage.objectAdmin"
+  member = "serviceAccount:${google_sql_database_instance.serving[0].service_account_email_address}"
+}
+
+# The export writes data, so it runs as the data-plane service account.
+resource "google_workflows_workflow" "serving_export" {
+  count = var.enable_serving_export ? 1 : 0
+
+  name            = "serving-export"
+  project         = module.project-services.project_id
+  region          = var.region
+  description     = "Exports the aggregated events to the Cloud SQL serving instance"
+  service_account = google_service_account.dataproc_service_account.email
+  labels          = var.labels
+  source_contents = templatefile("${path.module}/src/yaml/serving-export.yaml", {
+    serving_bucket   = google_storage_bucket.serving_bucket[0].name,
+    serving_instance = google_sql_database_instance.serving[0].name,
+    serving_database = google_sql_database.serving[0].name,
+    serving_tables   = google_storage_bucket_object.serving_tables[0].name,
+    export_sql = jsonencode(templatefile("${path.module}/src/sql/serving_export.sql", {
+      serving_bucket = google_storage_bucket.serving_bucket[0].name
+    }))
+  })
+
+  depends_on = [
+    google_project_iam_member.dataproc_sa_roles,
+    google_storage_bucket_iam_member.serving_instance_objects
+  ]
+}
@@ -0,0 +1,67 @@
+/**
+ * Copyright 2023 Google LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+# Optional table snapshots: the project-setup workflow snapshots the orders
+# into a dedicated dataset, and the restore_snapshot procedure clones a
+# snapshot back into a table.
+resource "google_bigquery_dataset" "gcp_lakehouse_snapshots" {
+  count = var.enable_snapshots ? 1 : 0
+
+  project                    = module.project-services.project_id
+  dataset_id                 = "gcp_lakehouse_snapshots"
+  friendly_name              = "Lakehouse snapshots"
+  description                = "Point-in-time snapshots of the lakehouse tables, kept for 7 days"
+  location                   = var.region
+  labels                     = var.labels
+  delete_contents_on_destroy = var.force_destroy
+
+  dynamic "default_encryption_configuration" {
+    for_each = local.kms_key_name == null ? [] : [local.kms_key_name]
+    content {
+      kms_key_name = default_encryption_configuration.value
+    }
+  }
+}
+
+resource "google_bigquery_dataset_iam_member" "workflows_sa_snapshots" {
+  count = var.enable_snapshots ? 1 : 0
+
+  project    = module.project-services.project_id
+  dataset_id = google_bigquery_dataset.gcp_lakehouse_snapshots[0].dataset_id
+  role       = "roles/bigquery.dataEditor"
+  member     = "serviceAccount:${google_service_account.workflows_sa.email}"
+}
+
+resource "google_bigquery_routine" "restore_snapshot" {
+  count = var.enable_snapshots ? 1 : 0
+
+  project         = module.project-services.project_id
+  dataset_id      = local.lakehouse_dataset_id
+  routine_id      = "restore_snapshot"
+  routine_type    = "PROCEDURE"
+  language        = "SQL"
+  definition_body = file("${path.module}/src/sql/restore_snapshot.sql")
+
+  arguments {
+    name      = "snapshot_table"
+    data_type = jsonencode({ typeKind = "STRING" })
+  }
+
+  arguments {
+    name      = "destination_table"
+    data_type = jsonencode({ typeKind = "STRING" })
+  }
+}
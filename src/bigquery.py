@@ -29,6 +29,8 @@ database = os.getenv("lakehouse_db", "lakehouse_db")
 bq_dataset = os.getenv("bq_dataset", "gcp_lakehouse_ds")
 bq_connection = os.getenv("bq_gcs_connection",
                           "us-central1.gcp_gcs_connection")
+enable_delta_lake = os.getenv("enable_delta_lake", "false") == "true"
+delta_lake_uri = os.getenv("delta_lake_uri")
 
 # Use the Cloud Storage bucket for temporary BigQuery export data
 # used by the connector.
@@ -69,3 +71,17 @@ spark.sql(
     group by user_id;
     """
 )
+
+# Write the same aggregate as a Delta Lake table, with the symlink manifest
+# BigQuery reads it through.
+if enable_delta_lake:
+    from delta.tables import DeltaTable
+
+    spark.sql(
+        """select user_id, count(session_id) as event_count
+        from events
+        group by user_id
+        """
+    ).write.format("delta").mode("overwrite").save(delta_lake_uri)
+    DeltaTable.forPath(spark, delta_lake_uri) \
+        .generate("symlink_format_manifest")
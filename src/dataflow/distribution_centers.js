@@ -0,0 +1,36 @@
+/**
+ * Copyright 2023 Google LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+/**
+ * Transforms a distribution centers CSV line into a BigQuery row, splitting
+ * the state off the name and adding a GEOGRAPHY point.
+ * @param {string} line A CSV line: id,name,latitude,longitude.
+ * @return {string} The row as JSON.
+ */
+function transform(line) {
+  var fields = line.split(',');
+  var name = fields[1];
+  var latitude = parseFloat(fields[2]);
+  var longitude = parseFloat(fields[3]);
+  return JSON.stringify({
+    id: parseInt(fields[0], 10),
+    name: name,
+    state: name.substring(name.lastIndexOf(' ') + 1),
+    latitude: latitude,
+    longitude: longitude,
+    location: 'POINT(' + longitude + ' ' + latitude + ')',
+  });
+}
@@ -0,0 +1,87 @@
+#!/usr/bin/python
+# Copyright 2023 Google LLC
+#
+# Licensed under the Apache License, Version 2.0 (the "License");
+# you may not use this file except in compliance with the License.
+# You may obtain a copy of the License at
+#
+#      http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+
+"""Writes a de-identified copy of the thelook users with a DLP template.
+
+Usage: deidentify_users.py <deidentify template> <destination table>
+"""
+import sys
+
+import google.auth
+from google.auth.transport.requests import AuthorizedSession
+from pyspark.sql import SparkSession
+
+spark = SparkSession \
+    .builder \
+    .appName("deidentify-users") \
+    .getOrCreate()
+
+template, destination = sys.argv[1:3]
+location = template.split("/")[3]
+
+# Columns the template transforms. The others are copied unchanged.
+columns = ["first_name", "last_name", "email", "street_address"]
+# DLP limits each request to about 0.5 MB
+batch_size = 500
+
+
+def deidentify(rows):
+    credentials, project = google.auth.default(
+        scopes=["https://www.googleapis.com/auth/cloud-platform"])
+    session = AuthorizedSession(credentials)
+    url = f"https://dlp.googleapis.com/v2/projects/{project}/" \
+        f"locations/{location}/content:deidentify"
+
+    def flush(batch):
+        table = {
+            "headers": [{"name": column} for column in columns],
+            "rows": [{"values": [{"stringValue": row[column] or ""}
+                                 for column in columns]} for row in batch],
+        }
+        response = session.post(url, json={
+            "deidentifyTemplateName": template,
+            "item": {"table": table},
+        })
+        response.raise_for_status()
+        for row, result in zip(batch,
+                               response.json()["item"]["table"]["rows"]):
+            values = row.asDict()
+            for column, value in zip(columns, result["values"]):
+                if values[column] is not None:
+                    values[column] = value.get("stringValue", "")
+            yield values
+
+    batch = []
+    for row in rows:
+        batch.append(row)
+        if len(batch) == batch_size:
+            yield from flush(batch)
+            batch = []
+    if batch:
+        yield from flush(batch)
+
+
+users = spark.read.format("bigquery") \
+    .option("table", "gcp_primary_staging.thelook_ecommerce_users") \
+    .load()
+
+spark.createDataFrame(users.rdd.mapPartitions(deidentify), users.schema) \
+    .write.format("bigquery") \
+    .option("table", destination) \
+    .option("writeMethod", "direct") \
+    .mode("overwrite") \
+    .save()
+
+spark.stop()
@@ -0,0 +1,36 @@
+#!/usr/bin/python
+# Copyright 2023 Google LLC
+#
+# Licensed under the Apache License, Version 2.0 (the "License");
+# you may not use this file except in compliance with the License.
+# You may obtain a copy of the License at
+#
+#      http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+
+"""Installs a package from PyPI to check the batch has internet egress."""
+import subprocess
+import sys
+import tempfile
+
+from pyspark.sql import SparkSession
+
+spark = SparkSession \
+    .builder \
+    .appName("egress-check") \
+    .getOrCreate()
+
+# Fails the batch if PyPI cannot be reached through Cloud NAT
+with tempfile.TemporaryDirectory() as target:
+    subprocess.run(
+        [sys.executable, "-m", "pip", "install", "--no-deps",
+         "--target", target, "six"],
+        check=True,
+        timeout=300)
+
+spark.stop()
@@ -0,0 +1,50 @@
+# Copyright 2023 Google LLC
+#
+# Licensed under the Apache License, Version 2.0 (the "License");
+# you may not use this file except in compliance with the License.
+# You may obtain a copy of the License at
+#
+#      http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+
+"""BigQuery remote function scoring order items by status and sale price."""
+
+import json
+
+import functions_framework
+
+# Weight applied to the sale price for each order item status. Unknown
+# statuses score zero.
+STATUS_WEIGHTS = {
+    "Complete": 1.0,
+    "Shipped": 0.8,
+    "Processing": 0.5,
+    "Cancelled": 0.0,
+    "Returned": -1.0,
+}
+
+
+def score(status, sale_price):
+    """Returns the score for one call, or None when an argument is NULL."""
+    if status is None or sale_price is None:
+        return None
+    return round(STATUS_WEIGHTS.get(status, 0.0) * sale_price, 2)
+
+
+@functions_framework.http
+def score_event(request):
+    """Handles a batch of BigQuery remote function calls.
+
+    BigQuery sends {"calls": [[status, sale_price], ...]} and expects one
+    reply per call, in order.
+    """
+    try:
+        calls = request.get_json()["calls"]
+        return json.dumps({"replies": [score(*call) for call in calls]})
+    except Exception as e:  # pylint: disable=broad-except
+        return json.dumps({"errorMessage": str(e)}), 400
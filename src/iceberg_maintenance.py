@@ -0,0 +1,50 @@
+#!/usr/bin/python
+# Copyright 2023 Google LLC
+#
+# Licensed under the Apache License, Version 2.0 (the "License");
+# you may not use this file except in compliance with the License.
+# You may obtain a copy of the License at
+#
+#      http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+
+"""Compacts agg_events_iceberg and expires its old snapshots."""
+from datetime import datetime, timezone
+from pyspark.sql import SparkSession
+import os
+
+spark = SparkSession \
+    .builder \
+    .appName("iceberg-maintenance") \
+    .getOrCreate()
+
+catalog = os.getenv("lakehouse_catalog", "lakehouse_catalog")
+database = os.getenv("lakehouse_db", "lakehouse_db")
+table = f"{database}.agg_events_iceberg"
+
+# Rewrite the small files each insert leaves into as few files as the target
+# file size allows.
+spark.sql(
+    f"""CALL {catalog}.system.rewrite_data_files(
+        table => '{table}',
+        options => map('min-input-files', '2'));
+    """
+).show()
+
+# Keep only the current snapshot, which deletes the data files the compaction
+# replaced.
+now = datetime.now(timezone.utc).strftime("%Y-%m-%d %H:%M:%S")
+spark.sql(
+    f"""CALL {catalog}.system.expire_snapshots(
+        table => '{table}',
+        older_than => TIMESTAMP '{now}',
+        retain_last => 1);
+    """
+).show()
+
+spark.stop()
@@ -0,0 +1,50 @@
+#!/usr/bin/python
+# Copyright 2023 Google LLC
+#
+# Licensed under the Apache License, Version 2.0 (the "License");
+# you may not use this file except in compliance with the License.
+# You may obtain a copy of the License at
+#
+#      http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+
+"""Checks BigQuery is reached through the Private Service Connect endpoint.
+
+Usage: psc_check.py <endpoint address> <project id>
+"""
+import json
+import socket
+import sys
+import urllib.request
+
+from pyspark.sql import SparkSession
+
+spark = SparkSession \
+    .builder \
+    .appName("psc-check") \
+    .getOrCreate()
+
+endpoint, project_id = sys.argv[1], sys.argv[2]
+
+# The private googleapis.com zone must resolve BigQuery to the endpoint
+resolved = socket.gethostbyname("bigquery.googleapis.com")
+if resolved != endpoint:
+    raise SystemExit(
+        f"bigquery.googleapis.com resolved to {resolved}, not {endpoint}")
+
+# A BigQuery call through the endpoint must succeed
+token = json.load(urllib.request.urlopen(urllib.request.Request(
+    "http://metadata.google.internal/computeMetadata/v1/instance/"
+    "service-accounts/default/token",
+    headers={"Metadata-Flavor": "Google"})))["access_token"]
+urllib.request.urlopen(urllib.request.Request(
+    f"https://bigquery.googleapis.com/bigquery/v2/projects/{project_id}"
+    "/datasets",
+    headers={"Authorization": f"Bearer {token}"}))
+
+spark.stop()
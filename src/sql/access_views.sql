@@ -0,0 +1,40 @@
+-- Copyright 2023 Google LLC
+--
+-- Licensed under the Apache License, Version 2.0 (the "License");
+-- you may not use this file except in compliance with the License.
+-- You may obtain a copy of the License at
+--
+--      http://www.apache.org/licenses/LICENSE-2.0
+--
+-- Unless required by applicable law or agreed to in writing, software
+-- distributed under the License is distributed on an "AS IS" BASIS,
+-- WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+-- See the License for the specific language governing permissions and
+-- limitations under the License.
+
+-- Curated views exposed to consumers of the access layer. The staging
+-- dataset authorizes these views, so consumers never need table access.
+CREATE OR REPLACE VIEW
+  gcp_lakehouse_access.orders AS
+SELECT
+  order_id,
+  status,
+  created_at,
+  shipped_at,
+  delivered_at,
+  returned_at,
+  num_of_item
+FROM
+  gcp_primary_staging.thelook_ecommerce_orders;
+
+CREATE OR REPLACE VIEW
+  gcp_lakehouse_access.products AS
+SELECT
+  id AS product_id,
+  category,
+  name,
+  brand,
+  department,
+  retail_price
+FROM
+  gcp_primary_staging.thelook_ecommerce_products;
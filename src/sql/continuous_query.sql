@@ -0,0 +1,25 @@
+-- Copyright 2023 Google LLC
+--
+-- Licensed under the Apache License, Version 2.0 (the "License");
+-- you may not use this file except in compliance with the License.
+-- You may obtain a copy of the License at
+--
+--      http://www.apache.org/licenses/LICENSE-2.0
+--
+-- Unless required by applicable law or agreed to in writing, software
+-- distributed under the License is distributed on an "AS IS" BASIS,
+-- WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+-- See the License for the specific language governing permissions and
+-- limitations under the License.
+
+-- Continuous query: every event streamed into the raw zone is appended to
+-- events_by_source as it arrives. The events_per_minute view aggregates it.
+INSERT INTO
+  gcp_lakehouse_ds.events_by_source (publish_minute, source, run, message_id)
+SELECT
+  TIMESTAMP_TRUNC(publish_time, MINUTE) AS publish_minute,
+  JSON_VALUE(attributes, '$.source') AS source,
+  JSON_VALUE(data, '$.run') AS run,
+  message_id
+FROM
+  gcp_primary_raw.events_stream
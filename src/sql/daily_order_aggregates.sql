@@ -0,0 +1,42 @@
+-- Copyright 2023 Google LLC
+--
+-- Licensed under the Apache License, Version 2.0 (the "License");
+-- you may not use this file except in compliance with the License.
+-- You may obtain a copy of the License at
+--
+--      http://www.apache.org/licenses/LICENSE-2.0
+--
+-- Unless required by applicable law or agreed to in writing, software
+-- distributed under the License is distributed on an "AS IS" BASIS,
+-- WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+-- See the License for the specific language governing permissions and
+-- limitations under the License.
+
+
+-- Scheduled query: folds each completed day of orders into the daily
+-- aggregates, touching only the days that are new or have changed since the
+-- last run.
+MERGE
+  gcp_lakehouse_ds.daily_order_aggregates AS target
+USING
+  (
+  SELECT
+    DATE(created_at) AS order_date,
+    status,
+    COUNT(*) AS orders,
+    SUM(num_of_item) AS items
+  FROM
+    gcp_primary_staging.thelook_ecommerce_orders
+  WHERE
+    DATE(created_at) < DATE(@run_time)
+  GROUP BY
+    order_date,
+    status) AS source
+ON
+  target.order_date = source.order_date
+  AND target.status = source.status
+WHEN MATCHED AND (target.orders != source.orders OR target.items != source.items) THEN
+  UPDATE SET orders = source.orders, items = source.items, updated_at = @run_time
+WHEN NOT MATCHED THEN
+  INSERT (order_date, status, orders, items, updated_at)
+  VALUES (source.order_date, source.status, source.orders, source.items, @run_time);
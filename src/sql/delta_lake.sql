@@ -0,0 +1,24 @@
+-- Copyright 2023 Google LLC
+--
+-- Licensed under the Apache License, Version 2.0 (the "License");
+-- you may not use this file except in compliance with the License.
+-- You may obtain a copy of the License at
+--
+--      http://www.apache.org/licenses/LICENSE-2.0
+--
+-- Unless required by applicable law or agreed to in writing, software
+-- distributed under the License is distributed on an "AS IS" BASIS,
+-- WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+-- See the License for the specific language governing permissions and
+-- limitations under the License.
+
+-- Delta Lake counterpart of agg_events_iceberg. BigQuery reads the Parquet
+-- files listed in the symlink manifest the Spark batch generates, so only the
+-- files of the current table version are read.
+CREATE OR REPLACE EXTERNAL TABLE
+  gcp_lakehouse_ds.agg_events_delta
+WITH CONNECTION `${region}.gcp_gcs_connection`
+OPTIONS (
+  format = 'PARQUET',
+  uris = ['${delta_lake_uri}/_symlink_format_manifest/manifest'],
+  file_set_spec_type = 'NEW_LINE_DELIMITED_MANIFEST');
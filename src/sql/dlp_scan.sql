@@ -0,0 +1,43 @@
+-- Copyright 2023 Google LLC
+--
+-- Licensed under the Apache License, Version 2.0 (the "License");
+-- you may not use this file except in compliance with the License.
+-- You may obtain a copy of the License at
+--
+--      http://www.apache.org/licenses/LICENSE-2.0
+--
+-- Unless required by applicable law or agreed to in writing, software
+-- distributed under the License is distributed on an "AS IS" BASIS,
+-- WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+-- See the License for the specific language governing permissions and
+-- limitations under the License.
+
+-- Sensitive Data Protection inspection jobs need native tables, so the staging
+-- users and events are upserted into the lakehouse dataset for the lakehouse-pii
+-- job triggers to scan. Both are no-ops when the materialized views or search
+-- index steps already loaded them.
+CREATE TABLE IF NOT EXISTS
+  gcp_lakehouse_ds.thelook_users
+CLUSTER BY
+  id AS
+SELECT
+  *
+FROM
+  gcp_primary_staging.thelook_ecommerce_users
+WHERE
+  FALSE;
+
+CALL gcp_lakehouse_ds.upsert_table('gcp_primary_staging.thelook_ecommerce_users', 'gcp_lakehouse_ds.thelook_users', ['id']);
+
+CREATE TABLE IF NOT EXISTS
+  gcp_lakehouse_ds.thelook_events
+CLUSTER BY
+  user_id AS
+SELECT
+  *
+FROM
+  gcp_primary_staging.thelook_ecommerce_events
+WHERE
+  FALSE;
+
+CALL gcp_lakehouse_ds.upsert_table('gcp_primary_staging.thelook_ecommerce_events', 'gcp_lakehouse_ds.thelook_events', ['id']);
@@ -0,0 +1,29 @@
+-- Copyright 2023 Google LLC
+--
+-- Licensed under the Apache License, Version 2.0 (the "License");
+-- you may not use this file except in compliance with the License.
+-- You may obtain a copy of the License at
+--
+--      http://www.apache.org/licenses/LICENSE-2.0
+--
+-- Unless required by applicable law or agreed to in writing, software
+-- distributed under the License is distributed on an "AS IS" BASIS,
+-- WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+-- See the License for the specific language governing permissions and
+-- limitations under the License.
+
+-- The 100 users with the most events. Anonymous sessions have no user to key
+-- a document by, so they are left out. Ties are broken by user ID so every
+-- run writes the same documents.
+SELECT
+  user_id,
+  event_count
+FROM
+  gcp_lakehouse_ds.agg_events_iceberg
+WHERE
+  user_id IS NOT NULL
+ORDER BY
+  event_count DESC,
+  user_id
+LIMIT
+  100
@@ -0,0 +1,55 @@
+-- Copyright 2023 Google LLC
+--
+-- Licensed under the Apache License, Version 2.0 (the "License");
+-- you may not use this file except in compliance with the License.
+-- You may obtain a copy of the License at
+--
+--      http://www.apache.org/licenses/LICENSE-2.0
+--
+-- Unless required by applicable law or agreed to in writing, software
+-- distributed under the License is distributed on an "AS IS" BASIS,
+-- WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+-- See the License for the specific language governing permissions and
+-- limitations under the License.
+
+
+-- Describes a sample of the TextOCR images with Gemini. The object table
+-- reads the images through the lakehouse connection; the remote model calls
+-- Vertex AI through its own connection.
+CREATE OR REPLACE EXTERNAL TABLE
+  gcp_lakehouse_ds.textocr_images_objects
+WITH CONNECTION `${lakehouse_connection}`
+OPTIONS (
+  object_metadata = 'SIMPLE',
+  uris = ['gs://${textocr_images_bucket}/*']
+);
+
+CREATE OR REPLACE MODEL
+  gcp_lakehouse_ds.gemini_vision
+REMOTE WITH CONNECTION `${vertex_connection}`
+OPTIONS (endpoint = 'gemini-1.5-flash-002');
+
+CREATE OR REPLACE TABLE
+  gcp_lakehouse_ds.textocr_image_descriptions AS
+SELECT
+  uri,
+  ml_generate_text_llm_result AS description,
+  ml_generate_text_status AS status
+FROM
+  ML.GENERATE_TEXT(
+    MODEL gcp_lakehouse_ds.gemini_vision,
+    (
+      SELECT
+        *
+      FROM
+        gcp_lakehouse_ds.textocr_images_objects
+      WHERE
+        content_type LIKE 'image/%'
+      LIMIT 20
+    ),
+    STRUCT(
+      'Transcribe the text visible in this image.' AS prompt,
+      256 AS max_output_tokens,
+      TRUE AS flatten_json_output
+    )
+  );
@@ -0,0 +1,67 @@
+-- Copyright 2023 Google LLC
+--
+-- Licensed under the Apache License, Version 2.0 (the "License");
+-- you may not use this file except in compliance with the License.
+-- You may obtain a copy of the License at
+--
+--      http://www.apache.org/licenses/LICENSE-2.0
+--
+-- Unless required by applicable law or agreed to in writing, software
+-- distributed under the License is distributed on an "AS IS" BASIS,
+-- WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+-- See the License for the specific language governing permissions and
+-- limitations under the License.
+
+
+-- Serving layer: materialized views over the orders x users join. Materialized
+-- views need native base tables, so the staging orders and users are upserted
+-- into the lakehouse dataset first. Upserting rather than replacing the tables
+-- keeps the views incremental when the workflow is rerun.
+CREATE TABLE IF NOT EXISTS
+  gcp_lakehouse_ds.thelook_orders
+CLUSTER BY
+  user_id AS
+SELECT
+  *
+FROM
+  gcp_primary_staging.thelook_ecommerce_orders
+WHERE
+  FALSE;
+
+CALL gcp_lakehouse_ds.upsert_table('gcp_primary_staging.thelook_ecommerce_orders', 'gcp_lakehouse_ds.thelook_orders', ['order_id']);
+
+CREATE TABLE IF NOT EXISTS
+  gcp_lakehouse_ds.thelook_users
+CLUSTER BY
+  id AS
+SELECT
+  *
+FROM
+  gcp_primary_staging.thelook_ecommerce_users
+WHERE
+  FALSE;
+
+CALL gcp_lakehouse_ds.upsert_table('gcp_primary_staging.thelook_ecommerce_users', 'gcp_lakehouse_ds.thelook_users', ['id']);
+
+CREATE OR REPLACE MATERIALIZED VIEW
+  gcp_lakehouse_ds.mv_orders_by_user_state
+OPTIONS (
+  enable_refresh = TRUE,
+  refresh_interval_minutes = 60
+) AS
+SELECT
+  u.country,
+  u.state,
+  o.status,
+  COUNT(*) AS orders,
+  SUM(o.num_of_item) AS items
+FROM
+  gcp_lakehouse_ds.thelook_orders o
+INNER JOIN
+  gcp_lakehouse_ds.thelook_users u
+ON
+  o.user_id = u.id
+GROUP BY
+  u.country,
+  u.state,
+  o.status;
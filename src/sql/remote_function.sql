@@ -0,0 +1,20 @@
+-- Copyright 2023 Google LLC
+--
+-- Licensed under the Apache License, Version 2.0 (the "License");
+-- you may not use this file except in compliance with the License.
+-- You may obtain a copy of the License at
+--
+--      http://www.apache.org/licenses/LICENSE-2.0
+--
+-- Unless required by applicable law or agreed to in writing, software
+-- distributed under the License is distributed on an "AS IS" BASIS,
+-- WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+-- See the License for the specific language governing permissions and
+-- limitations under the License.
+
+-- Scores an order item by status and sale price in a Cloud Function.
+CREATE OR REPLACE FUNCTION
+  gcp_lakehouse_ds.score_event(status STRING, sale_price FLOAT64)
+RETURNS FLOAT64
+REMOTE WITH CONNECTION `${connection}`
+OPTIONS (endpoint = '${endpoint}', max_batching_rows = 500)
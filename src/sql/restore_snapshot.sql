@@ -0,0 +1,19 @@
+-- Copyright 2023 Google LLC
+--
+-- Licensed under the Apache License, Version 2.0 (the "License");
+-- you may not use this file except in compliance with the License.
+-- You may obtain a copy of the License at
+--
+--      http://www.apache.org/licenses/LICENSE-2.0
+--
+-- Unless required by applicable law or agreed to in writing, software
+-- distributed under the License is distributed on an "AS IS" BASIS,
+-- WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+-- See the License for the specific language governing permissions and
+-- limitations under the License.
+
+
+-- Restores snapshot_table into destination_table, replacing the destination
+-- if it exists. The restored table is writable and independent of the
+-- snapshot.
+EXECUTE IMMEDIATE FORMAT("CREATE OR REPLACE TABLE `%s` CLONE `%s`", destination_table, snapshot_table);
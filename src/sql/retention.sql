@@ -0,0 +1,62 @@
+-- Copyright 2023 Google LLC
+--
+-- Licensed under the Apache License, Version 2.0 (the "License");
+-- you may not use this file except in compliance with the License.
+-- You may obtain a copy of the License at
+--
+--      http://www.apache.org/licenses/LICENSE-2.0
+--
+-- Unless required by applicable law or agreed to in writing, software
+-- distributed under the License is distributed on an "AS IS" BASIS,
+-- WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+-- See the License for the specific language governing permissions and
+-- limitations under the License.
+
+-- Moves the orders placed more than ${retention_days} days ago out of the hot,
+-- day-partitioned thelook_orders_hot table: they are exported as Parquet to
+-- the archive bucket, readable through gcp_lakehouse_archive.thelook_orders,
+-- and their partitions are then deleted. Nothing is deleted when the export
+-- fails, and re-running finds no partitions left to move.
+DECLARE cutoff DATE DEFAULT DATE_SUB(CURRENT_DATE(), INTERVAL ${retention_days} DAY);
+
+-- Seeded from the staging orders on the first run only, so archived orders
+-- are not loaded back.
+CREATE TABLE IF NOT EXISTS
+  gcp_lakehouse_ds.thelook_orders_hot
+PARTITION BY
+  DATE(created_at) AS
+SELECT
+  *
+FROM
+  gcp_primary_staging.thelook_ecommerce_orders;
+
+IF EXISTS (SELECT 1 FROM gcp_lakehouse_ds.thelook_orders_hot WHERE DATE(created_at) < cutoff) THEN
+  -- Each run writes its own Hive partition, named after when it ran
+  EXECUTE IMMEDIATE FORMAT("""
+  EXPORT DATA
+    OPTIONS (
+      uri = 'gs://${archive_bucket}/thelook_orders/archived_at=%s/*.parquet',
+      format = 'PARQUET')
+  AS
+  SELECT
+    *
+  FROM
+    gcp_lakehouse_ds.thelook_orders_hot
+  WHERE
+    DATE(created_at) < '%t'""", FORMAT_TIMESTAMP("%Y%m%d%H%M%S", CURRENT_TIMESTAMP()), cutoff);
+
+  CREATE EXTERNAL TABLE IF NOT EXISTS
+    gcp_lakehouse_archive.thelook_orders
+  WITH PARTITION COLUMNS (
+    archived_at STRING)
+  OPTIONS (
+    format = 'PARQUET',
+    uris = ['gs://${archive_bucket}/thelook_orders/*'],
+    hive_partition_uri_prefix = 'gs://${archive_bucket}/thelook_orders');
+
+  -- Whole partitions are dropped without rewriting the hot data
+  DELETE FROM
+    gcp_lakehouse_ds.thelook_orders_hot
+  WHERE
+    DATE(created_at) < cutoff;
+END IF;
@@ -0,0 +1,44 @@
+-- Copyright 2023 Google LLC
+--
+-- Licensed under the Apache License, Version 2.0 (the "License");
+-- you may not use this file except in compliance with the License.
+-- You may obtain a copy of the License at
+--
+--      http://www.apache.org/licenses/LICENSE-2.0
+--
+-- Unless required by applicable law or agreed to in writing, software
+-- distributed under the License is distributed on an "AS IS" BASIS,
+-- WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+-- See the License for the specific language governing permissions and
+-- limitations under the License.
+
+-- Point lookups over the clickstream: a search index over the string columns
+-- of the events lets SEARCH() find a session, IP address or URI without a full
+-- scan. Search indexes need a native base table, so the staging events are
+-- upserted into the lakehouse dataset first, keeping the index when the
+-- workflow is rerun.
+CREATE TABLE IF NOT EXISTS
+  gcp_lakehouse_ds.thelook_events
+CLUSTER BY
+  user_id AS
+SELECT
+  *
+FROM
+  gcp_primary_staging.thelook_ecommerce_events
+WHERE
+  FALSE;
+
+CALL gcp_lakehouse_ds.upsert_table('gcp_primary_staging.thelook_ecommerce_events', 'gcp_lakehouse_ds.thelook_events', ['id']);
+
+CREATE SEARCH INDEX IF NOT EXISTS
+  thelook_events_search
+ON
+  gcp_lakehouse_ds.thelook_events (
+    session_id,
+    ip_address,
+    city,
+    state,
+    browser,
+    traffic_source,
+    uri,
+    event_type);
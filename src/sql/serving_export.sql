@@ -0,0 +1,28 @@
+-- Copyright 2023 Google LLC
+--
+-- Licensed under the Apache License, Version 2.0 (the "License");
+-- you may not use this file except in compliance with the License.
+-- You may obtain a copy of the License at
+--
+--      http://www.apache.org/licenses/LICENSE-2.0
+--
+-- Unless required by applicable law or agreed to in writing, software
+-- distributed under the License is distributed on an "AS IS" BASIS,
+-- WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+-- See the License for the specific language governing permissions and
+-- limitations under the License.
+
+-- Stage the per-user event counts as headerless CSV files for a Cloud SQL
+-- import. Overwriting keeps a re-run from importing the previous export.
+EXPORT DATA
+  OPTIONS (
+    uri = 'gs://${serving_bucket}/agg_events/*.csv',
+    format = 'CSV',
+    overwrite = TRUE,
+    header = FALSE)
+AS
+SELECT
+  user_id,
+  event_count
+FROM
+  gcp_lakehouse_ds.agg_events_iceberg
@@ -0,0 +1,23 @@
+-- Copyright 2023 Google LLC
+--
+-- Licensed under the Apache License, Version 2.0 (the "License");
+-- you may not use this file except in compliance with the License.
+-- You may obtain a copy of the License at
+--
+--      http://www.apache.org/licenses/LICENSE-2.0
+--
+-- Unless required by applicable law or agreed to in writing, software
+-- distributed under the License is distributed on an "AS IS" BASIS,
+-- WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+-- See the License for the specific language governing permissions and
+-- limitations under the License.
+
+-- MySQL DDL imported into the Cloud SQL serving database before each export.
+-- The table is emptied so it always mirrors the latest export.
+CREATE TABLE IF NOT EXISTS agg_events (
+  user_id VARCHAR(64),
+  event_count BIGINT,
+  INDEX user_id_idx (user_id)
+);
+
+TRUNCATE TABLE agg_events;
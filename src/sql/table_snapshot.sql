@@ -0,0 +1,39 @@
+-- Copyright 2023 Google LLC
+--
+-- Licensed under the Apache License, Version 2.0 (the "License");
+-- you may not use this file except in compliance with the License.
+-- You may obtain a copy of the License at
+--
+--      http://www.apache.org/licenses/LICENSE-2.0
+--
+-- Unless required by applicable law or agreed to in writing, software
+-- distributed under the License is distributed on an "AS IS" BASIS,
+-- WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+-- See the License for the specific language governing permissions and
+-- limitations under the License.
+
+
+-- Takes a point-in-time snapshot of the orders. Snapshots need a native base
+-- table, so the staging orders are upserted into the lakehouse dataset, which
+-- is a no-op when the materialized views step already loaded them.
+CREATE TABLE IF NOT EXISTS
+  gcp_lakehouse_ds.thelook_orders
+CLUSTER BY
+  user_id AS
+SELECT
+  *
+FROM
+  gcp_primary_staging.thelook_ecommerce_orders
+WHERE
+  FALSE;
+
+CALL gcp_lakehouse_ds.upsert_table('gcp_primary_staging.thelook_ecommerce_orders', 'gcp_lakehouse_ds.thelook_orders', ['order_id']);
+
+EXECUTE IMMEDIATE FORMAT("""
+CREATE SNAPSHOT TABLE
+  gcp_lakehouse_snapshots.thelook_orders_%s
+CLONE
+  gcp_lakehouse_ds.thelook_orders
+OPTIONS (
+  expiration_timestamp = TIMESTAMP_ADD(CURRENT_TIMESTAMP(), INTERVAL 7 DAY)
+)""", FORMAT_TIMESTAMP("%Y%m%d%H%M%S", CURRENT_TIMESTAMP()));
@@ -0,0 +1,37 @@
+-- Copyright 2023 Google LLC
+--
+-- Licensed under the Apache License, Version 2.0 (the "License");
+-- you may not use this file except in compliance with the License.
+-- You may obtain a copy of the License at
+--
+--      http://www.apache.org/licenses/LICENSE-2.0
+--
+-- Unless required by applicable law or agreed to in writing, software
+-- distributed under the License is distributed on an "AS IS" BASIS,
+-- WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+-- See the License for the specific language governing permissions and
+-- limitations under the License.
+
+
+-- Forecasts hourly yellow taxi pickups. December is held out of training so
+-- the model can be evaluated against it.
+CREATE OR REPLACE MODEL
+  gcp_lakehouse_ds.taxi_trips_forecast
+OPTIONS (
+  model_type = 'ARIMA_PLUS',
+  time_series_timestamp_col = 'pickup_hour',
+  time_series_data_col = 'trips',
+  data_frequency = 'HOURLY',
+  holiday_region = 'US',
+  horizon = 168
+) AS
+SELECT
+  TIMESTAMP_TRUNC(CAST(pickup_datetime AS TIMESTAMP), HOUR) AS pickup_hour,
+  COUNT(*) AS trips
+FROM
+  gcp_primary_staging.new_york_taxi_trips_tlc_yellow_trips_2022
+WHERE
+  CAST(pickup_datetime AS TIMESTAMP) >= '2022-01-01'
+  AND CAST(pickup_datetime AS TIMESTAMP) < '2022-12-01'
+GROUP BY
+  pickup_hour;
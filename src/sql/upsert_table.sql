@@ -0,0 +1,57 @@
+-- Copyright 2023 Google LLC
+--
+-- Licensed under the Apache License, Version 2.0 (the "License");
+-- you may not use this file except in compliance with the License.
+-- You may obtain a copy of the License at
+--
+--      http://www.apache.org/licenses/LICENSE-2.0
+--
+-- Unless required by applicable law or agreed to in writing, software
+-- distributed under the License is distributed on an "AS IS" BASIS,
+-- WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+-- See the License for the specific language governing permissions and
+-- limitations under the License.
+
+-- Upserts source_table into target_table on key_columns, so loading the same
+-- or overlapping data again leaves one row per key. Duplicate keys within the
+-- source are collapsed to one arbitrary row first, and NULL keys match each
+-- other. The target is created empty with the source schema if missing.
+DECLARE target_dataset STRING DEFAULT REGEXP_EXTRACT(target_table, r'^(.*)\.[^.]+$');
+DECLARE target_name STRING DEFAULT REGEXP_EXTRACT(target_table, r'[^.]+$');
+DECLARE key_list STRING DEFAULT (
+  SELECT STRING_AGG(FORMAT('`%s`', key), ', ') FROM UNNEST(key_columns) AS key);
+DECLARE match_condition STRING DEFAULT (
+  SELECT STRING_AGG(FORMAT('target.`%s` IS NOT DISTINCT FROM source.`%s`', key, key), ' AND ') FROM UNNEST(key_columns) AS key);
+DECLARE update_list STRING;
+
+EXECUTE IMMEDIATE FORMAT("CREATE TABLE IF NOT EXISTS `%s` AS SELECT * FROM `%s` WHERE FALSE", target_table, source_table);
+
+EXECUTE IMMEDIATE FORMAT("""
+SELECT
+  STRING_AGG(FORMAT('`%%s` = source.`%%s`', column_name, column_name), ', ' ORDER BY ordinal_position)
+FROM
+  `%s`.INFORMATION_SCHEMA.COLUMNS
+WHERE
+  table_name = @table_name
+  AND column_name NOT IN UNNEST(@key_columns)""", target_dataset)
+INTO update_list USING target_name AS table_name, key_columns AS key_columns;
+
+EXECUTE IMMEDIATE FORMAT("""
+MERGE
+  `%s` AS target
+USING
+  (
+  SELECT
+    *
+  FROM
+    `%s`
+  WHERE
+    TRUE
+  QUALIFY
+    ROW_NUMBER() OVER (PARTITION BY %s) = 1) AS source
+ON
+  %s
+%s
+WHEN NOT MATCHED THEN
+  INSERT ROW""", target_table, source_table, key_list, match_condition,
+  IF(update_list IS NULL, "", FORMAT("WHEN MATCHED THEN\n  UPDATE SET %s", update_list)));
@@ -0,0 +1,82 @@
+-- Copyright 2023 Google LLC
+--
+-- Licensed under the Apache License, Version 2.0 (the "License");
+-- you may not use this file except in compliance with the License.
+-- You may obtain a copy of the License at
+--
+--      http://www.apache.org/licenses/LICENSE-2.0
+--
+-- Unless required by applicable law or agreed to in writing, software
+-- distributed under the License is distributed on an "AS IS" BASIS,
+-- WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+-- See the License for the specific language governing permissions and
+-- limitations under the License.
+
+
+-- Embeds the thelook product catalog, indexes the embeddings and exposes a
+-- proximity search over them as a table function.
+CREATE OR REPLACE MODEL
+  gcp_lakehouse_ds.text_embedding
+REMOTE WITH CONNECTION `${vertex_connection}`
+OPTIONS (endpoint = 'text-embedding-004');
+
+CREATE OR REPLACE TABLE
+  gcp_lakehouse_ds.product_embeddings AS
+SELECT
+  id AS product_id,
+  name,
+  brand,
+  category,
+  content,
+  ml_generate_embedding_result AS embedding
+FROM
+  ML.GENERATE_EMBEDDING(
+    MODEL gcp_lakehouse_ds.text_embedding,
+    (
+      SELECT
+        id,
+        name,
+        brand,
+        category,
+        CONCAT(name, ' by ', brand, ' in ', category) AS content
+      FROM
+        gcp_primary_staging.thelook_ecommerce_products
+      WHERE
+        name IS NOT NULL
+    ),
+    STRUCT(TRUE AS flatten_json_output)
+  )
+WHERE
+  ml_generate_embedding_status = '';
+
+CREATE VECTOR INDEX IF NOT EXISTS
+  product_embeddings_index
+ON
+  gcp_lakehouse_ds.product_embeddings(embedding)
+OPTIONS (index_type = 'IVF', distance_type = 'COSINE');
+
+CREATE OR REPLACE TABLE FUNCTION
+  gcp_lakehouse_ds.similar_products(search STRING) AS
+SELECT
+  base.product_id,
+  base.name,
+  base.brand,
+  base.category,
+  distance
+FROM
+  VECTOR_SEARCH(
+    TABLE gcp_lakehouse_ds.product_embeddings,
+    'embedding',
+    (
+      SELECT
+        ml_generate_embedding_result AS embedding
+      FROM
+        ML.GENERATE_EMBEDDING(
+          MODEL gcp_lakehouse_ds.text_embedding,
+          (SELECT search AS content),
+          STRUCT(TRUE AS flatten_json_output)
+        )
+    ),
+    top_k => 5,
+    distance_type => 'COSINE'
+  );
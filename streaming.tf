@@ -0,0 +1,157 @@
+/**
+ * Copyright 2023 Google LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+# Optional streaming ingestion: events published to Pub/Sub are written
+# straight into the raw zone by a BigQuery subscription.
+resource "google_pubsub_topic" "events" {
+  count = var.enable_streaming ? 1 : 0
+
+  project = module.project-services.project_id
+  name    = "lakehouse-events"
+  labels  = var.labels
+
+  depends_on = [time_sleep.wait_after_apis_activate]
+}
+
+resource "google_bigquery_table" "events_stream" {
+  count = var.enable_streaming ? 1 : 0
+
+  project             = module.project-services.project_id
+  dataset_id          = replace(google_dataplex_zone.gcp_primary_raw.name, "-", "_")
+  table_id            = "events_stream"
+  description         = "Events streamed from the lakehouse-events Pub/Sub topic"
+  labels              = var.labels
+  deletion_protection = !var.force_destroy
+
+  time_partitioning {
+    type  = "DAY"
+    field = "publish_time"
+  }
+
+  # Columns written by a BigQuery subscription with write_metadata enabled
+  schema = jsonencode([
+    { name = "subscription_name", type = "STRING", mode = "NULLABLE" },
+    { name = "message_id", type = "STRING", mode = "NULLABLE" },
+    { name = "publish_time", type = "TIMESTAMP", mode = "NULLABLE" },
+    { name = "data", type = "STRING", mode = "NULLABLE" },
+    { name = "attributes", type = "STRING", mode = "NULLABLE" },
+  ])
+}
+
+# # Allow the Pub/Sub service agent to write into the streaming table
+resource "google_bigquery_table_iam_member" "pubsub_writer" {
+  count = var.enable_streaming ? 1 : 0
+
+  project    = module.project-services.project_id
+  dataset_id = google_bigquery_table.events_stream[0].dataset_id
+  table_id   = google_bigquery_table.events_stream[0].table_id
+  role       = "roles/bigquery.dataEditor"
+  member     = "serviceAccount:service-${data.google_project.project.number}@gcp-sa-pubsub.iam.gserviceaccount.com"
+}
+
+resource "google_pubsub_subscription" "events_bigquery" {
+  count = var.enable_streaming ? 1 : 0
+
+  project = module.project-services.project_id
+  name    = "lakehouse-events-bigquery"
+  topic   = google_pubsub_topic.events[0].id
+  labels  = var.labels
+
+  bigquery_config {
+    table          = "${google_bigquery_table.events_stream[0].project}.${google_bigquery_table.events_stream[0].dataset_id}.${google_bigquery_table.events_stream[0].table_id}"
+    write_metadata = true
+  }
+
+  depends_on = [google_bigquery_table_iam_member.pubsub_writer]
+}
+
+# Optional continuous query over the streaming table. Continuous queries only
+# run on an Enterprise edition reservation assigned to CONTINUOUS jobs; the
+# project-setup workflow starts the query itself.
+locals {
+  enable_continuous_query = var.enable_streaming && var.enable_continuous_query
+}
+
+resource "google_bigquery_reservation" "continuous" {
+  count = local.enable_continuous_query ? 1 : 0
+
+  project           = module.project-services.project_id
+  location          = var.region
+  name              = "lakehouse-continuous"
+  edition           = "ENTERPRISE"
+  slot_capacity     = 50
+  ignore_idle_slots = false
+}
+
+resource "google_bigquery_reservation_assignment" "continuous" {
+  count = local.enable_continuous_query ? 1 : 0
+
+  project     = module.project-services.project_id
+  location    = var.region
+  reservation = google_bigquery_reservation.continuous[0].id
+  assignee    = "projects/${module.project-services.project_id}"
+  job_type    = "CONTINUOUS"
+}
+
+resource "google_bigquery_table" "events_by_source" {
+  count = local.enable_continuous_query ? 1 : 0
+
+  project             = module.project-services.project_id
+  dataset_id          = local.lakehouse_dataset_id
+  table_id            = "events_by_source"
+  description         = "Streamed events appended by the lakehouse continuous query"
+  labels              = var.labels
+  deletion_protection = !var.force_destroy
+
+  time_partitioning {
+    type  = "DAY"
+    field = "publish_minute"
+  }
+
+  schema = jsonencode([
+    { name = "publish_minute", type = "TIMESTAMP", mode = "NULLABLE" },
+    { name = "source", type = "STRING", mode = "NULLABLE" },
+    { name = "run", type = "STRING", mode = "NULLABLE" },
+    { name = "message_id", type = "STRING", mode = "NULLABLE" },
+  ])
+}
+
+resource "google_bigquery_table" "events_per_minute" {
+  count = local.enable_continuous_query ? 1 : 0
+
+  project             = module.project-services.project_id
+  dataset_id          = local.lakehouse_dataset_id
+  table_id            = "events_per_minute"
+  description         = "Real-time count of streamed events per minute and source"
+  labels              = var.labels
+  deletion_protection = !var.force_destroy
+
+  view {
+    query          = "SELECT publish_minute, source, COUNT(*) AS events FROM `${google_bigquery_table.events_by_source[0].project}.${google_bigquery_table.events_by_source[0].dataset_id}.${google_bigquery_table.events_by_source[0].table_id}` GROUP BY publish_minute, source"
+    use_legacy_sql = false
+  }
+}
+
+# # The continuous query runs as the workflows service account
+resource "google_bigquery_table_iam_member" "workflows_sa_stream_reader" {
+  count = local.enable_continuous_query ? 1 : 0
+
+  project    = module.project-services.project_id
+  dataset_id = google_bigquery_table.events_stream[0].dataset_id
+  table_id   = google_bigquery_table.events_stream[0].table_id
+  role       = "roles/bigquery.dataViewer"
+  member     = "serviceAccount:${google_service_account.workflows_sa.email}"
+}
@@ -0,0 +1,67 @@
+/**
+ * Copyright 2023 Google LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+# Secure tags that org policies and IAM conditions can target
+locals {
+  tagged_buckets = concat([
+    google_storage_bucket.raw_bucket.name,
+    google_storage_bucket.provisioning_bucket.name,
+    google_storage_bucket.ga4_images_bucket.name,
+    google_storage_bucket.textocr_images_bucket.name,
+    google_storage_bucket.dataplex_bucket.name,
+    google_storage_bucket.spark-log-directory.name,
+    google_storage_bucket.phs-staging-bucket.name,
+    google_storage_bucket.phs-temp-bucket.name,
+  ], google_storage_bucket.warehouse_bucket[*].name, google_storage_bucket.tables_bucket[*].name, google_storage_bucket.serving_bucket[*].name)
+
+  bucket_tag_bindings = {
+    for pair in setproduct(keys(var.resource_tags), local.tagged_buckets) : "${pair[0]}/${pair[1]}" => {
+      key    = pair[0]
+      bucket = pair[1]
+    }
+  }
+}
+
+resource "google_tags_tag_key" "keys" {
+  for_each = var.resource_tags
+
+  parent     = "projects/${module.project-services.project_id}"
+  short_name = each.key
+
+  depends_on = [time_sleep.wait_after_apis_activate]
+}
+
+resource "google_tags_tag_value" "values" {
+  for_each = var.resource_tags
+
+  parent     = google_tags_tag_key.keys[each.key].id
+  short_name = each.value
+}
+
+resource "google_tags_tag_binding" "project" {
+  for_each = var.resource_tags
+
+  parent    = "//cloudresourcemanager.googleapis.com/projects/${data.google_project.project.number}"
+  tag_value = google_tags_tag_value.values[each.key].id
+}
+
+resource "google_tags_location_tag_binding" "buckets" {
+  for_each = local.bucket_tag_bindings
+
+  parent    = "//storage.googleapis.com/projects/_/buckets/${each.value.bucket}"
+  location  = var.region
+  tag_value = google_tags_tag_value.values[each.value.key].id
+}
@@ -0,0 +1,74 @@
+/**
+ * Copyright 2023 Google LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+# Every optional feature of the module is enabled on top of the
+# analytics_lakehouse example, so TestAnalyticsLakehouseFull can verify each
+# of them. Keep the flags in step with the checks the test gates on them.
+module "analytics_lakehouse" {
+  source = "../../.."
+
+  project_id    = var.project_id
+  region        = "us-central1"
+  force_destroy = true
+
+  raw_data_format = var.raw_data_format
+  labels          = var.labels
+
+  enable_data_attributes    = true
+  enable_aspect_types       = true
+  enable_glossary           = true
+  enable_access_layer       = true
+  enable_streaming          = true
+  enable_continuous_query   = true
+  enable_dataflow_load      = true
+  enable_transfer_load      = true
+  enable_dataform           = true
+  enable_analytics_hub      = true
+  enable_remote_function    = true
+  enable_image_inference    = true
+  enable_vector_search      = true
+  enable_forecasting        = true
+  enable_materialized_views = true
+  enable_snapshots          = true
+  enable_retention          = true
+  enable_search_index       = true
+  enable_dlp_scan           = true
+  enable_dlp_deidentify     = true
+  enable_notebook           = true
+  enable_scheduled_queries  = true
+  enable_serving_export     = true
+  enable_firestore_export   = true
+  enable_slot_reservation   = true
+
+  enable_data_access_audit_logs = true
+  enable_log_sink               = true
+  enable_iceberg_maintenance    = true
+  enable_delta_lake             = true
+  enable_conditional_access     = true
+  enable_nat                    = true
+
+  enable_private_service_connect = true
+  enable_vpc_connector           = true
+
+  bi_engine_reservation_gb = 1
+
+  budget_billing_account = var.budget_billing_account
+  budget_alert_emails    = var.budget_alert_email == "" ? [] : [var.budget_alert_email]
+
+  resource_tags = {
+    environment = "demo"
+  }
+}
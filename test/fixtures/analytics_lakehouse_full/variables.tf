@@ -0,0 +1,44 @@
+/**
+ * Copyright 2023 Google LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+variable "project_id" {
+  description = "The ID of the project in which to provision resources."
+  type        = string
+}
+
+variable "raw_data_format" {
+  description = "File format of the raw thelook tables, one of PARQUET, CSV or JSON."
+  type        = string
+  default     = "PARQUET"
+}
+
+variable "labels" {
+  description = "Labels to apply to the blueprint's resources, such as a label identifying a test run."
+  type        = map(string)
+  default     = { "analytics-lakehouse" = "true" }
+}
+
+variable "budget_billing_account" {
+  description = "ID of the billing account to create a monthly budget for the blueprint on. No budget is created when empty."
+  type        = string
+  default     = ""
+}
+
+variable "budget_alert_email" {
+  description = "Email address the budget alerts are sent to."
+  type        = string
+  default     = ""
+}
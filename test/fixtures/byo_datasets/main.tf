@@ -0,0 +1,36 @@
+/**
+ * Copyright 2023 Google LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+# The dataset and buckets are created by TestBYODatasets before apply, under
+# names derived from the project ID. Keep these in step with the test.
+locals {
+  tables_bucket_name    = "lakehouse-byo-tables-${var.project_id}"
+  warehouse_bucket_name = "lakehouse-byo-warehouse-${var.project_id}"
+}
+
+module "analytics_lakehouse" {
+  source = "../../.."
+
+  project_id    = var.project_id
+  region        = "us-central1"
+  force_destroy = true
+
+  create_lakehouse_dataset = false
+  tables_bucket_name       = local.tables_bucket_name
+  warehouse_bucket_name    = local.warehouse_bucket_name
+
+  sample_datasets = ["thelook_ecommerce"]
+}
@@ -0,0 +1,51 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package multiple_buckets
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// Dataset the subscriber links the curated listing into.
+const linkedDataset = "gcp_lakehouse_curated_linked"
+
+// verifyAnalyticsHub asserts the subscriber service account can subscribe to
+// the curated listing and query every curated table through the linked
+// dataset, but not the source dataset. The linked dataset is removed
+// afterwards so the listing can be destroyed.
+func verifyAnalyticsHub(t *testing.T, assert *assert.Assertions, projectID, region, listing, subscriberSA string) {
+	token := impersonatedAccessToken(t, subscriberSA)
+	subscribe := fmt.Sprintf(`{"destinationDataset": {"datasetReference": {"projectId": %q, "datasetId": %q}, "location": %q}}`, projectID, linkedDataset, region)
+	subscription := callAPIWithToken(t, token, "POST", "https://analyticshub.googleapis.com/v1/"+listing+":subscribe", subscribe)
+	assert.Equal("ACTIVE", subscription.Get("subscription.state").String(), "Subscription to %s is not active", listing)
+
+	url := fmt.Sprintf("https://bigquery.googleapis.com/bigquery/v2/projects/%s/queries", projectID)
+	query := func(table string) string {
+		return fmt.Sprintf("{\"query\": \"SELECT count(*) AS count FROM `%s.%s`\", \"useLegacySql\": false, \"timeoutMs\": 60000}", projectID, table)
+	}
+	for _, table := range dataformTables {
+		_, tableID := splitTable(table)
+		result := callAPIWithToken(t, token, "POST", url, query(linkedDataset+"."+tableID))
+		assert.Greater(result.Get("rows.0.f.0.v").Int(), int64(0), "%s returned no rows to the subscriber", tableID)
+		assert.Equal(http.StatusForbidden, callAPIStatus(t, token, "POST", url, query(table)), "Subscriber can query %s directly", table)
+	}
+
+	dataset := fmt.Sprintf("https://bigquery.googleapis.com/bigquery/v2/projects/%s/datasets/%s?deleteContents=true", projectID, linkedDataset)
+	assert.Equal(http.StatusNoContent, callAPIStatus(t, accessToken(t), "DELETE", dataset, ""), "Could not remove the linked dataset")
+}
@@ -16,66 +16,233 @@ package multiple_buckets
 
 import (
 	"fmt"
-	"log"
 	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/GoogleCloudPlatform/cloud-foundation-toolkit/infra/blueprint-test/pkg/bq"
 	"github.com/GoogleCloudPlatform/cloud-foundation-toolkit/infra/blueprint-test/pkg/gcloud"
 	"github.com/GoogleCloudPlatform/cloud-foundation-toolkit/infra/blueprint-test/pkg/tft"
-	"github.com/GoogleCloudPlatform/cloud-foundation-toolkit/infra/blueprint-test/pkg/utils"
 	"github.com/stretchr/testify/assert"
-	"github.com/stretchr/testify/require"
+	"github.com/terraform-google-modules/terraform-google-analytics-lakehouse/test/integration/testutils"
 )
 
-// Retry if these errors are encountered.
-var retryErrors = map[string]string{
-	".*does not have enough resources available to fulfill the request.  Try a different zone,.*": "Compute zone resources currently unavailable.",
-	".*Error 400: The subnetwork resource*":                                                       "Subnet is eventually drained",
+// fullFixtureDir is the test fixture enabling every optional feature of the
+// module on top of the example.
+var fullFixtureDir = filepath.Join("..", "..", "fixtures", "analytics_lakehouse_full")
+
+// lakehouseQuotas are the regional quotas the example needs free: CPUs for
+// the Persistent History Server and the serverless Spark batches the
+// workflows run alongside it, and the batches' disks.
+var lakehouseQuotas = []testutils.Quota{
+	{Metric: "CPUS", Amount: 24},
+	{Metric: "N2_CPUS", Amount: 16},
+	{Metric: "DISKS_TOTAL_GB", Amount: 2000},
 }
 
+// fullQuotas are the regional quotas the full fixture needs free: the
+// example's, the Cloud NAT addresses, and the slots of the autoscaling and
+// continuous query reservations.
+var fullQuotas = append([]testutils.Quota{
+	{Metric: "IN_USE_ADDRESSES", Amount: 2},
+	{Metric: testutils.SlotsQuota, Amount: 150},
+}, lakehouseQuotas...)
+
 func TestAnalyticsLakehouse(t *testing.T) {
-	dwh := tft.NewTFBlueprintTest(t, tft.WithRetryableTerraformErrors(retryErrors, 60, time.Minute))
+	testLakehouse(t, exampleDir, "analytics_lakehouse", false)
+}
 
-	dwh.DefineVerify(func(assert *assert.Assertions) {
-		dwh.DefaultVerify(assert)
+// TestAnalyticsLakehouseFull deploys the fixture enabling every optional
+// feature, and verifies each of them along with the example's checks.
+func TestAnalyticsLakehouseFull(t *testing.T) {
+	testLakehouse(t, fullFixtureDir, "analytics_lakehouse_full", true)
+}
+
+// testLakehouse deploys and verifies the example or fixture in tfDir,
+// reporting under name. The checks of the optional features only run when
+// full is set, as the example leaves them disabled.
+func testLakehouse(t *testing.T, tfDir, name string, full bool) {
+	testutils.ConfigureAuth(t)
+
+	vars := map[string]interface{}{}
+	if labels := testutils.RunLabels(); labels != nil {
+		vars["labels"] = labels
+	}
+	dwh := tft.NewTFBlueprintTest(t, tft.WithTFDir(tfDir), tft.WithRetryableTerraformErrors(testutils.RetryErrors, 60, time.Minute), tft.WithVars(vars))
+	timer := testutils.NewStageTimer(t, dwh, name)
+	notifier := testutils.NewNotifier(t, dwh, name, timer)
+	quotas := lakehouseQuotas
+	if full {
+		quotas = fullQuotas
+	}
+
+	dwh.DefineApply(func(assert *assert.Assertions) {
+		timer.Time("apis", func() {
+			testutils.EnableModuleAPIs(t, dwh.GetTFSetupStringOutput("project_id"), dwh.GetTFOptions().TerraformDir)
+		})
+		testutils.CheckQuotas(t, dwh.GetTFSetupStringOutput("project_id"), dwh.GetTFSetupStringOutput("region"), quotas)
+		timer.Time("apply", func() { dwh.DefaultApply(assert) })
+	})
 
+	dwh.DefineVerify(func(assert *assert.Assertions) {
 		projectID := dwh.GetTFSetupStringOutput("project_id")
+		report := testutils.NewReport(t, name, projectID)
+		notifier.Watch(report)
+
+		// Assert the binary plans no changes over what it just applied
+		report.Check("A plan after apply has no changes", nil, func(assert *testutils.Assertions) {
+			dwh.DefaultVerify(assert)
+		})
 
 		region := dwh.GetTFSetupStringOutput("region")
 
-		verifyWorkflow := func(workflow string) (bool, error) {
-			executions := gcloud.Runf(t, "workflows executions list %s --project %s --sort-by=startTime", workflow, projectID)
-			state := executions.Get("0.state").String()
-			if state == "FAILED" {
-				id := executions.Get("0.name")
-				gcloud.Runf(t, "workflows executions describe %s", id)
-				t.FailNow()
+		warehouseBucket := dwh.GetStringOutput("warehouse_bucket")
+		suffix := testutils.RandomSuffix(warehouseBucket)
+		rawDataFormat := dwh.GetStringOutput("raw_data_format")
+		assetBuckets := []string{
+			dwh.GetStringOutput("tables_bucket"),
+			dwh.GetStringOutput("textocr_images_bucket"),
+			dwh.GetStringOutput("ga4_images_bucket"),
+		}
+
+		// Time each group of verifications separately
+		stop := timer.Start("verify/workflows")
+
+		// Assert every documented output is set and shaped as consumers expect
+		report.Check("Every documented output is set and shaped as consumers expect", nil, func(assert *testutils.Assertions) {
+			optionalOutputs := map[string]bool{
+				"budget":                       dwh.GetTFSetupStringOutput("budget_billing_account") == "",
+				"budget_notification_channels": dwh.GetTFSetupStringOutput("budget_billing_account") == "",
+				"transfer_load_config":         rawDataFormat != "PARQUET",
 			}
-			if state == "SUCCEEDED" {
-				return false, nil
+			if !full {
+				for _, output := range featureOutputs {
+					optionalOutputs[output] = true
+				}
 			}
-			return true, nil
-		}
+			verifyOutputContract(t, assert, tfDir, projectID, region, optionalOutputs)
+		})
+
+		// Assert the URL outputs open the right pages of this project
+		report.Check("The URL outputs open the right pages of this project", nil, func(assert *testutils.Assertions) {
+			urls := map[string]string{}
+			for _, name := range []string{"bigquery_editor_url", "neos_tutorial_url", "lakehouse_colab_url", "lookerstudio_report_url"} {
+				urls[name] = dwh.GetStringOutput(name)
+			}
+			verifyOutputURLs(assert, projectID, urls)
+		})
+
+		// Assert the Dataproc subnet can reach Google APIs before waiting on Spark
+		report.Check("The Dataproc subnet can reach Google APIs before waiting on Spark", nil, func(assert *testutils.Assertions) {
+			verifySubnetPrivateAccess(t, assert, projectID, region)
+		})
 
 		// Assert copy-data workflow ran successfully
-		verifyCopyDataWorkflow := func() (bool, error) {
-			return verifyWorkflow("copy-data")
-		}
-		utils.Poll(t, verifyCopyDataWorkflow, 150, 5*time.Second)
+		report.Check("Copy-data workflow ran successfully", []testutils.Link{testutils.WorkflowLink(projectID, region, "copy-data")}, func(assert *testutils.Assertions) {
+			testutils.WaitForWorkflow(t, projectID, "copy-data")
+		})
 
 		// Assert project-setup workflow ran successfully
-		verifyProjectSetupWorkflow := func() (bool, error) {
-			return verifyWorkflow("project-setup")
+		report.Check("Project-setup workflow ran successfully", []testutils.Link{testutils.WorkflowLink(projectID, region, "project-setup")}, func(assert *testutils.Assertions) {
+			testutils.WaitForWorkflow(t, projectID, "project-setup")
+		})
+
+		stop()
+		stop = timer.Start("verify/processing")
+
+		if full {
+			// Assert the Dataflow load finished and applied its transform
+			report.Check("The Dataflow load finished and applied its transform", nil, func(assert *testutils.Assertions) {
+				verifyDataflowLoad(t, assert, projectID, region)
+			})
+
+			// Assert the Dataform models compile and build the curated tables
+			report.Check("The Dataform models compile and build the curated tables", nil, func(assert *testutils.Assertions) {
+				verifyDataform(t, assert, projectID, dwh.GetStringOutput("dataform_repository"))
+			})
+
+			// Assert query jobs are assigned to the Enterprise edition slot reservation
+			report.Check("Query jobs are assigned to the Enterprise edition slot reservation", nil, func(assert *testutils.Assertions) {
+				verifySlotReservation(t, assert, projectID, region, dwh.GetStringOutput("slot_reservation"))
+			})
+
+			// Assert BI Engine accelerates dashboard queries over the curated tables
+			report.Check("BI Engine accelerates dashboard queries over the curated tables", nil, func(assert *testutils.Assertions) {
+				verifyBIEngine(t, assert, projectID, region, dwh.GetStringOutput("bi_engine_reservation"))
+			})
+
+			// Assert a subscriber can query the curated layer through Analytics Hub
+			report.Check("A subscriber can query the curated layer through Analytics Hub", nil, func(assert *testutils.Assertions) {
+				verifyAnalyticsHub(t, assert, projectID, region, dwh.GetStringOutput("analytics_hub_listing"), dwh.GetStringOutput("analytics_hub_subscriber_service_account"))
+			})
+
+			// Assert Gemini described a sample of the TextOCR images
+			report.Check("Gemini described a sample of the TextOCR images", nil, func(assert *testutils.Assertions) {
+				verifyImageInference(t, assert, projectID)
+			})
+
+			// Assert the product embeddings are indexed and searchable
+			report.Check("The product embeddings are indexed and searchable", nil, func(assert *testutils.Assertions) {
+				verifyVectorSearch(t, assert, projectID)
+			})
+
+			// Assert the taxi trips forecast trained and is within the MAPE threshold
+			report.Check("The taxi trips forecast trained and is within the MAPE threshold", nil, func(assert *testutils.Assertions) {
+				verifyForecast(t, assert, projectID, dwh.GetTFSetupStringOutput("forecast_max_mape"))
+			})
+
+			// Assert the materialized view is refreshed and answers queries over the join
+			report.Check("The materialized view is refreshed and answers queries over the join", nil, func(assert *testutils.Assertions) {
+				verifyMaterializedViews(t, assert, projectID, region)
+			})
+
+			// Assert the orders snapshot restores into a scratch dataset unchanged
+			report.Check("The orders snapshot restores into a scratch dataset unchanged", nil, func(assert *testutils.Assertions) {
+				verifySnapshotRestore(t, assert, projectID, region)
+			})
+
+			// Assert the events search index is active and answers SEARCH() lookups
+			report.Check("The events search index is active and answers SEARCH() lookups", nil, func(assert *testutils.Assertions) {
+				verifySearchIndex(t, assert, projectID, region)
+			})
 		}
-		utils.Poll(t, verifyProjectSetupWorkflow, 150, 5*time.Second)
 
-		// Assert BigQuery tables are not empty
-		verifyTables := func() (bool, error) {
-			data
+		// Assert reloading overlapping batches with upsert_table leaves no duplicate keys
+		report.Check("Reloading overlapping batches with upsert_table leaves no duplicate keys", nil, func(assert *testutils.Assertions) {
+			verifyUpsertIdempotency(t, assert, projectID, region)
+		})
+
+		stop()
+
+		if full {
+			stop = timer.Start("verify/dlp")
+
+			// Assert the DLP scan completes and saves the email addresses found in the users
+			report.Check("The DLP scan completes and saves the email addresses found in the users", nil, func(assert *testutils.Assertions) {
+				verifyDLPScan(t, assert, projectID, region, dwh.GetStringOutput("dlp_findings_table"))
+			})
+
+			// Assert the de-identified users match the source row for row with personal columns masked
+			report.Check("The de-identified users match the source row for row with personal columns masked", nil, func(assert *testutils.Assertions) {
+				verifyDLPDeidentify(t, assert, projectID, dwh.GetStringOutput("dlp_deidentified_users_table"))
+			})
+
+			stop()
+			stop = timer.Start("verify/notebook")
+
+			// Assert the sample notebook executes on the Colab Enterprise runtime template
+			report.Check("The sample notebook executes on the Colab Enterprise runtime template", nil, func(assert *testutils.Assertions) {
+				verifyNotebookExecution(t, assert, projectID, region, dwh.GetStringOutput("notebook_runtime_template"), dwh.GetStringOutput("notebook_gcs_uri"), dwh.GetStringOutput("dataproc_service_account"))
+			})
+
+			stop()
 		}
 
+		stop = timer.Start("verify/tables")
+
+		// Assert BigQuery tables are not empty
 		tables := []string{
 			"gcp_primary_raw.ga4_obfuscated_sample_ecommerce_images",
 			"gcp_primary_raw.textocr_images",
@@ -92,41 +259,415 @@ func TestAnalyticsLakehouse(t *testing.T) {
 
 		query_template := "SELECT count(*) AS count FROM `%[1]s.%[2]s`;"
 		for _, table := range tables {
-			query := fmt.Sprintf(query_template, projectID, table)
-			op := bq.Runf(t, "--project_id=%[1]s query --nouse_legacy_sql %[2]s", projectID, query)
+			report.Check(table+" is not empty", []testutils.Link{testutils.TableLink(projectID, table)}, func(assert *testutils.Assertions) {
+				query := fmt.Sprintf(query_template, projectID, table)
+				op := bq.Runf(t, "--project_id=%[1]s query --nouse_legacy_sql %[2]s", projectID, query)
+
+				count := op.Get("0.count").Int()
+				assert.Greater(count, int64(0), table)
+			})
+		}
+
+		// Assert the staging tables read the raw files in the configured format
+		report.Check("The staging tables read the raw files in the configured format", nil, func(assert *testutils.Assertions) {
+			verifyRawDataFormat(t, assert, projectID, dwh.GetStringOutput("tables_bucket"), rawDataFormat)
+		})
+
+		// Assert CSV, JSON, Parquet and Iceberg BigLake tables all read the same data
+		report.Check("CSV, JSON, Parquet and Iceberg BigLake tables all read the same data", nil, func(assert *testutils.Assertions) {
+			verifyExternalFormats(t, assert, projectID, region, warehouseBucket)
+		})
+
+		stop()
+		stop = timer.Start("verify/serving")
+
+		if full {
+			// Assert the serving export copies every aggregated event row into Cloud SQL
+			report.Check("The serving export copies every aggregated event row into Cloud SQL", nil, func(assert *testutils.Assertions) {
+				verifyServingExport(t, assert, projectID, region, dwh.GetStringOutput("serving_instance"), dwh.GetStringOutput("serving_database"), dwh.GetStringOutput("serving_bucket"))
+			})
+
+			// Assert the top users are served from Firestore with their BigQuery counts
+			report.Check("The top users are served from Firestore with their BigQuery counts", nil, func(assert *testutils.Assertions) {
+				verifyFirestoreExport(t, assert, projectID, region, dwh.GetStringOutput("firestore_database"))
+			})
+		}
+
+		// Assert the Looker Studio report URL targets the lakehouse view and is served
+		report.Check("The Looker Studio report URL targets the lakehouse view and is served", nil, func(assert *testutils.Assertions) {
+			verifyLookerStudioURL(t, assert, dwh.GetStringOutput("lookerstudio_report_url"), projectID, dwh.GetStringOutput("lakehouse_dataset_id"))
+		})
+
+		if full {
+			// Assert the daily aggregates scheduled query is enabled and a manual run succeeds
+			report.Check("The daily aggregates scheduled query is enabled and a manual run succeeds", nil, func(assert *testutils.Assertions) {
+				verifyScheduledQuery(t, assert, projectID, dwh.GetStringOutput("scheduled_query_transfer_config"))
+			})
+		}
+
+		// Assert the Cloud Storage transfer loads every raw order without the workflows,
+		// which it only does for Parquet files
+		if full && rawDataFormat == "PARQUET" {
+			report.Check("The Cloud Storage transfer loads every raw order without the workflows", nil, func(assert *testutils.Assertions) {
+				verifyTransferLoad(t, assert, projectID, dwh.GetStringOutput("transfer_load_config"))
+			})
+		}
+
+		stop()
+		stop = timer.Start("verify/streaming")
+
+		if full {
+			// Assert events published to Pub/Sub become queryable in the raw zone
+			report.Check("Events published to Pub/Sub become queryable in the raw zone", nil, func(assert *testutils.Assertions) {
+				verifyStreamingIngestion(t, assert, projectID, dwh.GetStringOutput("streaming_topic"))
+			})
+
+			// Assert the continuous query keeps the per-minute aggregate up to date
+			report.Check("The continuous query keeps the per-minute aggregate up to date", nil, func(assert *testutils.Assertions) {
+				verifyContinuousQuery(t, assert, projectID, dwh.GetStringOutput("streaming_topic"))
+			})
+		}
+
+		// Assert analyst-style queries succeed within the SLO under concurrent load
+		// when the optional load test is enabled in test/setup
+		if concurrency := dwh.GetTFSetupStringOutput("load_test_concurrency"); concurrency != "0" {
+			report.Check("Analyst-style queries succeed within the SLO under concurrent load", nil, func(assert *testutils.Assertions) {
+				verifyConcurrentQueries(t, assert, projectID, region, concurrency, dwh.GetTFSetupStringOutput("load_test_slo_seconds"))
+			})
+		}
+
+		// Assert the dashboard queries keep their p95 latency under simulated viewers
+		// when the optional benchmark is enabled in test/setup
+		if minutes := dwh.GetTFSetupStringOutput("dashboard_benchmark_minutes"); minutes != "0" {
+			report.Check("The dashboard queries keep their p95 latency under simulated viewers", nil, func(assert *testutils.Assertions) {
+				verifyDashboardLatency(t, assert, projectID, region, minutes, dwh.GetTFSetupStringOutput("dashboard_benchmark_viewers"), dwh.GetTFSetupStringOutput("dashboard_p95_seconds"))
+			})
+		}
+
+		stop()
+		stop = timer.Start("verify/operations")
+
+		if full {
+			// Assert the reads and writes above were recorded in Data Access audit logs
+			report.Check("The reads and writes above were recorded in Data Access audit logs", nil, func(assert *testutils.Assertions) {
+				verifyDataAccessAuditLogs(t, assert, projectID)
+			})
+
+			// Assert Workflows and Dataproc logs are routed into the ops dataset
+			report.Check("Workflows and Dataproc logs are routed into the ops dataset", nil, func(assert *testutils.Assertions) {
+				verifyLogSink(t, assert, projectID, dwh.GetStringOutput("ops_dataset_id"))
+			})
+		}
+
+		// Assert the Iceberg table is consistently registered in BigLake Metastore
+		report.Check("The Iceberg table is consistently registered in BigLake Metastore", nil, func(assert *testutils.Assertions) {
+			verifyBigLakeMetastore(t, assert, projectID, region, warehouseBucket)
+		})
 
-			count := op.Get("0.count").Int()
-			assert.Greater(count, int64(0), table)
+		if full {
+			// Assert the Delta Lake table is queryable and matches the Iceberg table
+			report.Check("The Delta Lake table is queryable and matches the Iceberg table", nil, func(assert *testutils.Assertions) {
+				verifyDeltaLake(t, assert, projectID, dwh.GetStringOutput("delta_lake_uri"))
+			})
+
+			// Assert the scheduled maintenance compacts the Iceberg table and expires old snapshots
+			report.Check("The scheduled maintenance compacts the Iceberg table and expires old snapshots", []testutils.Link{testutils.WorkflowLink(projectID, region, dwh.GetStringOutput("iceberg_maintenance_workflow"))}, func(assert *testutils.Assertions) {
+				verifyIcebergMaintenance(t, assert, projectID, region, dwh.GetStringOutput("iceberg_maintenance_workflow"))
+			})
+
+			// Assert the retention workflow moves aged order partitions to the archive
+			report.Check("The retention workflow moves aged order partitions to the archive", []testutils.Link{testutils.WorkflowLink(projectID, region, dwh.GetStringOutput("retention_workflow")), testutils.BucketLink(dwh.GetStringOutput("archive_bucket"))}, func(assert *testutils.Assertions) {
+				verifyRetention(t, assert, projectID, region, dwh.GetStringOutput("retention_workflow"), dwh.GetStringOutput("archive_bucket"))
+			})
+		}
+
+		stop()
+		stop = timer.Start("verify/security")
+
+		// Assert the budget is scoped to the blueprint and alerts the notification channel
+		// when the optional budget is enabled in test/setup
+		if full && dwh.GetTFSetupStringOutput("budget_billing_account") != "" {
+			report.Check("The budget is scoped to the blueprint and alerts the notification channel", nil, func(assert *testutils.Assertions) {
+				verifyBudget(t, assert, projectID, dwh.GetStringOutput("budget"), dwh.GetTFSetupStringOutput("budget_alert_email"))
+			})
+		}
+
+		// The IAM and asset goldens record what the full fixture deploys
+		if full {
+			// Assert project and resource IAM matches the golden bindings
+			report.Check("Project and resource IAM matches the golden bindings", nil, func(assert *testutils.Assertions) {
+				verifyIAMGolden(t, assert, projectID, region, warehouseBucket)
+			})
+		}
+
+		// Assert blueprint service accounts hold no primitive roles
+		report.Check("Blueprint service accounts hold no primitive roles", nil, func(assert *testutils.Assertions) {
+			verifyNoPrimitiveRoles(t, assert, projectID, suffix)
+		})
+
+		// Assert every dataset uses Google-managed encryption in this fixture
+		report.Check("Every dataset uses Google-managed encryption in this fixture", nil, func(assert *testutils.Assertions) {
+			testutils.VerifyDatasetEncryption(t, assert, projectID, nil)
+		})
+
+		// Assert no dataset is open to the public or a whole domain
+		report.Check("No dataset is open to the public or a whole domain", nil, func(assert *testutils.Assertions) {
+			verifyNoPublicDatasets(t, assert, projectID)
+		})
+
+		if full {
+			// Assert the marketing user's access is scoped by an IAM condition
+			report.Check("The marketing user's access is scoped by an IAM condition", nil, func(assert *testutils.Assertions) {
+				verifyConditionalAccess(t, assert, projectID, suffix, dwh.GetStringOutput("lakehouse_dataset_id"))
+			})
+
+			// Assert the access layer consumer can read the views but not the tables
+			report.Check("The access layer consumer can read the views but not the tables", nil, func(assert *testutils.Assertions) {
+				verifyAuthorizedViews(t, assert, projectID, dwh.GetStringOutput("access_consumer_service_account"))
+			})
+		}
+
+		// Assert nothing runs as the Compute Engine default service account
+		report.Check("Nothing runs as the Compute Engine default service account", nil, func(assert *testutils.Assertions) {
+			verifyNoDefaultServiceAccounts(t, assert, projectID, region)
+		})
+
+		// Assert the blueprint deployed under the restrictive org policies from test/setup
+		report.Check("The blueprint deployed under the restrictive org policies from test/setup", nil, func(assert *testutils.Assertions) {
+			verifyOrgPolicies(t, assert, projectID)
+		})
+
+		// Assert no API call would be blocked by VPC Service Controls when the
+		// optional dry-run perimeter is configured in test/setup
+		if perimeter := dwh.GetTFSetupStringOutput("vpc_sc_dry_run_perimeter"); perimeter != "" {
+			report.Check("No API call would be blocked by VPC Service Controls", nil, func(assert *testutils.Assertions) {
+				verifyNoPerimeterViolations(t, assert, projectID, perimeter)
+			})
+		}
+
+		// Assert Security Command Center found no severe misconfigurations when
+		// the optional findings gate is enabled in test/setup
+		if dwh.GetTFSetupStringOutput("scc_findings_gate") == "true" {
+			report.Check("Security Command Center found no severe misconfigurations", nil, func(assert *testutils.Assertions) {
+				verifySecurityFindings(t, assert, projectID)
+			})
+		}
+
+		// Assert blueprint service accounts have no user-managed keys
+		report.Check("Blueprint service accounts have no user-managed keys", nil, func(assert *testutils.Assertions) {
+			verifyNoUserManagedKeys(t, assert, projectID, suffix)
+		})
+
+		// Assert orchestration and data writes run as separate, minimally scoped identities
+		report.Check("Orchestration and data writes run as separate, minimally scoped identities", nil, func(assert *testutils.Assertions) {
+			verifyServiceAccountSeparation(t, assert, projectID, region, dwh.GetStringOutput("workflows_service_account"), dwh.GetStringOutput("dataproc_service_account"), full)
+		})
+
+		// Assert connection service accounts can only read their own buckets
+		connectionBuckets := map[string][]string{
+			"gcp_lakehouse_connection": assetBuckets,
+			"gcp_gcs_connection":       {warehouseBucket},
+		}
+		bucketLinks := []testutils.Link{testutils.BucketLink(warehouseBucket)}
+		for _, bucket := range assetBuckets {
+			bucketLinks = append(bucketLinks, testutils.BucketLink(bucket))
+		}
+		report.Check("Connection service accounts can only read their own buckets", bucketLinks, func(assert *testutils.Assertions) {
+			verifyConnectionScoping(t, assert, projectID, region, suffix, connectionBuckets)
+		})
+
+		if full {
+			// Assert the secure tags are bound to the project and buckets
+			report.Check("The secure tags are bound to the project and buckets", nil, func(assert *testutils.Assertions) {
+				verifyTagBindings(t, assert, projectID, region, suffix)
+			})
+		}
+
+		stop()
+		stop = timer.Start("verify/network")
+
+		// Assert the network and subnet match the expected layout
+		report.Check("The network and subnet match the expected layout", nil, func(assert *testutils.Assertions) {
+			verifyNetworkLayout(t, assert, projectID, region)
+		})
+
+		// Assert the PHS and serverless Spark batches run on the created subnet
+		report.Check("The PHS and serverless Spark batches run on the created subnet", []testutils.Link{testutils.DataprocBatchesLink(projectID, region)}, func(assert *testutils.Assertions) {
+			dataprocSubnetwork := dwh.GetStringOutput("dataproc_subnetwork")
+			assert.Contains(dataprocSubnetwork, "/regions/"+region+"/subnetworks/"+dataprocSubnet, "Module is not using the created subnet")
+			testutils.VerifyDataprocSubnet(t, assert, projectID, region, dataprocSubnetwork)
+		})
+
+		if full {
+			// Assert serverless Spark can reach the internet through Cloud NAT
+			report.Check("Serverless Spark can reach the internet through Cloud NAT", []testutils.Link{testutils.DataprocBatchesLink(projectID, region)}, func(assert *testutils.Assertions) {
+				verifyNAT(t, assert, projectID, region, suffix, dwh.GetStringOutput("dataproc_service_account"))
+			})
+
+			// Assert Dataproc reaches BigQuery through the Private Service Connect endpoint
+			report.Check("Dataproc reaches BigQuery through the Private Service Connect endpoint", nil, func(assert *testutils.Assertions) {
+				verifyPrivateServiceConnect(t, assert, projectID, region, suffix, dwh.GetStringOutput("dataproc_service_account"))
+			})
+
+			// Assert the Serverless VPC Access connector is ready on the network
+			report.Check("The Serverless VPC Access connector is ready on the network", nil, func(assert *testutils.Assertions) {
+				verifyVPCConnector(t, assert, projectID, region, dwh.GetStringOutput("vpc_connector"))
+			})
+
+			// Assert the remote function scores rows from SQL through the connector
+			report.Check("The remote function scores rows from SQL through the connector", nil, func(assert *testutils.Assertions) {
+				verifyRemoteFunction(t, assert, projectID, region, dwh.GetStringOutput("vpc_connector"))
+			})
 		}
 
+		// Assert no VM in the project has an external IP
+		report.Check("No VM in the project has an external IP", nil, func(assert *testutils.Assertions) {
+			testutils.VerifyNoExternalIPs(t, assert, projectID)
+		})
+
+		// Assert the firewall only allows the internal Dataproc traffic
+		report.Check("The firewall only allows the internal Dataproc traffic", nil, func(assert *testutils.Assertions) {
+			verifyFirewallRules(t, assert, projectID)
+		})
+
+		// Assert Dataproc nodes and batches carry the tag the firewall targets
+		report.Check("Dataproc nodes and batches carry the tag the firewall targets", []testutils.Link{testutils.DataprocBatchesLink(projectID, region)}, func(assert *testutils.Assertions) {
+			verifyNetworkTags(t, assert, projectID, region)
+		})
+
+		stop()
+		stop = timer.Start("verify/dataproc")
+
 		// Assert only one Dataproc cluster is available
 		currentComputeInstances := gcloud.Runf(t, "dataproc clusters list --project=%s --region=%s", projectID, region).Array()
-		assert.Equal(len(currentComputeInstances), 1, "More than one Dataproc cluster is available.")
+		phsName := ""
+		var phsLinks []testutils.Link
+		if len(currentComputeInstances) > 0 {
+			phsName = currentComputeInstances[0].Get("clusterName").String()
+			phsLinks = []testutils.Link{testutils.DataprocClusterLink(projectID, region, phsName)}
+		}
+		report.Check("Only one Dataproc cluster is available", phsLinks, func(assert *testutils.Assertions) {
+			assert.Len(currentComputeInstances, 1, "Unexpected number of Dataproc clusters")
+		})
 
 		// Assert Dataproc cluster is stopped
-		phsName := currentComputeInstances[0].Get("clusterName")
-		cluster := gcloud.Runf(t, "dataproc clusters describe %s --project=%s", phsName, projectID)
-		state := cluster.Get("status").Get("state").String()
-		assert.Equal(state, "TERMINATED", "PHS is not in a stopped state")
+		if phsName != "" {
+			report.Check("Dataproc cluster is stopped", phsLinks, func(assert *testutils.Assertions) {
+				cluster := gcloud.Runf(t, "dataproc clusters describe %s --project=%s", phsName, projectID)
+				state := cluster.Get("status").Get("state").String()
+				assert.Equal("TERMINATED", state, "PHS is not in a stopped state")
+			})
+		}
+
+		stop()
+		stop = timer.Start("verify/governance")
+
+		if full {
+			// Assert Dataplex data attributes are bound to the zone entities
+			report.Check("Dataplex data attributes are bound to the zone entities", nil, func(assert *testutils.Assertions) {
+				verifyDataAttributes(t, assert, projectID, region)
+			})
+		}
+
+		// Assert zone discovery settings match the intended configuration
+		report.Check("Zone discovery settings match the intended configuration", nil, func(assert *testutils.Assertions) {
+			verifyZoneDiscovery(t, assert, projectID, region)
+		})
+
+		// Assert lineage links agg_events_iceberg to its staging sources
+		report.Check("Lineage links agg_events_iceberg to its staging sources", nil, func(assert *testutils.Assertions) {
+			verifyIcebergLineage(t, assert, projectID, region)
+		})
+
+		// Assert the thelook tables are discoverable in the catalog
+		report.Check("The thelook tables are discoverable in the catalog", nil, func(assert *testutils.Assertions) {
+			verifyCatalogEntries(t, assert, projectID, region)
+			verifyCatalogSearch(t, assert, projectID)
+		})
+
+		if full {
+			// Assert the data-freshness aspect is attached to the thelook entries
+			report.Check("The data-freshness aspect is attached to the thelook entries", nil, func(assert *testutils.Assertions) {
+				verifyAspects(t, assert, projectID, region)
+			})
+
+			// Assert the glossary terms exist and are linked to their tables
+			report.Check("The glossary terms exist and are linked to their tables", nil, func(assert *testutils.Assertions) {
+				verifyGlossary(t, assert, projectID, region)
+			})
+		}
+
+		// Assert an analyst without project-level roles can discover the data
+		report.Check("An analyst without project-level roles can discover the data", nil, func(assert *testutils.Assertions) {
+			verifyAnalystDiscovery(t, assert, projectID, region, dwh.GetStringOutput("data_analyst_service_account"))
+		})
+
+		// Assert Dataplex assets map to the buckets exported by the module
+		report.Check("Dataplex assets map to the buckets exported by the module", nil, func(assert *testutils.Assertions) {
+			verifyAssetMappings(t, assert, projectID, region, assetBuckets, []string{dwh.GetStringOutput("lakehouse_dataset_id")})
+		})
 
+		if full {
+			// Assert the labeled resources match the golden asset manifest for the raw data format
+			report.Check("The labeled resources match the golden asset manifest for the raw data format", nil, func(assert *testutils.Assertions) {
+				labels := testutils.RunLabels()
+				if labels == nil {
+					labels = map[string]string{testutils.SolutionLabel: "true"}
+				}
+				testutils.VerifyAssetGolden(t, assert, projectID, labels, "assets_"+strings.ToLower(rawDataFormat)+".json")
+			})
+		}
+
+		// Assert every resource created this run carries the run's labels
+		if labels := testutils.RunLabels(); labels != nil {
+			report.Check("Every resource created this run carries the run's labels", nil, func(assert *testutils.Assertions) {
+				testutils.VerifyRunLabels(t, assert, tfDir, projectID, labels)
+			})
+		}
+
+		stop()
+		stop = timer.Start("verify/drift")
+
+		// Assert out-of-band changes are detected by plan and corrected by apply
+		report.Check("Out-of-band changes are detected by plan and corrected by apply", nil, func(assert *testutils.Assertions) {
+			verifyDriftCorrection(t, assert, dwh, projectID, dwh.GetStringOutput("lakehouse_dataset_id"), warehouseBucket)
+		})
+
+		stop()
+
+		if os.Getenv(chaosEnvVar) == "true" {
+			stop = timer.Start("verify/chaos")
+
+			// Assert forcing project-setup restores a deleted staging table
+			report.Check("Forcing project-setup restores a deleted staging table", []testutils.Link{testutils.WorkflowLink(projectID, region, "project-setup")}, func(assert *testutils.Assertions) {
+				verifyStagingTableRecovery(t, assert, projectID, region)
+			})
+
+			stop()
+		}
+
+		if os.Getenv(scaleEnvVar) == "large" {
+			stop = timer.Start("verify/scale")
+
+			// Assert copy-data and Spark process the generated data within their SLOs
+			report.Check("Copy-data and Spark process the generated data within their SLOs", []testutils.Link{testutils.WorkflowLink(projectID, region, "copy-data"), testutils.DataprocBatchesLink(projectID, region)}, func(assert *testutils.Assertions) {
+				verifyScale(t, assert, projectID, region, suffix, rawDataFormat, dwh.GetStringOutput("tables_bucket"), dwh.GetStringOutput("dataproc_service_account"))
+			})
+
+			stop()
+		}
 	})
 
 	dwh.DefineTeardown(func(assert *assert.Assertions) {
+		stop := timer.Start("teardown")
 
 		projectID := dwh.GetTFSetupStringOutput("project_id")
 
-		verifyNoVMs := func() (bool, error) {
-			currentComputeInstances := gcloud.Runf(t, "compute instances list --project %s", projectID).Array()
-			// There should only be 1 compute instance (Dataproc PHS). Wait to destroy if other instances exist.
-			if len(currentComputeInstances) > 1 {
-				return true, nil
-			}
-			return false, nil
-		}
-		utils.Poll(t, verifyNoVMs, 120, 30*time.Second)
+		testutils.WaitForDataprocVMs(t, projectID)
 
 		dwh.DefaultTeardown(assert)
 
+		stop()
 	})
 	dwh.Test()
 }
@@ -0,0 +1,55 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package multiple_buckets
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/GoogleCloudPlatform/cloud-foundation-toolkit/infra/blueprint-test/pkg/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+// BI Engine reservation size requested by examples/analytics_lakehouse.
+const biEngineReservationBytes = 1 << 30
+
+// verifyBIEngine asserts the BI Engine reservation has the requested size and
+// prefers the curated tables, and that a dashboard-style aggregation over
+// daily_sales is accelerated. BI Engine loads tables on first use, so the
+// query is repeated until its statistics report acceleration.
+func verifyBIEngine(t *testing.T, assert *assert.Assertions, projectID, region, reservation string) {
+	bi := callAPI(t, "GET", "https://bigqueryreservation.googleapis.com/v1/"+reservation, "")
+	assert.Equal(int64(biEngineReservationBytes), bi.Get("size").Int(), "BI Engine reservation has the wrong size")
+	for _, table := range dataformTables {
+		dataset, tableID := splitTable(table)
+		assert.True(bi.Get(fmt.Sprintf("preferredTables.#(datasetId==%q)#|#(tableId==%q)", dataset, tableID)).Exists(), "%s is not a preferred BI Engine table", table)
+	}
+
+	api := fmt.Sprintf("https://bigquery.googleapis.com/bigquery/v2/projects/%s/", projectID)
+	query := fmt.Sprintf("SELECT product_category, SUM(revenue) AS revenue, SUM(margin) AS margin FROM `%s.gcp_lakehouse_curated.daily_sales` GROUP BY product_category ORDER BY revenue DESC", projectID)
+	body := fmt.Sprintf(`{"query": %q, "useLegacySql": false, "useQueryCache": false, "location": %q}`, query, region)
+
+	var stats string
+	verifyAcceleration := func() (bool, error) {
+		jobID := callAPI(t, "POST", api+"queries", body).Get("jobReference.jobId").String()
+		job := callAPI(t, "GET", api+"jobs/"+jobID+"?location="+region, "")
+		mode := job.Get("statistics.query.biEngineStatistics.accelerationMode").String()
+		stats = job.Get("statistics.query.biEngineStatistics").Raw
+		return mode != "FULL_INPUT" && mode != "PARTIAL_INPUT" && mode != "FULL_QUERY", nil
+	}
+	err := utils.PollE(t, verifyAcceleration, 10, 30*time.Second)
+	assert.NoError(err, "Dashboard query was not accelerated by BI Engine: %s", stats)
+}
@@ -0,0 +1,57 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package multiple_buckets
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/GoogleCloudPlatform/cloud-foundation-toolkit/infra/blueprint-test/pkg/bq"
+	"github.com/GoogleCloudPlatform/cloud-foundation-toolkit/infra/blueprint-test/pkg/gcloud"
+	"github.com/stretchr/testify/assert"
+)
+
+// BigLake Metastore names used by src/bigquery.py.
+const (
+	blmsCatalog  = "lakehouse_catalog"
+	blmsDatabase = "lakehouse_db"
+	blmsTable    = "agg_events_iceberg"
+)
+
+// verifyBigLakeMetastore asserts the Iceberg table is registered in BigLake
+// Metastore, that its metadata file exists in the warehouse bucket, and that
+// the BigQuery table reads the same metadata file.
+func verifyBigLakeMetastore(t *testing.T, assert *assert.Assertions, projectID, region, warehouseBucket string) {
+	catalog := fmt.Sprintf("projects/%s/locations/%s/catalogs/%s", projectID, region, blmsCatalog)
+	database := catalog + "/databases/" + blmsDatabase
+	table := database + "/tables/" + blmsTable
+
+	for _, name := range []string{catalog, database, table} {
+		op := callAPI(t, "GET", "https://biglake.googleapis.com/v1/"+name, "")
+		assert.Equal(name, op.Get("name").String(), "BigLake Metastore resource %s does not exist", name)
+	}
+
+	op := callAPI(t, "GET", "https://biglake.googleapis.com/v1/"+table, "")
+	metadataLocation := op.Get("hiveOptions.parameters.metadata_location").String()
+	assert.True(strings.HasPrefix(metadataLocation, "gs://"+warehouseBucket+"/warehouse/"), "Metadata location %s is outside the warehouse bucket", metadataLocation)
+	assert.True(strings.HasPrefix(op.Get("hiveOptions.storageDescriptor.locationUri").String(), "gs://"+warehouseBucket+"/warehouse/"), "Table location is outside the warehouse bucket")
+
+	object := gcloud.Runf(t, "storage objects describe %s", metadataLocation)
+	assert.Equal(strings.TrimPrefix(metadataLocation, "gs://"+warehouseBucket+"/"), object.Get("name").String(), "Metadata file %s does not exist", metadataLocation)
+
+	bqTable := bq.Runf(t, "--project_id=%s show gcp_lakehouse_ds.%s", projectID, blmsTable)
+	assert.Equal(metadataLocation, bqTable.Get("externalDataConfiguration.sourceUris.0").String(), "BigQuery table and BigLake Metastore disagree on the current metadata file")
+}
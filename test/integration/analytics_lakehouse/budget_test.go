@@ -0,0 +1,67 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package multiple_buckets
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/GoogleCloudPlatform/cloud-foundation-toolkit/infra/blueprint-test/pkg/gcloud"
+	"github.com/GoogleCloudPlatform/cloud-foundation-toolkit/infra/blueprint-test/pkg/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+// budgetAmount is the default budget_amount the example deploys with.
+const budgetAmount = "1000"
+
+// budgetThresholds are the spend bases and percentages the budget alerts at.
+var budgetThresholds = []string{
+	"CURRENT_SPEND 0.5",
+	"CURRENT_SPEND 0.9",
+	"CURRENT_SPEND 1",
+	"FORECASTED_SPEND 1",
+}
+
+// verifyBudget asserts the budget is scoped to the project and the
+// blueprint's label, has the expected amount and threshold rules, and sends
+// its alerts only to an email notification channel for alertEmail.
+func verifyBudget(t *testing.T, assert *assert.Assertions, projectID, budget, alertEmail string) {
+	b := callAPI(t, "GET", "https://billingbudgets.googleapis.com/v1/"+budget, "")
+
+	number := gcloud.Runf(t, "projects describe %s", projectID).Get("projectNumber").String()
+	assert.Equal([]string{"projects/" + number}, utils.GetResultStrSlice(b.Get("budgetFilter.projects").Array()), "Budget is not scoped to the project")
+	assert.Equal([]string{"true"}, utils.GetResultStrSlice(b.Get("budgetFilter.labels.analytics-lakehouse.values").Array()), "Budget is not scoped to the analytics-lakehouse label")
+	assert.Equal("USD", b.Get("amount.specifiedAmount.currencyCode").String(), "Budget is not in USD")
+	assert.Equal(budgetAmount, b.Get("amount.specifiedAmount.units").String(), "Budget has the wrong amount")
+
+	thresholds := []string{}
+	for _, rule := range b.Get("thresholdRules").Array() {
+		basis := rule.Get("spendBasis").String()
+		if basis == "" {
+			basis = "CURRENT_SPEND"
+		}
+		thresholds = append(thresholds, fmt.Sprintf("%s %g", basis, rule.Get("thresholdPercent").Float()))
+	}
+	assert.ElementsMatch(budgetThresholds, thresholds, "Budget has the wrong threshold rules")
+
+	channels := b.Get("allUpdatesRule.monitoringNotificationChannels").Array()
+	if !assert.Len(channels, 1, "Budget does not alert exactly one notification channel") {
+		return
+	}
+	channel := callAPI(t, "GET", "https://monitoring.googleapis.com/v3/"+channels[0].String(), "")
+	assert.Equal("email", channel.Get("type").String(), "Budget notification channel is not an email channel")
+	assert.Equal(alertEmail, channel.Get("labels.email_address").String(), "Budget alerts are sent to the wrong address")
+	assert.True(b.Get("allUpdatesRule.disableDefaultIamRecipients").Bool(), "Billing account users are alerted in addition to the channel")
+}
@@ -0,0 +1,158 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package multiple_buckets
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/GoogleCloudPlatform/cloud-foundation-toolkit/infra/blueprint-test/pkg/gcloud"
+	"github.com/GoogleCloudPlatform/cloud-foundation-toolkit/infra/blueprint-test/pkg/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+// Tables published by Dataplex discovery that analysts should find by searching "thelook".
+var thelookTables = []string{
+	"gcp_primary_staging.thelook_ecommerce_distribution_centers",
+	"gcp_primary_staging.thelook_ecommerce_events",
+	"gcp_primary_staging.thelook_ecommerce_inventory_items",
+	"gcp_primary_staging.thelook_ecommerce_order_items",
+	"gcp_primary_staging.thelook_ecommerce_orders",
+	"gcp_primary_staging.thelook_ecommerce_products",
+	"gcp_primary_staging.thelook_ecommerce_users",
+}
+
+// verifyCatalogEntries asserts each thelook table has a Dataplex Catalog entry
+// in the BigQuery system entry group.
+func verifyCatalogEntries(t *testing.T, assert *assert.Assertions, projectID, region string) {
+	entryGroup := fmt.Sprintf("projects/%s/locations/%s/entryGroups/@bigquery", projectID, region)
+	group := callAPI(t, "GET", "https://dataplex.googleapis.com/v1/"+entryGroup, "")
+	assert.Equal(entryGroup, group.Get("name").String(), "BigQuery entry group does not exist")
+
+	for _, table := range thelookTables {
+		dataset, tableID := splitTable(table)
+		entry := fmt.Sprintf("%s/entries/bigquery.googleapis.com/projects/%s/datasets/%s/tables/%s", entryGroup, projectID, dataset, tableID)
+		url := fmt.Sprintf("https://dataplex.googleapis.com/v1/projects/%s/locations/%s:lookupEntry?entry=%s", projectID, region, entry)
+		op := callAPI(t, "GET", url, "")
+		assert.Equal(entry, op.Get("name").String(), "Catalog entry for %s does not exist", table)
+	}
+}
+
+// verifyCatalogSearch asserts searching the catalog for "thelook" returns
+// every thelook table, the way an analyst would discover them.
+func verifyCatalogSearch(t *testing.T, assert *assert.Assertions, projectID string) {
+	url := fmt.Sprintf("https://dataplex.googleapis.com/v1/projects/%s/locations/global:searchEntries", projectID)
+	body := fmt.Sprintf(`{"query": "thelook", "scope": "projects/%s", "pageSize": 500}`, projectID)
+
+	// Newly published tables take a few minutes to become searchable.
+	var found []string
+	searchTables := func() (bool, error) {
+		found = nil
+		results := callAPI(t, "POST", url, body)
+		for _, result := range results.Get("results").Array() {
+			found = append(found, result.Get("dataplexEntry.fullyQualifiedName").String())
+		}
+		for _, table := range thelookTables {
+			if !contains(found, fmt.Sprintf("bigquery:%s.%s", projectID, table)) {
+				return true, nil
+			}
+		}
+		return false, nil
+	}
+	err := utils.PollE(t, searchTables, 20, 30*time.Second)
+	assert.NoError(err, "Catalog search for thelook did not return all tables, found %v", found)
+}
+
+// verifyAspects asserts the data-freshness aspect type exists and is attached
+// to every thelook table entry.
+func verifyAspects(t *testing.T, assert *assert.Assertions, projectID, region string) {
+	aspectType := fmt.Sprintf("projects/%s/locations/%s/aspectTypes/data-freshness", projectID, region)
+	op := callAPI(t, "GET", "https://dataplex.googleapis.com/v1/"+aspectType, "")
+	assert.Equal(aspectType, op.Get("name").String(), "data-freshness aspect type does not exist")
+
+	aspectKey := fmt.Sprintf("%s.%s.data-freshness", projectID, region)
+	entryGroup := fmt.Sprintf("projects/%s/locations/%s/entryGroups/@bigquery", projectID, region)
+	for _, table := range thelookTables {
+		dataset, tableID := splitTable(table)
+		entry := fmt.Sprintf("%s/entries/bigquery.googleapis.com/projects/%s/datasets/%s/tables/%s", entryGroup, projectID, dataset, tableID)
+		url := fmt.Sprintf("https://dataplex.googleapis.com/v1/projects/%s/locations/%s:lookupEntry?view=ALL&entry=%s", projectID, region, entry)
+		aspect := callAPI(t, "GET", url, "").Get("aspects").Get(escapeKey(aspectKey))
+		assert.True(aspect.Exists(), "Entry for %s is missing the data-freshness aspect", table)
+		assert.NotEmpty(aspect.Get("data.refresh_cadence").String(), "data-freshness aspect on %s has no refresh_cadence", table)
+	}
+}
+
+// verifyAnalystDiscovery asserts the data analyst persona, holding only
+// lake-level read roles, can search the catalog and read table metadata.
+func verifyAnalystDiscovery(t *testing.T, assert *assert.Assertions, projectID, region, analyst string) {
+	policy := gcloud.Runf(t, "projects get-iam-policy %s", projectID)
+	for _, binding := range policy.Get("bindings").Array() {
+		members := utils.GetResultStrSlice(binding.Get("members").Array())
+		assert.NotContains(members, "serviceAccount:"+analyst, "Analyst holds project-level role %s", binding.Get("role").String())
+	}
+
+	token := impersonatedAccessToken(t, analyst)
+
+	url := fmt.Sprintf("https://dataplex.googleapis.com/v1/projects/%s/locations/global:searchEntries", projectID)
+	body := fmt.Sprintf(`{"query": "thelook", "scope": "projects/%s", "pageSize": 500}`, projectID)
+	var found []string
+	searchAsAnalyst := func() (bool, error) {
+		found = nil
+		results := callAPIWithToken(t, token, "POST", url, body)
+		for _, result := range results.Get("results").Array() {
+			found = append(found, result.Get("dataplexEntry.fullyQualifiedName").String())
+		}
+		return !contains(found, fmt.Sprintf("bigquery:%s.%s", projectID, thelookTables[0])), nil
+	}
+	err := utils.PollE(t, searchAsAnalyst, 20, 30*time.Second)
+	assert.NoError(err, "Analyst catalog search for thelook did not return %s, found %v", thelookTables[0], found)
+
+	dataset, tableID := splitTable(thelookTables[0])
+	entry := fmt.Sprintf("projects/%s/locations/%s/entryGroups/@bigquery/entries/bigquery.googleapis.com/projects/%s/datasets/%s/tables/%s", projectID, region, projectID, dataset, tableID)
+	lookup := fmt.Sprintf("https://dataplex.googleapis.com/v1/projects/%s/locations/%s:lookupEntry?view=ALL&entry=%s", projectID, region, entry)
+	op := callAPIWithToken(t, token, "GET", lookup, "")
+	assert.True(op.Get("aspects").Exists(), "Analyst could not read metadata for %s", thelookTables[0])
+}
+
+// Glossary terms created by the project-setup workflow and the tables they define.
+var glossaryTerms = map[string]string{
+	"orders":     "gcp_primary_staging.thelook_ecommerce_orders",
+	"events":     "gcp_primary_staging.thelook_ecommerce_events",
+	"taxi-trips": "gcp_primary_staging.new_york_taxi_trips_tlc_yellow_trips_2022",
+}
+
+// verifyGlossary asserts the business glossary and its terms exist and that
+// each term is linked to the table it defines.
+func verifyGlossary(t *testing.T, assert *assert.Assertions, projectID, region string) {
+	glossary := fmt.Sprintf("projects/%s/locations/global/glossaries/lakehouse-glossary", projectID)
+	op := callAPI(t, "GET", "https://dataplex.googleapis.com/v1/"+glossary, "")
+	assert.Equal(glossary, op.Get("name").String(), "Business glossary does not exist")
+
+	terms := callAPI(t, "GET", "https://dataplex.googleapis.com/v1/"+glossary+"/terms", "")
+	termNames := utils.GetResultStrSlice(terms.Get("terms.#.name").Array())
+
+	entryGroup := fmt.Sprintf("projects/%s/locations/%s/entryGroups/@bigquery", projectID, region)
+	for term, table := range glossaryTerms {
+		termName := glossary + "/terms/" + term
+		assert.Contains(termNames, termName, "Glossary term %s does not exist", term)
+
+		dataset, tableID := splitTable(table)
+		link := callAPI(t, "GET", fmt.Sprintf("https://dataplex.googleapis.com/v1/%s/entryLinks/%s-definition", entryGroup, term), "")
+		references := utils.GetResultStrSlice(link.Get("entryReferences.#.name").Array())
+		assert.Contains(references, fmt.Sprintf("%s/entries/bigquery.googleapis.com/projects/%s/datasets/%s/tables/%s", entryGroup, projectID, dataset, tableID), "Term %s is not linked to %s", term, table)
+		assert.Contains(references, fmt.Sprintf("projects/%s/locations/global/entryGroups/@dataplex/entries/%s", projectID, termName), "Link for %s does not resolve to the term", term)
+	}
+}
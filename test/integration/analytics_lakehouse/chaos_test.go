@@ -0,0 +1,61 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package multiple_buckets
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/GoogleCloudPlatform/cloud-foundation-toolkit/infra/blueprint-test/pkg/bq"
+	"github.com/GoogleCloudPlatform/cloud-foundation-toolkit/infra/blueprint-test/pkg/gcloud"
+	"github.com/GoogleCloudPlatform/cloud-foundation-toolkit/infra/blueprint-test/pkg/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+// chaosEnvVar opts a run into the chaos scenarios, which break a deployed
+// resource and check the blueprint recovers. They change the deployment, so
+// they run last.
+const chaosEnvVar = "CHAOS_TESTS"
+
+// chaosTable is the staging table deleted by verifyStagingTableRecovery.
+// Other checks do not read it, so they are unaffected if it is not restored.
+const chaosTable = "gcp_primary_staging.thelook_ecommerce_distribution_centers"
+
+// verifyStagingTableRecovery deletes a staging table, as an operator might by
+// mistake after copy-data succeeded, and asserts running project-setup again
+// with {"force": true} republishes it with its rows.
+func verifyStagingTableRecovery(t *testing.T, assert *assert.Assertions, projectID, region string) {
+	rows := bq.Runf(t, "--project_id=%s query --nouse_legacy_sql SELECT count(*) AS rows FROM `%s.%s`;", projectID, projectID, chaosTable).Get("0.rows").Int()
+	if !assert.Greater(rows, int64(0), "%s is empty before it is deleted", chaosTable) {
+		return
+	}
+	bq.RunCmd(t, fmt.Sprintf("--project_id=%s rm -f -t %s", projectID, chaosTable))
+
+	execution := gcloud.Runf(t, "workflows run project-setup --project=%s --location=%s --data={\"force\":true}", projectID, region)
+	if !assert.Equal("SUCCEEDED", execution.Get("state").String(), "Forced project-setup failed: %s", execution.Get("error.payload")) {
+		return
+	}
+
+	// Publishing lags the end of the discovery run
+	restored := func() (bool, error) {
+		_, err := bq.RunCmdE(t, fmt.Sprintf("--project_id=%s show %s", projectID, chaosTable))
+		return err != nil, nil
+	}
+	utils.Poll(t, restored, 40, 15*time.Second)
+
+	got := bq.Runf(t, "--project_id=%s query --nouse_legacy_sql SELECT count(*) AS rows FROM `%s.%s`;", projectID, projectID, chaosTable).Get("0.rows").Int()
+	assert.Equal(rows, got, "%s is not restored with its rows", chaosTable)
+}
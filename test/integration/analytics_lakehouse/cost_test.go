@@ -0,0 +1,82 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package multiple_buckets
+
+import (
+	"fmt"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/GoogleCloudPlatform/cloud-foundation-toolkit/infra/blueprint-test/pkg/bq"
+	"github.com/GoogleCloudPlatform/cloud-foundation-toolkit/infra/blueprint-test/pkg/tft"
+	"github.com/GoogleCloudPlatform/cloud-foundation-toolkit/infra/blueprint-test/pkg/utils"
+	"github.com/stretchr/testify/assert"
+	"github.com/terraform-google-modules/terraform-google-analytics-lakehouse/test/integration/testutils"
+)
+
+// The billing export usually lags usage by a few hours, so TestRunCost waits
+// up to six hours for it to catch up with the end of the run.
+const (
+	billingExportPolls    = 36
+	billingExportInterval = 10 * time.Minute
+)
+
+// TestRunCost asserts the resources the CI run labeled with its run ID cost
+// less than the setup's run_cost_threshold, after credits. It runs after the
+// fixture is torn down, waiting until the billing export covers usage up to
+// then. It is skipped unless both the setup's billing_export_table and the
+// run ID are set.
+func TestRunCost(t *testing.T) {
+	testutils.ConfigureAuth(t)
+
+	dwh := tft.NewTFBlueprintTest(t)
+	table := dwh.GetTFSetupStringOutput("billing_export_table")
+	labels := testutils.RunLabels()
+	if table == "" || labels == nil {
+		t.Skipf("billing_export_table in test/setup or %s is unset", testutils.RunIDEnvVar)
+	}
+	projectID := dwh.GetTFSetupStringOutput("project_id")
+	threshold, err := strconv.ParseFloat(dwh.GetTFSetupStringOutput("run_cost_threshold"), 64)
+	if err != nil {
+		t.Fatalf("parsing run_cost_threshold: %v", err)
+	}
+
+	// Only the last three days of exports can hold the run
+	partitions := "_PARTITIONTIME >= TIMESTAMP_SUB(CURRENT_TIMESTAMP(), INTERVAL 3 DAY)"
+	end := time.Now().UTC()
+	query := fmt.Sprintf("SELECT FORMAT_TIMESTAMP('%%FT%%TZ', MAX(usage_end_time)) AS exported FROM `%s` WHERE %s;", table, partitions)
+	verifyExported := func() (bool, error) {
+		exported, err := time.Parse(time.RFC3339, bq.Runf(t, "--project_id=%s query --nouse_legacy_sql %s", projectID, query).Get("0.exported").String())
+		return err != nil || exported.Before(end), nil
+	}
+	utils.Poll(t, verifyExported, billingExportPolls, billingExportInterval)
+
+	query = fmt.Sprintf("SELECT service.description AS service, SUM(cost) + SUM(IFNULL((SELECT SUM(c.amount) FROM UNNEST(credits) c), 0)) AS cost FROM `%s` "+
+		"WHERE %s AND EXISTS(SELECT 1 FROM UNNEST(labels) l WHERE l.key = '%s' AND l.value = '%s') GROUP BY service ORDER BY cost DESC;",
+		table, partitions, testutils.RunLabel, labels[testutils.RunLabel])
+	services := bq.Runf(t, "--project_id=%s query --nouse_legacy_sql %s", projectID, query).Array()
+
+	assert := assert.New(t)
+	if !assert.NotEmpty(services, "No cost in %s is labeled %s=%s", table, testutils.RunLabel, labels[testutils.RunLabel]) {
+		return
+	}
+	total := 0.0
+	for _, service := range services {
+		t.Logf("%s: %.2f USD", service.Get("service").String(), service.Get("cost").Float())
+		total += service.Get("cost").Float()
+	}
+	assert.LessOrEqual(total, threshold, "Run cost %.2f USD, more than the %.2f USD threshold", total, threshold)
+}
@@ -0,0 +1,149 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package multiple_buckets
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/tidwall/gjson"
+)
+
+// dashboardQuery is a query of one chart of the Looker Studio report.
+type dashboardQuery struct {
+	chart string
+	sql   string
+}
+
+// dashboardQueries returns the queries in testdata/dashboard_queries.sql,
+// each named by the comment preceding it.
+func dashboardQueries(t *testing.T) []dashboardQuery {
+	data, err := os.ReadFile(filepath.Join("testdata", "dashboard_queries.sql"))
+	if err != nil {
+		t.Fatalf("reading the dashboard queries: %v", err)
+	}
+	queries := []dashboardQuery{}
+	chart, sql := "", []string{}
+	for _, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(line)
+		if strings.HasPrefix(line, "--") {
+			chart = strings.TrimSpace(strings.TrimPrefix(line, "--"))
+			continue
+		}
+		if line == "" {
+			continue
+		}
+		sql = append(sql, line)
+		if strings.HasSuffix(line, ";") {
+			queries = append(queries, dashboardQuery{chart: chart, sql: strings.Join(sql, " ")})
+			sql = nil
+		}
+	}
+	if len(queries) == 0 {
+		t.Fatal("testdata/dashboard_queries.sql has no queries")
+	}
+	return queries
+}
+
+// percentile returns the p-th percentile of sorted latencies.
+func percentile(sorted []time.Duration, p int) time.Duration {
+	return sorted[(len(sorted)-1)*p/100]
+}
+
+// verifyDashboardLatency replays the Looker Studio report's queries for
+// minutes, as viewers each refreshing the report as soon as the last refresh
+// finished, and asserts the 95th percentile query latency stays within
+// p95Seconds and no query fails. Results bypass the query cache, so every
+// refresh runs on the project's capacity as a first view would.
+func verifyDashboardLatency(t *testing.T, assert *assert.Assertions, projectID, region, minutes, viewers, p95Seconds string) {
+	duration, err := strconv.Atoi(minutes)
+	if !assert.NoError(err, "Invalid dashboard_benchmark_minutes %q", minutes) {
+		return
+	}
+	n, err := strconv.Atoi(viewers)
+	if !assert.NoError(err, "Invalid dashboard_benchmark_viewers %q", viewers) {
+		return
+	}
+	slo, err := strconv.ParseFloat(p95Seconds, 64)
+	if !assert.NoError(err, "Invalid dashboard_p95_seconds %q", p95Seconds) {
+		return
+	}
+
+	queries := dashboardQueries(t)
+	token := accessToken(t)
+	api := fmt.Sprintf("https://bigquery.googleapis.com/bigquery/v2/projects/%s/queries", projectID)
+	deadline := time.Now().Add(time.Duration(duration) * time.Minute)
+
+	var mu sync.Mutex
+	latencies := map[string][]time.Duration{}
+	failures := []string{}
+	var wg sync.WaitGroup
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for time.Now().Before(deadline) {
+				for _, q := range queries {
+					body := fmt.Sprintf(`{"query": %q, "useLegacySql": false, "useQueryCache": false, "location": %q, "timeoutMs": 60000}`, q.sql, region)
+					start := time.Now()
+					status, respBody, err := doAPIE(token, "POST", api, body)
+					latency := time.Since(start)
+					switch {
+					case err == nil && status != http.StatusOK:
+						err = fmt.Errorf("returned %d: %s", status, respBody)
+					case err == nil && !gjson.GetBytes(respBody, "jobComplete").Bool():
+						err = fmt.Errorf("job %s did not complete within 60s", gjson.GetBytes(respBody, "jobReference.jobId"))
+					}
+					mu.Lock()
+					if err != nil {
+						failures = append(failures, fmt.Sprintf("%s: %v", q.chart, err))
+					} else {
+						latencies[q.chart] = append(latencies[q.chart], latency)
+					}
+					mu.Unlock()
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	assert.Empty(failures, "Dashboard queries failed during the benchmark")
+	all := []time.Duration{}
+	for _, q := range queries {
+		chart := latencies[q.chart]
+		if !assert.NotEmpty(chart, "No %s query succeeded", q.chart) {
+			continue
+		}
+		sort.Slice(chart, func(i, j int) bool { return chart[i] < chart[j] })
+		t.Logf("%s: %d queries, p50 %s, p95 %s", q.chart, len(chart), percentile(chart, 50), percentile(chart, 95))
+		all = append(all, chart...)
+	}
+	if len(all) == 0 {
+		return
+	}
+	sort.Slice(all, func(i, j int) bool { return all[i] < all[j] })
+	p95 := percentile(all, 95)
+	t.Logf("%d viewers for %d minutes: %d queries, p50 %s, p95 %s, max %s", n, duration, len(all), percentile(all, 50), p95, all[len(all)-1])
+	assert.LessOrEqual(p95.Seconds(), slo, "Dashboard query p95 latency exceeded %gs", slo)
+}
@@ -0,0 +1,77 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package multiple_buckets
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/GoogleCloudPlatform/cloud-foundation-toolkit/infra/blueprint-test/pkg/bq"
+	"github.com/GoogleCloudPlatform/cloud-foundation-toolkit/infra/blueprint-test/pkg/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+const dataTransferAPI = "https://bigquerydatatransfer.googleapis.com/v1/"
+
+// runTransfer starts a manual run of the transfer config and returns its final
+// state once the run stops.
+func runTransfer(t *testing.T, config string) string {
+	body := fmt.Sprintf(`{"requestedRunTime": %q}`, time.Now().UTC().Format(time.RFC3339))
+	run := callAPI(t, "POST", dataTransferAPI+config+":startManualRuns", body).Get("runs.0.name").String()
+
+	state := ""
+	verifyRun := func() (bool, error) {
+		state = callAPI(t, "GET", dataTransferAPI+run, "").Get("state").String()
+		return state != "SUCCEEDED" && state != "FAILED" && state != "CANCELLED", nil
+	}
+	utils.Poll(t, verifyRun, 30, 30*time.Second)
+	return state
+}
+
+// verifyScheduledQuery asserts the daily aggregates scheduled query is
+// enabled on a schedule, and that a manually triggered run succeeds and
+// fills the aggregates table.
+func verifyScheduledQuery(t *testing.T, assert *assert.Assertions, projectID, config string) {
+	transfer := callAPI(t, "GET", dataTransferAPI+config, "")
+	assert.Equal("scheduled_query", transfer.Get("dataSourceId").String(), "Transfer config is not a scheduled query")
+	assert.False(transfer.Get("disabled").Bool(), "Scheduled query is disabled")
+	assert.NotEmpty(transfer.Get("schedule").String(), "Scheduled query has no schedule")
+
+	assert.Equal("SUCCEEDED", runTransfer(t, config), "Manual run of the scheduled query did not succeed")
+
+	query := fmt.Sprintf("SELECT count(*) AS count FROM `%s.gcp_lakehouse_ds.daily_order_aggregates`;", projectID)
+	count := bq.Runf(t, "--project_id=%s query --nouse_legacy_sql %s", projectID, query).Get("0.count").Int()
+	assert.Greater(count, int64(0), "Scheduled query did not aggregate any orders")
+}
+
+// verifyTransferLoad asserts the Cloud Storage transfer reads the raw orders
+// files in the format Dataplex discovered them in, and that a manually
+// triggered run loads as many rows as the discovered staging table has.
+func verifyTransferLoad(t *testing.T, assert *assert.Assertions, projectID, config string) {
+	transfer := callAPI(t, "GET", dataTransferAPI+config, "")
+	assert.Equal("google_cloud_storage", transfer.Get("dataSourceId").String(), "Transfer config does not load from Cloud Storage")
+	staging := bq.Runf(t, "--project_id=%s show gcp_primary_staging.thelook_ecommerce_orders", projectID)
+	assert.Equal(staging.Get("externalDataConfiguration.sourceFormat").String(), transfer.Get("params.file_format").String(), "Transfer file format does not match the discovered orders files")
+
+	if !assert.Equal("SUCCEEDED", runTransfer(t, config), "Manual run of the Cloud Storage transfer did not succeed") {
+		return
+	}
+
+	query := fmt.Sprintf("SELECT (SELECT count(*) FROM `%[1]s.gcp_lakehouse_ds.thelook_ecommerce_orders_transfer`) AS loaded, (SELECT count(*) FROM `%[1]s.gcp_primary_staging.thelook_ecommerce_orders`) AS expected;", projectID)
+	op := bq.Runf(t, "--project_id=%s query --nouse_legacy_sql %s", projectID, query)
+	assert.Greater(op.Get("0.expected").Int(), int64(0), "Staging orders table is empty")
+	assert.Equal(op.Get("0.expected").Int(), op.Get("0.loaded").Int(), "Transfer did not load every order")
+}
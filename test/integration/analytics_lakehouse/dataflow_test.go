@@ -0,0 +1,47 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package multiple_buckets
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/GoogleCloudPlatform/cloud-foundation-toolkit/infra/blueprint-test/pkg/bq"
+	"github.com/GoogleCloudPlatform/cloud-foundation-toolkit/infra/blueprint-test/pkg/gcloud"
+	"github.com/stretchr/testify/assert"
+)
+
+// Number of distribution centers in src/dataflow/distribution_centers.csv.
+const dataflowRows = 10
+
+// verifyDataflowLoad asserts the project-setup workflow's Dataflow job
+// finished and that its rows landed in the lakehouse dataset with the
+// JavaScript transform applied.
+func verifyDataflowLoad(t *testing.T, assert *assert.Assertions, projectID, region string) {
+	jobs := gcloud.Runf(t, "dataflow jobs list --project=%s --region=%s --status=all --filter=name~^lakehouse-distribution-centers", projectID, region).Array()
+	if !assert.NotEmpty(jobs, "Dataflow load job was not launched") {
+		return
+	}
+	for _, job := range jobs {
+		state := gcloud.Runf(t, "dataflow jobs describe %s --project=%s --region=%s", job.Get("id").String(), projectID, region).Get("currentState").String()
+		assert.Equal("JOB_STATE_DONE", state, "Dataflow job %s did not complete", job.Get("name").String())
+	}
+
+	query := fmt.Sprintf("SELECT count(*) AS count, COUNTIF(state IS NULL) AS missing_state, COUNTIF(ST_X(location)!=longitude OR ST_Y(location)!=latitude) AS bad_location FROM `%s.gcp_lakehouse_ds.distribution_centers_dataflow`;", projectID)
+	op := bq.Runf(t, "--project_id=%s query --nouse_legacy_sql %s", projectID, query)
+	assert.Equal(int64(dataflowRows), op.Get("0.count").Int(), "Dataflow did not load every distribution center")
+	assert.Zero(op.Get("0.missing_state").Int(), "Transform did not derive the state")
+	assert.Zero(op.Get("0.bad_location").Int(), "Transform did not build the location point")
+}
@@ -0,0 +1,71 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package multiple_buckets
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/GoogleCloudPlatform/cloud-foundation-toolkit/infra/blueprint-test/pkg/bq"
+	"github.com/GoogleCloudPlatform/cloud-foundation-toolkit/infra/blueprint-test/pkg/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+const dataformAPI = "https://dataform.googleapis.com/v1beta1/"
+
+// Tables the Dataform models in src/dataform build in the curated dataset.
+var dataformTables = []string{
+	"gcp_lakehouse_curated.daily_sales",
+	"gcp_lakehouse_curated.order_details",
+}
+
+// verifyDataform asserts the Dataform repository exists, compiles the
+// workspace the project-setup workflow wrote without errors, and that
+// invoking the compilation result builds non-empty curated tables.
+func verifyDataform(t *testing.T, assert *assert.Assertions, projectID, repository string) {
+	assert.Equal(repository, callAPI(t, "GET", dataformAPI+repository, "").Get("name").String(), "Dataform repository not found")
+
+	workspaces := callAPI(t, "GET", dataformAPI+repository+"/workspaces", "").Get("workspaces").Array()
+	if !assert.NotEmpty(workspaces, "project-setup did not create a Dataform workspace") {
+		return
+	}
+	workspace := workspaces[0].Get("name").String()
+
+	compilation := callAPI(t, "POST", dataformAPI+repository+"/compilationResults", fmt.Sprintf(`{"workspace": %q}`, workspace))
+	if !assert.False(compilation.Get("compilationErrors").Exists(), "Dataform compilation failed: %s", compilation.Get("compilationErrors").String()) {
+		return
+	}
+	actions := callAPI(t, "GET", dataformAPI+compilation.Get("name").String()+":query", "").Get("compilationResultActions.#.target")
+	for _, table := range dataformTables {
+		dataset, tableID := splitTable(table)
+		assert.True(actions.Get(fmt.Sprintf("#(name==%q)#|#(schema==%q)", tableID, dataset)).Exists(), "Compilation has no action for %s", table)
+	}
+
+	invocation := callAPI(t, "POST", dataformAPI+repository+"/workflowInvocations", fmt.Sprintf(`{"compilationResult": %q}`, compilation.Get("name").String()))
+	state := ""
+	verifyInvocation := func() (bool, error) {
+		state = callAPI(t, "GET", dataformAPI+invocation.Get("name").String(), "").Get("state").String()
+		return state == "RUNNING", nil
+	}
+	utils.Poll(t, verifyInvocation, 40, 15*time.Second)
+	assert.Equal("SUCCEEDED", state, "Dataform workflow invocation did not succeed")
+
+	for _, table := range dataformTables {
+		query := fmt.Sprintf("SELECT count(*) AS count FROM `%s.%s`;", projectID, table)
+		count := bq.Runf(t, "--project_id=%s query --nouse_legacy_sql %s", projectID, query).Get("0.count").Int()
+		assert.Greater(count, int64(0), table)
+	}
+}
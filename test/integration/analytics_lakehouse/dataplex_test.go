@@ -0,0 +1,114 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package multiple_buckets
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/GoogleCloudPlatform/cloud-foundation-toolkit/infra/blueprint-test/pkg/gcloud"
+	"github.com/stretchr/testify/assert"
+)
+
+// Taxonomy created by the project-setup workflow.
+const dataTaxonomy = "sample-taxonomy"
+
+// Data attributes the project-setup workflow binds to the zone entities.
+var dataAttributes = []string{
+	"sensitivity",
+	"domain",
+}
+
+// verifyDataAttributes asserts the Dataplex data attributes exist and that
+// every binding created for the lakehouse zones references all of them.
+func verifyDataAttributes(t *testing.T, assert *assert.Assertions, projectID, region string) {
+	attributes := gcloud.Runf(t, "dataplex datataxonomies attributes list --data_taxonomy=%s --location=%s --project=%s", dataTaxonomy, region, projectID).Array()
+	attributeNames := make([]string, 0, len(attributes))
+	for _, attribute := range attributes {
+		attributeNames = append(attributeNames, attribute.Get("name").String())
+	}
+	for _, attribute := range dataAttributes {
+		name := "projects/" + projectID + "/locations/" + region + "/dataTaxonomies/" + dataTaxonomy + "/attributes/" + attribute
+		assert.Contains(attributeNames, name, "Data attribute %s does not exist", attribute)
+	}
+
+	bindings := gcloud.Runf(t, "dataplex datataxonomies attribute-bindings list --location=%s --project=%s", region, projectID).Array()
+	assert.NotEmpty(bindings, "No data attribute bindings exist")
+	for _, binding := range bindings {
+		resource := binding.Get("resource").String()
+		assert.True(strings.Contains(resource, "/lakes/gcp-primary-lake/zones/"), "Binding %s is not on a lakehouse zone entity", resource)
+		bound := binding.Get("attributes").String()
+		for _, attribute := range dataAttributes {
+			assert.Contains(bound, "/attributes/"+attribute, "Binding for %s is missing attribute %s", resource, attribute)
+		}
+	}
+}
+
+// verifyAssetMappings asserts every Dataplex asset in the lake points at a
+// bucket or dataset exported by the module, so a rename in one place cannot
+// silently orphan the governance resources.
+func verifyAssetMappings(t *testing.T, assert *assert.Assertions, projectID, region string, buckets, datasets []string) {
+	expected := make([]string, 0, len(buckets)+len(datasets))
+	for _, bucket := range buckets {
+		expected = append(expected, fmt.Sprintf("projects/%s/buckets/%s", projectID, bucket))
+	}
+	for _, dataset := range datasets {
+		expected = append(expected, fmt.Sprintf("projects/%s/datasets/%s", projectID, dataset))
+	}
+
+	var mapped []string
+	zones := gcloud.Runf(t, "dataplex zones list --lake=gcp-primary-lake --location=%s --project=%s", region, projectID).Array()
+	for _, zone := range zones {
+		zoneID := zone.Get("name").String()
+		zoneID = zoneID[strings.LastIndex(zoneID, "/")+1:]
+		assets := gcloud.Runf(t, "dataplex assets list --lake=gcp-primary-lake --zone=%s --location=%s --project=%s", zoneID, region, projectID).Array()
+		for _, asset := range assets {
+			resource := asset.Get("resourceSpec.name").String()
+			assert.Contains(expected, resource, "Asset %s maps to %s which is not a module output", asset.Get("name").String(), resource)
+			assert.Equal("READY", asset.Get("resourceStatus.state").String(), "Asset %s resource is not ready", asset.Get("name").String())
+			mapped = append(mapped, resource)
+		}
+	}
+	for _, bucket := range buckets {
+		assert.Contains(mapped, fmt.Sprintf("projects/%s/buckets/%s", projectID, bucket), "Bucket %s is not registered as a Dataplex asset", bucket)
+	}
+}
+
+// verifyZoneDiscovery asserts each zone's discovery spec matches the
+// schedule, file options, and exclude patterns set in dataplex.tf.
+func verifyZoneDiscovery(t *testing.T, assert *assert.Assertions, projectID, region string) {
+	zones := []string{
+		"gcp-primary-raw",
+		"gcp-primary-staging",
+		"gcp-primary-curated",
+	}
+	expected := map[string]string{
+		"discoverySpec.enabled":               "true",
+		"discoverySpec.schedule":              "0 */6 * * *",
+		"discoverySpec.excludePatterns.0":     "**/metadata/**",
+		"discoverySpec.csvOptions.headerRows": "1",
+		"discoverySpec.csvOptions.delimiter":  ",",
+		"discoverySpec.jsonOptions.encoding":  "UTF-8",
+	}
+
+	for _, zone := range zones {
+		op := gcloud.Runf(t, "dataplex zones describe %s --lake=gcp-primary-lake --location=%s --project=%s", zone, region, projectID)
+		for path, want := range expected {
+			assert.Equal(want, op.Get(path).String(), "Zone %s has unexpected %s", zone, path)
+		}
+		assert.Len(op.Get("discoverySpec.excludePatterns").Array(), 1, "Zone %s has unexpected exclude patterns", zone)
+	}
+}
@@ -0,0 +1,45 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package multiple_buckets
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/GoogleCloudPlatform/cloud-foundation-toolkit/infra/blueprint-test/pkg/bq"
+	"github.com/GoogleCloudPlatform/cloud-foundation-toolkit/infra/blueprint-test/pkg/gcloud"
+	"github.com/stretchr/testify/assert"
+)
+
+// verifyDeltaLake asserts the Delta Lake table has a transaction log and a
+// symlink manifest in the warehouse bucket, that agg_events_delta reads it
+// through the manifest, and that it holds the same aggregate as
+// agg_events_iceberg.
+func verifyDeltaLake(t *testing.T, assert *assert.Assertions, projectID, deltaLakeURI string) {
+	log := gcloud.Runf(t, "storage objects list %s/_delta_log/*.json", deltaLakeURI).Array()
+	assert.NotEmpty(log, "Delta Lake table at %s has no transaction log", deltaLakeURI)
+
+	table := bq.Runf(t, "--project_id=%s show gcp_lakehouse_ds.agg_events_delta", projectID)
+	assert.Equal("PARQUET", table.Get("externalDataConfiguration.sourceFormat").String(), "agg_events_delta does not read Parquet")
+	assert.Equal("FILE_SET_SPEC_TYPE_NEW_LINE_DELIMITED_MANIFEST", table.Get("externalDataConfiguration.fileSetSpecType").String(), "agg_events_delta does not read the symlink manifest")
+	assert.Equal(deltaLakeURI+"/_symlink_format_manifest/manifest", table.Get("externalDataConfiguration.sourceUris.0").String(), "agg_events_delta reads an unexpected manifest")
+
+	query := fmt.Sprintf("SELECT count(*) AS count, SUM(event_count) AS events FROM `%s.gcp_lakehouse_ds.%%s`;", projectID)
+	delta := bq.Runf(t, "--project_id=%s query --nouse_legacy_sql %s", projectID, fmt.Sprintf(query, "agg_events_delta"))
+	iceberg := bq.Runf(t, "--project_id=%s query --nouse_legacy_sql %s", projectID, fmt.Sprintf(query, blmsTable))
+	assert.Greater(delta.Get("0.count").Int(), int64(0), "agg_events_delta is empty")
+	assert.Equal(iceberg.Get("0.count").Int(), delta.Get("0.count").Int(), "Delta and Iceberg tables hold a different number of users")
+	assert.Equal(iceberg.Get("0.events").Int(), delta.Get("0.events").Int(), "Delta and Iceberg tables hold a different number of events")
+}
@@ -0,0 +1,100 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package multiple_buckets
+
+import (
+	"fmt"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/GoogleCloudPlatform/cloud-foundation-toolkit/infra/blueprint-test/pkg/bq"
+	"github.com/GoogleCloudPlatform/cloud-foundation-toolkit/infra/blueprint-test/pkg/utils"
+	"github.com/stretchr/testify/assert"
+	"github.com/tidwall/gjson"
+)
+
+// dlpScanTables maps the job trigger IDs the project-setup workflow activates
+// to the lakehouse table each inspects.
+var dlpScanTables = map[string]string{
+	"lakehouse-pii-thelook-users":  "thelook_users",
+	"lakehouse-pii-thelook-events": "thelook_events",
+}
+
+// waitForDLPJob waits for the latest inspection job started by the trigger
+// to stop and returns it.
+func waitForDLPJob(t *testing.T, projectID, region, trigger string) gjson.Result {
+	jobs := fmt.Sprintf("https://dlp.googleapis.com/v2/projects/%s/locations/%s/dlpJobs?type=INSPECT_JOB&orderBy=%s&filter=%s",
+		projectID, region, url.QueryEscape("create_time desc"), url.QueryEscape("trigger_name = "+trigger))
+
+	var job gjson.Result
+	verifyJob := func() (bool, error) {
+		job = callAPI(t, "GET", jobs, "").Get("jobs.0")
+		state := job.Get("state").String()
+		return state != "DONE" && state != "FAILED" && state != "CANCELED", nil
+	}
+	utils.Poll(t, verifyJob, 40, 30*time.Second)
+	return job
+}
+
+// verifyDLPScan asserts the inspection jobs the project-setup workflow
+// activated over the thelook users and events complete, that email addresses
+// are found in the users, and that those findings are saved into the findings
+// table with the column they were found in.
+func verifyDLPScan(t *testing.T, assert *assert.Assertions, projectID, region, findingsTable string) {
+	for trigger, table := range dlpScanTables {
+		job := waitForDLPJob(t, projectID, region, trigger)
+		if !assert.Equal("DONE", job.Get("state").String(), "Inspection of %s did not complete: %s", table, job.Get("errors")) {
+			continue
+		}
+		assert.Equal(table, job.Get("inspectDetails.requestedOptions.jobConfig.storageConfig.bigQueryOptions.tableReference.tableId").String(), "%s inspected an unexpected table", trigger)
+		assert.Greater(job.Get("inspectDetails.result.processedBytes").Int(), int64(0), "Inspection of %s processed no data", table)
+		if table != "thelook_users" {
+			continue
+		}
+
+		emails := job.Get(`inspectDetails.result.infoTypeStats.#(infoType.name=="EMAIL_ADDRESS").count`).Int()
+		assert.Greater(emails, int64(0), "No EMAIL_ADDRESS found in %s", table)
+
+		query := fmt.Sprintf("SELECT count(*) AS count FROM `%s.%s`, UNNEST(location.content_locations) AS content WHERE job_name = '%s' AND info_type.name = 'EMAIL_ADDRESS' AND content.record_location.field_id.name = 'email';",
+			projectID, findingsTable, job.Get("name").String())
+		findings := bq.Runf(t, "--project_id=%s query --nouse_legacy_sql %s", projectID, query).Get("0.count").Int()
+		assert.Greater(findings, int64(0), "EMAIL_ADDRESS findings in %s.email were not saved to %s", table, findingsTable)
+	}
+}
+
+// verifyDLPDeidentify asserts the de-identified copy of the users holds a row
+// for every source user, that the names are tokenized and the email and
+// street address masked in every row, and that the other columns are copied
+// unchanged.
+func verifyDLPDeidentify(t *testing.T, assert *assert.Assertions, projectID, deidentifiedTable string) {
+	source := fmt.Sprintf("`%s.gcp_primary_staging.thelook_ecommerce_users`", projectID)
+	target := fmt.Sprintf("`%s.%s`", projectID, deidentifiedTable)
+	query := fmt.Sprintf("SELECT (SELECT count(*) FROM %[1]s) AS source_rows, (SELECT count(*) FROM %[2]s) AS rows, count(*) AS joined_rows, "+
+		"COUNTIF(d.first_name = s.first_name OR d.last_name = s.last_name) AS clear_names, "+
+		"COUNTIF(NOT REGEXP_CONTAINS(d.email, r'^[#@.]+$') OR NOT REGEXP_CONTAINS(d.street_address, r'^[#@. ]*$')) AS unmasked, "+
+		"COUNTIF(LENGTH(d.email) != LENGTH(s.email)) AS resized_emails, "+
+		"COUNTIF(d.state IS DISTINCT FROM s.state OR d.age IS DISTINCT FROM s.age OR d.created_at IS DISTINCT FROM s.created_at) AS changed "+
+		"FROM %[2]s d JOIN %[1]s s USING (id);", source, target)
+	op := bq.Runf(t, "--project_id=%s query --nouse_legacy_sql %s", projectID, query).Get("0")
+
+	assert.Greater(op.Get("source_rows").Int(), int64(0), "Source users are empty")
+	assert.Equal(op.Get("source_rows").Int(), op.Get("rows").Int(), "%s has a different number of rows than the source users", deidentifiedTable)
+	assert.Equal(op.Get("rows").Int(), op.Get("joined_rows").Int(), "%s has users missing from the source", deidentifiedTable)
+	assert.Equal(int64(0), op.Get("clear_names").Int(), "Names in %s are not tokenized", deidentifiedTable)
+	assert.Equal(int64(0), op.Get("unmasked").Int(), "Emails or street addresses in %s are not masked", deidentifiedTable)
+	assert.Equal(int64(0), op.Get("resized_emails").Int(), "Masking changed the length of emails in %s", deidentifiedTable)
+	assert.Equal(int64(0), op.Get("changed").Int(), "Columns outside the template changed in %s", deidentifiedTable)
+}
@@ -0,0 +1,78 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package multiple_buckets
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/GoogleCloudPlatform/cloud-foundation-toolkit/infra/blueprint-test/pkg/bq"
+	"github.com/GoogleCloudPlatform/cloud-foundation-toolkit/infra/blueprint-test/pkg/gcloud"
+	"github.com/GoogleCloudPlatform/cloud-foundation-toolkit/infra/blueprint-test/pkg/tft"
+	"github.com/gruntwork-io/terratest/modules/terraform"
+	"github.com/stretchr/testify/assert"
+	"github.com/terraform-google-modules/terraform-google-analytics-lakehouse/test/integration/testutils"
+)
+
+// Resources changed out of band by verifyDriftCorrection, as addressed in
+// the example's state.
+const (
+	driftDatasetAddress = "module.analytics_lakehouse.google_bigquery_dataset.gcp_lakehouse_ds[0]"
+	driftBucketAddress  = "module.analytics_lakehouse.google_storage_bucket.warehouse_bucket[0]"
+)
+
+// verifyDriftCorrection changes the lakehouse dataset's description and a
+// label of the warehouse bucket outside of Terraform, then asserts a plan
+// detects both as in-place updates, as no lifecycle block ignores them, and
+// that applying it restores the configured values. Plan and apply target
+// the two resources, so the rest of the deployment is left as is.
+func verifyDriftCorrection(t *testing.T, assert *assert.Assertions, dwh *tft.TFBlueprintTest, projectID, dataset, bucket string) {
+	description := bq.Runf(t, "--project_id=%s show %s", projectID, dataset).Get("description").String()
+	labels := gcloud.Runf(t, "storage buckets describe gs://%s", bucket).Get("labels")
+	if !assert.True(labels.Get(testutils.SolutionLabel).Exists(), "Bucket %s has no %s label", bucket, testutils.SolutionLabel) {
+		return
+	}
+
+	bq.Runf(t, "--project_id=%s update --description=drifted-out-of-band %s", projectID, dataset)
+	gcloud.Runf(t, "storage buckets update gs://%s --update-labels=%s=drifted", bucket, testutils.SolutionLabel)
+
+	opts := dwh.GetTFOptions()
+	opts.Targets = []string{driftDatasetAddress, driftBucketAddress}
+	opts.PlanFilePath = filepath.Join(t.TempDir(), "drift.tfplan")
+	plan, err := terraform.InitAndPlanAndShowWithStructE(t, opts)
+	if !assert.NoError(err, "Planning after the out-of-band changes failed") {
+		return
+	}
+	for _, address := range opts.Targets {
+		change, ok := plan.ResourceChangesMap[address]
+		if !assert.True(ok, "Plan has no change for %s", address) {
+			continue
+		}
+		assert.True(change.Change.Actions.Update(), "Drift on %s is not planned as an in-place update: %v", address, change.Change.Actions)
+	}
+
+	_, err = terraform.ApplyE(t, opts)
+	if !assert.NoError(err, "Applying the drift correction failed") {
+		return
+	}
+	assert.Equal(description, bq.Runf(t, "--project_id=%s show %s", projectID, dataset).Get("description").String(), "Dataset description is not restored")
+	restored := gcloud.Runf(t, "storage buckets describe gs://%s", bucket).Get("labels")
+	assert.Equal(labels.Get(testutils.SolutionLabel).String(), restored.Get(testutils.SolutionLabel).String(), "Bucket label is not restored")
+
+	opts.PlanFilePath = ""
+	code, err := terraform.PlanExitCodeE(t, opts)
+	assert.NoError(err, "Planning after the drift correction failed")
+	assert.Equal(0, code, "Plan after the drift correction is not empty")
+}
@@ -0,0 +1,60 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package multiple_buckets
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/GoogleCloudPlatform/cloud-foundation-toolkit/infra/blueprint-test/pkg/bq"
+	"github.com/GoogleCloudPlatform/cloud-foundation-toolkit/infra/blueprint-test/pkg/gcloud"
+	"github.com/stretchr/testify/assert"
+)
+
+// Number of users src/sql/firestore_top_users.sql exports.
+const firestoreTopUsers = 100
+
+// verifyFirestoreExport runs the firestore-export workflow, reads the
+// user_event_counts documents back, and asserts each holds the event count
+// BigQuery has for that user and that no more active user was left out.
+func verifyFirestoreExport(t *testing.T, assert *assert.Assertions, projectID, region, database string) {
+	execution := gcloud.Runf(t, "workflows run firestore-export --project=%s --location=%s", projectID, region)
+	if !assert.Equal("SUCCEEDED", execution.Get("state").String(), "firestore-export workflow failed: %s", execution.Get("error.payload")) {
+		return
+	}
+
+	url := fmt.Sprintf("https://firestore.googleapis.com/v1/projects/%s/databases/%s/documents/user_event_counts?pageSize=%d", projectID, database, 2*firestoreTopUsers)
+	documents := callAPI(t, "GET", url, "").Get("documents").Array()
+	if !assert.Len(documents, firestoreTopUsers, "Unexpected number of user_event_counts documents") {
+		return
+	}
+
+	served := map[string]int64{}
+	for _, document := range documents {
+		userID := document.Get("fields.user_id.stringValue").String()
+		assert.True(strings.HasSuffix(document.Get("name").String(), "/"+userID), "Document %s is not keyed by its user_id", document.Get("name"))
+		served[userID] = document.Get("fields.event_count.integerValue").Int()
+	}
+
+	query := fmt.Sprintf("SELECT user_id, event_count FROM `%s.gcp_lakehouse_ds.agg_events_iceberg` WHERE user_id IS NOT NULL ORDER BY event_count DESC, user_id LIMIT %d;", projectID, firestoreTopUsers)
+	for _, row := range bq.Runf(t, "--project_id=%s query --nouse_legacy_sql %s", projectID, query).Array() {
+		userID := row.Get("user_id").String()
+		count, ok := served[userID]
+		if assert.True(ok, "User %s is missing from Firestore", userID) {
+			assert.Equal(row.Get("event_count").Int(), count, "Firestore event_count for user %s does not match BigQuery", userID)
+		}
+	}
+}
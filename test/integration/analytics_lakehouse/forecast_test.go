@@ -0,0 +1,47 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package multiple_buckets
+
+import (
+	"fmt"
+	"strconv"
+	"testing"
+
+	"github.com/GoogleCloudPlatform/cloud-foundation-toolkit/infra/blueprint-test/pkg/bq"
+	"github.com/stretchr/testify/assert"
+)
+
+// verifyForecast asserts the project-setup workflow trained the ARIMA_PLUS
+// taxi trips model, and that its forecast for the first week of the December
+// holdout is within maxMAPE percent of the actual hourly pickups.
+func verifyForecast(t *testing.T, assert *assert.Assertions, projectID, maxMAPE string) {
+	threshold, err := strconv.ParseFloat(maxMAPE, 64)
+	if !assert.NoError(err, "forecast_max_mape is not a number") {
+		return
+	}
+
+	model := bq.Runf(t, "--project_id=%s show --model gcp_lakehouse_ds.taxi_trips_forecast", projectID)
+	assert.Equal("ARIMA_PLUS", model.Get("modelType").String(), "taxi_trips_forecast is not an ARIMA_PLUS model")
+	if !assert.NotEmpty(model.Get("trainingRuns").Array(), "taxi_trips_forecast has no training runs") {
+		return
+	}
+
+	query := fmt.Sprintf("SELECT mean_absolute_percentage_error AS mape FROM ML.EVALUATE(MODEL `%[1]s.gcp_lakehouse_ds.taxi_trips_forecast`, (SELECT TIMESTAMP_TRUNC(CAST(pickup_datetime AS TIMESTAMP), HOUR) AS pickup_hour, COUNT(*) AS trips FROM `%[1]s.gcp_primary_staging.new_york_taxi_trips_tlc_yellow_trips_2022` WHERE CAST(pickup_datetime AS TIMESTAMP)>='2022-12-01' AND CAST(pickup_datetime AS TIMESTAMP)<'2022-12-08' GROUP BY pickup_hour), STRUCT(TRUE AS perform_aggregation, 168 AS horizon));", projectID)
+	op := bq.Runf(t, "--project_id=%s query --nouse_legacy_sql %s", projectID, query)
+	mape := op.Get("0.mape")
+	if assert.True(mape.Exists(), "ML.EVALUATE returned no metrics") {
+		assert.Less(mape.Float(), threshold, "taxi_trips_forecast MAPE is above the threshold")
+	}
+}
@@ -0,0 +1,456 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package multiple_buckets
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/GoogleCloudPlatform/cloud-foundation-toolkit/infra/blueprint-test/pkg/bq"
+	"github.com/GoogleCloudPlatform/cloud-foundation-toolkit/infra/blueprint-test/pkg/gcloud"
+	"github.com/GoogleCloudPlatform/cloud-foundation-toolkit/infra/blueprint-test/pkg/golden"
+	"github.com/GoogleCloudPlatform/cloud-foundation-toolkit/infra/blueprint-test/pkg/utils"
+	"github.com/stretchr/testify/assert"
+	"github.com/terraform-google-modules/terraform-google-analytics-lakehouse/test/integration/testutils"
+	"github.com/tidwall/gjson"
+)
+
+// BigQuery connections whose generated service accounts hold lakehouse roles.
+var bigQueryConnections = []string{
+	"gcp_lakehouse_connection",
+	"gcp_gcs_connection",
+	"gcp_vertex_connection",
+}
+
+// orchestrationRoles and dataPlaneRoles are the complete project-level role
+// sets of the workflows and Dataproc service accounts in the example, and the
+// feature roles are what the full fixture's optional features add to them.
+// Only the roles both need to run a workflow may appear in both sets.
+var (
+	orchestrationRoles = []string{
+		"roles/bigquery.jobUser",
+		"roles/bigquery.metadataViewer",
+		"roles/dataplex.admin",
+		"roles/dataproc.editor",
+		"roles/logging.logWriter",
+		"roles/workflows.viewer",
+	}
+	orchestrationFeatureRoles = []string{
+		"roles/aiplatform.notebookRuntimeAdmin",
+		"roles/dataflow.developer",
+		"roles/dlp.inspectTemplatesReader",
+		"roles/dlp.jobTriggersReader",
+		"roles/dlp.jobsEditor",
+		"roles/workflows.invoker",
+	}
+	dataPlaneRoles = []string{
+		"roles/biglake.admin",
+		"roles/bigquery.connectionAdmin",
+		"roles/bigquery.dataOwner",
+		"roles/bigquery.user",
+		"roles/dataproc.worker",
+		"roles/logging.logWriter",
+		"roles/storage.objectAdmin",
+		"roles/workflows.viewer",
+	}
+	dataPlaneFeatureRoles = []string{
+		"roles/cloudsql.admin",
+		"roles/dataflow.worker",
+		"roles/datastore.user",
+		"roles/dlp.deidentifyTemplatesReader",
+		"roles/dlp.user",
+	}
+	sharedRoles = []string{
+		"roles/logging.logWriter",
+		"roles/workflows.viewer",
+	}
+)
+
+// iamBinding records conditional bindings by condition title, since
+// expressions may embed values that change on every apply.
+type iamBinding struct {
+	Role      string   `json:"role"`
+	Condition string   `json:"condition,omitempty"`
+	Members   []string `json:"members"`
+}
+
+// iamSnapshot is the access granted on the project and lakehouse resources.
+// Project bindings are limited to identities the blueprint creates or grants
+// so unrelated org-level grants on the test project don't cause diffs.
+type iamSnapshot struct {
+	Project  []iamBinding            `json:"project"`
+	Lake     []iamBinding            `json:"lake"`
+	Buckets  map[string][]iamBinding `json:"buckets"`
+	Datasets map[string][]string     `json:"datasets"`
+}
+
+// verifyIAMGolden compares the effective IAM of the project and lakehouse
+// resources against testdata/iam_policy.json. Run with UPDATE_GOLDEN=true to
+// regenerate the file after an intentional access change.
+func verifyIAMGolden(t *testing.T, assert *assert.Assertions, projectID, region, warehouseBucket string) {
+	suffix := testutils.RandomSuffix(warehouseBucket)
+	projectNumber := gcloud.Runf(t, "projects describe %s", projectID).Get("projectNumber").String()
+	account := testutils.ActiveAccount(t)
+
+	sanitizers := []golden.Sanitizer{}
+	for _, connection := range bigQueryConnections {
+		sa := connectionServiceAccount(t, projectID, region, connection)
+		sanitizers = append(sanitizers, golden.StringSanitizer(sa, "CONNECTION_SA_"+connection))
+	}
+	for _, instance := range gcloud.Runf(t, "sql instances list --project=%s", projectID).Array() {
+		sa := instance.Get("serviceAccountEmailAddress").String()
+		sanitizers = append(sanitizers, golden.StringSanitizer(sa, "CLOUD_SQL_SA_"+instance.Get("name").String()))
+	}
+	sanitizers = append(sanitizers,
+		golden.StringSanitizer(account, "CI_ACCOUNT"),
+		golden.StringSanitizer(suffix, "RANDOM"),
+		golden.StringSanitizer(projectNumber, "PROJECT_NUMBER"),
+		golden.StringSanitizer(projectID, "PROJECT_ID"),
+	)
+	// Sanitize before sorting so generated identities sort deterministically.
+	sanitize := func(s string) string {
+		for _, sanitizer := range sanitizers {
+			s = sanitizer(s)
+		}
+		return s
+	}
+	isBlueprintMember := func(member string) bool {
+		if strings.Contains(member, suffix) || strings.Contains(member, "@gcp-sa-bigquery-condel.") {
+			return true
+		}
+		return member == fmt.Sprintf("serviceAccount:service-%s@gcp-sa-dataplex.iam.gserviceaccount.com", projectNumber)
+	}
+
+	snapshot := iamSnapshot{
+		Project:  normalizeBindings(gcloud.Runf(t, "projects get-iam-policy %s", projectID), isBlueprintMember, sanitize),
+		Lake:     normalizeBindings(gcloud.Runf(t, "dataplex lakes get-iam-policy gcp-primary-lake --location=%s --project=%s", region, projectID), nil, sanitize),
+		Buckets:  map[string][]iamBinding{},
+		Datasets: map[string][]string{},
+	}
+	for _, bucket := range gcloud.Runf(t, "storage buckets list --project=%s", projectID).Array() {
+		name := bucket.Get("name").String()
+		if !strings.HasSuffix(name, "-"+suffix) {
+			continue
+		}
+		snapshot.Buckets[sanitize(name)] = normalizeBindings(gcloud.Runf(t, "storage buckets get-iam-policy gs://%s", name), nil, sanitize)
+	}
+	for _, dataset := range []string{"gcp_lakehouse_ds"} {
+		access := []string{}
+		for _, entry := range bq.Runf(t, "--project_id=%s show %s", projectID, dataset).Get("access").Array() {
+			role := entry.Get("role").String()
+			entry.ForEach(func(key, value gjson.Result) bool {
+				if key.String() != "role" {
+					access = append(access, sanitize(fmt.Sprintf("%s %s:%s", role, key.String(), value.String())))
+				}
+				return true
+			})
+		}
+		sort.Strings(access)
+		snapshot.Datasets[dataset] = access
+	}
+
+	data, err := json.MarshalIndent(snapshot, "", "  ")
+	if err != nil {
+		t.Fatal(err)
+	}
+	g := golden.NewOrUpdate(t, string(data), golden.WithFileName("iam_policy.json"))
+	got := gjson.ParseBytes(data)
+	for _, path := range []string{"project", "lake", "buckets", "datasets"} {
+		g.JSONEq(assert, got, path)
+	}
+}
+
+// normalizeBindings returns the sanitized policy bindings sorted by role with
+// sorted members, keeping only members accepted by include when it is non-nil.
+func normalizeBindings(policy gjson.Result, include func(string) bool, sanitize func(string) string) []iamBinding {
+	bindings := []iamBinding{}
+	for _, b := range policy.Get("bindings").Array() {
+		members := []string{}
+		for _, member := range b.Get("members").Array() {
+			if include == nil || include(member.String()) {
+				members = append(members, sanitize(member.String()))
+			}
+		}
+		if len(members) == 0 {
+			continue
+		}
+		sort.Strings(members)
+		bindings = append(bindings, iamBinding{Role: b.Get("role").String(), Condition: b.Get("condition.title").String(), Members: members})
+	}
+	sort.Slice(bindings, func(i, j int) bool {
+		if bindings[i].Role != bindings[j].Role {
+			return bindings[i].Role < bindings[j].Role
+		}
+		return bindings[i].Condition < bindings[j].Condition
+	})
+	return bindings
+}
+
+// connectionServiceAccount returns the email of the service account BigQuery
+// generated for a Cloud Resource connection.
+func connectionServiceAccount(t *testing.T, projectID, region, connection string) string {
+	op := bq.Runf(t, "--project_id=%s show --connection %s.%s.%s", projectID, projectID, region, connection)
+	return op.Get("cloudResource.serviceAccountId").String()
+}
+
+// blueprintServiceAccounts returns the emails of the service accounts created
+// by the module, identified by the random suffix in their account IDs.
+func blueprintServiceAccounts(t *testing.T, projectID, suffix string) []string {
+	emails := []string{}
+	for _, sa := range gcloud.Runf(t, "iam service-accounts list --project=%s", projectID).Array() {
+		email := sa.Get("email").String()
+		if strings.Contains(email, "-"+suffix+"@") {
+			emails = append(emails, email)
+		}
+	}
+	return emails
+}
+
+// verifyNoPrimitiveRoles asserts none of the module's service accounts hold
+// the basic owner or editor roles on the project.
+func verifyNoPrimitiveRoles(t *testing.T, assert *assert.Assertions, projectID, suffix string) {
+	serviceAccounts := blueprintServiceAccounts(t, projectID, suffix)
+	assert.NotEmpty(serviceAccounts, "No blueprint service accounts found")
+
+	policy := gcloud.Runf(t, "projects get-iam-policy %s", projectID)
+	for _, binding := range policy.Get("bindings").Array() {
+		role := binding.Get("role").String()
+		if role != "roles/owner" && role != "roles/editor" {
+			continue
+		}
+		members := utils.GetResultStrSlice(binding.Get("members").Array())
+		for _, sa := range serviceAccounts {
+			assert.NotContains(members, "serviceAccount:"+sa, "%s holds primitive role %s", sa, role)
+		}
+	}
+}
+
+// verifyNoDefaultServiceAccounts asserts no compute instance, Dataproc
+// cluster or batch, workflow, or function runs as the Compute Engine default
+// service account, which enterprise org policies commonly block.
+func verifyNoDefaultServiceAccounts(t *testing.T, assert *assert.Assertions, projectID, region string) {
+	projectNumber := gcloud.Runf(t, "projects describe %s", projectID).Get("projectNumber").String()
+	defaultSA := projectNumber + "-compute@developer.gserviceaccount.com"
+
+	identities := map[string][]string{}
+	for _, instance := range gcloud.Runf(t, "compute instances list --project=%s", projectID).Array() {
+		name := "instance " + instance.Get("name").String()
+		identities[name] = utils.GetResultStrSlice(instance.Get("serviceAccounts.#.email").Array())
+	}
+	for _, cluster := range gcloud.Runf(t, "dataproc clusters list --project=%s --region=%s", projectID, region).Array() {
+		name := "cluster " + cluster.Get("clusterName").String()
+		identities[name] = []string{cluster.Get("config.gceClusterConfig.serviceAccount").String()}
+	}
+	for _, batch := range gcloud.Runf(t, "dataproc batches list --project=%s --region=%s", projectID, region).Array() {
+		name := "batch " + batch.Get("name").String()
+		identities[name] = []string{batch.Get("environmentConfig.executionConfig.serviceAccount").String()}
+	}
+	for _, workflow := range gcloud.Runf(t, "workflows list --project=%s --location=%s", projectID, region).Array() {
+		name := "workflow " + workflow.Get("name").String()
+		sa := workflow.Get("serviceAccount").String()
+		identities[name] = []string{sa[strings.LastIndex(sa, "/")+1:]}
+	}
+	for _, function := range gcloud.Runf(t, "functions list --project=%s", projectID).Array() {
+		name := "function " + function.Get("name").String()
+		sa := function.Get("serviceConfig.serviceAccountEmail").String()
+		if sa == "" {
+			sa = function.Get("serviceAccountEmail").String()
+		}
+		identities[name] = []string{sa}
+	}
+
+	for resource, emails := range identities {
+		for _, email := range emails {
+			// An unset identity falls back to the default service account.
+			assert.NotEmpty(email, "%s does not set a service account", resource)
+			assert.NotEqual(defaultSA, email, "%s runs as the Compute Engine default service account", resource)
+		}
+	}
+}
+
+// verifyNoUserManagedKeys asserts none of the module's service accounts have
+// user-managed keys, keeping the blueprint compatible with the
+// iam.disableServiceAccountKeyCreation constraint enforced in test/setup.
+func verifyNoUserManagedKeys(t *testing.T, assert *assert.Assertions, projectID, suffix string) {
+	for _, sa := range blueprintServiceAccounts(t, projectID, suffix) {
+		keys := gcloud.Runf(t, "iam service-accounts keys list --iam-account=%s --managed-by=user --project=%s", sa, projectID).Array()
+		assert.Empty(keys, "%s has user-managed keys", sa)
+	}
+}
+
+// verifyConnectionScoping asserts each connection's service account holds no
+// project-level storage roles and only roles/storage.objectViewer on the
+// buckets it reads, with no access to any other blueprint bucket.
+func verifyConnectionScoping(t *testing.T, assert *assert.Assertions, projectID, region, suffix string, connectionBuckets map[string][]string) {
+	projectPolicy := gcloud.Runf(t, "projects get-iam-policy %s", projectID)
+	buckets := []string{}
+	for _, bucket := range gcloud.Runf(t, "storage buckets list --project=%s", projectID).Array() {
+		if name := bucket.Get("name").String(); strings.HasSuffix(name, "-"+suffix) {
+			buckets = append(buckets, name)
+		}
+	}
+
+	for connection, readable := range connectionBuckets {
+		member := "serviceAccount:" + connectionServiceAccount(t, projectID, region, connection)
+
+		for _, binding := range projectPolicy.Get("bindings").Array() {
+			role := binding.Get("role").String()
+			if strings.HasPrefix(role, "roles/storage.") {
+				members := utils.GetResultStrSlice(binding.Get("members").Array())
+				assert.NotContains(members, member, "%s connection holds project-wide %s", connection, role)
+			}
+		}
+
+		for _, bucket := range buckets {
+			roles := []string{}
+			policy := gcloud.Runf(t, "storage buckets get-iam-policy gs://%s", bucket)
+			for _, binding := range policy.Get("bindings").Array() {
+				if contains(utils.GetResultStrSlice(binding.Get("members").Array()), member) {
+					roles = append(roles, binding.Get("role").String())
+				}
+			}
+			if contains(readable, bucket) {
+				assert.Equal([]string{"roles/storage.objectViewer"}, roles, "%s connection has unexpected roles on %s", connection, bucket)
+			} else {
+				assert.Empty(roles, "%s connection has access to unrelated bucket %s", connection, bucket)
+			}
+		}
+	}
+}
+
+// projectRoles returns the sorted project-level roles granted to member.
+func projectRoles(t *testing.T, projectID, member string) []string {
+	roles := []string{}
+	for _, binding := range gcloud.Runf(t, "projects get-iam-policy %s", projectID).Get("bindings").Array() {
+		if contains(utils.GetResultStrSlice(binding.Get("members").Array()), member) {
+			roles = append(roles, binding.Get("role").String())
+		}
+	}
+	sort.Strings(roles)
+	return roles
+}
+
+// verifyServiceAccountSeparation asserts the orchestration and data-plane
+// service accounts are distinct, hold exactly their minimal role sets, with
+// the feature roles when full is set, and that each workflow runs as the
+// identity matching its responsibility.
+func verifyServiceAccountSeparation(t *testing.T, assert *assert.Assertions, projectID, region, orchestrationSA, dataPlaneSA string, full bool) {
+	assert.NotEqual(orchestrationSA, dataPlaneSA, "Orchestration and data-plane service accounts are the same")
+
+	expectedOrchestration := append([]string{}, orchestrationRoles...)
+	expectedDataPlane := append([]string{}, dataPlaneRoles...)
+	if full {
+		expectedOrchestration = append(expectedOrchestration, orchestrationFeatureRoles...)
+		expectedDataPlane = append(expectedDataPlane, dataPlaneFeatureRoles...)
+	}
+	orchestration := projectRoles(t, projectID, "serviceAccount:"+orchestrationSA)
+	dataPlane := projectRoles(t, projectID, "serviceAccount:"+dataPlaneSA)
+	assert.ElementsMatch(expectedOrchestration, orchestration, "Unexpected orchestration service account roles")
+	assert.ElementsMatch(expectedDataPlane, dataPlane, "Unexpected data-plane service account roles")
+	for _, role := range orchestration {
+		if contains(dataPlane, role) {
+			assert.Contains(sharedRoles, role, "%s is granted to both service accounts", role)
+		}
+	}
+
+	// The orchestration service account may only act as the data-plane service account.
+	policy := gcloud.Runf(t, "iam service-accounts get-iam-policy %s --project=%s", dataPlaneSA, projectID)
+	members := utils.GetResultStrSlice(policy.Get(`bindings.#(role=="roles/iam.serviceAccountUser").members`).Array())
+	assert.Contains(members, "serviceAccount:"+orchestrationSA, "Orchestration service account cannot act as the data-plane service account")
+
+	workflows := map[string]string{
+		"copy-data":     dataPlaneSA,
+		"project-setup": orchestrationSA,
+	}
+	for workflow, sa := range workflows {
+		op := gcloud.Runf(t, "workflows describe %s --project=%s --location=%s", workflow, projectID, region)
+		assert.True(strings.HasSuffix(op.Get("serviceAccount").String(), "/"+sa), "%s does not run as %s", workflow, sa)
+	}
+}
+
+// verifyNoPublicDatasets asserts no dataset in the project is shared with
+// allUsers, allAuthenticatedUsers, or a whole domain, and that the remaining
+// grants go to project roles, groups, service accounts, or authorized
+// resources rather than individual users.
+func verifyNoPublicDatasets(t *testing.T, assert *assert.Assertions, projectID string) {
+	projectGroups := []string{"projectOwners", "projectReaders", "projectWriters"}
+	publicMembers := []string{"allUsers", "allAuthenticatedUsers"}
+
+	datasets := bq.Runf(t, "--project_id=%s ls", projectID).Array()
+	assert.NotEmpty(datasets, "No datasets found")
+	for _, ds := range datasets {
+		dataset := ds.Get("datasetReference.datasetId").String()
+		for _, entry := range bq.Runf(t, "--project_id=%s show %s", projectID, dataset).Get("access").Array() {
+			switch {
+			case entry.Get("domain").Exists():
+				assert.Fail("Dataset is shared domain-wide", "%s grants %s to domain %s", dataset, entry.Get("role").String(), entry.Get("domain").String())
+			case entry.Get("iamMember").Exists():
+				assert.NotContains(publicMembers, entry.Get("iamMember").String(), "%s is publicly accessible", dataset)
+			case entry.Get("specialGroup").Exists():
+				assert.Contains(projectGroups, entry.Get("specialGroup").String(), "%s is shared with special group %s", dataset, entry.Get("specialGroup").String())
+			case entry.Get("userByEmail").Exists():
+				email := entry.Get("userByEmail").String()
+				assert.True(strings.HasSuffix(email, ".gserviceaccount.com"), "%s grants access to individual user %s", dataset, email)
+			}
+		}
+	}
+}
+
+// verifyConditionalAccess asserts the marketing user's only project-level
+// grant is roles/bigquery.dataViewer, conditioned on the lakehouse dataset
+// and an expiry time.
+func verifyConditionalAccess(t *testing.T, assert *assert.Assertions, projectID, suffix, dataset string) {
+	member := fmt.Sprintf("serviceAccount:user-marketing-sa-%s@%s.iam.gserviceaccount.com", suffix, projectID)
+	bindings := []gjson.Result{}
+	for _, binding := range gcloud.Runf(t, "projects get-iam-policy %s", projectID).Get("bindings").Array() {
+		if contains(utils.GetResultStrSlice(binding.Get("members").Array()), member) {
+			bindings = append(bindings, binding)
+		}
+	}
+	if !assert.Len(bindings, 1, "Unexpected project bindings for the marketing user") {
+		return
+	}
+
+	binding := bindings[0]
+	expression := binding.Get("condition.expression").String()
+	assert.Equal("roles/bigquery.dataViewer", binding.Get("role").String(), "Unexpected conditional role")
+	assert.Equal("lakehouse-dataset-time-bound", binding.Get("condition.title").String(), "Unexpected condition title")
+	assert.Contains(expression, fmt.Sprintf(`resource.name.startsWith("projects/%s/datasets/%s")`, projectID, dataset), "Condition is not scoped to %s", dataset)
+	assert.Contains(expression, "request.time < timestamp(", "Condition does not expire")
+}
+
+// verifyAuthorizedViews asserts the access layer consumer can query each
+// curated view but is denied the staging table behind it.
+func verifyAuthorizedViews(t *testing.T, assert *assert.Assertions, projectID, consumerSA string) {
+	views := map[string]string{
+		"gcp_lakehouse_access.orders":   "gcp_primary_staging.thelook_ecommerce_orders",
+		"gcp_lakehouse_access.products": "gcp_primary_staging.thelook_ecommerce_products",
+	}
+	token := impersonatedAccessToken(t, consumerSA)
+	url := fmt.Sprintf("https://bigquery.googleapis.com/bigquery/v2/projects/%s/queries", projectID)
+	query := func(table string) string {
+		return fmt.Sprintf("{\"query\": \"SELECT count(*) AS count FROM `%s.%s`\", \"useLegacySql\": false, \"timeoutMs\": 60000}", projectID, table)
+	}
+
+	for view, table := range views {
+		result := callAPIWithToken(t, token, "POST", url, query(view))
+		assert.Greater(result.Get("rows.0.f.0.v").Int(), int64(0), "%s returned no rows to the consumer", view)
+		assert.Equal(http.StatusForbidden, callAPIStatus(t, token, "POST", url, query(table)), "Consumer can query %s directly", table)
+	}
+}
@@ -0,0 +1,72 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package multiple_buckets
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/GoogleCloudPlatform/cloud-foundation-toolkit/infra/blueprint-test/pkg/bq"
+	"github.com/GoogleCloudPlatform/cloud-foundation-toolkit/infra/blueprint-test/pkg/gcloud"
+	"github.com/stretchr/testify/assert"
+	"github.com/tidwall/gjson"
+)
+
+// icebergState is the number of snapshots in the current metadata of the
+// Iceberg table, the number of data files under its location, and its row
+// count as read through BigQuery.
+type icebergState struct {
+	snapshots int
+	dataFiles int
+	rows      int64
+}
+
+// readIcebergState reads the current metadata file BigLake Metastore points
+// at for agg_events_iceberg and lists the table's data files.
+func readIcebergState(t *testing.T, projectID, region string) icebergState {
+	table := fmt.Sprintf("projects/%s/locations/%s/catalogs/%s/databases/%s/tables/%s", projectID, region, blmsCatalog, blmsDatabase, blmsTable)
+	op := callAPI(t, "GET", "https://biglake.googleapis.com/v1/"+table, "")
+	metadata := gjson.Parse(gcloud.RunCmd(t, "storage cat "+op.Get("hiveOptions.parameters.metadata_location").String()))
+	location := strings.TrimSuffix(op.Get("hiveOptions.storageDescriptor.locationUri").String(), "/")
+	files := gcloud.Runf(t, "storage objects list %s/data/**", location).Array()
+
+	query := fmt.Sprintf("SELECT count(*) AS count FROM `%s.gcp_lakehouse_ds.%s`;", projectID, blmsTable)
+	rows := bq.Runf(t, "--project_id=%s query --nouse_legacy_sql %s", projectID, query).Get("0.count").Int()
+	return icebergState{snapshots: len(metadata.Get("snapshots").Array()), dataFiles: len(files), rows: rows}
+}
+
+// verifyIcebergMaintenance asserts Cloud Scheduler executes the
+// iceberg-maintenance workflow on the configured schedule, then runs it and
+// asserts agg_events_iceberg is left with a single compaction snapshot over
+// fewer data files and unchanged rows.
+func verifyIcebergMaintenance(t *testing.T, assert *assert.Assertions, projectID, region, workflow string) {
+	job := gcloud.Runf(t, "scheduler jobs describe iceberg-maintenance --project=%s --location=%s", projectID, region)
+	assert.Equal("ENABLED", job.Get("state").String(), "Iceberg maintenance schedule is not enabled")
+	assert.Equal("0 3 * * 0", job.Get("schedule").String(), "Unexpected Iceberg maintenance schedule")
+	assert.True(strings.HasSuffix(job.Get("httpTarget.uri").String(), "/workflows/"+workflow+"/executions"), "Schedule does not execute the %s workflow", workflow)
+
+	before := readIcebergState(t, projectID, region)
+	execution := gcloud.Runf(t, "workflows run %s --project=%s --location=%s", workflow, projectID, region)
+	if !assert.Equal("SUCCEEDED", execution.Get("state").String(), "%s workflow failed: %s", workflow, execution.Get("error.payload")) {
+		return
+	}
+	after := readIcebergState(t, projectID, region)
+
+	assert.Equal(1, after.snapshots, "Old snapshots were not expired")
+	assert.LessOrEqual(after.snapshots, before.snapshots, "Snapshot count grew from %d", before.snapshots)
+	assert.Less(after.dataFiles, before.dataFiles, "Compaction did not reduce the %d data files", before.dataFiles)
+	assert.Equal(before.rows, after.rows, "Maintenance changed the rows of %s", blmsTable)
+}
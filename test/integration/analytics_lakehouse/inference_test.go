@@ -0,0 +1,61 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package multiple_buckets
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/GoogleCloudPlatform/cloud-foundation-toolkit/infra/blueprint-test/pkg/bq"
+	"github.com/GoogleCloudPlatform/cloud-foundation-toolkit/infra/blueprint-test/pkg/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+// verifyImageInference asserts the project-setup workflow stored Gemini
+// descriptions for a sample of the TextOCR images, and that every row the
+// model answered without error has non-empty output.
+func verifyImageInference(t *testing.T, assert *assert.Assertions, projectID string) {
+	objects := bq.Runf(t, "--project_id=%s show gcp_lakehouse_ds.textocr_images_objects", projectID)
+	assert.Equal("SIMPLE", objects.Get("externalDataConfiguration.objectMetadata").String(), "textocr_images_objects is not an object table")
+
+	model := bq.Runf(t, "--project_id=%s show --model gcp_lakehouse_ds.gemini_vision", projectID)
+	assert.NotEmpty(model.Get("remoteModelInfo.endpoint").String(), "gemini_vision is not a remote model")
+
+	query := fmt.Sprintf("SELECT count(*) AS count, COUNTIF(status='') AS answered, COUNTIF(status='' AND LENGTH(TRIM(description))>0) AS described FROM `%s.gcp_lakehouse_ds.textocr_image_descriptions`;", projectID)
+	op := bq.Runf(t, "--project_id=%s query --nouse_legacy_sql %s", projectID, query)
+	assert.Greater(op.Get("0.count").Int(), int64(0), "textocr_image_descriptions is empty")
+	assert.Greater(op.Get("0.answered").Int(), int64(0), "Gemini returned an error for every sampled image")
+	assert.Equal(op.Get("0.answered").Int(), op.Get("0.described").Int(), "Gemini returned empty descriptions")
+}
+
+// verifyVectorSearch asserts the product embeddings index becomes ACTIVE and
+// that searching for a product category returns neighbors from it.
+func verifyVectorSearch(t *testing.T, assert *assert.Assertions, projectID string) {
+	query := fmt.Sprintf("SELECT index_status, coverage_percentage FROM `%s.gcp_lakehouse_ds.INFORMATION_SCHEMA.VECTOR_INDEXES` WHERE index_name='product_embeddings_index';", projectID)
+	status := ""
+	verifyIndex := func() (bool, error) {
+		index := bq.Runf(t, "--project_id=%s query --nouse_legacy_sql %s", projectID, query).Get("0")
+		status = index.Get("index_status").String()
+		return status != "ACTIVE" || index.Get("coverage_percentage").Int() < 100, nil
+	}
+	utils.Poll(t, verifyIndex, 40, 30*time.Second)
+	assert.Equal("ACTIVE", status, "product_embeddings_index is not active")
+
+	query = fmt.Sprintf("SELECT count(*) AS count, COUNTIF(category='Jeans') AS jeans FROM `%s.gcp_lakehouse_ds.similar_products`('jeans');", projectID)
+	op := bq.Runf(t, "--project_id=%s query --nouse_legacy_sql %s", projectID, query)
+	assert.Equal(int64(5), op.Get("0.count").Int(), "similar_products did not return its top 5 neighbors")
+	assert.GreaterOrEqual(op.Get("0.jeans").Int(), int64(4), "Search for jeans returned unrelated products")
+}
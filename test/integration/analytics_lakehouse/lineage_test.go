@@ -0,0 +1,59 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package multiple_buckets
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/GoogleCloudPlatform/cloud-foundation-toolkit/infra/blueprint-test/pkg/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+// Staging tables read by the Spark job that builds agg_events_iceberg.
+var icebergUpstreamTables = []string{
+	"gcp_primary_staging.thelook_ecommerce_events",
+}
+
+// verifyIcebergLineage asserts Data Lineage recorded a link from each staging
+// table the project-setup Spark job reads to agg_events_iceberg.
+func verifyIcebergLineage(t *testing.T, assert *assert.Assertions, projectID, region string) {
+	url := fmt.Sprintf("https://datalineage.googleapis.com/v1/projects/%s/locations/%s:searchLinks", projectID, region)
+
+	for _, table := range icebergUpstreamTables {
+		source := fmt.Sprintf("bigquery:%s.%s", projectID, table)
+		body := fmt.Sprintf(`{"source": {"fullyQualifiedName": %q}}`, source)
+
+		// Lineage events are processed asynchronously after the batch completes.
+		var targets []string
+		verifyLink := func() (bool, error) {
+			targets = nil
+			links := callAPI(t, "POST", url, body)
+			for _, link := range links.Get("links").Array() {
+				targets = append(targets, link.Get("target.fullyQualifiedName").String())
+			}
+			for _, target := range targets {
+				if strings.Contains(target, "agg_events_iceberg") {
+					return false, nil
+				}
+			}
+			return true, nil
+		}
+		err := utils.PollE(t, verifyLink, 40, 15*time.Second)
+		assert.NoError(err, "No lineage link from %s to agg_events_iceberg, found targets %v", table, targets)
+	}
+}
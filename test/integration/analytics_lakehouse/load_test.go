@@ -0,0 +1,92 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package multiple_buckets
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/tidwall/gjson"
+)
+
+// Analyst-style queries the load test cycles through, formatted with the
+// project ID. Each scans a different staging table.
+var loadTestQueries = []string{
+	"SELECT p.category, SUM(oi.sale_price) AS revenue FROM `%[1]s.gcp_primary_staging.thelook_ecommerce_order_items` oi JOIN `%[1]s.gcp_primary_staging.thelook_ecommerce_products` p ON oi.product_id = p.id GROUP BY p.category ORDER BY revenue DESC",
+	"SELECT status, COUNT(*) AS orders FROM `%[1]s.gcp_primary_staging.thelook_ecommerce_orders` GROUP BY status",
+	"SELECT traffic_source, COUNT(DISTINCT session_id) AS sessions FROM `%[1]s.gcp_primary_staging.thelook_ecommerce_events` GROUP BY traffic_source",
+	"SELECT DATE(CAST(pickup_datetime AS TIMESTAMP)) AS day, COUNT(*) AS trips FROM `%[1]s.gcp_primary_staging.new_york_taxi_trips_tlc_yellow_trips_2022` GROUP BY day ORDER BY day",
+}
+
+// loadTestResult is the outcome of one concurrent query.
+type loadTestResult struct {
+	latency time.Duration
+	err     error
+}
+
+// verifyConcurrentQueries fires concurrency analyst-style queries at once and
+// asserts every one succeeds within sloSeconds. Results bypass the query cache
+// so each query runs on the project's slots or on-demand capacity.
+func verifyConcurrentQueries(t *testing.T, assert *assert.Assertions, projectID, region, concurrency, sloSeconds string) {
+	n, err := strconv.Atoi(concurrency)
+	if !assert.NoError(err, "Invalid load_test_concurrency %q", concurrency) {
+		return
+	}
+	slo, err := strconv.Atoi(sloSeconds)
+	if !assert.NoError(err, "Invalid load_test_slo_seconds %q", sloSeconds) {
+		return
+	}
+
+	token := accessToken(t)
+	api := fmt.Sprintf("https://bigquery.googleapis.com/bigquery/v2/projects/%s/queries", projectID)
+	results := make([]loadTestResult, n)
+	var wg sync.WaitGroup
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			query := fmt.Sprintf(loadTestQueries[i%len(loadTestQueries)], projectID)
+			body := fmt.Sprintf(`{"query": %q, "useLegacySql": false, "useQueryCache": false, "location": %q, "timeoutMs": %d}`, query, region, slo*1000)
+			start := time.Now()
+			status, respBody, err := doAPIE(token, "POST", api, body)
+			results[i].latency = time.Since(start)
+			switch {
+			case err != nil:
+				results[i].err = err
+			case status != http.StatusOK:
+				results[i].err = fmt.Errorf("returned %d: %s", status, respBody)
+			case !gjson.GetBytes(respBody, "jobComplete").Bool():
+				results[i].err = fmt.Errorf("job %s did not complete within %ds", gjson.GetBytes(respBody, "jobReference.jobId"), slo)
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	latencies := []time.Duration{}
+	for i, result := range results {
+		assert.NoError(result.err, "Concurrent query %d failed", i)
+		latencies = append(latencies, result.latency)
+	}
+	sort.Slice(latencies, func(i, j int) bool { return latencies[i] < latencies[j] })
+	p50, p95, slowest := latencies[len(latencies)/2], latencies[len(latencies)*95/100], latencies[len(latencies)-1]
+	t.Logf("%d concurrent queries: p50 %s, p95 %s, max %s", n, p50, p95, slowest)
+	assert.LessOrEqual(slowest, time.Duration(slo)*time.Second, "Slowest of %d concurrent queries exceeded the %ds SLO", n, slo)
+}
@@ -0,0 +1,97 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package multiple_buckets
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/GoogleCloudPlatform/cloud-foundation-toolkit/infra/blueprint-test/pkg/bq"
+	"github.com/GoogleCloudPlatform/cloud-foundation-toolkit/infra/blueprint-test/pkg/gcloud"
+	"github.com/GoogleCloudPlatform/cloud-foundation-toolkit/infra/blueprint-test/pkg/utils"
+	"github.com/stretchr/testify/assert"
+	"github.com/tidwall/gjson"
+)
+
+// readLogs returns up to limit log entries from the last day matching filter.
+// The filter is passed as a single argument since gcloud.Runf splits
+// commands on whitespace.
+func readLogs(t *testing.T, projectID, filter string, limit int) []gjson.Result {
+	cmd := fmt.Sprintf("logging read --project=%s --freshness=1d --limit=%d", projectID, limit)
+	return gcloud.Run(t, cmd, gcloud.WithCommonArgs([]string{filter, "--format", "json"})).Array()
+}
+
+// verifyDataAccessAuditLogs asserts Data Access audit logging is enabled for
+// BigQuery and Cloud Storage, and that entries were emitted for the test's
+// table reads and for the objects copy-data wrote and BigLake read.
+func verifyDataAccessAuditLogs(t *testing.T, assert *assert.Assertions, projectID string) {
+	policy := gcloud.Runf(t, "projects get-iam-policy %s", projectID)
+	for _, service := range []string{"bigquery.googleapis.com", "storage.googleapis.com"} {
+		logTypes := utils.GetResultStrSlice(policy.Get(fmt.Sprintf(`auditConfigs.#(service=="%s").auditLogConfigs.#.logType`, service)).Array())
+		assert.ElementsMatch([]string{"DATA_READ", "DATA_WRITE"}, logTypes, "Unexpected Data Access audit log types for %s", service)
+	}
+
+	dataAccess := fmt.Sprintf(`logName="projects/%s/logs/cloudaudit.googleapis.com%%2Fdata_access"`, projectID)
+	filters := map[string]string{
+		"BigQuery table reads": dataAccess + ` AND protoPayload.serviceName="bigquery.googleapis.com" AND protoPayload.metadata.tableDataRead:*`,
+		"Cloud Storage reads":  dataAccess + ` AND protoPayload.serviceName="storage.googleapis.com" AND protoPayload.methodName="storage.objects.get"`,
+		"Cloud Storage writes": dataAccess + ` AND protoPayload.serviceName="storage.googleapis.com" AND protoPayload.methodName="storage.objects.create"`,
+	}
+	for name, filter := range filters {
+		// Audit entries can take a few minutes to become readable.
+		logged := func() (bool, error) {
+			return len(readLogs(t, projectID, filter, 1)) == 0, nil
+		}
+		if err := utils.PollE(t, logged, 20, 30*time.Second); err != nil {
+			assert.Fail(fmt.Sprintf("No Data Access audit entries for %s", name), err.Error())
+		}
+	}
+}
+
+// verifyLogSink asserts the operations log sink routes into dataset, its
+// writer identity can write there, and Workflows and Dataproc log rows arrive.
+func verifyLogSink(t *testing.T, assert *assert.Assertions, projectID, dataset string) {
+	sink := gcloud.Runf(t, "logging sinks describe lakehouse-ops-sink --project=%s", projectID)
+	assert.Equal(fmt.Sprintf("bigquery.googleapis.com/projects/%s/datasets/%s", projectID, dataset), sink.Get("destination").String(), "Unexpected sink destination")
+	assert.Contains(sink.Get("filter").String(), "workflows.googleapis.com/Workflow", "Sink does not route Workflows logs")
+	assert.Contains(sink.Get("filter").String(), "cloud_dataproc_batch", "Sink does not route Dataproc logs")
+
+	// dataEditor surfaces as WRITER in the dataset access list.
+	writer := strings.TrimPrefix(sink.Get("writerIdentity").String(), "serviceAccount:")
+	access := bq.Runf(t, "--project_id=%s show %s", projectID, dataset).Get("access").Array()
+	writable := false
+	for _, entry := range access {
+		if entry.Get("userByEmail").String() == writer && entry.Get("role").String() == "WRITER" {
+			writable = true
+		}
+	}
+	assert.True(writable, "Sink writer %s cannot write to %s", writer, dataset)
+
+	// Log rows are streamed, so poll until the sink has created a table with rows.
+	rowsArrived := func() (bool, error) {
+		for _, table := range bq.Runf(t, "--project_id=%s ls %s", projectID, dataset).Array() {
+			query := fmt.Sprintf("SELECT count(*) AS count FROM `%s.%s.%s`;", projectID, dataset, table.Get("tableReference.tableId").String())
+			if bq.Runf(t, "--project_id=%s query --nouse_legacy_sql %s", projectID, query).Get("0.count").Int() > 0 {
+				return false, nil
+			}
+		}
+		return true, nil
+	}
+	if err := utils.PollE(t, rowsArrived, 20, 30*time.Second); err != nil {
+		assert.Fail(fmt.Sprintf("No log rows arrived in %s", dataset), err.Error())
+	}
+}
@@ -0,0 +1,54 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package multiple_buckets
+
+import (
+	"net/http"
+	"net/url"
+	"testing"
+
+	"github.com/GoogleCloudPlatform/cloud-foundation-toolkit/infra/blueprint-test/pkg/bq"
+	"github.com/stretchr/testify/assert"
+)
+
+// verifyLookerStudioURL asserts the Looker Studio report URL points its data
+// source at the lakehouse view in this project, and that Looker Studio serves
+// it. Unauthenticated requests are redirected to sign in, so any non-error
+// response is accepted.
+func verifyLookerStudioURL(t *testing.T, assert *assert.Assertions, reportURL, projectID, datasetID string) {
+	u, err := url.Parse(reportURL)
+	if !assert.NoError(err, "Looker Studio report URL does not parse") {
+		return
+	}
+	assert.Equal("https", u.Scheme, "Looker Studio report URL is not HTTPS")
+	assert.Equal("lookerstudio.google.com", u.Host, "Looker Studio report URL has an unexpected host")
+	assert.Equal("/reporting/create", u.Path, "Looker Studio report URL does not create a report")
+
+	params := u.Query()
+	assert.NotEmpty(params.Get("c.reportId"), "Looker Studio report URL has no template report")
+	assert.Equal(projectID, params.Get("ds.ds0.projectId"), "Looker Studio data source is in another project")
+	assert.Equal(datasetID, params.Get("ds.ds0.datasetId"), "Looker Studio data source is not in the lakehouse dataset")
+	assert.Equal("TABLE", params.Get("ds.ds0.type"), "Looker Studio data source is not a BigQuery table")
+
+	table := bq.Runf(t, "--project_id=%s show %s.%s", projectID, params.Get("ds.ds0.datasetId"), params.Get("ds.ds0.tableId"))
+	assert.Equal("VIEW", table.Get("type").String(), "Looker Studio data source %s is not a view", params.Get("ds.ds0.tableId"))
+
+	resp, err := http.Get(reportURL)
+	if !assert.NoError(err, "Looker Studio report URL is unreachable") {
+		return
+	}
+	defer resp.Body.Close()
+	assert.Less(resp.StatusCode, http.StatusBadRequest, "Looker Studio returned %s", resp.Status)
+}
@@ -0,0 +1,55 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package multiple_buckets
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/GoogleCloudPlatform/cloud-foundation-toolkit/infra/blueprint-test/pkg/bq"
+	"github.com/GoogleCloudPlatform/cloud-foundation-toolkit/infra/blueprint-test/pkg/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+const materializedView = "mv_orders_by_user_state"
+
+// verifyMaterializedViews asserts the orders x users materialized view has
+// been refreshed, and that BigQuery answers an aggregate over the base join
+// from the view instead of the base tables.
+func verifyMaterializedViews(t *testing.T, assert *assert.Assertions, projectID, region string) {
+	query := fmt.Sprintf("SELECT last_refresh_time FROM `%s.gcp_lakehouse_ds.INFORMATION_SCHEMA.MATERIALIZED_VIEWS` WHERE table_name='%s';", projectID, materializedView)
+	refreshed := ""
+	verifyRefresh := func() (bool, error) {
+		refreshed = bq.Runf(t, "--project_id=%s query --nouse_legacy_sql %s", projectID, query).Get("0.last_refresh_time").String()
+		return refreshed == "", nil
+	}
+	utils.Poll(t, verifyRefresh, 20, 30*time.Second)
+	if !assert.NotEmpty(refreshed, "%s was never refreshed", materializedView) {
+		return
+	}
+
+	join := fmt.Sprintf("SELECT u.state, COUNT(*) AS orders FROM `%[1]s.gcp_lakehouse_ds.thelook_orders` o INNER JOIN `%[1]s.gcp_lakehouse_ds.thelook_users` u ON o.user_id = u.id WHERE u.country = 'United States' GROUP BY u.state", projectID)
+	body := fmt.Sprintf(`{"query": %q, "useLegacySql": false, "useQueryCache": false, "timeoutMs": 60000}`, join)
+	result := callAPI(t, "POST", fmt.Sprintf("https://bigquery.googleapis.com/bigquery/v2/projects/%s/queries", projectID), body)
+	jobID := result.Get("jobReference.jobId").String()
+	job := callAPI(t, "GET", fmt.Sprintf("https://bigquery.googleapis.com/bigquery/v2/projects/%s/jobs/%s?location=%s", projectID, jobID, region), "")
+
+	views := job.Get("statistics.query.materializedViewStatistics.materializedView")
+	view := views.Get(fmt.Sprintf("#(tableReference.tableId==%q)", materializedView))
+	if assert.True(view.Exists(), "%s was not considered for the join query", materializedView) {
+		assert.True(view.Get("chosen").Bool(), "%s was not used for the join query: %s", materializedView, view.Get("rejectedReason").String())
+	}
+}
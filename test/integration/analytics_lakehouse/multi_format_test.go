@@ -0,0 +1,85 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package multiple_buckets
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/GoogleCloudPlatform/cloud-foundation-toolkit/infra/blueprint-test/pkg/bq"
+	"github.com/GoogleCloudPlatform/cloud-foundation-toolkit/infra/blueprint-test/pkg/gcloud"
+	"github.com/stretchr/testify/assert"
+)
+
+// externalFormats are the file formats verifyExternalFormats writes the
+// staging orders in, with the EXPORT DATA options and external table options
+// for each. Parquet tables take their schema from the files; CSV and JSON
+// tables declare it.
+var externalFormats = []struct {
+	name          string
+	exportOptions string
+	tableOptions  string
+	schema        string
+}{
+	{"csv", "format = 'CSV', header = true", "format = 'CSV', skip_leading_rows = 1", "(order_id INT64, user_id INT64, status STRING, num_of_item INT64)"},
+	{"json", "format = 'JSON'", "format = 'NEWLINE_DELIMITED_JSON'", "(order_id INT64, user_id INT64, status STRING, num_of_item INT64)"},
+	{"parquet", "format = 'PARQUET'", "format = 'PARQUET'", ""},
+}
+
+// verifyExternalFormats writes the staging orders to the warehouse bucket as
+// CSV, JSON and Parquet, reads each back through a BigLake table over the
+// Cloud Storage connection, and asserts every format returns the same orders.
+// It also asserts the Iceberg BigLake table aggregates every staged event, so
+// each supported format is covered whatever raw_data_format is set to. The
+// scratch dataset and files are removed afterwards.
+func verifyExternalFormats(t *testing.T, assert *assert.Assertions, projectID, region, warehouseBucket string) {
+	api := fmt.Sprintf("https://bigquery.googleapis.com/bigquery/v2/projects/%s/", projectID)
+	scratch := fmt.Sprintf("scratch_formats_%d", time.Now().Unix())
+	prefix := fmt.Sprintf("gs://%s/%s", warehouseBucket, scratch)
+	callAPI(t, "POST", api+"datasets", fmt.Sprintf(`{"datasetReference": {"datasetId": %q}, "location": %q}`, scratch, region))
+
+	statements := []string{}
+	for _, format := range externalFormats {
+		statements = append(statements,
+			fmt.Sprintf("EXPORT DATA OPTIONS (uri = '%s/%s/*.%s', %s, overwrite = true) AS SELECT order_id, user_id, status, num_of_item FROM `%s.gcp_primary_staging.thelook_ecommerce_orders`", prefix, format.name, format.name, format.exportOptions, projectID),
+			fmt.Sprintf("CREATE EXTERNAL TABLE `%s.%s.orders_%s` %s WITH CONNECTION `%s.%s.gcp_gcs_connection` OPTIONS (%s, uris = ['%s/%s/*.%s'])", projectID, scratch, format.name, format.schema, projectID, region, format.tableOptions, prefix, format.name, format.name))
+	}
+	script := strings.Join(statements, ";\n")
+	result := callAPI(t, "POST", api+"queries", fmt.Sprintf(`{"query": %q, "useLegacySql": false, "location": %q, "timeoutMs": 300000}`, script, region))
+	assert.True(result.Get("jobComplete").Bool(), "Format export script did not complete")
+
+	summary := "SELECT count(*) AS orders, SUM(num_of_item) AS items, COUNT(DISTINCT status) AS statuses FROM `%s`;"
+	expected := bq.Runf(t, "--project_id=%s query --nouse_legacy_sql %s", projectID, fmt.Sprintf(summary, projectID+".gcp_primary_staging.thelook_ecommerce_orders"))
+	assert.Greater(expected.Get("0.orders").Int(), int64(0), "Staging orders are empty")
+	for _, format := range externalFormats {
+		table := fmt.Sprintf("%s.%s.orders_%s", projectID, scratch, format.name)
+		op := bq.Runf(t, "--project_id=%s query --nouse_legacy_sql %s", projectID, fmt.Sprintf(summary, table))
+		for _, column := range []string{"orders", "items", "statuses"} {
+			assert.Equal(expected.Get("0."+column).Int(), op.Get("0."+column).Int(), "%s BigLake table returned different %s", format.name, column)
+		}
+	}
+
+	iceberg := bq.Runf(t, "--project_id=%s show gcp_lakehouse_ds.%s", projectID, blmsTable)
+	assert.Equal("ICEBERG", iceberg.Get("externalDataConfiguration.sourceFormat").String(), "%s is not an Iceberg table", blmsTable)
+	query := fmt.Sprintf("SELECT (SELECT SUM(event_count) FROM `%[1]s.gcp_lakehouse_ds.%[2]s`) AS aggregated, (SELECT count(session_id) FROM `%[1]s.gcp_primary_staging.thelook_ecommerce_events`) AS expected;", projectID, blmsTable)
+	op := bq.Runf(t, "--project_id=%s query --nouse_legacy_sql %s", projectID, query)
+	assert.Equal(op.Get("0.expected").Int(), op.Get("0.aggregated").Int(), "Iceberg table does not aggregate every staged event")
+
+	assert.Equal(http.StatusNoContent, callAPIStatus(t, accessToken(t), "DELETE", api+"datasets/"+scratch+"?deleteContents=true", ""), "Could not remove the scratch dataset")
+	gcloud.RunCmd(t, "storage rm --recursive "+prefix)
+}
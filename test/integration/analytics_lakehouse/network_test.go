@@ -0,0 +1,228 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package multiple_buckets
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	compute "cloud.google.com/go/compute/apiv1"
+	"cloud.google.com/go/compute/apiv1/computepb"
+	"github.com/GoogleCloudPlatform/cloud-foundation-toolkit/infra/blueprint-test/pkg/gcloud"
+	"github.com/GoogleCloudPlatform/cloud-foundation-toolkit/infra/blueprint-test/pkg/utils"
+	"github.com/stretchr/testify/assert"
+	"github.com/terraform-google-modules/terraform-google-analytics-lakehouse/test/integration/testutils"
+)
+
+// Network tag on Dataproc cluster nodes and serverless batches.
+const dataprocNetworkTag = "dataproc"
+
+// firewallRule is the part of a firewall rule the posture check compares.
+type firewallRule struct {
+	direction    string
+	sourceRanges []string
+	targetTags   []string
+	protocols    []string
+}
+
+// expectedFirewallRules is the complete set of firewall rules the blueprint
+// may create. Dataproc nodes only need to reach each other inside the subnet.
+var expectedFirewallRules = map[string]firewallRule{
+	"dataproc-firewall": {
+		direction:    "INGRESS",
+		sourceRanges: []string{dataprocSubnetRange},
+		targetTags:   []string{dataprocNetworkTag},
+		protocols:    []string{"icmp", "tcp", "udp"},
+	},
+}
+
+// isConnectorRule reports whether a firewall rule was created by Serverless
+// VPC Access for its connector instances, which are tagged vpc-connector.
+func isConnectorRule(targetTags []string) bool {
+	for _, tag := range targetTags {
+		if strings.HasPrefix(tag, "vpc-connector") {
+			return true
+		}
+	}
+	return false
+}
+
+// verifyFirewallRules asserts the project's firewall rules are exactly
+// expectedFirewallRules, that no rule allows ingress from the internet, and
+// that every rule is scoped to tagged instances rather than the whole network.
+func verifyFirewallRules(t *testing.T, assert *assert.Assertions, projectID string) {
+	rules := gcloud.Runf(t, "compute firewall-rules list --project=%s", projectID).Array()
+	blueprintRules := 0
+	for _, rule := range rules {
+		if !isConnectorRule(utils.GetResultStrSlice(rule.Get("targetTags").Array())) {
+			blueprintRules++
+		}
+	}
+	assert.Equal(len(expectedFirewallRules), blueprintRules, "Unexpected number of firewall rules")
+
+	for _, rule := range rules {
+		name := rule.Get("name").String()
+		sourceRanges := utils.GetResultStrSlice(rule.Get("sourceRanges").Array())
+		if rule.Get("direction").String() == "INGRESS" && rule.Get("allowed").Exists() {
+			assert.NotContains(sourceRanges, "0.0.0.0/0", "%s allows ingress from the internet", name)
+		}
+		targetTags := utils.GetResultStrSlice(rule.Get("targetTags").Array())
+		assert.NotEmpty(targetTags, "%s applies to every instance in the network", name)
+
+		// Serverless VPC Access manages the rules for its connector instances
+		if isConnectorRule(targetTags) {
+			continue
+		}
+
+		expected, ok := expectedFirewallRules[name]
+		if !assert.True(ok, "Unexpected firewall rule %s", name) {
+			continue
+		}
+		assert.Equal(expected.direction, rule.Get("direction").String(), "Unexpected direction for %s", name)
+		assert.ElementsMatch(expected.sourceRanges, sourceRanges, "Unexpected source ranges for %s", name)
+		assert.ElementsMatch(expected.targetTags, targetTags, "Unexpected target tags for %s", name)
+		assert.ElementsMatch(expected.protocols, utils.GetResultStrSlice(rule.Get("allowed.#.IPProtocol").Array()), "Unexpected protocols for %s", name)
+	}
+}
+
+// Subnet the Dataproc cluster and serverless batches run in.
+const dataprocSubnet = "dataproc-subnet"
+
+// verifySubnetPrivateAccess asserts the Dataproc subnet has Private Google
+// Access, without which internal-only Dataproc nodes and serverless batches
+// cannot reach Google APIs. It stops the test immediately, since the
+// workflow checks that follow would otherwise only time out.
+func verifySubnetPrivateAccess(t *testing.T, assert *assert.Assertions, projectID, region string) {
+	subnet := gcloud.Runf(t, "compute networks subnets describe %s --project=%s --region=%s", dataprocSubnet, projectID, region)
+	if !assert.True(subnet.Get("privateIpGoogleAccess").Bool(), "%s does not have Private Google Access enabled", dataprocSubnet) {
+		t.FailNow()
+	}
+}
+
+// Network layout the blueprint creates. Asserting it explicitly catches
+// provider default changes that would silently alter the network.
+const (
+	dataprocNetwork     = "vpc-lakehouse"
+	dataprocNetworkMTU  = 1460
+	dataprocSubnetRange = "10.3.0.0/16"
+)
+
+// verifyNetworkLayout asserts the network and Dataproc subnet match the
+// expected layout, using the Compute Engine Go client.
+func verifyNetworkLayout(t *testing.T, assert *assert.Assertions, projectID, region string) {
+	ctx := context.Background()
+
+	networks, err := compute.NewNetworksRESTClient(ctx, testutils.ClientOptions(ctx, t)...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer networks.Close()
+	network, err := networks.Get(ctx, &computepb.GetNetworkRequest{Project: projectID, Network: dataprocNetwork})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.False(network.GetAutoCreateSubnetworks(), "%s auto-creates subnetworks", dataprocNetwork)
+	assert.Equal(int32(dataprocNetworkMTU), network.GetMtu(), "Unexpected MTU for %s", dataprocNetwork)
+	assert.Len(network.GetSubnetworks(), 1, "Unexpected subnetworks in %s", dataprocNetwork)
+
+	subnets, err := compute.NewSubnetworksRESTClient(ctx, testutils.ClientOptions(ctx, t)...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer subnets.Close()
+	subnet, err := subnets.Get(ctx, &computepb.GetSubnetworkRequest{Project: projectID, Region: region, Subnetwork: dataprocSubnet})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(dataprocSubnetRange, subnet.GetIpCidrRange(), "Unexpected range for %s", dataprocSubnet)
+	assert.True(strings.HasSuffix(subnet.GetRegion(), "/regions/"+region), "%s is not in %s", dataprocSubnet, region)
+	assert.True(strings.HasSuffix(subnet.GetNetwork(), "/global/networks/"+dataprocNetwork), "%s is not in %s", dataprocSubnet, dataprocNetwork)
+	assert.False(subnet.GetLogConfig().GetEnable(), "Unexpected flow logs on %s", dataprocSubnet)
+}
+
+// verifyNAT asserts the optional Cloud NAT translates the Dataproc subnet and
+// that a serverless Spark batch, which has no external IP, can install a
+// package from PyPI through it.
+func verifyNAT(t *testing.T, assert *assert.Assertions, projectID, region, suffix, dataprocSA string) {
+	nat := gcloud.Runf(t, "compute routers nats describe dataproc-nat --router=dataproc-router --project=%s --region=%s", projectID, region)
+	assert.Equal("AUTO_ONLY", nat.Get("natIpAllocateOption").String(), "Unexpected NAT IP allocation")
+	assert.Equal("ALL_SUBNETWORKS_ALL_IP_RANGES", nat.Get("sourceSubnetworkIpRangesToNat").String(), "NAT does not cover %s", dataprocSubnet)
+
+	// Submitting waits for the batch and fails if the install fails
+	batch := gcloud.Runf(t, "dataproc batches submit pyspark gs://gcp-lakehouse-provisioner-%[1]s/egress_check.py --batch=egress-check-%[1]s --project=%[2]s --region=%[3]s --subnet=%[4]s --tags=%[5]s --service-account=%[6]s --version=1.1",
+		suffix, projectID, region, dataprocSubnet, dataprocNetworkTag, dataprocSA)
+	assert.Equal("SUCCEEDED", batch.Get("state").String(), "Egress check batch did not succeed")
+}
+
+// Private Service Connect endpoint the optional googleapis.com zone resolves to.
+const pscAddress = "10.10.0.5"
+
+// verifyPrivateServiceConnect asserts the optional Google APIs endpoint and
+// private DNS zone exist, and that a serverless Spark batch resolves BigQuery
+// to the endpoint and can call it.
+func verifyPrivateServiceConnect(t *testing.T, assert *assert.Assertions, projectID, region, suffix, dataprocSA string) {
+	rule := gcloud.Runf(t, "compute forwarding-rules describe lakehouseapis --global --project=%s", projectID)
+	assert.Equal("all-apis", rule.Get("target").String(), "Unexpected PSC endpoint target")
+	assert.Equal(pscAddress, rule.Get("IPAddress").String(), "Unexpected PSC endpoint address")
+
+	zone := gcloud.Runf(t, "dns managed-zones describe googleapis --project=%s", projectID)
+	assert.Equal("private", zone.Get("visibility").String(), "googleapis.com zone is not private")
+	assert.Equal("googleapis.com.", zone.Get("dnsName").String(), "Unexpected zone DNS name")
+	assert.True(strings.HasSuffix(zone.Get("privateVisibilityConfig.networks.0.networkUrl").String(), "/global/networks/"+dataprocNetwork), "googleapis.com zone is not visible to %s", dataprocNetwork)
+
+	records := map[string][]string{}
+	for _, record := range gcloud.Runf(t, "dns record-sets list --zone=googleapis --project=%s", projectID).Array() {
+		records[record.Get("type").String()+" "+record.Get("name").String()] = utils.GetResultStrSlice(record.Get("rrdatas").Array())
+	}
+	assert.Equal([]string{pscAddress}, records["A private.googleapis.com."], "private.googleapis.com does not point at the endpoint")
+	assert.Equal([]string{"private.googleapis.com."}, records["CNAME *.googleapis.com."], "Google APIs are not aliased to private.googleapis.com")
+
+	// Submitting waits for the batch and fails if resolution or the call fails
+	batch := gcloud.Runf(t, "dataproc batches submit pyspark gs://gcp-lakehouse-provisioner-%[1]s/psc_check.py --batch=psc-check-%[1]s --project=%[2]s --region=%[3]s --subnet=%[4]s --tags=%[5]s --service-account=%[6]s --version=1.1 -- %[7]s %[2]s",
+		suffix, projectID, region, dataprocSubnet, dataprocNetworkTag, dataprocSA, pscAddress)
+	assert.Equal("SUCCEEDED", batch.Get("state").String(), "PSC check batch did not succeed")
+}
+
+// verifyNetworkTags asserts the PHS, its instances, and the serverless Spark
+// batches carry the Dataproc network tag the firewall rule targets.
+func verifyNetworkTags(t *testing.T, assert *assert.Assertions, projectID, region string) {
+	clusters := gcloud.Runf(t, "dataproc clusters list --project=%s --region=%s", projectID, region).Array()
+	assert.NotEmpty(clusters, "No Dataproc cluster found")
+	for _, cluster := range clusters {
+		name := cluster.Get("clusterName").String()
+		assert.Contains(utils.GetResultStrSlice(cluster.Get("config.gceClusterConfig.tags").Array()), dataprocNetworkTag, "%s is not tagged %s", name, dataprocNetworkTag)
+
+		instances := gcloud.Runf(t, "compute instances list --project=%s --filter=labels.goog-dataproc-cluster-name=%s", projectID, name).Array()
+		assert.NotEmpty(instances, "No instances found for %s", name)
+		for _, instance := range instances {
+			assert.Contains(utils.GetResultStrSlice(instance.Get("tags.items").Array()), dataprocNetworkTag, "%s is not tagged %s", instance.Get("name").String(), dataprocNetworkTag)
+		}
+	}
+
+	for _, batch := range gcloud.Runf(t, "dataproc batches list --project=%s --region=%s", projectID, region).Array() {
+		assert.Contains(utils.GetResultStrSlice(batch.Get("environmentConfig.executionConfig.networkTags").Array()), dataprocNetworkTag, "%s is not tagged %s", batch.Get("name").String(), dataprocNetworkTag)
+	}
+}
+
+// verifyVPCConnector asserts the optional Serverless VPC Access connector is
+// ready on the lakehouse network with its own range.
+func verifyVPCConnector(t *testing.T, assert *assert.Assertions, projectID, region, connectorID string) {
+	connector := gcloud.Runf(t, "compute networks vpc-access connectors describe %s", connectorID)
+	assert.Equal("READY", connector.Get("state").String(), "VPC connector is not ready")
+	assert.Equal(dataprocNetwork, connector.Get("network").String(), "VPC connector is not on %s", dataprocNetwork)
+	assert.Equal("10.8.0.0/28", connector.Get("ipCidrRange").String(), "Unexpected VPC connector range")
+	assert.Contains(connectorID, "/locations/"+region+"/", "VPC connector is not in %s", region)
+}
@@ -0,0 +1,51 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package multiple_buckets
+
+import (
+	"fmt"
+	"path"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/GoogleCloudPlatform/cloud-foundation-toolkit/infra/blueprint-test/pkg/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+// verifyNotebookExecution asserts the project-setup workflow created the
+// Colab Enterprise runtime template, and that the sample notebook executes
+// headlessly on it to completion. The notebook fails its own execution when
+// the lakehouse view is empty.
+func verifyNotebookExecution(t *testing.T, assert *assert.Assertions, projectID, region, template, notebookURI, serviceAccount string) {
+	api := fmt.Sprintf("https://%s-aiplatform.googleapis.com/v1/", region)
+	assert.Equal(template, callAPI(t, "GET", api+template, "").Get("name").String(), "Notebook runtime template not found")
+
+	body := fmt.Sprintf(`{"displayName": "lakehouse-quickstart", "notebookRuntimeTemplateResourceName": %q, "gcsNotebookSource": {"uri": %q}, "gcsOutputUri": %q, "serviceAccount": %q}`,
+		template, notebookURI, path.Dir(notebookURI)+"/executions", serviceAccount)
+	operation := callAPI(t, "POST", fmt.Sprintf("%sprojects/%s/locations/%s/notebookExecutionJobs", api, projectID, region), body)
+	job, _, _ := strings.Cut(operation.Get("name").String(), "/operations/")
+
+	state := ""
+	message := ""
+	verifyJob := func() (bool, error) {
+		execution := callAPI(t, "GET", api+job, "")
+		state = execution.Get("jobState").String()
+		message = execution.Get("status.message").String()
+		return state != "JOB_STATE_SUCCEEDED" && state != "JOB_STATE_FAILED" && state != "JOB_STATE_CANCELLED", nil
+	}
+	utils.Poll(t, verifyJob, 40, 30*time.Second)
+	assert.Equal("JOB_STATE_SUCCEEDED", state, "Notebook execution %s did not succeed: %s", job, message)
+}
@@ -0,0 +1,49 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package multiple_buckets
+
+import (
+	"testing"
+
+	"github.com/GoogleCloudPlatform/cloud-foundation-toolkit/infra/blueprint-test/pkg/gcloud"
+	"github.com/stretchr/testify/assert"
+)
+
+// Boolean constraints test/setup enforces on the project before apply.
+var enforcedBooleanConstraints = []string{
+	"compute.requireOsLogin",
+	"compute.requireShieldedVm",
+	"iam.disableServiceAccountKeyCreation",
+}
+
+// verifyOrgPolicies asserts the restrictive org policies from test/setup are
+// in effect, so a successful apply proves compatibility with them, and that
+// every VM the blueprint creates complies.
+func verifyOrgPolicies(t *testing.T, assert *assert.Assertions, projectID string) {
+	for _, constraint := range enforcedBooleanConstraints {
+		policy := gcloud.Runf(t, "resource-manager org-policies describe %s --project=%s --effective", constraint, projectID)
+		assert.True(policy.Get("booleanPolicy.enforced").Bool(), "%s is not enforced", constraint)
+	}
+	policy := gcloud.Runf(t, "resource-manager org-policies describe compute.vmExternalIpAccess --project=%s --effective", projectID)
+	assert.Equal("DENY", policy.Get("listPolicy.allValues").String(), "compute.vmExternalIpAccess does not deny all")
+
+	for _, instance := range gcloud.Runf(t, "compute instances list --project=%s", projectID).Array() {
+		name := instance.Get("name").String()
+		assert.Empty(instance.Get("networkInterfaces.#.accessConfigs|@flatten").Array(), "%s has an external IP", name)
+		assert.True(instance.Get("shieldedInstanceConfig.enableSecureBoot").Bool(), "%s does not use Secure Boot", name)
+		assert.True(instance.Get("shieldedInstanceConfig.enableVtpm").Bool(), "%s does not use vTPM", name)
+		assert.True(instance.Get("shieldedInstanceConfig.enableIntegrityMonitoring").Bool(), "%s does not use integrity monitoring", name)
+	}
+}
@@ -0,0 +1,189 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package multiple_buckets
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/terraform-google-modules/terraform-google-analytics-lakehouse/test/integration/testutils"
+	"github.com/tidwall/gjson"
+)
+
+// exampleDir is the directory of the example TestAnalyticsLakehouse deploys.
+var exampleDir = filepath.Join("..", "..", "..", "examples", "analytics_lakehouse")
+
+// Shapes of the values outputs are built from. {project} stands for the
+// project ID and {region} for the region.
+const (
+	bucketShape         = `[a-z0-9][a-z0-9._-]{1,61}[a-z0-9]`
+	datasetShape        = `[A-Za-z0-9_]+`
+	serviceAccountShape = `[a-z][a-z0-9-]{4,28}[a-z0-9]@{project}\.iam\.gserviceaccount\.com`
+	workflowShape       = `[A-Za-z][A-Za-z0-9_-]*`
+	locationShape       = `projects/{project}/locations/{region}`
+)
+
+// outputShapes are the values each documented output of the example must
+// match in full, as consumed by downstream modules and the Jump Start
+// Solution. A list output must be a non-empty list of such values.
+var outputShapes = map[string]string{
+	"access_consumer_service_account":          serviceAccountShape,
+	"analytics_hub_listing":                    `projects/[^/]+/locations/[^/]+/dataExchanges/[^/]+/listings/[^/]+`,
+	"analytics_hub_subscriber_service_account": serviceAccountShape,
+	"archive_bucket":                           bucketShape,
+	"bi_engine_reservation":                    locationShape + `/biReservation`,
+	"bigquery_editor_url":                      `https://console\.cloud\.google\.com/bigquery\?\S+`,
+	"budget":                                   `billingAccounts/[0-9A-F-]+/budgets/[^/]+`,
+	"budget_notification_channels":             `projects/[^/]+/notificationChannels/[0-9]+`,
+	"data_analyst_service_account":             serviceAccountShape,
+	"dataform_repository":                      locationShape + `/repositories/[^/]+`,
+	"dataproc_service_account":                 serviceAccountShape,
+	"dataproc_subnetwork":                      `\S*/regions/{region}/subnetworks/[a-z0-9-]+`,
+	"delta_lake_uri":                           `gs://` + bucketShape + `/\S+`,
+	"dlp_deidentified_users_table":             datasetShape + `\.` + datasetShape,
+	"dlp_findings_table":                       datasetShape + `\.` + datasetShape,
+	"firestore_database":                       `[a-z][a-z0-9-]{3,62}`,
+	"ga4_images_bucket":                        bucketShape,
+	"iceberg_maintenance_workflow":             workflowShape,
+	"lakehouse_colab_url":                      `https://colab\.research\.google\.com/\S+\.ipynb`,
+	"lakehouse_dataset_id":                     datasetShape,
+	"lookerstudio_report_url":                  `https://lookerstudio\.google\.com/\S+`,
+	"neos_tutorial_url":                        `https://console\.cloud\.google\.com/products/solutions/deployments\?\S+`,
+	"notebook_gcs_uri":                         `gs://` + bucketShape + `/\S+\.ipynb`,
+	"notebook_runtime_template":                locationShape + `/notebookRuntimeTemplates/[^/]+`,
+	"ops_dataset_id":                           datasetShape,
+	"raw_data_format":                          `PARQUET|CSV|JSON`,
+	"region":                                   `{region}`,
+	"retention_workflow":                       workflowShape,
+	"scheduled_query_transfer_config":          `projects/[^/]+/locations/{region}/transferConfigs/[^/]+`,
+	"serving_bucket":                           bucketShape,
+	"serving_database":                         `[A-Za-z0-9_]+`,
+	"serving_instance":                         `[a-z][a-z0-9-]*`,
+	"slot_reservation":                         locationShape + `/reservations/[^/]+`,
+	"streaming_topic":                          `projects/{project}/topics/[^/]+`,
+	"tables_bucket":                            bucketShape,
+	"textocr_images_bucket":                    bucketShape,
+	"transfer_load_config":                     `projects/[^/]+/locations/{region}/transferConfigs/[^/]+`,
+	"vpc_connector":                            locationShape + `/connectors/[^/]+`,
+	"warehouse_bucket":                         bucketShape,
+	"workflows_service_account":                serviceAccountShape,
+}
+
+// featureOutputs are the outputs of the optional features, which are only
+// set by the full fixture.
+var featureOutputs = []string{
+	"access_consumer_service_account",
+	"analytics_hub_listing",
+	"analytics_hub_subscriber_service_account",
+	"archive_bucket",
+	"bi_engine_reservation",
+	"budget",
+	"budget_notification_channels",
+	"dataform_repository",
+	"delta_lake_uri",
+	"dlp_deidentified_users_table",
+	"dlp_findings_table",
+	"firestore_database",
+	"iceberg_maintenance_workflow",
+	"notebook_gcs_uri",
+	"notebook_runtime_template",
+	"ops_dataset_id",
+	"retention_workflow",
+	"scheduled_query_transfer_config",
+	"serving_bucket",
+	"serving_database",
+	"serving_instance",
+	"slot_reservation",
+	"streaming_topic",
+	"transfer_load_config",
+	"vpc_connector",
+}
+
+// documentedOutputs returns the outputs listed in the Outputs table of the
+// example's README.
+func documentedOutputs(t *testing.T) []string {
+	readme, err := os.ReadFile(filepath.Join(exampleDir, "README.md"))
+	if err != nil {
+		t.Fatalf("reading the example README: %v", err)
+	}
+	_, table, ok := strings.Cut(string(readme), "## Outputs")
+	if !ok {
+		t.Fatal("the example README has no Outputs table")
+	}
+	outputs := []string{}
+	for _, line := range strings.Split(table, "\n") {
+		cells := strings.Split(line, "|")
+		if len(cells) < 3 {
+			continue
+		}
+		name := strings.ReplaceAll(strings.TrimSpace(cells[1]), `\_`, "_")
+		if name == "Name" || strings.HasPrefix(name, "-") {
+			continue
+		}
+		outputs = append(outputs, name)
+	}
+	return outputs
+}
+
+// verifyOutputContract asserts the example or fixture in tfDir defines
+// exactly the outputs documented for the example, and each of them is set and
+// shaped as outputShapes describes.
+// Outputs in optional are only checked when set, as the features behind them
+// are not enabled in every run.
+func verifyOutputContract(t *testing.T, assert *assert.Assertions, tfDir, projectID, region string, optional map[string]bool) {
+	out, err := exec.Command(testutils.TerraformBinary(), "-chdir="+tfDir, "output", "-json").Output()
+	if !assert.NoError(err, "Reading the example outputs failed") {
+		return
+	}
+	outputs := gjson.ParseBytes(out).Map()
+
+	placeholders := strings.NewReplacer("{project}", regexp.QuoteMeta(projectID), "{region}", regexp.QuoteMeta(region))
+	documented := documentedOutputs(t)
+	for name := range outputs {
+		assert.Contains(documented, name, "Output %s is not documented", name)
+	}
+	for _, name := range documented {
+		output, ok := outputs[name]
+		if !assert.True(ok, "Documented output %s is not defined", name) {
+			continue
+		}
+		shape, ok := outputShapes[name]
+		if !assert.True(ok, "Output %s has no expected shape", name) {
+			continue
+		}
+		re := regexp.MustCompile("^(?:" + placeholders.Replace(shape) + ")$")
+
+		value := output.Get("value")
+		values := []gjson.Result{value}
+		if value.IsArray() {
+			values = value.Array()
+		}
+		empty := len(values) == 0 || values[0].String() == ""
+		if optional[name] && empty {
+			continue
+		}
+		if !assert.False(empty, "Output %s is empty", name) {
+			continue
+		}
+		for _, v := range values {
+			assert.Regexp(re, v.String(), "Output %s is not shaped like %s", name, shape)
+		}
+	}
+}
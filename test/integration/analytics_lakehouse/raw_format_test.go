@@ -0,0 +1,70 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package multiple_buckets
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/GoogleCloudPlatform/cloud-foundation-toolkit/infra/blueprint-test/pkg/bq"
+	"github.com/stretchr/testify/assert"
+)
+
+// rawFormats maps each raw_data_format to the source format of the external
+// tables Dataplex discovery creates over the files, and to any options the
+// format needs on them.
+var rawFormats = map[string]struct {
+	sourceFormat string
+	options      map[string]string
+}{
+	"PARQUET": {sourceFormat: "PARQUET"},
+	"CSV":     {sourceFormat: "CSV", options: map[string]string{"csvOptions.skipLeadingRows": "1"}},
+	"JSON":    {sourceFormat: "NEWLINE_DELIMITED_JSON"},
+}
+
+// verifyRawDataFormat asserts every thelook staging table reads files from
+// the tables bucket in the configured format with that format's options, and
+// that discovery inferred the orders schema the downstream SQL relies on.
+func verifyRawDataFormat(t *testing.T, assert *assert.Assertions, projectID, tablesBucket, format string) {
+	expected, ok := rawFormats[format]
+	if !assert.True(ok, "Unsupported raw data format %s", format) {
+		return
+	}
+
+	for _, table := range thelookTables {
+		op := bq.Runf(t, "--project_id=%s show %s", projectID, table)
+		config := op.Get("externalDataConfiguration")
+		assert.Equal(expected.sourceFormat, config.Get("sourceFormat").String(), "%s does not read %s files", table, format)
+		for path, value := range expected.options {
+			assert.Equal(value, config.Get(path).String(), "Unexpected %s on %s", path, table)
+		}
+
+		name := strings.TrimPrefix(table, "gcp_primary_staging.thelook_ecommerce_")
+		prefix := fmt.Sprintf("gs://%s/thelook_ecommerce/%s/", tablesBucket, name)
+		for _, uri := range config.Get("sourceUris").Array() {
+			assert.True(strings.HasPrefix(uri.String(), prefix), "%s reads %s outside %s", table, uri.String(), prefix)
+		}
+	}
+
+	// Column types must be inferred the same way whatever the file format
+	schema := map[string]string{}
+	for _, field := range bq.Runf(t, "--project_id=%s show gcp_primary_staging.thelook_ecommerce_orders", projectID).Get("schema.fields").Array() {
+		schema[field.Get("name").String()] = field.Get("type").String()
+	}
+	for column, columnType := range map[string]string{"order_id": "INTEGER", "user_id": "INTEGER", "status": "STRING", "num_of_item": "INTEGER"} {
+		assert.Equal(columnType, schema[column], "Unexpected type inferred for orders.%s from %s files", column, format)
+	}
+}
@@ -0,0 +1,47 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package multiple_buckets
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/GoogleCloudPlatform/cloud-foundation-toolkit/infra/blueprint-test/pkg/bq"
+	"github.com/GoogleCloudPlatform/cloud-foundation-toolkit/infra/blueprint-test/pkg/gcloud"
+	"github.com/stretchr/testify/assert"
+)
+
+// verifyRemoteFunction asserts the score_event remote function returns the
+// scores computed by its Cloud Function, and that the function egresses
+// through the Serverless VPC Access connector.
+func verifyRemoteFunction(t *testing.T, assert *assert.Assertions, projectID, region, connector string) {
+	function := gcloud.Runf(t, "functions describe score-event --gen2 --project=%s --region=%s", projectID, region)
+	assert.Equal("ACTIVE", function.Get("state").String(), "score-event function is not active")
+	assert.Equal(connector, function.Get("serviceConfig.vpcConnector").String(), "score-event function is not on the VPC connector")
+	assert.Equal("ALL_TRAFFIC", function.Get("serviceConfig.vpcConnectorEgressSettings").String(), "score-event function egress bypasses the VPC connector")
+
+	query := fmt.Sprintf("SELECT `%[1]s.gcp_lakehouse_ds.score_event`('Complete',10.0) AS complete, `%[1]s.gcp_lakehouse_ds.score_event`('Returned',10.0) AS returned, `%[1]s.gcp_lakehouse_ds.score_event`('Unknown',5.0) AS unknown, `%[1]s.gcp_lakehouse_ds.score_event`(NULL,5.0) IS NULL AS null_status;", projectID)
+	scores := bq.Runf(t, "--project_id=%s query --nouse_legacy_sql %s", projectID, query).Get("0")
+	assert.Equal(10.0, scores.Get("complete").Float(), "Unexpected score for a complete item")
+	assert.Equal(-10.0, scores.Get("returned").Float(), "Unexpected score for a returned item")
+	assert.Equal(0.0, scores.Get("unknown").Float(), "Unexpected score for an unknown status")
+	assert.True(scores.Get("null_status").Bool(), "NULL arguments should score NULL")
+
+	// Assert batched calls over real rows are all answered
+	query = fmt.Sprintf("SELECT count(*) AS count, COUNTIF(`%[1]s.gcp_lakehouse_ds.score_event`(status,sale_price) IS NULL) AS unscored FROM (SELECT status, sale_price FROM `%[1]s.gcp_primary_staging.thelook_ecommerce_order_items` WHERE status IS NOT NULL AND sale_price IS NOT NULL LIMIT 2000);", projectID)
+	op := bq.Runf(t, "--project_id=%s query --nouse_legacy_sql %s", projectID, query)
+	assert.Greater(op.Get("0.count").Int(), int64(0), "No order items to score")
+	assert.Zero(op.Get("0.unscored").Int(), "Remote function left order items unscored")
+}
@@ -0,0 +1,67 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package multiple_buckets
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/GoogleCloudPlatform/cloud-foundation-toolkit/infra/blueprint-test/pkg/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+// Slot settings of the reservation with the module defaults used by
+// examples/analytics_lakehouse.
+const (
+	reservationBaselineSlots = 0
+	reservationMaxSlots      = 100
+)
+
+// verifySlotReservation asserts the query reservation is an Enterprise
+// edition reservation with the expected slots, that the project's QUERY jobs
+// are assigned to it, and that a query run by the test reports the
+// reservation in its statistics. New assignments take a few minutes to apply,
+// so the query is repeated until it does.
+func verifySlotReservation(t *testing.T, assert *assert.Assertions, projectID, region, reservation string) {
+	api := "https://bigqueryreservation.googleapis.com/v1/"
+	r := callAPI(t, "GET", api+reservation, "")
+	assert.Equal("ENTERPRISE", r.Get("edition").String(), "Reservation is not an Enterprise edition reservation")
+	assert.Equal(int64(reservationBaselineSlots), r.Get("slotCapacity").Int(), "Reservation has the wrong baseline")
+	assert.Equal(int64(reservationMaxSlots), r.Get("autoscale.maxSlots").Int(), "Reservation has the wrong autoscale limit")
+
+	query := url.QueryEscape("assignee=projects/" + projectID)
+	assignments := callAPI(t, "GET", fmt.Sprintf("%sprojects/%s/locations/%s:searchAllAssignments?query=%s", api, projectID, region, query), "").Get("assignments")
+	assigned := assignments.Get(`#(jobType=="QUERY")#.name`).Array()
+	if assert.Len(assigned, 1, "Expected one QUERY assignment for the project") {
+		assert.True(strings.HasPrefix(assigned[0].String(), reservation+"/assignments/"), "QUERY jobs are assigned by %s instead of the reservation", assigned[0])
+	}
+
+	name := reservation[strings.LastIndex(reservation, "/")+1:]
+	bqAPI := fmt.Sprintf("https://bigquery.googleapis.com/bigquery/v2/projects/%s/", projectID)
+	count := fmt.Sprintf("SELECT COUNT(*) FROM `%s.gcp_primary_staging.thelook_ecommerce_orders`", projectID)
+	body := fmt.Sprintf(`{"query": %q, "useLegacySql": false, "useQueryCache": false, "location": %q}`, count, region)
+
+	var reservationID string
+	verifyReservationUsed := func() (bool, error) {
+		jobID := callAPI(t, "POST", bqAPI+"queries", body).Get("jobReference.jobId").String()
+		reservationID = callAPI(t, "GET", bqAPI+"jobs/"+jobID+"?location="+region, "").Get("statistics.reservation_id").String()
+		return !strings.HasSuffix(reservationID, "."+name), nil
+	}
+	err := utils.PollE(t, verifyReservationUsed, 10, 30*time.Second)
+	assert.NoError(err, "Test query ran under %q instead of %s", reservationID, name)
+}
@@ -0,0 +1,113 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package multiple_buckets
+
+import (
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/GoogleCloudPlatform/cloud-foundation-toolkit/infra/blueprint-test/pkg/gcloud"
+	"github.com/tidwall/gjson"
+)
+
+// accessToken returns an OAuth2 access token for the active gcloud credentials.
+func accessToken(t *testing.T) string {
+	return strings.TrimSpace(gcloud.RunCmd(t, "auth print-access-token", gcloud.WithCommonArgs([]string{})))
+}
+
+// impersonatedAccessToken returns an OAuth2 access token for serviceAccount,
+// minted by impersonating it with the active gcloud credentials.
+func impersonatedAccessToken(t *testing.T, serviceAccount string) string {
+	cmd := "auth print-access-token --impersonate-service-account=" + serviceAccount
+	return strings.TrimSpace(gcloud.RunCmd(t, cmd, gcloud.WithCommonArgs([]string{})))
+}
+
+// callAPI calls a Google Cloud REST endpoint that has no gcloud equivalent
+// and returns the parsed JSON response. It fails the test on non-200 responses.
+func callAPI(t *testing.T, method, url, body string) gjson.Result {
+	return callAPIWithToken(t, accessToken(t), method, url, body)
+}
+
+// callAPIWithToken is callAPI authenticated with the given access token.
+func callAPIWithToken(t *testing.T, token, method, url, body string) gjson.Result {
+	status, respBody := doAPI(t, token, method, url, body)
+	if status != http.StatusOK {
+		t.Fatalf("%s %s returned %d: %s", method, url, status, respBody)
+	}
+	return gjson.ParseBytes(respBody)
+}
+
+// callAPIStatus is callAPIWithToken for calls that are expected to be
+// rejected, returning the HTTP status code instead of failing the test.
+func callAPIStatus(t *testing.T, token, method, url, body string) int {
+	status, _ := doAPI(t, token, method, url, body)
+	return status
+}
+
+// doAPI sends an authenticated JSON request and returns the response status
+// code and body.
+func doAPI(t *testing.T, token, method, url, body string) (int, []byte) {
+	status, respBody, err := doAPIE(token, method, url, body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return status, respBody
+}
+
+// doAPIE is doAPI returning errors instead of failing the test, so it can be
+// called from goroutines.
+func doAPIE(token, method, url, body string) (int, []byte, error) {
+	req, err := http.NewRequest(method, url, strings.NewReader(body))
+	if err != nil {
+		return 0, nil, err
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return 0, nil, err
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return 0, nil, err
+	}
+	return resp.StatusCode, respBody, nil
+}
+
+// splitTable splits a "dataset.table" reference into its dataset and table IDs.
+func splitTable(table string) (string, string) {
+	dataset, tableID, _ := strings.Cut(table, ".")
+	return dataset, tableID
+}
+
+// contains reports whether s is present in values.
+func contains(values []string, s string) bool {
+	for _, v := range values {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+// escapeKey escapes the gjson path separators in a literal map key.
+func escapeKey(key string) string {
+	return strings.NewReplacer(".", `\.`, "*", `\*`, "?", `\?`).Replace(key)
+}
@@ -0,0 +1,60 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package multiple_buckets
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/GoogleCloudPlatform/cloud-foundation-toolkit/infra/blueprint-test/pkg/bq"
+	"github.com/GoogleCloudPlatform/cloud-foundation-toolkit/infra/blueprint-test/pkg/gcloud"
+	"github.com/stretchr/testify/assert"
+)
+
+// retentionDays is the default retention_days the example deploys with.
+const retentionDays = 365
+
+// verifyRetention runs the data-retention workflow and asserts the partitions
+// of orders older than the retention period moved from thelook_orders_hot to
+// the Coldline archive bucket, while the newer partitions stayed. Expected
+// partition and row counts are derived from the staging orders, so the check
+// holds whether or not the daily schedule already ran.
+func verifyRetention(t *testing.T, assert *assert.Assertions, projectID, region, workflow, archiveBucket string) {
+	bucket := gcloud.Runf(t, "storage buckets describe gs://%s", archiveBucket)
+	assert.Equal("COLDLINE", bucket.Get("default_storage_class").String(), "Archive bucket is not Coldline")
+
+	cutoff := fmt.Sprintf("DATE_SUB(CURRENT_DATE(), INTERVAL %d DAY)", retentionDays)
+	query := fmt.Sprintf("SELECT COUNTIF(DATE(created_at) < %[2]s) AS old_rows, COUNT(DISTINCT IF(DATE(created_at) < %[2]s, DATE(created_at), NULL)) AS old_days, "+
+		"COUNT(DISTINCT IF(DATE(created_at) >= %[2]s, DATE(created_at), NULL)) AS hot_days FROM `%[1]s.gcp_primary_staging.thelook_ecommerce_orders`;", projectID, cutoff)
+	expected := bq.Runf(t, "--project_id=%s query --nouse_legacy_sql %s", projectID, query).Get("0")
+	assert.Greater(expected.Get("old_days").Int(), int64(0), "No staged orders are older than %d days", retentionDays)
+
+	execution := gcloud.Runf(t, "workflows run %s --project=%s --location=%s", workflow, projectID, region)
+	if !assert.Equal("SUCCEEDED", execution.Get("state").String(), "%s workflow failed: %s", workflow, execution.Get("error.payload")) {
+		return
+	}
+
+	query = fmt.Sprintf("SELECT COUNTIF(SAFE.PARSE_DATE('%%Y%%m%%d', partition_id) < %[2]s) AS old_partitions, COUNTIF(SAFE.PARSE_DATE('%%Y%%m%%d', partition_id) >= %[2]s) AS hot_partitions "+
+		"FROM `%[1]s.gcp_lakehouse_ds.INFORMATION_SCHEMA.PARTITIONS` WHERE table_name = 'thelook_orders_hot' AND total_rows > 0;", projectID, cutoff)
+	hot := bq.Runf(t, "--project_id=%s query --nouse_legacy_sql %s", projectID, query).Get("0")
+	assert.Equal(int64(0), hot.Get("old_partitions").Int(), "Partitions older than %d days remain in thelook_orders_hot", retentionDays)
+	assert.Equal(expected.Get("hot_days").Int(), hot.Get("hot_partitions").Int(), "Recent partitions were removed from thelook_orders_hot")
+
+	query = fmt.Sprintf("SELECT count(*) AS rows, COUNT(DISTINCT DATE(created_at)) AS days, COUNTIF(DATE(created_at) >= %[2]s) AS recent_rows FROM `%[1]s.gcp_lakehouse_archive.thelook_orders`;", projectID, cutoff)
+	archived := bq.Runf(t, "--project_id=%s query --nouse_legacy_sql %s", projectID, query).Get("0")
+	assert.Equal(expected.Get("old_days").Int(), archived.Get("days").Int(), "Archive does not hold every aged partition")
+	assert.Equal(expected.Get("old_rows").Int(), archived.Get("rows").Int(), "Archive does not hold every aged order exactly once")
+	assert.Equal(int64(0), archived.Get("recent_rows").Int(), "Orders within the retention period were archived")
+}
@@ -0,0 +1,115 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package multiple_buckets
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/GoogleCloudPlatform/cloud-foundation-toolkit/infra/blueprint-test/pkg/gcloud"
+	"github.com/stretchr/testify/assert"
+)
+
+// scaleEnvVar set to "large" runs the scale check, which loads
+// scaleFactorEnvVar times the public sample's users, 10 unless set, through
+// copy-data and a Spark batch. Factors from 10 to 100 are supported.
+const (
+	scaleEnvVar       = "SCALE"
+	scaleFactorEnvVar = "SCALE_FACTOR"
+)
+
+// Extended SLOs of the scale check at 100 times the sample. Smaller factors
+// are held to the same SLOs.
+const (
+	scaleCopySLO  = 30 * time.Minute
+	scaleSparkSLO = 45 * time.Minute
+)
+
+// scaleShards is the number of files generated per table. The three tables
+// then take more than one page of an object listing, so copying them goes
+// through copy-data's paging.
+const scaleShards = 400
+
+// scalePrefix is where generated data is copied to in the tables bucket,
+// apart from the tables the other checks read.
+const scalePrefix = "scale/thelook_ecommerce"
+
+// scaleFactor returns the multiple of the sample to generate, from
+// scaleFactorEnvVar.
+func scaleFactor(t *testing.T) float64 {
+	raw := os.Getenv(scaleFactorEnvVar)
+	if raw == "" {
+		return 10
+	}
+	factor, err := strconv.ParseFloat(raw, 64)
+	if err != nil || factor < 10 || factor > 100 {
+		t.Fatalf("%s must be a number from 10 to 100, got %q", scaleFactorEnvVar, raw)
+	}
+	return factor
+}
+
+// verifyScale generates thelook-like data into a scratch bucket with
+// cmd/datagen, has copy-data copy it into the tables bucket, and runs a Spark
+// batch joining and aggregating it, asserting both finish within their SLOs
+// and no file is lost on the way. Datagen writes CSV or JSON only, so the
+// check is skipped for Parquet runs.
+func verifyScale(t *testing.T, assert *assert.Assertions, projectID, region, suffix, format, tablesBucket, dataprocSA string) {
+	format = strings.ToLower(format)
+	if format != "csv" && format != "json" {
+		t.Logf("not running the scale check: datagen cannot write %s", format)
+		return
+	}
+	factor := scaleFactor(t)
+	users := int(factor * 100000)
+
+	scratch := "gcp-lakehouse-scale-" + suffix
+	gcloud.Runf(t, "storage buckets create gs://%s --project=%s --location=%s --uniform-bucket-level-access", scratch, projectID, region)
+	t.Cleanup(func() { gcloud.RunCmd(t, "storage rm --recursive gs://"+scratch) })
+
+	generate := exec.Command("go", "run", "../cmd/datagen", "-bucket="+scratch, "-prefix="+scalePrefix, "-format="+format,
+		fmt.Sprintf("-users=%d", users), fmt.Sprintf("-shards=%d", scaleShards))
+	generate.Stdout, generate.Stderr = os.Stdout, os.Stderr
+	if !assert.NoError(generate.Run(), "Generating %gx the sample failed", factor) {
+		return
+	}
+	generated := len(gcloud.Runf(t, "storage objects list gs://%s/%s/**", scratch, scalePrefix).Array())
+
+	begin := time.Now()
+	execution := gcloud.Runf(t, "workflows run copy-data --project=%s --location=%s --data={\"prefix\":\"%s\",\"source_bucket\":\"%s\"}", projectID, region, scalePrefix, scratch)
+	copyTime := time.Since(begin)
+	if !assert.Equal("SUCCEEDED", execution.Get("state").String(), "copy-data failed copying %gx the sample: %s", factor, execution.Get("error.payload")) {
+		return
+	}
+	t.Logf("copy-data copied %d files in %s", generated, copyTime.Round(time.Second))
+	assert.Less(copyTime, scaleCopySLO, "copy-data took longer than %s to copy %gx the sample", scaleCopySLO, factor)
+	copied := len(gcloud.Runf(t, "storage objects list gs://%s/%s/**", tablesBucket, scalePrefix).Array())
+	assert.Equal(generated, copied, "copy-data did not copy every generated file")
+
+	gcloud.RunCmd(t, fmt.Sprintf("storage cp testdata/scale_aggregate.py gs://gcp-lakehouse-provisioner-%s/scale_aggregate.py", suffix))
+	begin = time.Now()
+	batch := gcloud.Runf(t, "dataproc batches submit pyspark gs://gcp-lakehouse-provisioner-%[1]s/scale_aggregate.py --batch=scale-aggregate-%[1]s --project=%[2]s --region=%[3]s --subnet=%[4]s --tags=%[5]s --service-account=%[6]s --version=1.1 -- gs://%[7]s/%[8]s %[9]s %[10]d",
+		suffix, projectID, region, dataprocSubnet, dataprocNetworkTag, dataprocSA, tablesBucket, scalePrefix, format, users)
+	sparkTime := time.Since(begin)
+	if !assert.Equal("SUCCEEDED", batch.Get("state").String(), "Spark batch failed over %gx the sample", factor) {
+		return
+	}
+	t.Logf("Spark batch aggregated %gx the sample in %s", factor, sparkTime.Round(time.Second))
+	assert.Less(sparkTime, scaleSparkSLO, "Spark batch took longer than %s over %gx the sample", scaleSparkSLO, factor)
+}
@@ -0,0 +1,45 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package multiple_buckets
+
+import (
+	"fmt"
+	"sort"
+	"testing"
+
+	"github.com/GoogleCloudPlatform/cloud-foundation-toolkit/infra/blueprint-test/pkg/gcloud"
+	"github.com/stretchr/testify/assert"
+)
+
+// Active high-impact misconfigurations reported by Security Command Center.
+const sccFindingsFilter = `state="ACTIVE" AND finding_class="MISCONFIGURATION" AND (severity="HIGH" OR severity="CRITICAL")`
+
+// verifySecurityFindings asserts Security Command Center reports no active
+// HIGH or CRITICAL misconfiguration findings on the test project, which only
+// holds resources created by this run.
+func verifySecurityFindings(t *testing.T, assert *assert.Assertions, projectID string) {
+	cmd := fmt.Sprintf("scc findings list projects/%s --source=-", projectID)
+	results := gcloud.Run(t, cmd, gcloud.WithCommonArgs([]string{"--filter", sccFindingsFilter, "--format", "json"})).Array()
+
+	findings := []string{}
+	for _, result := range results {
+		findings = append(findings, fmt.Sprintf("%s %s on %s",
+			result.Get("finding.severity").String(),
+			result.Get("finding.category").String(),
+			result.Get("finding.resourceName").String()))
+	}
+	sort.Strings(findings)
+	assert.Empty(findings, "Security Command Center reports misconfigurations")
+}
@@ -0,0 +1,57 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package multiple_buckets
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/GoogleCloudPlatform/cloud-foundation-toolkit/infra/blueprint-test/pkg/bq"
+	"github.com/GoogleCloudPlatform/cloud-foundation-toolkit/infra/blueprint-test/pkg/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+// verifySearchIndex asserts the search index over thelook_events becomes
+// ACTIVE with full coverage, and that a SEARCH() lookup of a session uses it
+// and finds exactly the events an equality filter does.
+func verifySearchIndex(t *testing.T, assert *assert.Assertions, projectID, region string) {
+	var status, coverage string
+	verifyIndexed := func() (bool, error) {
+		query := fmt.Sprintf("SELECT index_status, coverage_percentage FROM `%s.gcp_lakehouse_ds.INFORMATION_SCHEMA.SEARCH_INDEXES` WHERE index_name='thelook_events_search';", projectID)
+		op := bq.Runf(t, "--project_id=%s query --nouse_legacy_sql %s", projectID, query)
+		status, coverage = op.Get("0.index_status").String(), op.Get("0.coverage_percentage").String()
+		return status != "ACTIVE" || coverage != "100", nil
+	}
+	err := utils.PollE(t, verifyIndexed, 20, 30*time.Second)
+	if !assert.NoError(err, "Search index is %q with %s%% coverage", status, coverage) {
+		return
+	}
+
+	query := fmt.Sprintf("SELECT session_id FROM `%s.gcp_lakehouse_ds.thelook_events` WHERE session_id IS NOT NULL LIMIT 1;", projectID)
+	session := bq.Runf(t, "--project_id=%s query --nouse_legacy_sql %s", projectID, query).Get("0.session_id").String()
+	query = fmt.Sprintf("SELECT count(*) AS count FROM `%s.gcp_lakehouse_ds.thelook_events` WHERE session_id='%s';", projectID, session)
+	expected := bq.Runf(t, "--project_id=%s query --nouse_legacy_sql %s", projectID, query).Get("0.count").Int()
+
+	api := fmt.Sprintf("https://bigquery.googleapis.com/bigquery/v2/projects/%s/", projectID)
+	search := fmt.Sprintf("SELECT COUNT(*) FROM `%s.gcp_lakehouse_ds.thelook_events` WHERE SEARCH(session_id, '`%s`')", projectID, session)
+	result := callAPI(t, "POST", api+"queries", fmt.Sprintf(`{"query": %q, "useLegacySql": false, "useQueryCache": false, "location": %q, "timeoutMs": 120000}`, search, region))
+	assert.Greater(expected, int64(0), "Session %s has no events", session)
+	assert.Equal(expected, result.Get("rows.0.f.0.v").Int(), "SEARCH() found a different number of events for session %s", session)
+
+	job := callAPI(t, "GET", api+"jobs/"+result.Get("jobReference.jobId").String()+"?location="+region, "")
+	mode := job.Get("statistics.query.searchStatistics.indexUsageMode").String()
+	assert.Equal("FULLY_USED", mode, "SEARCH() did not use the index: %s", job.Get("statistics.query.searchStatistics.indexUnusedReasons"))
+}
@@ -0,0 +1,69 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package multiple_buckets
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/GoogleCloudPlatform/cloud-foundation-toolkit/infra/blueprint-test/pkg/bq"
+	"github.com/GoogleCloudPlatform/cloud-foundation-toolkit/infra/blueprint-test/pkg/gcloud"
+	"github.com/GoogleCloudPlatform/cloud-foundation-toolkit/infra/blueprint-test/pkg/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+// verifyServingExport runs the serving-export workflow and asserts the
+// agg_events table in Cloud SQL then holds as many rows as agg_events_iceberg.
+// Cloud SQL is only reachable through its Admin API here, so the row count is
+// read back with a query export into the serving bucket.
+func verifyServingExport(t *testing.T, assert *assert.Assertions, projectID, region, instance, database, bucket string) {
+	execution := gcloud.Runf(t, "workflows run serving-export --project=%s --location=%s", projectID, region)
+	if !assert.Equal("SUCCEEDED", execution.Get("state").String(), "serving-export workflow failed: %s", execution.Get("error.payload")) {
+		return
+	}
+
+	query := fmt.Sprintf("SELECT count(*) AS count FROM `%s.gcp_lakehouse_ds.agg_events_iceberg`;", projectID)
+	expected := bq.Runf(t, "--project_id=%s query --nouse_legacy_sql %s", projectID, query).Get("0.count").Int()
+
+	api := fmt.Sprintf("https://sqladmin.googleapis.com/v1/projects/%s/", projectID)
+	uri := fmt.Sprintf("gs://%s/verify/agg_events_count_%d.csv", bucket, time.Now().Unix())
+	body := fmt.Sprintf(`{"exportContext": {"fileType": "CSV", "uri": %q, "databases": [%q], "csvExportOptions": {"selectQuery": "SELECT COUNT(*) FROM agg_events"}}}`, uri, database)
+	operation := callAPI(t, "POST", api+"instances/"+instance+"/export", body).Get("name").String()
+
+	verifyExport := func() (bool, error) {
+		op := callAPI(t, "GET", api+"operations/"+operation, "")
+		if op.Get("status").String() != "DONE" {
+			return true, nil
+		}
+		if op.Get("error").Exists() {
+			return false, fmt.Errorf("Cloud SQL export failed: %s", op.Get("error"))
+		}
+		return false, nil
+	}
+	if err := utils.PollE(t, verifyExport, 30, 10*time.Second); !assert.NoError(err) {
+		return
+	}
+
+	out := gcloud.RunCmd(t, "storage cat "+uri, gcloud.WithCommonArgs([]string{}))
+	served, err := strconv.ParseInt(strings.Trim(strings.TrimSpace(out), `"`), 10, 64)
+	if !assert.NoError(err, "Could not parse the Cloud SQL row count %q", out) {
+		return
+	}
+	assert.Greater(expected, int64(0), "agg_events_iceberg is empty")
+	assert.Equal(expected, served, "Cloud SQL agg_events does not match agg_events_iceberg")
+}
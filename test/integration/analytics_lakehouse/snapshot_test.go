@@ -0,0 +1,51 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package multiple_buckets
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/GoogleCloudPlatform/cloud-foundation-toolkit/infra/blueprint-test/pkg/bq"
+	"github.com/stretchr/testify/assert"
+)
+
+// verifySnapshotRestore asserts the project-setup workflow snapshotted the
+// orders, and that restoring the snapshot with the restore_snapshot procedure
+// into a scratch dataset reproduces its row count. The scratch dataset is
+// removed afterwards.
+func verifySnapshotRestore(t *testing.T, assert *assert.Assertions, projectID, region string) {
+	query := fmt.Sprintf("SELECT table_name FROM `%s.gcp_lakehouse_snapshots.INFORMATION_SCHEMA.TABLE_SNAPSHOTS` WHERE base_table_name='thelook_orders' ORDER BY snapshot_time DESC LIMIT 1;", projectID)
+	snapshot := bq.Runf(t, "--project_id=%s query --nouse_legacy_sql %s", projectID, query).Get("0.table_name").String()
+	if !assert.NotEmpty(snapshot, "project-setup did not snapshot thelook_orders") {
+		return
+	}
+
+	api := fmt.Sprintf("https://bigquery.googleapis.com/bigquery/v2/projects/%s/", projectID)
+	scratch := fmt.Sprintf("scratch_restore_%d", time.Now().Unix())
+	callAPI(t, "POST", api+"datasets", fmt.Sprintf(`{"datasetReference": {"datasetId": %q}, "location": %q}`, scratch, region))
+
+	restore := fmt.Sprintf("CALL `%[1]s.gcp_lakehouse_ds.restore_snapshot`('%[1]s.gcp_lakehouse_snapshots.%[2]s', '%[1]s.%[3]s.thelook_orders')", projectID, snapshot, scratch)
+	callAPI(t, "POST", api+"queries", fmt.Sprintf(`{"query": %q, "useLegacySql": false, "timeoutMs": 120000}`, restore))
+
+	query = fmt.Sprintf("SELECT (SELECT count(*) FROM `%[1]s.%[2]s.thelook_orders`) AS restored, (SELECT count(*) FROM `%[1]s.gcp_lakehouse_snapshots.%[3]s`) AS snapshotted;", projectID, scratch, snapshot)
+	op := bq.Runf(t, "--project_id=%s query --nouse_legacy_sql %s", projectID, query)
+	assert.Greater(op.Get("0.snapshotted").Int(), int64(0), "Snapshot %s is empty", snapshot)
+	assert.Equal(op.Get("0.snapshotted").Int(), op.Get("0.restored").Int(), "Restored table does not match snapshot %s", snapshot)
+
+	assert.Equal(http.StatusNoContent, callAPIStatus(t, accessToken(t), "DELETE", api+"datasets/"+scratch+"?deleteContents=true", ""), "Could not remove the scratch dataset")
+}
@@ -0,0 +1,78 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package multiple_buckets
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/GoogleCloudPlatform/cloud-foundation-toolkit/infra/blueprint-test/pkg/bq"
+	"github.com/GoogleCloudPlatform/cloud-foundation-toolkit/infra/blueprint-test/pkg/gcloud"
+	"github.com/GoogleCloudPlatform/cloud-foundation-toolkit/infra/blueprint-test/pkg/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+// Number of test events published to the streaming topic.
+const streamingEvents = 5
+
+// publishEvents publishes streamingEvents test events to topic, tagged with a
+// new run ID and the given source attribute, and returns the run ID.
+func publishEvents(t *testing.T, topic, source string) string {
+	run := fmt.Sprintf("it-%d", time.Now().UnixNano())
+	for i := 0; i < streamingEvents; i++ {
+		gcloud.Runf(t, "pubsub topics publish %s --message={\"run\":\"%s\",\"seq\":%d} --attribute=source=%s", topic, run, i, source)
+	}
+	return run
+}
+
+// verifyStreamingIngestion publishes test events to the streaming topic and
+// asserts they become queryable in the raw zone within five minutes.
+func verifyStreamingIngestion(t *testing.T, assert *assert.Assertions, projectID, topic string) {
+	run := publishEvents(t, topic, "integration-test")
+
+	query := fmt.Sprintf("SELECT count(*) AS count FROM `%s.gcp_primary_raw.events_stream` WHERE JSON_VALUE(data,'$.run')='%s';", projectID, run)
+	count := int64(0)
+	verifyRows := func() (bool, error) {
+		count = bq.Runf(t, "--project_id=%s query --nouse_legacy_sql %s", projectID, query).Get("0.count").Int()
+		return count < streamingEvents, nil
+	}
+	utils.Poll(t, verifyRows, 20, 15*time.Second)
+	assert.Equal(int64(streamingEvents), count, "Streamed events are not queryable in gcp_primary_raw.events_stream")
+}
+
+// verifyContinuousQuery asserts the project-setup workflow's continuous query
+// is running, and that events published afterwards reach the per-minute
+// aggregate without any batch job being started.
+func verifyContinuousQuery(t *testing.T, assert *assert.Assertions, projectID, topic string) {
+	jobs := bq.Runf(t, "--project_id=%s ls --jobs --all_users --max_results=1000", projectID)
+	running := jobs.Get(`#(configuration.query.continuous==true)#|#(status.state=="RUNNING")#`).Array()
+	assert.NotEmpty(running, "No continuous query is running")
+
+	source := "continuous-query-test"
+	run := publishEvents(t, topic, source)
+	query := fmt.Sprintf("SELECT count(*) AS count FROM `%s.gcp_lakehouse_ds.events_by_source` WHERE run='%s';", projectID, run)
+	count := int64(0)
+	verifyRows := func() (bool, error) {
+		count = bq.Runf(t, "--project_id=%s query --nouse_legacy_sql %s", projectID, query).Get("0.count").Int()
+		return count < streamingEvents, nil
+	}
+	utils.Poll(t, verifyRows, 20, 15*time.Second)
+	assert.Equal(int64(streamingEvents), count, "Continuous query did not append the published events")
+
+	query = fmt.Sprintf("SELECT SUM(events) AS events FROM `%s.gcp_lakehouse_ds.events_per_minute` WHERE source='%s';", projectID, source)
+	events := bq.Runf(t, "--project_id=%s query --nouse_legacy_sql %s", projectID, query).Get("0.events").Int()
+	assert.GreaterOrEqual(events, int64(streamingEvents), "events_per_minute does not include the published events")
+}
@@ -0,0 +1,55 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package multiple_buckets
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/GoogleCloudPlatform/cloud-foundation-toolkit/infra/blueprint-test/pkg/gcloud"
+	"github.com/GoogleCloudPlatform/cloud-foundation-toolkit/infra/blueprint-test/pkg/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+// Secure tags the example binds, as key short name to value short name.
+var resourceTags = map[string]string{
+	"environment": "demo",
+}
+
+// verifyTagBindings asserts every resource tag is bound to the project and
+// to each of the blueprint's buckets.
+func verifyTagBindings(t *testing.T, assert *assert.Assertions, projectID, region, suffix string) {
+	projectNumber := gcloud.Runf(t, "projects describe %s", projectID).Get("projectNumber").String()
+	parents := map[string]string{
+		"//cloudresourcemanager.googleapis.com/projects/" + projectNumber: "",
+	}
+	for _, bucket := range gcloud.Runf(t, "storage buckets list --project=%s", projectID).Array() {
+		if name := bucket.Get("name").String(); strings.HasSuffix(name, "-"+suffix) {
+			parents["//storage.googleapis.com/projects/_/buckets/"+name] = region
+		}
+	}
+
+	for parent, location := range parents {
+		cmd := fmt.Sprintf("resource-manager tags bindings list --parent=%s --effective", parent)
+		if location != "" {
+			cmd += " --location=" + location
+		}
+		bound := utils.GetResultStrSlice(gcloud.Runf(t, cmd).Get("#.namespacedTagValue").Array())
+		for key, value := range resourceTags {
+			assert.Contains(bound, fmt.Sprintf("%s/%s/%s", projectID, key, value), "%s is not tagged %s=%s", parent, key, value)
+		}
+	}
+}
@@ -0,0 +1,51 @@
+-- Copyright 2023 Google LLC
+--
+-- Licensed under the Apache License, Version 2.0 (the "License");
+-- you may not use this file except in compliance with the License.
+-- You may obtain a copy of the License at
+--
+--      http://www.apache.org/licenses/LICENSE-2.0
+--
+-- Unless required by applicable law or agreed to in writing, software
+-- distributed under the License is distributed on an "AS IS" BASIS,
+-- WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+-- See the License for the specific language governing permissions and
+-- limitations under the License.
+
+-- The queries one refresh of the Looker Studio report over view_ecommerce
+-- issues, one per chart, in the shape Looker Studio generates them. Each
+-- query ends with a semicolon and is preceded by a comment naming its chart.
+
+-- Scorecards
+SELECT SUM(order_items_sale_price) AS revenue, COUNT(DISTINCT order_id) AS orders, COUNT(DISTINCT user_id) AS customers
+FROM (SELECT * FROM `gcp_lakehouse_ds.view_ecommerce`) AS t0;
+
+-- Revenue by month
+SELECT DATE_TRUNC(DATE(order_created_at), MONTH) AS month, SUM(order_items_sale_price) AS revenue
+FROM (SELECT * FROM `gcp_lakehouse_ds.view_ecommerce`) AS t0
+GROUP BY month ORDER BY month ASC LIMIT 2000;
+
+-- Revenue by product category
+SELECT product_category, SUM(order_items_sale_price) AS revenue, SUM(order_items_sale_price - product_cost) AS margin
+FROM (SELECT * FROM `gcp_lakehouse_ds.view_ecommerce`) AS t0
+GROUP BY product_category ORDER BY revenue DESC LIMIT 20;
+
+-- Orders by country
+SELECT user_country, COUNT(DISTINCT order_id) AS orders
+FROM (SELECT * FROM `gcp_lakehouse_ds.view_ecommerce`) AS t0
+GROUP BY user_country ORDER BY orders DESC LIMIT 500;
+
+-- Traffic sources
+SELECT user_traffic_source, COUNT(DISTINCT user_id) AS customers
+FROM (SELECT * FROM `gcp_lakehouse_ds.view_ecommerce`) AS t0
+GROUP BY user_traffic_source ORDER BY customers DESC LIMIT 10;
+
+-- Order status
+SELECT order_status, COUNT(DISTINCT order_id) AS orders
+FROM (SELECT * FROM `gcp_lakehouse_ds.view_ecommerce`) AS t0
+GROUP BY order_status ORDER BY orders DESC LIMIT 10;
+
+-- Top brands
+SELECT product_brand, dist_center_name, SUM(order_items_sale_price) AS revenue, COUNT(*) AS items
+FROM (SELECT * FROM `gcp_lakehouse_ds.view_ecommerce`) AS t0
+GROUP BY product_brand, dist_center_name ORDER BY revenue DESC LIMIT 100;
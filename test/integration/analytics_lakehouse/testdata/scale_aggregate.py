@@ -0,0 +1,49 @@
+#!/usr/bin/python
+# Copyright 2023 Google LLC
+#
+# Licensed under the Apache License, Version 2.0 (the "License");
+# you may not use this file except in compliance with the License.
+# You may obtain a copy of the License at
+#
+#      http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+
+"""Aggregates generated thelook events per day and country for scale tests.
+
+Usage: scale_aggregate.py <gs:// prefix of the tables> <csv|json> <users>
+"""
+import sys
+
+from pyspark.sql import SparkSession
+from pyspark.sql import functions as F
+
+prefix, fmt, users = sys.argv[1], sys.argv[2], int(sys.argv[3])
+
+spark = SparkSession \
+    .builder \
+    .appName("scale-aggregate") \
+    .getOrCreate()
+
+
+def read(table):
+    reader = spark.read
+    if fmt == "csv":
+        reader = reader.option("header", True).option("inferSchema", True)
+    return reader.format(fmt).load(f"{prefix}/{table}/")
+
+
+user_rows = read("users")
+# Fails the batch if the copy lost or duplicated files
+if user_rows.count() != users:
+    raise SystemExit(f"expected {users} users, read {user_rows.count()}")
+
+daily = read("events") \
+    .join(user_rows.select(F.col("id").alias("user_id"), "country"), "user_id") \
+    .groupBy(F.to_date("created_at").alias("day"), "country") \
+    .agg(F.count("*").alias("events"), F.countDistinct("session_id").alias("sessions"))
+print(f"{daily.count()} day and country aggregates")
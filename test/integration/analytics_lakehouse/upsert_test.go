@@ -0,0 +1,59 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package multiple_buckets
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/GoogleCloudPlatform/cloud-foundation-toolkit/infra/blueprint-test/pkg/bq"
+	"github.com/stretchr/testify/assert"
+)
+
+// verifyUpsertIdempotency loads the staging orders into a scratch table with
+// the upsert_table procedure in two overlapping batches, the second holding
+// duplicate keys and loaded twice, and asserts the result has exactly one row
+// per order. The scratch dataset is removed afterwards.
+func verifyUpsertIdempotency(t *testing.T, assert *assert.Assertions, projectID, region string) {
+	api := fmt.Sprintf("https://bigquery.googleapis.com/bigquery/v2/projects/%s/", projectID)
+	scratch := fmt.Sprintf("scratch_upsert_%d", time.Now().Unix())
+	callAPI(t, "POST", api+"datasets", fmt.Sprintf(`{"datasetReference": {"datasetId": %q}, "location": %q}`, scratch, region))
+
+	// Batch 1 holds orders with order_id % 3 in (1, 2), batch 2 those in (0, 2)
+	// with the overlapping orders repeated. Batch 2 is loaded twice.
+	dataset := projectID + "." + scratch
+	staging := fmt.Sprintf("`%s.gcp_primary_staging.thelook_ecommerce_orders`", projectID)
+	statements := []string{
+		fmt.Sprintf("CREATE TABLE `%s.batch_1` AS SELECT * FROM %s WHERE MOD(order_id, 3) != 0", dataset, staging),
+		fmt.Sprintf("CREATE TABLE `%[1]s.batch_2` AS SELECT * FROM %[2]s WHERE MOD(order_id, 3) != 1 UNION ALL SELECT * FROM %[2]s WHERE MOD(order_id, 3) = 2", dataset, staging),
+	}
+	for _, batch := range []string{"batch_1", "batch_2", "batch_2"} {
+		statements = append(statements, fmt.Sprintf("CALL `%s.gcp_lakehouse_ds.upsert_table`('%s.%s', '%s.orders', ['order_id'])", projectID, dataset, batch, dataset))
+	}
+	script := strings.Join(statements, ";\n")
+	result := callAPI(t, "POST", api+"queries", fmt.Sprintf(`{"query": %q, "useLegacySql": false, "location": %q, "timeoutMs": 300000}`, script, region))
+	assert.True(result.Get("jobComplete").Bool(), "Upsert script did not complete")
+
+	query := fmt.Sprintf("SELECT (SELECT count(*) FROM `%[1]s.%[2]s.orders`) AS loaded, (SELECT count(DISTINCT order_id) FROM `%[1]s.%[2]s.orders`) AS keys, (SELECT count(DISTINCT order_id) FROM `%[1]s.gcp_primary_staging.thelook_ecommerce_orders`) AS expected;", projectID, scratch)
+	op := bq.Runf(t, "--project_id=%s query --nouse_legacy_sql %s", projectID, query)
+	assert.Greater(op.Get("0.expected").Int(), int64(0), "Staging orders are empty")
+	assert.Equal(op.Get("0.keys").Int(), op.Get("0.loaded").Int(), "Upserts left duplicate order_id keys")
+	assert.Equal(op.Get("0.expected").Int(), op.Get("0.keys").Int(), "Upserts did not load every order exactly once")
+
+	assert.Equal(http.StatusNoContent, callAPIStatus(t, accessToken(t), "DELETE", api+"datasets/"+scratch+"?deleteContents=true", ""), "Could not remove the scratch dataset")
+}
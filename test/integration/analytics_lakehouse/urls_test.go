@@ -0,0 +1,87 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package multiple_buckets
+
+import (
+	"net/url"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// colabNotebookPrefix is the path of the notebooks in this repository on
+// Colab, up to the branch.
+const colabNotebookPrefix = "/github/GoogleCloudPlatform/terraform-google-analytics-lakehouse/blob/"
+
+// templateLeftovers appear in a URL whose templating went wrong, such as an
+// interpolation left unexpanded or a null variable.
+var templateLeftovers = []string{"${", "%{", "%24%7B", "<nil>", "null", "undefined"}
+
+// parseOutputURL parses the URL output name, asserting it is an HTTPS URL on
+// host with no templating leftovers or empty query parameters. It returns
+// nil when the URL does not parse.
+func parseOutputURL(assert *assert.Assertions, name, raw, host string) *url.URL {
+	for _, leftover := range templateLeftovers {
+		assert.NotContains(raw, leftover, "Output %s has a templating leftover", name)
+	}
+	u, err := url.Parse(raw)
+	if !assert.NoError(err, "Output %s does not parse", name) {
+		return nil
+	}
+	assert.Equal("https", u.Scheme, "Output %s is not HTTPS", name)
+	assert.Equal(host, u.Host, "Output %s has an unexpected host", name)
+	assert.NotContains(u.Path, "//", "Output %s has an empty path segment", name)
+
+	params, err := url.ParseQuery(u.RawQuery)
+	if !assert.NoError(err, "Output %s has a malformed query", name) {
+		return u
+	}
+	for param, values := range params {
+		for _, v := range values {
+			assert.NotEmpty(v, "Output %s has an empty %s parameter", name, param)
+		}
+	}
+	return u
+}
+
+// verifyOutputURLs asserts the URL outputs link to the right console pages
+// of this project, and the Colab URL opens a notebook that exists in this
+// repository. The Looker Studio URL's data source is checked by
+// verifyLookerStudioURL.
+func verifyOutputURLs(assert *assert.Assertions, projectID string, urls map[string]string) {
+	if u := parseOutputURL(assert, "bigquery_editor_url", urls["bigquery_editor_url"], "console.cloud.google.com"); u != nil {
+		assert.Equal("/bigquery", u.Path, "BigQuery editor URL does not open BigQuery")
+		assert.Equal(projectID, u.Query().Get("project"), "BigQuery editor URL opens another project")
+	}
+
+	if u := parseOutputURL(assert, "neos_tutorial_url", urls["neos_tutorial_url"], "console.cloud.google.com"); u != nil {
+		assert.Equal("/products/solutions/deployments", u.Path, "Tutorial URL does not open the solution deployments")
+		assert.NotEmpty(u.Query().Get("walkthrough_id"), "Tutorial URL has no walkthrough")
+	}
+
+	if u := parseOutputURL(assert, "lakehouse_colab_url", urls["lakehouse_colab_url"], "colab.research.google.com"); u != nil {
+		if assert.True(strings.HasPrefix(u.Path, colabNotebookPrefix), "Colab URL does not open a notebook of this repository") {
+			// The path continues with the branch, then the notebook's path in the repository
+			_, notebook, ok := strings.Cut(strings.TrimPrefix(u.Path, colabNotebookPrefix), "/")
+			assert.True(ok, "Colab URL has no notebook path")
+			assert.Equal(".ipynb", path.Ext(notebook), "Colab URL does not open a notebook")
+			assert.FileExists(filepath.Join("..", "..", "..", filepath.FromSlash(notebook)), "Colab URL opens a notebook missing from the repository")
+		}
+	}
+
+	parseOutputURL(assert, "lookerstudio_report_url", urls["lookerstudio_report_url"], "lookerstudio.google.com")
+}
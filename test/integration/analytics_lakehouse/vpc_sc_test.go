@@ -0,0 +1,52 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package multiple_buckets
+
+import (
+	"fmt"
+	"sort"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// Dry-run VPC Service Controls violations are written to the policy audit
+// log with dryRun set in their metadata.
+const vpcSCDryRunFilter = `logName:"cloudaudit.googleapis.com%2Fpolicy" AND ` +
+	`protoPayload.metadata."@type"="type.googleapis.com/google.cloud.audit.VpcServiceControlAuditMetadata" AND ` +
+	`protoPayload.metadata.dryRun=true`
+
+// verifyNoPerimeterViolations asserts the dry-run perimeter created by
+// test/setup logged no violations while the blueprint was applied and its
+// workflows ran. Each violating API call is reported once so it can be fixed
+// before the blueprint is deployed inside an enforced perimeter.
+func verifyNoPerimeterViolations(t *testing.T, assert *assert.Assertions, projectID, perimeter string) {
+	entries := readLogs(t, projectID, vpcSCDryRunFilter, 1000)
+
+	calls := map[string]bool{}
+	for _, entry := range entries {
+		call := fmt.Sprintf("%s %s (%s)",
+			entry.Get("protoPayload.serviceName").String(),
+			entry.Get("protoPayload.methodName").String(),
+			entry.Get("protoPayload.metadata.violationReason").String())
+		calls[call] = true
+	}
+	violations := []string{}
+	for call := range calls {
+		violations = append(violations, call)
+	}
+	sort.Strings(violations)
+	assert.Empty(violations, "API calls would be blocked by perimeter %s", perimeter)
+}
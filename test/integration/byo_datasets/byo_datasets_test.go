@@ -0,0 +1,206 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package byo_datasets
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/GoogleCloudPlatform/cloud-foundation-toolkit/infra/blueprint-test/pkg/bq"
+	"github.com/GoogleCloudPlatform/cloud-foundation-toolkit/infra/blueprint-test/pkg/gcloud"
+	"github.com/stretchr/testify/assert"
+	"github.com/terraform-google-modules/terraform-google-analytics-lakehouse/test/integration/testutils"
+	"github.com/tidwall/gjson"
+)
+
+const (
+	// byoDataset is the dataset the module reuses, which must have the name
+	// it would have created.
+	byoDataset = "gcp_lakehouse_ds"
+	// sentinel names a table and objects created alongside the resources, so
+	// emptying them on destroy fails the test as well as deleting them.
+	sentinel = "byo_sentinel"
+	// createdFile, in the fixture directory, holds the creation times
+	// recorded before apply, as cft may run each stage in its own process.
+	createdFile = "created.json"
+)
+
+// byoResources are the dataset and buckets pre-provisioned in a project,
+// named as in test/fixtures/byo_datasets.
+type byoResources struct {
+	projectID, region             string
+	tablesBucket, warehouseBucket string
+}
+
+func newBYOResources(projectID, region string) byoResources {
+	return byoResources{
+		projectID:       projectID,
+		region:          region,
+		tablesBucket:    "lakehouse-byo-tables-" + projectID,
+		warehouseBucket: "lakehouse-byo-warehouse-" + projectID,
+	}
+}
+
+func (r byoResources) buckets() []string {
+	return []string{r.tablesBucket, r.warehouseBucket}
+}
+
+// provision creates the dataset and buckets, each with a sentinel, unless a
+// run that did not reach teardown left them behind.
+func (r byoResources) provision(t *testing.T) {
+	if _, err := bq.RunCmdE(t, fmt.Sprintf("--project_id=%s show %s", r.projectID, byoDataset)); err != nil {
+		bq.RunCmd(t, fmt.Sprintf("--project_id=%s --location=%s mk --dataset %s:%s", r.projectID, r.region, r.projectID, byoDataset))
+		bq.RunCmd(t, fmt.Sprintf("--project_id=%s mk --table %s.%s id:STRING", r.projectID, byoDataset, sentinel))
+	}
+
+	object := filepath.Join(t.TempDir(), sentinel)
+	if err := os.WriteFile(object, []byte(sentinel), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	for _, bucket := range r.buckets() {
+		if _, err := gcloud.RunCmdE(t, "storage buckets describe gs://"+bucket); err == nil {
+			continue
+		}
+		gcloud.RunCmd(t, fmt.Sprintf("storage buckets create gs://%s --project=%s --location=%s --uniform-bucket-level-access", bucket, r.projectID, r.region))
+		gcloud.RunCmd(t, fmt.Sprintf("storage cp %s gs://%s/%s", object, bucket, sentinel))
+	}
+}
+
+// creationTimes returns when the dataset and buckets were created, by name,
+// empty for those that do not exist.
+func (r byoResources) creationTimes(t *testing.T) map[string]string {
+	dataset, _ := bq.RunCmdE(t, fmt.Sprintf("--project_id=%s show %s", r.projectID, byoDataset))
+	times := map[string]string{byoDataset: gjson.Get(dataset, "creationTime").String()}
+	for _, bucket := range r.buckets() {
+		out, _ := gcloud.RunCmdE(t, "storage buckets describe gs://"+bucket)
+		times[bucket] = gjson.Get(out, "creation_time").String()
+	}
+	return times
+}
+
+// saveCreationTimes records the creation times of the dataset and buckets
+// in tfDir, for the later stages to compare against.
+func (r byoResources) saveCreationTimes(t *testing.T, tfDir string) {
+	data, err := json.Marshal(r.creationTimes(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(tfDir, createdFile), data, 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// savedCreationTimes returns the creation times saveCreationTimes recorded
+// in tfDir.
+func savedCreationTimes(t *testing.T, tfDir string) map[string]string {
+	data, err := os.ReadFile(filepath.Join(tfDir, createdFile))
+	if err != nil {
+		t.Fatalf("reading the creation times recorded before apply: %v", err)
+	}
+	created := map[string]string{}
+	if err := json.Unmarshal(data, &created); err != nil {
+		t.Fatal(err)
+	}
+	return created
+}
+
+// verifyKept asserts the dataset and buckets still have the creation times
+// recorded before apply, and still hold their sentinels.
+func (r byoResources) verifyKept(t *testing.T, assert *assert.Assertions, created map[string]string) {
+	for name, createdAt := range r.creationTimes(t) {
+		assert.NotEmpty(createdAt, "%s no longer exists", name)
+		assert.Equal(created[name], createdAt, "%s was recreated", name)
+	}
+	_, err := bq.RunCmdE(t, fmt.Sprintf("--project_id=%s show %s.%s", r.projectID, byoDataset, sentinel))
+	assert.NoError(err, "The sentinel table in %s was deleted", byoDataset)
+	for _, bucket := range r.buckets() {
+		_, err := gcloud.RunCmdE(t, fmt.Sprintf("storage objects describe gs://%s/%s", bucket, sentinel))
+		assert.NoError(err, "The sentinel object in %s was deleted", bucket)
+	}
+}
+
+// remove deletes the dataset and buckets with what the deployment left in
+// them, so the next fixture gets a clean project.
+func (r byoResources) remove(t *testing.T) {
+	bq.RunCmd(t, fmt.Sprintf("--project_id=%s rm -r -f -d %s:%s", r.projectID, r.projectID, byoDataset))
+	for _, bucket := range r.buckets() {
+		gcloud.RunCmd(t, "storage rm --recursive gs://"+bucket)
+	}
+}
+
+// TestBYODatasets deploys the module into a dataset and buckets created
+// before apply. It checks the module uses them without taking them over:
+// they are neither in the state nor recreated, and outlive destroy.
+func TestBYODatasets(t *testing.T) {
+	tfDir := filepath.Join("..", "..", "fixtures", "byo_datasets")
+	byo := testutils.NewExampleTest(t, tfDir, "byo_datasets")
+
+	resources := func() byoResources {
+		return newBYOResources(byo.ProjectID(), byo.Region())
+	}
+
+	byo.BeforeApply(func(assert *assert.Assertions) {
+		byo.Timer.Time("provision", func() {
+			r := resources()
+			r.provision(t)
+			r.saveCreationTimes(t, tfDir)
+		})
+	})
+
+	byo.Verify(func(assert *assert.Assertions) {
+		r := resources()
+		assert.Equal(byoDataset, byo.GetStringOutput("lakehouse_dataset_id"), "Module is not using the existing dataset")
+		assert.Equal(r.tablesBucket, byo.GetStringOutput("tables_bucket"), "Module is not using the existing tables bucket")
+		assert.Equal(r.warehouseBucket, byo.GetStringOutput("warehouse_bucket"), "Module is not using the existing warehouse bucket")
+
+		// Assert the module manages neither the dataset nor the buckets
+		state, err := testutils.StateResources(tfDir)
+		if assert.NoError(err) {
+			for address, resource := range state {
+				switch resource.Get("type").String() {
+				case "google_bigquery_dataset":
+					assert.NotEqual(byoDataset, resource.Get("values.dataset_id").String(), "%s manages the existing dataset", address)
+				case "google_storage_bucket":
+					assert.NotContains(r.buckets(), resource.Get("values.name").String(), "%s manages an existing bucket", address)
+				}
+			}
+		}
+
+		// Assert the workflows load the data into them
+		testutils.WaitForWorkflow(t, r.projectID, "copy-data")
+		testutils.WaitForWorkflow(t, r.projectID, "project-setup")
+		assert.NotEmpty(gcloud.Runf(t, "storage objects list gs://%s/thelook_ecommerce/**", r.tablesBucket).Array(), "thelook tables were not copied to the existing bucket")
+		assert.NotEmpty(gcloud.Runf(t, "storage objects list gs://%s/**/metadata/*.json", r.warehouseBucket).Array(), "No Iceberg table was written to the existing warehouse bucket")
+		query := fmt.Sprintf("SELECT count(*) AS count FROM `%s.%s.view_ecommerce`;", r.projectID, byoDataset)
+		count := bq.Runf(t, "--project_id=%s query --nouse_legacy_sql %s", r.projectID, query).Get("0.count").Int()
+		assert.Greater(count, int64(0), "view_ecommerce is empty")
+
+		r.verifyKept(t, assert, savedCreationTimes(t, tfDir))
+	})
+
+	// Assert destroy left the dataset and buckets, then remove them
+	byo.AfterTeardown(func(assert *assert.Assertions) {
+		r := resources()
+		r.verifyKept(t, assert, savedCreationTimes(t, tfDir))
+		r.remove(t)
+		if err := os.Remove(filepath.Join(tfDir, createdFile)); err != nil {
+			t.Error(err)
+		}
+	})
+	byo.Test()
+}
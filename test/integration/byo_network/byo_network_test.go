@@ -0,0 +1,52 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package byo_network
+
+import (
+	"testing"
+
+	"github.com/GoogleCloudPlatform/cloud-foundation-toolkit/infra/blueprint-test/pkg/gcloud"
+	"github.com/GoogleCloudPlatform/cloud-foundation-toolkit/infra/blueprint-test/pkg/utils"
+	"github.com/stretchr/testify/assert"
+	"github.com/terraform-google-modules/terraform-google-analytics-lakehouse/test/integration/testutils"
+)
+
+func TestBYONetwork(t *testing.T) {
+	byo := testutils.NewExampleTest(t, "", "byo_network")
+
+	byo.Verify(func(assert *assert.Assertions) {
+		projectID := byo.ProjectID()
+		region := byo.Region()
+		subnet := byo.GetStringOutput("subnetwork_self_link")
+
+		assert.Equal(subnet, byo.GetStringOutput("dataproc_subnetwork"), "Module is not using the existing subnet")
+
+		// Assert the module created no network or firewall rule of its own
+		networks := utils.GetResultStrSlice(gcloud.Runf(t, "compute networks list --project=%s", projectID).Get("#.name").Array())
+		assert.Equal([]string{"vpc-byo"}, networks, "Unexpected networks in the project")
+		rules := utils.GetResultStrSlice(gcloud.Runf(t, "compute firewall-rules list --project=%s", projectID).Get("#.name").Array())
+		assert.Equal([]string{"byo-internal"}, rules, "Unexpected firewall rules in the project")
+
+		// Assert Google APIs are routed through the restricted VIP
+		testutils.VerifyRestrictedAPIAccess(t, assert, projectID, "vpc-byo")
+
+		// Assert the serverless Spark batch could start on the existing subnet
+		testutils.WaitForWorkflow(t, projectID, "project-setup")
+
+		// Assert the PHS and serverless Spark batches run on the existing subnet
+		testutils.VerifyDataprocSubnet(t, assert, projectID, region, subnet)
+	})
+	byo.Test()
+}
@@ -0,0 +1,136 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	cloudbilling "google.golang.org/api/cloudbilling/v1"
+)
+
+// catalog prices usage with the public list prices of the Cloud Billing
+// Catalog API, in USD.
+type catalog struct {
+	ctx      context.Context
+	api      *cloudbilling.APIService
+	services []*cloudbilling.Service
+	skus     map[string][]*cloudbilling.Sku
+}
+
+func newCatalog(ctx context.Context) (*catalog, error) {
+	api, err := cloudbilling.NewService(ctx)
+	if err != nil {
+		return nil, err
+	}
+	c := &catalog{ctx: ctx, api: api, skus: map[string][]*cloudbilling.Sku{}}
+	err = api.Services.List().Pages(ctx, func(resp *cloudbilling.ListServicesResponse) error {
+		c.services = append(c.services, resp.Services...)
+		return nil
+	})
+	return c, err
+}
+
+// serviceSkus returns the SKUs of the first service whose display name
+// matches u, listing them once per service.
+func (c *catalog) serviceSkus(u usage) ([]*cloudbilling.Sku, error) {
+	for _, s := range c.services {
+		if !u.service.MatchString(s.DisplayName) {
+			continue
+		}
+		if skus, ok := c.skus[s.Name]; ok {
+			return skus, nil
+		}
+		skus := []*cloudbilling.Sku{}
+		err := c.api.Services.Skus.List(s.Name).CurrencyCode("USD").Pages(c.ctx, func(resp *cloudbilling.ListSkusResponse) error {
+			skus = append(skus, resp.Skus...)
+			return nil
+		})
+		if err != nil {
+			return nil, fmt.Errorf("listing the SKUs of %s: %v", s.DisplayName, err)
+		}
+		c.skus[s.Name] = skus
+		return skus, nil
+	}
+	return nil, fmt.Errorf("no Cloud Billing service matches %s", u.service)
+}
+
+// price returns the most expensive SKU matching u in its region, or globally
+// when the SKU is not regional. Tiers are ignored, pricing the whole usage at
+// the highest tier price.
+func (c *catalog) price(u usage) (price, error) {
+	skus, err := c.serviceSkus(u)
+	if err != nil {
+		return price{}, err
+	}
+	best := price{}
+	for _, sku := range skus {
+		if !u.sku.MatchString(sku.Description) || (u.exclude != nil && u.exclude.MatchString(sku.Description)) {
+			continue
+		}
+		if !inRegion(sku.ServiceRegions, u.region) || len(sku.PricingInfo) == 0 {
+			continue
+		}
+		expression := sku.PricingInfo[0].PricingExpression
+		monthly, err := monthlyUnitPrice(expression, u.gib)
+		if err != nil {
+			return price{}, fmt.Errorf("SKU %s (%s): %v", sku.SkuId, sku.Description, err)
+		}
+		if monthly > best.monthly || best.sku == "" {
+			best = price{sku: sku.Description, monthly: monthly}
+		}
+	}
+	if best.sku == "" {
+		return price{}, fmt.Errorf("no SKU of %s matching %s in %s", u.service, u.sku, u.region)
+	}
+	return best, nil
+}
+
+func inRegion(regions []string, region string) bool {
+	for _, r := range regions {
+		if strings.EqualFold(r, region) || r == "global" {
+			return true
+		}
+	}
+	return false
+}
+
+// monthlyUnitPrice converts the price of a SKU to the price of running one
+// unit, or one GiB when gib is set, for a month.
+func monthlyUnitPrice(expression *cloudbilling.PricingExpression, gib bool) (float64, error) {
+	unitPrice := 0.0
+	for _, rate := range expression.TieredRates {
+		if rate.UnitPrice == nil {
+			continue
+		}
+		p := float64(rate.UnitPrice.Units) + float64(rate.UnitPrice.Nanos)/1e9
+		if p > unitPrice {
+			unitPrice = p
+		}
+	}
+
+	unit := expression.UsageUnit
+	if strings.HasPrefix(unit, "GiBy") != gib {
+		return 0, fmt.Errorf("unexpected usage unit %s", unit)
+	}
+	switch strings.TrimPrefix(strings.TrimPrefix(unit, "GiBy"), ".") {
+	case "h":
+		return unitPrice * hoursPerMonth, nil
+	case "mo":
+		return unitPrice, nil
+	}
+	return 0, fmt.Errorf("unexpected usage unit %s", unit)
+}
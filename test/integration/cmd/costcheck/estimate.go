@@ -0,0 +1,326 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// hoursPerMonth is the number of hours Cloud Billing prices a month at.
+const hoursPerMonth = 730
+
+// resource is a resource the plan creates or keeps, with its planned values.
+type resource struct {
+	address string
+	typ     string
+	values  map[string]interface{}
+}
+
+// parsePlan returns the resources that exist after applying the plan given
+// as `terraform show -json` output. Resources the plan only deletes are left
+// out.
+func parsePlan(data []byte) ([]resource, error) {
+	var plan struct {
+		ResourceChanges []struct {
+			Address string `json:"address"`
+			Mode    string `json:"mode"`
+			Type    string `json:"type"`
+			Change  struct {
+				Actions []string               `json:"actions"`
+				After   map[string]interface{} `json:"after"`
+			} `json:"change"`
+		} `json:"resource_changes"`
+	}
+	if err := json.Unmarshal(data, &plan); err != nil {
+		return nil, fmt.Errorf("parsing the plan: %v", err)
+	}
+	resources := []resource{}
+	for _, rc := range plan.ResourceChanges {
+		if rc.Mode != "managed" || rc.Change.After == nil {
+			continue
+		}
+		resources = append(resources, resource{address: rc.Address, typ: rc.Type, values: rc.Change.After})
+	}
+	return resources, nil
+}
+
+// get returns the planned value at path, walking nested blocks by index.
+func (r resource) get(path ...interface{}) interface{} {
+	var v interface{} = r.values
+	for _, p := range path {
+		switch p := p.(type) {
+		case string:
+			m, ok := v.(map[string]interface{})
+			if !ok {
+				return nil
+			}
+			v = m[p]
+		case int:
+			l, ok := v.([]interface{})
+			if !ok || p >= len(l) {
+				return nil
+			}
+			v = l[p]
+		}
+	}
+	return v
+}
+
+// str returns the string at path, or def when it is unset or unknown at plan
+// time.
+func (r resource) str(def string, path ...interface{}) string {
+	if s, ok := r.get(path...).(string); ok && s != "" {
+		return s
+	}
+	return def
+}
+
+// num returns the number at path, or def when it is unset or unknown at plan
+// time.
+func (r resource) num(def float64, path ...interface{}) float64 {
+	switch n := r.get(path...).(type) {
+	case float64:
+		return n
+	case string:
+		if f, err := strconv.ParseFloat(n, 64); err == nil {
+			return f
+		}
+	}
+	return def
+}
+
+// usage is an amount of a SKU a resource uses all month long, such as vCPUs,
+// GiB of memory or slots. SKUs are matched by the display name of their Cloud
+// Billing service and by their description.
+type usage struct {
+	service *regexp.Regexp
+	sku     *regexp.Regexp
+	// exclude drops SKUs sku also matches, such as commitments.
+	exclude *regexp.Regexp
+	region  string
+	// amount is in the SKU's usage unit without its time component.
+	amount float64
+	// gib is true for amounts in GiB, whose SKUs are priced per GiBy.
+	gib bool
+}
+
+var (
+	computeService   = regexp.MustCompile(`^Compute Engine$`)
+	dataprocService  = regexp.MustCompile(`(?i)^(cloud )?dataproc$`)
+	sqlService       = regexp.MustCompile(`^Cloud SQL$`)
+	bigqueryEditions = regexp.MustCompile(`(?i)^bigquery reservation api$`)
+	biEngineService  = regexp.MustCompile(`(?i)^bigquery bi engine$`)
+	composerService  = regexp.MustCompile(`(?i)^cloud composer$`)
+	lookerService    = regexp.MustCompile(`(?i)^looker`)
+	commitments      = regexp.MustCompile(`(?i)commit`)
+)
+
+// machineType matches predefined machine types such as n2-standard-4.
+var machineType = regexp.MustCompile(`^([a-z0-9]+)-(standard|highmem|highcpu)-([0-9]+)$`)
+
+// gibPerCPU is the memory of predefined machine types per vCPU.
+var gibPerCPU = map[string]float64{"standard": 4, "highmem": 8, "highcpu": 1}
+
+// machineSpec returns the family, vCPUs and GiB of memory of a machine type.
+func machineSpec(name string) (string, float64, float64, error) {
+	switch name {
+	case "e2-micro":
+		return "e2", 0.25, 1, nil
+	case "e2-small":
+		return "e2", 0.5, 2, nil
+	case "e2-medium":
+		return "e2", 1, 4, nil
+	}
+	m := machineType.FindStringSubmatch(name)
+	if m == nil {
+		return "", 0, 0, fmt.Errorf("unsupported machine type %q", name)
+	}
+	cpus, _ := strconv.ParseFloat(m[3], 64)
+	gib := cpus * gibPerCPU[m[2]]
+	if m[1] == "n1" && m[2] == "standard" {
+		gib = cpus * 3.75
+	}
+	return m[1], cpus, gib, nil
+}
+
+// machineUsage returns the Compute Engine cores and memory of count VMs of
+// the machine type.
+func machineUsage(name, region string, count float64) ([]usage, error) {
+	family, cpus, gib, err := machineSpec(name)
+	if err != nil {
+		return nil, err
+	}
+	prefix := `(?i)^` + family + ` (predefined )?instance `
+	return []usage{
+		{service: computeService, sku: regexp.MustCompile(prefix + `core running in`), region: region, amount: cpus * count},
+		{service: computeService, sku: regexp.MustCompile(prefix + `ram running in`), region: region, amount: gib * count, gib: true},
+	}, nil
+}
+
+// rules map the resource types that cost money whether or not they are used
+// to their usage. Resources billed only by use, such as buckets, datasets and
+// serverless batches, are not estimated.
+var rules = map[string]func(r resource) ([]usage, error){
+	"google_bigquery_reservation": func(r resource) ([]usage, error) {
+		edition := strings.ToLower(r.str("STANDARD", "edition"))
+		return []usage{{
+			service: bigqueryEditions,
+			sku:     regexp.MustCompile(`(?i)^(bigquery )?` + edition + ` edition`),
+			exclude: commitments,
+			region:  r.str("", "location"),
+			amount:  r.num(0, "slot_capacity"),
+		}}, nil
+	},
+	"google_bigquery_bi_reservation": func(r resource) ([]usage, error) {
+		return []usage{{
+			service: biEngineService,
+			sku:     regexp.MustCompile(`(?i)bi engine`),
+			exclude: commitments,
+			region:  r.str("", "location"),
+			amount:  r.num(0, "size") / (1 << 30),
+			gib:     true,
+		}}, nil
+	},
+	"google_sql_database_instance": func(r resource) ([]usage, error) {
+		engine := "MySQL"
+		switch version := r.str("", "database_version"); {
+		case strings.HasPrefix(version, "POSTGRES"):
+			engine = "PostgreSQL"
+		case strings.HasPrefix(version, "SQLSERVER"):
+			engine = "SQL Server"
+		}
+		availability := "Zonal"
+		if r.str("ZONAL", "settings", 0, "availability_type") == "REGIONAL" {
+			availability = "Regional"
+		}
+		prefix := `(?i)^cloud sql for ` + engine + `: ` + availability + ` - `
+		region := r.str("", "region")
+
+		tier := r.str("", "settings", 0, "tier")
+		switch tier {
+		case "db-f1-micro":
+			return []usage{{service: sqlService, sku: regexp.MustCompile(prefix + `micro instance`), region: region, amount: 1}}, nil
+		case "db-g1-small":
+			return []usage{{service: sqlService, sku: regexp.MustCompile(prefix + `small instance`), region: region, amount: 1}}, nil
+		}
+		var cpus, mib float64
+		if _, err := fmt.Sscanf(tier, "db-custom-%g-%g", &cpus, &mib); err != nil {
+			return nil, fmt.Errorf("unsupported Cloud SQL tier %q", tier)
+		}
+		return []usage{
+			{service: sqlService, sku: regexp.MustCompile(prefix + `vcpu`), exclude: commitments, region: region, amount: cpus},
+			{service: sqlService, sku: regexp.MustCompile(prefix + `ram`), exclude: commitments, region: region, amount: mib / 1024, gib: true},
+		}, nil
+	},
+	"google_dataproc_cluster": func(r resource) ([]usage, error) {
+		region := r.str("", "region")
+		usages := []usage{}
+		for _, group := range []string{"master_config", "worker_config"} {
+			def := 0.0
+			if group == "master_config" {
+				def = 1
+			}
+			count := r.num(def, "cluster_config", 0, group, 0, "num_instances")
+			if count == 0 {
+				continue
+			}
+			machine := r.str("n2-standard-4", "cluster_config", 0, group, 0, "machine_type")
+			vms, err := machineUsage(machine, region, count)
+			if err != nil {
+				return nil, err
+			}
+			usages = append(usages, vms...)
+			usages = append(usages, usage{service: dataprocService, sku: regexp.MustCompile(`(?i)licensing fee for .*dataproc`), region: region, amount: vms[0].amount})
+		}
+		return usages, nil
+	},
+	"google_vpc_access_connector": func(r resource) ([]usage, error) {
+		return machineUsage(r.str("e2-micro", "machine_type"), r.str("", "region"), r.num(2, "min_instances"))
+	},
+	"google_compute_router_nat": func(r resource) ([]usage, error) {
+		return []usage{{service: computeService, sku: regexp.MustCompile(`(?i)nat gateway.*uptime`), region: r.str("", "region"), amount: 1}}, nil
+	},
+	"google_composer_environment": func(r resource) ([]usage, error) {
+		size := strings.TrimPrefix(r.str("ENVIRONMENT_SIZE_SMALL", "config", 0, "environment_size"), "ENVIRONMENT_SIZE_")
+		return []usage{{service: composerService, sku: regexp.MustCompile(`(?i)` + size + `.*environment|environment.*` + size), region: r.str("", "region"), amount: 1}}, nil
+	},
+	"google_looker_instance": func(r resource) ([]usage, error) {
+		edition := strings.TrimPrefix(r.str("LOOKER_CORE_STANDARD", "platform_edition"), "LOOKER_CORE_")
+		edition = strings.ReplaceAll(edition, "_", " ")
+		return []usage{{service: lookerService, sku: regexp.MustCompile(`(?i)` + edition), exclude: commitments, region: r.str("", "region"), amount: 1}}, nil
+	},
+}
+
+// price is the monthly price of one unit of a SKU.
+type price struct {
+	sku     string
+	monthly float64
+}
+
+// pricer looks up the monthly price of one unit of the most expensive SKU
+// matching u in its region.
+type pricer interface {
+	price(u usage) (price, error)
+}
+
+// item is the estimated monthly cost of one usage of a resource.
+type item struct {
+	address string
+	sku     string
+	amount  float64
+	cost    float64
+}
+
+// estimate returns the estimated monthly cost of each usage of the planned
+// resources, most expensive first.
+func estimate(resources []resource, p pricer) ([]item, error) {
+	items := []item{}
+	for _, r := range resources {
+		rule, ok := rules[r.typ]
+		if !ok {
+			continue
+		}
+		usages, err := rule(r)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", r.address, err)
+		}
+		for _, u := range usages {
+			if u.amount == 0 {
+				continue
+			}
+			pr, err := p.price(u)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %v", r.address, err)
+			}
+			items = append(items, item{address: r.address, sku: pr.sku, amount: u.amount, cost: u.amount * pr.monthly})
+		}
+	}
+	sort.SliceStable(items, func(i, j int) bool { return items[i].cost > items[j].cost })
+	return items, nil
+}
+
+// total is the estimated monthly cost of all items.
+func total(items []item) float64 {
+	sum := 0.0
+	for _, i := range items {
+		sum += i.cost
+	}
+	return sum
+}
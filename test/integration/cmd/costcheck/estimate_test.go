@@ -0,0 +1,129 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	cloudbilling "google.golang.org/api/cloudbilling/v1"
+)
+
+// testPlan is a trimmed `terraform show -json` plan of the analytics_lakehouse
+// example.
+const testPlan = `{
+  "resource_changes": [
+    {"address": "module.analytics_lakehouse.google_bigquery_reservation.queries[0]", "mode": "managed", "type": "google_bigquery_reservation",
+     "change": {"actions": ["create"], "after": {"edition": "ENTERPRISE", "location": "us-central1", "slot_capacity": 50}}},
+    {"address": "module.analytics_lakehouse.google_sql_database_instance.serving[0]", "mode": "managed", "type": "google_sql_database_instance",
+     "change": {"actions": ["create"], "after": {"database_version": "POSTGRES_15", "region": "us-central1", "settings": [{"tier": "db-custom-2-7680"}]}}},
+    {"address": "module.analytics_lakehouse.google_dataproc_cluster.phs[0]", "mode": "managed", "type": "google_dataproc_cluster",
+     "change": {"actions": ["create"], "after": {"region": "us-central1", "cluster_config": [{}]}}},
+    {"address": "module.analytics_lakehouse.google_storage_bucket.raw_bucket", "mode": "managed", "type": "google_storage_bucket",
+     "change": {"actions": ["create"], "after": {"location": "US-CENTRAL1"}}},
+    {"address": "module.analytics_lakehouse.google_compute_router_nat.nat[0]", "mode": "managed", "type": "google_compute_router_nat",
+     "change": {"actions": ["delete"], "after": null}},
+    {"address": "module.analytics_lakehouse.data.google_project.project", "mode": "data", "type": "google_project",
+     "change": {"actions": ["read"], "after": {}}}
+  ]
+}`
+
+// fakePricer prices each SKU pattern at a fixed monthly price.
+type fakePricer map[string]float64
+
+func (f fakePricer) price(u usage) (price, error) {
+	monthly, ok := f[u.sku.String()]
+	if !ok {
+		return price{}, fmt.Errorf("no SKU matching %s", u.sku)
+	}
+	return price{sku: u.sku.String(), monthly: monthly}, nil
+}
+
+// TestEstimate asserts planned resources that cost money while idle are
+// priced by their amounts, unit defaults are used for values unknown at plan
+// time, and deleted, data and usage-billed resources are not estimated.
+func TestEstimate(t *testing.T) {
+	assert := assert.New(t)
+
+	resources, err := parsePlan([]byte(testPlan))
+	if !assert.NoError(err) {
+		return
+	}
+	assert.Len(resources, 4, "Deleted and data resources were not left out")
+
+	p := fakePricer{
+		`(?i)^(bigquery )?enterprise edition`:            43.8,
+		`(?i)^cloud sql for PostgreSQL: Zonal - vcpu`:    30,
+		`(?i)^cloud sql for PostgreSQL: Zonal - ram`:     5,
+		`(?i)^n2 (predefined )?instance core running in`: 20,
+		`(?i)^n2 (predefined )?instance ram running in`:  3,
+		`(?i)licensing fee for .*dataproc`:               7.3,
+	}
+	items, err := estimate(resources, p)
+	if !assert.NoError(err) {
+		return
+	}
+
+	costs := map[string]float64{}
+	for _, i := range items {
+		costs[i.address] += i.cost
+	}
+	assert.InDelta(50*43.8, costs["module.analytics_lakehouse.google_bigquery_reservation.queries[0]"], 0.01, "Slots are not priced by capacity")
+	assert.InDelta(2*30+7.5*5, costs["module.analytics_lakehouse.google_sql_database_instance.serving[0]"], 0.01, "Custom tier is not priced by vCPU and RAM")
+	assert.InDelta(4*20+16*3+4*7.3, costs["module.analytics_lakehouse.google_dataproc_cluster.phs[0]"], 0.01, "Default master is not priced as one n2-standard-4")
+	assert.NotContains(costs, "module.analytics_lakehouse.google_storage_bucket.raw_bucket", "Usage-billed bucket was estimated")
+	assert.InDelta(50*43.8+2*30+7.5*5+4*20+16*3+4*7.3, total(items), 0.01)
+	assert.Equal("module.analytics_lakehouse.google_bigquery_reservation.queries[0]", items[0].address, "Items are not sorted by cost")
+}
+
+// TestEstimateUnsupported asserts resources whose amounts cannot be derived
+// fail the estimate instead of being priced at zero.
+func TestEstimateUnsupported(t *testing.T) {
+	assert := assert.New(t)
+	r := resource{address: "google_sql_database_instance.serving", typ: "google_sql_database_instance", values: map[string]interface{}{
+		"settings": []interface{}{map[string]interface{}{"tier": "db-perf-optimized-N-2"}},
+	}}
+	_, err := estimate([]resource{r}, fakePricer{})
+	assert.ErrorContains(err, "unsupported Cloud SQL tier")
+
+	r = resource{address: "google_vpc_access_connector.connector", typ: "google_vpc_access_connector", values: map[string]interface{}{"machine_type": "f1-micro"}}
+	_, err = estimate([]resource{r}, fakePricer{})
+	assert.ErrorContains(err, "unsupported machine type")
+}
+
+// TestMonthlyUnitPrice asserts SKU prices are converted to a month of one
+// unit, at the highest tier price.
+func TestMonthlyUnitPrice(t *testing.T) {
+	assert := assert.New(t)
+	rates := []*cloudbilling.TierRate{
+		{StartUsageAmount: 0, UnitPrice: &cloudbilling.Money{}},
+		{StartUsageAmount: 10, UnitPrice: &cloudbilling.Money{Units: 1, Nanos: 500000000}},
+	}
+
+	monthly, err := monthlyUnitPrice(&cloudbilling.PricingExpression{UsageUnit: "h", TieredRates: rates}, false)
+	assert.NoError(err)
+	assert.InDelta(1.5*hoursPerMonth, monthly, 0.001)
+
+	monthly, err = monthlyUnitPrice(&cloudbilling.PricingExpression{UsageUnit: "GiBy.mo", TieredRates: rates}, true)
+	assert.NoError(err)
+	assert.InDelta(1.5, monthly, 0.001)
+
+	_, err = monthlyUnitPrice(&cloudbilling.PricingExpression{UsageUnit: "GiBy.h", TieredRates: rates}, false)
+	assert.Error(err, "Memory SKU accepted for a vCPU usage")
+
+	_, err = monthlyUnitPrice(&cloudbilling.PricingExpression{UsageUnit: "GiBy", TieredRates: rates}, true)
+	assert.Error(err, "Usage-billed SKU accepted for an idle cost")
+}
@@ -0,0 +1,89 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Command costcheck estimates the monthly cost of what an example deploys
+// before it is applied, and fails if the estimate exceeds a budget, so an
+// expensive default does not reach users unnoticed. It plans the example with
+// the test setup's outputs as variables, like the fixtures are applied, walks
+// the plan JSON, and prices the resources that cost money while idle with the
+// list prices of the Cloud Billing Catalog API. Usage-billed resources, such
+// as storage, queries and serverless batches, are not estimated.
+//
+// Run it from test/integration after the example is initialized:
+//
+//	go run ./cmd/costcheck -example=analytics_lakehouse -budget=2500
+//	terraform show -json tfplan > plan.json && go run ./cmd/costcheck -plan=plan.json -budget=2500
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"text/tabwriter"
+
+	"github.com/terraform-google-modules/terraform-google-analytics-lakehouse/test/integration/testutils"
+)
+
+func main() {
+	var example, setup, planFile string
+	var budget float64
+	flag.StringVar(&example, "example", "", "Example to plan, as its directory under examples.")
+	flag.StringVar(&setup, "setup", "../setup", "Directory of the applied test setup, whose outputs are passed to the example.")
+	flag.StringVar(&planFile, "plan", "", "JSON file of an existing plan, as written by terraform show -json, to check instead of planning -example.")
+	flag.Float64Var(&budget, "budget", 2500, "Largest estimated monthly cost in USD the plan may have.")
+	flag.Parse()
+
+	var data []byte
+	var err error
+	switch {
+	case planFile != "":
+		data, err = os.ReadFile(planFile)
+	case example != "":
+		data, err = testutils.PlanExample(filepath.Join("..", "..", "examples", example), setup)
+	default:
+		log.Fatal("one of -example or -plan is required")
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	resources, err := parsePlan(data)
+	if err != nil {
+		log.Fatal(err)
+	}
+	c, err := newCatalog(context.Background())
+	if err != nil {
+		log.Fatalf("reading the Cloud Billing Catalog: %v", err)
+	}
+	items, err := estimate(resources, c)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	table := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(table, "RESOURCE\tSKU\tAMOUNT\tUSD/MONTH")
+	for _, i := range items {
+		fmt.Fprintf(table, "%s\t%s\t%g\t%.2f\n", i.address, i.sku, i.amount, i.cost)
+	}
+	table.Flush()
+
+	sum := total(items)
+	if sum > budget {
+		log.Fatalf("estimated monthly cost %.2f USD exceeds the %.2f USD budget", sum, budget)
+	}
+	log.Printf("estimated monthly cost %.2f USD is within the %.2f USD budget", sum, budget)
+}
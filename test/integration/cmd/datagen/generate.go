@@ -0,0 +1,153 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"fmt"
+	"math/rand"
+	"time"
+)
+
+// tables are the thelook tables the generator writes, with their columns in
+// file order. Column names and types follow the public thelook_ecommerce
+// dataset so the lakehouse SQL runs unchanged over generated data.
+var tables = []struct {
+	name    string
+	columns []string
+}{
+	{"users", []string{"id", "first_name", "last_name", "email", "age", "gender", "state", "country", "city", "traffic_source", "created_at"}},
+	{"orders", []string{"order_id", "user_id", "status", "gender", "created_at", "returned_at", "shipped_at", "delivered_at", "num_of_item"}},
+	{"events", []string{"id", "user_id", "sequence_number", "session_id", "created_at", "ip_address", "city", "state", "postal_code", "browser", "traffic_source", "uri", "event_type"}},
+}
+
+var (
+	firstNames     = []string{"Ava", "Ben", "Chloe", "David", "Elena", "Felix", "Grace", "Hugo", "Isla", "Jonah", "Kai", "Lena", "Maya", "Noah", "Omar", "Priya"}
+	lastNames      = []string{"Garcia", "Smith", "Nguyen", "Johnson", "Kim", "Brown", "Lopez", "Patel", "Miller", "Wilson", "Davis", "Martin"}
+	locations      = []struct{ city, state, postalCode string }{{"Seattle", "Washington", "98101"}, {"Austin", "Texas", "73301"}, {"Chicago", "Illinois", "60601"}, {"Boston", "Massachusetts", "02108"}, {"Denver", "Colorado", "80202"}, {"Miami", "Florida", "33101"}}
+	trafficSources = []string{"Search", "Organic", "Email", "Facebook", "Display"}
+	browsers       = []string{"Chrome", "Safari", "Firefox", "Edge", "IE", "Other"}
+	statuses       = []string{"Complete", "Shipped", "Processing", "Cancelled", "Returned"}
+	departments    = []string{"men", "women"}
+)
+
+// timeLayout is the timestamp layout BigQuery parses from both CSV and JSON.
+const timeLayout = "2006-01-02 15:04:05 UTC"
+
+// row is one generated record, keyed by column name.
+type row map[string]interface{}
+
+// generator synthesizes the users of one shard with their orders and
+// clickstream events. Every shard draws from its own seeded source, so the
+// output is reproducible for a given seed and shard count, and interleaves
+// its IDs with the other shards' so they never collide.
+type generator struct {
+	rnd    *rand.Rand
+	shard  int
+	shards int
+	start  time.Time
+	span   time.Duration
+
+	orders int
+	events int
+}
+
+func newGenerator(seed int64, shard, shards int, start time.Time, span time.Duration) *generator {
+	return &generator{rnd: rand.New(rand.NewSource(seed + int64(shard))), shard: shard, shards: shards, start: start, span: span}
+}
+
+// nextID returns the next ID of a sequence interleaved across shards.
+func (g *generator) nextID(counter *int) int64 {
+	id := int64(*counter*g.shards + g.shard + 1)
+	*counter++
+	return id
+}
+
+func (g *generator) pick(values []string) string {
+	return values[g.rnd.Intn(len(values))]
+}
+
+func timestamp(t time.Time) string {
+	return t.UTC().Format(timeLayout)
+}
+
+// user generates the user with the given ID and returns it with its orders
+// and events. Each order is preceded by a browsing session that ends in a
+// purchase; some sessions end without one.
+func (g *generator) user(id int64) (row, []row, []row) {
+	first, last := g.pick(firstNames), g.pick(lastNames)
+	gender := []string{"F", "M"}[g.rnd.Intn(2)]
+	location := locations[g.rnd.Intn(len(locations))]
+	source := g.pick(trafficSources)
+	created := g.start.Add(time.Duration(g.rnd.Int63n(int64(g.span))))
+	user := row{
+		"id": id, "first_name": first, "last_name": last,
+		"email":  fmt.Sprintf("%s.%s.%d@example.com", first, last, id),
+		"age":    18 + g.rnd.Intn(53),
+		"gender": gender, "state": location.state, "country": "United States", "city": location.city,
+		"traffic_source": source, "created_at": timestamp(created),
+	}
+
+	orders, events := []row{}, []row{}
+	sessions := 1 + g.rnd.Intn(4)
+	at := created
+	for s := 0; s < sessions; s++ {
+		at = at.Add(time.Duration(1+g.rnd.Intn(72)) * time.Hour)
+		session := fmt.Sprintf("%08x-%04x-%04x", id, s, g.rnd.Intn(1<<16))
+		department := g.pick(departments)
+		uris := []string{"/", "/department/" + department, fmt.Sprintf("/product/%d", 1+g.rnd.Intn(29000))}
+		types := []string{"home", "department", "product"}
+		purchased := g.rnd.Intn(3) > 0
+		if purchased {
+			uris = append(uris, "/cart", "/purchase")
+			types = append(types, "cart", "purchase")
+		}
+		for i := range uris {
+			at = at.Add(time.Duration(10+g.rnd.Intn(300)) * time.Second)
+			events = append(events, row{
+				"id": g.nextID(&g.events), "user_id": id, "sequence_number": i + 1, "session_id": session,
+				"created_at": timestamp(at), "ip_address": fmt.Sprintf("10.%d.%d.%d", g.rnd.Intn(256), g.rnd.Intn(256), 1+g.rnd.Intn(254)),
+				"city": location.city, "state": location.state, "postal_code": location.postalCode,
+				"browser": g.pick(browsers), "traffic_source": source, "uri": uris[i], "event_type": types[i],
+			})
+		}
+		if purchased {
+			orders = append(orders, g.order(id, gender, at))
+		}
+	}
+	return user, orders, events
+}
+
+// order generates an order placed at the given time, with the fulfilment
+// timestamps its status implies.
+func (g *generator) order(userID int64, gender string, placed time.Time) row {
+	status := g.pick(statuses)
+	order := row{
+		"order_id": g.nextID(&g.orders), "user_id": userID, "status": status, "gender": gender,
+		"created_at": timestamp(placed), "returned_at": nil, "shipped_at": nil, "delivered_at": nil,
+		"num_of_item": 1 + g.rnd.Intn(4),
+	}
+	shipped := placed.Add(time.Duration(1+g.rnd.Intn(72)) * time.Hour)
+	delivered := shipped.Add(time.Duration(24+g.rnd.Intn(96)) * time.Hour)
+	switch status {
+	case "Shipped":
+		order["shipped_at"] = timestamp(shipped)
+	case "Complete":
+		order["shipped_at"], order["delivered_at"] = timestamp(shipped), timestamp(delivered)
+	case "Returned":
+		order["shipped_at"], order["delivered_at"] = timestamp(shipped), timestamp(delivered)
+		order["returned_at"] = timestamp(delivered.Add(time.Duration(24+g.rnd.Intn(240)) * time.Hour))
+	}
+	return order
+}
@@ -0,0 +1,107 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestGenerate asserts generated files are reproducible for a seed, that IDs
+// are unique across shards, and that every order and event belongs to a
+// generated user.
+func TestGenerate(t *testing.T) {
+	assert := assert.New(t)
+	opts := options{prefix: "thelook_ecommerce", format: "json", users: 200, shards: 3, seed: 7, start: time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC), days: 30}
+
+	first, second := t.TempDir(), t.TempDir()
+	counts, err := generate(opts, localFile(first))
+	if !assert.NoError(err) {
+		return
+	}
+	_, err = generate(opts, localFile(second))
+	assert.NoError(err)
+	assert.Equal(opts.users, counts["users"], "Unexpected number of users")
+
+	users := map[float64]bool{}
+	for _, table := range tables {
+		ids := map[float64]bool{}
+		rows := 0
+		for shard := 0; shard < opts.shards; shard++ {
+			name := filepath.Join("thelook_ecommerce", table.name, fmt.Sprintf("part-%05d.json", shard))
+			want, err := os.ReadFile(filepath.Join(first, name))
+			assert.NoError(err)
+			got, err := os.ReadFile(filepath.Join(second, name))
+			assert.NoError(err)
+			assert.Equal(string(want), string(got), "%s differs between runs with the same seed", name)
+
+			file, err := os.Open(filepath.Join(first, name))
+			if !assert.NoError(err) {
+				return
+			}
+			defer file.Close()
+			scanner := bufio.NewScanner(file)
+			for scanner.Scan() {
+				var r map[string]interface{}
+				assert.NoError(json.Unmarshal(scanner.Bytes(), &r))
+				id := r[table.columns[0]].(float64)
+				assert.False(ids[id], "Duplicate %s %s %v", table.name, table.columns[0], id)
+				ids[id] = true
+				if table.name == "users" {
+					users[id] = true
+				} else {
+					assert.True(users[r["user_id"].(float64)], "%s %v belongs to an unknown user", table.name, id)
+				}
+				rows++
+			}
+		}
+		assert.Equal(counts[table.name], rows, "Unexpected number of %s rows", table.name)
+	}
+	assert.Greater(counts["orders"], 0, "No orders generated")
+	assert.Greater(counts["events"], counts["orders"], "Fewer events than orders")
+}
+
+// TestGenerateCSV asserts CSV files start with a header row and have one
+// field per column.
+func TestGenerateCSV(t *testing.T) {
+	assert := assert.New(t)
+	dir := t.TempDir()
+	opts := options{prefix: "thelook_ecommerce", format: "csv", users: 20, shards: 1, seed: 1, start: time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC), days: 1}
+	_, err := generate(opts, localFile(dir))
+	if !assert.NoError(err) {
+		return
+	}
+
+	for _, table := range tables {
+		file, err := os.Open(filepath.Join(dir, "thelook_ecommerce", table.name, "part-00000.csv"))
+		if !assert.NoError(err) {
+			return
+		}
+		defer file.Close()
+		records, err := csv.NewReader(file).ReadAll()
+		if !assert.NoError(err, "%s is not valid CSV", table.name) {
+			continue
+		}
+		assert.Equal(table.columns, records[0], "Unexpected %s header", table.name)
+	}
+}
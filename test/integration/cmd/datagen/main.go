@@ -0,0 +1,295 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Command datagen synthesizes thelook-like users, orders and events as CSV or
+// newline-delimited JSON files, laid out like the thelook_ecommerce prefix of
+// the public data bucket:
+//
+//	<prefix>/<table>/part-<shard>.<csv|json>
+//
+// Files are uploaded to a Cloud Storage bucket with application default
+// credentials, or written under a local directory. The public sample has about
+// 100,000 users; use -scale to generate 10 to 100 times that for scale tests.
+//
+//	go run ./cmd/datagen -bucket=gcp-lakehouse-tables-abcd -format=csv -scale=10
+package main
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"google.golang.org/api/googleapi"
+	storage "google.golang.org/api/storage/v1"
+)
+
+// sampleUsers is the number of users in the public thelook sample.
+const sampleUsers = 100000
+
+type options struct {
+	bucket string
+	dir    string
+	prefix string
+	format string
+	scale  float64
+	users  int
+	shards int
+	seed   int64
+	start  time.Time
+	days   int
+}
+
+func main() {
+	var opts options
+	var start string
+	flag.StringVar(&opts.bucket, "bucket", "", "Cloud Storage bucket to upload the files to. Files are written under -dir when empty.")
+	flag.StringVar(&opts.dir, "dir", ".", "Local directory to write the files under when -bucket is empty.")
+	flag.StringVar(&opts.prefix, "prefix", "thelook_ecommerce", "Object prefix the table directories are created under.")
+	flag.StringVar(&opts.format, "format", "csv", "File format, csv or json.")
+	flag.Float64Var(&opts.scale, "scale", 1, "Multiple of the public sample's user count to generate.")
+	flag.IntVar(&opts.users, "users", 0, "Exact number of users to generate, overriding -scale.")
+	flag.IntVar(&opts.shards, "shards", 8, "Number of files per table, generated in parallel.")
+	flag.Int64Var(&opts.seed, "seed", 1, "Random seed. The same seed and shard count produce the same files.")
+	flag.StringVar(&start, "start", "2022-01-01", "Date the earliest user signs up, as YYYY-MM-DD.")
+	flag.IntVar(&opts.days, "days", 365, "Number of days over which users sign up.")
+	flag.Parse()
+
+	var err error
+	if opts.start, err = time.Parse("2006-01-02", start); err != nil {
+		log.Fatalf("invalid -start: %v", err)
+	}
+	if opts.users == 0 {
+		opts.users = int(opts.scale * sampleUsers)
+	}
+	if opts.format != "csv" && opts.format != "json" {
+		log.Fatalf("invalid -format %q: must be csv or json", opts.format)
+	}
+	if opts.users <= 0 || opts.shards <= 0 || opts.days <= 0 {
+		log.Fatal("-users or -scale, -shards and -days must be positive")
+	}
+
+	ctx := context.Background()
+	create := localFile(opts.dir)
+	if opts.bucket != "" {
+		service, err := storage.NewService(ctx)
+		if err != nil {
+			log.Fatalf("creating Cloud Storage client: %v", err)
+		}
+		create = gcsObject(ctx, service, opts.bucket)
+	}
+
+	begin := time.Now()
+	counts, err := generate(opts, create)
+	if err != nil {
+		log.Fatal(err)
+	}
+	for _, table := range tables {
+		log.Printf("%s: %d rows", table.name, counts[table.name])
+	}
+	log.Printf("generated %d users in %s", opts.users, time.Since(begin).Round(time.Second))
+}
+
+// createFunc opens a file for writing by its slash-separated name.
+type createFunc func(name string) (io.WriteCloser, error)
+
+// localFile creates files under dir.
+func localFile(dir string) createFunc {
+	return func(name string) (io.WriteCloser, error) {
+		file := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(file), 0o755); err != nil {
+			return nil, err
+		}
+		return os.Create(file)
+	}
+}
+
+// gcsWriter streams a file into a Cloud Storage upload. Close returns the
+// result of the upload.
+type gcsWriter struct {
+	*io.PipeWriter
+	done chan error
+}
+
+func (w *gcsWriter) Close() error {
+	if err := w.PipeWriter.Close(); err != nil {
+		return err
+	}
+	return <-w.done
+}
+
+// gcsObject creates objects in bucket.
+func gcsObject(ctx context.Context, service *storage.Service, bucket string) createFunc {
+	return func(name string) (io.WriteCloser, error) {
+		r, w := io.Pipe()
+		done := make(chan error, 1)
+		go func() {
+			_, err := service.Objects.Insert(bucket, &storage.Object{Name: name}).Media(r, googleapi.ContentType(contentType(name))).Context(ctx).Do()
+			r.CloseWithError(err)
+			done <- err
+		}()
+		return &gcsWriter{PipeWriter: w, done: done}, nil
+	}
+}
+
+func contentType(name string) string {
+	if path.Ext(name) == ".json" {
+		return "application/x-ndjson"
+	}
+	return "text/csv"
+}
+
+// encoder writes rows of one table in the chosen format.
+type encoder interface {
+	encode(r row) error
+	flush() error
+}
+
+type csvEncoder struct {
+	w       *csv.Writer
+	columns []string
+}
+
+func newCSVEncoder(w io.Writer, columns []string) (encoder, error) {
+	e := &csvEncoder{w: csv.NewWriter(w), columns: columns}
+	return e, e.w.Write(columns)
+}
+
+func (e *csvEncoder) encode(r row) error {
+	record := make([]string, len(e.columns))
+	for i, column := range e.columns {
+		if value := r[column]; value != nil {
+			record[i] = fmt.Sprint(value)
+		}
+	}
+	return e.w.Write(record)
+}
+
+func (e *csvEncoder) flush() error {
+	e.w.Flush()
+	return e.w.Error()
+}
+
+type jsonEncoder struct{ e *json.Encoder }
+
+func (e jsonEncoder) encode(r row) error { return e.e.Encode(r) }
+func (e jsonEncoder) flush() error       { return nil }
+
+// generate writes every shard in parallel and returns the rows written per
+// table.
+func generate(opts options, create createFunc) (map[string]int, error) {
+	counts := map[string]int{}
+	var mu sync.Mutex
+	errs := make(chan error, opts.shards)
+	var wg sync.WaitGroup
+	for shard := 0; shard < opts.shards; shard++ {
+		wg.Add(1)
+		go func(shard int) {
+			defer wg.Done()
+			shardCounts, err := generateShard(opts, create, shard)
+			if err != nil {
+				errs <- fmt.Errorf("shard %d: %w", shard, err)
+				return
+			}
+			mu.Lock()
+			defer mu.Unlock()
+			for table, count := range shardCounts {
+				counts[table] += count
+			}
+		}(shard)
+	}
+	wg.Wait()
+	close(errs)
+	if err := <-errs; err != nil {
+		return nil, err
+	}
+	return counts, nil
+}
+
+// generateShard writes the users whose ID falls in the shard, with their
+// orders and events, to one file per table.
+func generateShard(opts options, create createFunc, shard int) (map[string]int, error) {
+	files := map[string]io.WriteCloser{}
+	encoders := map[string]encoder{}
+	// Files still open on return belong to a failed shard. Uploads are
+	// aborted rather than finalized with partial contents.
+	defer func() {
+		for _, file := range files {
+			if upload, ok := file.(*gcsWriter); ok {
+				upload.CloseWithError(fmt.Errorf("shard %d failed", shard))
+				continue
+			}
+			file.Close()
+		}
+	}()
+	for _, table := range tables {
+		name := fmt.Sprintf("%s/%s/part-%05d.%s", opts.prefix, table.name, shard, opts.format)
+		file, err := create(name)
+		if err != nil {
+			return nil, fmt.Errorf("creating %s: %w", name, err)
+		}
+		files[table.name] = file
+		if opts.format == "csv" {
+			if encoders[table.name], err = newCSVEncoder(file, table.columns); err != nil {
+				return nil, err
+			}
+		} else {
+			encoders[table.name] = jsonEncoder{json.NewEncoder(file)}
+		}
+	}
+
+	g := newGenerator(opts.seed, shard, opts.shards, opts.start, time.Duration(opts.days)*24*time.Hour)
+	counts := map[string]int{}
+	write := func(table string, rows ...row) error {
+		for _, r := range rows {
+			if err := encoders[table].encode(r); err != nil {
+				return fmt.Errorf("writing %s: %w", table, err)
+			}
+		}
+		counts[table] += len(rows)
+		return nil
+	}
+	for id := int64(shard + 1); id <= int64(opts.users); id += int64(opts.shards) {
+		user, orders, events := g.user(id)
+		if err := write("users", user); err != nil {
+			return nil, err
+		}
+		if err := write("orders", orders...); err != nil {
+			return nil, err
+		}
+		if err := write("events", events...); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, table := range tables {
+		if err := encoders[table.name].flush(); err != nil {
+			return nil, err
+		}
+		file := files[table.name]
+		delete(files, table.name)
+		if err := file.Close(); err != nil {
+			return nil, fmt.Errorf("closing %s file: %w", table.name, err)
+		}
+	}
+	return counts, nil
+}
@@ -0,0 +1,213 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Command shard runs the integration test fixtures in parallel across the
+// setup project and a pool of seed projects created with the setup's
+// project_pool_size, so the full matrix fits in a CI time budget. Each
+// project runs its fixtures one after another; fixtures that need the setup
+// project always run there. Examples and test fixtures without a test
+// package run as subtests of TestAll. Results are printed as a table and
+// written as JSON, and the command fails if any fixture failed.
+//
+// Run it from test/integration after the setup is applied:
+//
+//	go run ./cmd/shard -budget=3h
+//	go run ./cmd/shard -fixtures=cmek,dual_region -dry-run
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"text/tabwriter"
+	"time"
+
+	"github.com/terraform-google-modules/terraform-google-analytics-lakehouse/test/integration/testutils"
+)
+
+// result is the outcome of one fixture.
+type result struct {
+	Fixture  string        `json:"fixture"`
+	Project  string        `json:"project"`
+	Status   string        `json:"status"`
+	Duration time.Duration `json:"duration"`
+	Log      string        `json:"log"`
+}
+
+func main() {
+	var projects, names, out, logs, setup string
+	var budget time.Duration
+	var dryRun bool
+	flag.StringVar(&projects, "projects", "", "Comma-separated pool projects. Defaults to the setup's project_pool output.")
+	flag.StringVar(&names, "fixtures", "", "Comma-separated fixtures to run. Defaults to all of them.")
+	flag.StringVar(&out, "out", "shard-results.json", "File to write the results to as JSON.")
+	flag.StringVar(&logs, "logs", "shard-logs", "Directory to write each fixture's test output to.")
+	flag.StringVar(&setup, "setup", "../setup", "Directory of the applied test setup.")
+	flag.DurationVar(&budget, "budget", 0, "Time the run must fit in. A plan estimated to take longer fails before running anything.")
+	flag.BoolVar(&dryRun, "dry-run", false, "Print the assignment of fixtures to projects without running them.")
+	flag.Parse()
+
+	discovered, err := discover(".")
+	if err != nil {
+		log.Fatalf("discovering examples without a test: %v", err)
+	}
+	fixtures = append(fixtures, discovered...)
+	selected, err := selectFixtures(split(names))
+	if err != nil {
+		log.Fatal(err)
+	}
+	setupProject, err := setupOutput(setup, "-raw", "project_id")
+	if err != nil {
+		log.Fatalf("reading the setup project: %v", err)
+	}
+	pool := split(projects)
+	if projects == "" {
+		output, err := setupOutput(setup, "-json", "project_pool")
+		if err != nil {
+			log.Fatalf("reading the project pool: %v", err)
+		}
+		if err := json.Unmarshal([]byte(output), &pool); err != nil {
+			log.Fatalf("parsing the project pool: %v", err)
+		}
+	}
+
+	shards := assign(selected, setupProject, pool)
+	for _, s := range shards {
+		planned := []string{}
+		for _, f := range s.fixtures {
+			planned = append(planned, f.name)
+		}
+		log.Printf("%s: %s (about %s)", s.project, strings.Join(planned, ", "), s.estimate)
+	}
+	estimate := makespan(shards)
+	log.Printf("estimated duration %s across %d projects", estimate, len(shards))
+	if budget > 0 && estimate > budget {
+		log.Fatalf("estimated duration %s exceeds the %s budget; add pool projects with project_pool_size", estimate, budget)
+	}
+	if dryRun {
+		return
+	}
+
+	if err := os.MkdirAll(logs, 0o755); err != nil {
+		log.Fatal(err)
+	}
+	results := run(context.Background(), shards, logs)
+	if err := report(results, out); err != nil {
+		log.Fatal(err)
+	}
+	for _, r := range results {
+		if r.Status == "failed" {
+			os.Exit(1)
+		}
+	}
+}
+
+func split(list string) []string {
+	if list == "" {
+		return nil
+	}
+	return strings.Split(list, ",")
+}
+
+// setupOutput reads an output of the applied test setup.
+func setupOutput(dir string, args ...string) (string, error) {
+	cmd := exec.Command(testutils.TerraformBinary(), append([]string{"-chdir=" + dir, "output"}, args...)...)
+	cmd.Stderr = os.Stderr
+	output, err := cmd.Output()
+	return strings.TrimSpace(string(output)), err
+}
+
+// run runs every shard in parallel and each shard's fixtures in sequence.
+func run(ctx context.Context, shards []*shard, logs string) []result {
+	var mu sync.Mutex
+	results := []result{}
+	var wg sync.WaitGroup
+	for _, s := range shards {
+		wg.Add(1)
+		go func(s *shard) {
+			defer wg.Done()
+			for _, f := range s.fixtures {
+				r := runFixture(ctx, s, f, logs)
+				log.Printf("%s %s in %s after %s", f.name, r.Status, s.project, r.Duration.Round(time.Second))
+				mu.Lock()
+				results = append(results, r)
+				mu.Unlock()
+			}
+		}(s)
+	}
+	wg.Wait()
+	sort.Slice(results, func(i, j int) bool { return results[i].Fixture < results[j].Fixture })
+	return results
+}
+
+// runFixture runs the fixture's test from init to teardown, pointing it at
+// the shard's project when that is a pool project.
+func runFixture(ctx context.Context, s *shard, f fixture, logs string) result {
+	r := result{Fixture: f.name, Project: s.project, Log: filepath.Join(logs, f.name+".log")}
+	file, err := os.Create(r.Log)
+	if err != nil {
+		r.Status = "failed"
+		return r
+	}
+	defer file.Close()
+
+	var output bytes.Buffer
+	cmd := exec.CommandContext(ctx, "go", "test", f.dir(), "-run", f.runPattern(), "-timeout", "0", "-count", "1", "-v")
+	cmd.Stdout = io.MultiWriter(file, &output)
+	cmd.Stderr = cmd.Stdout
+	cmd.Env = os.Environ()
+	if s.pool {
+		cmd.Env = append(cmd.Env, fmt.Sprintf("%s=%s", testutils.PoolProjectEnvVar, s.project))
+	}
+
+	start := time.Now()
+	err = cmd.Run()
+	r.Duration = time.Since(start)
+	switch {
+	case err != nil:
+		r.Status = "failed"
+	case bytes.Contains(output.Bytes(), []byte("--- SKIP: "+f.test)):
+		r.Status = "skipped"
+	default:
+		r.Status = "passed"
+	}
+	return r
+}
+
+// report prints the results as a table and writes them to out as JSON.
+func report(results []result, out string) error {
+	table := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(table, "FIXTURE\tPROJECT\tSTATUS\tDURATION\tLOG")
+	for _, r := range results {
+		fmt.Fprintf(table, "%s\t%s\t%s\t%s\t%s\n", r.Fixture, r.Project, r.Status, r.Duration.Round(time.Second), r.Log)
+	}
+	if err := table.Flush(); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(results, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(out, append(data, '\n'), 0o644)
+}
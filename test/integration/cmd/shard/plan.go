@@ -0,0 +1,223 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// fixture is an integration test that deploys one example or test fixture.
+type fixture struct {
+	// name is the example or test fixture and, unless pkg is set, the test
+	// package directory.
+	name string
+	// pkg is the test package directory of a fixture TestAll discovers, or
+	// of a test fixture a package of another name deploys.
+	pkg string
+	// test is the test function that runs the fixture end to end, or the
+	// TestAll subtest for a discovered one.
+	test string
+	// estimate is how long the fixture usually takes from init to teardown.
+	estimate time.Duration
+	// pinned fixtures need the setup project: its org policies, VPC Service
+	// Controls perimeter, Shared VPC attachment or OAuth client.
+	pinned bool
+}
+
+// fixtures are the fixtures the coordinator knows, with typical durations.
+var fixtures = []fixture{
+	{name: "analytics_lakehouse_full", pkg: "./analytics_lakehouse", test: "TestAnalyticsLakehouseFull", estimate: 150 * time.Minute, pinned: true},
+	{name: "analytics_lakehouse", test: "TestAnalyticsLakehouse", estimate: 90 * time.Minute, pinned: true},
+	{name: "looker", test: "TestLooker", estimate: 90 * time.Minute, pinned: true},
+	{name: "shared_vpc", test: "TestSharedVPC", estimate: 60 * time.Minute, pinned: true},
+	{name: "composer", test: "TestComposer", estimate: 75 * time.Minute},
+	{name: "datastream", test: "TestDatastream", estimate: 60 * time.Minute},
+	{name: "dbt", test: "TestDBT", estimate: 50 * time.Minute},
+	{name: "cmek", test: "TestCMEK", estimate: 45 * time.Minute},
+	{name: "dual_region", test: "TestDualRegion", estimate: 45 * time.Minute},
+	{name: "byo_network", test: "TestBYONetwork", estimate: 45 * time.Minute},
+	{name: "byo_datasets", test: "TestBYODatasets", estimate: 45 * time.Minute},
+	{name: "provider_min", test: "TestProviderMin", estimate: 45 * time.Minute},
+	{name: "provider_latest", test: "TestProviderLatest", estimate: 45 * time.Minute},
+	{name: "simple_example", test: "TestSimpleExample", estimate: 15 * time.Minute},
+}
+
+// discoveredEstimate is assumed for discovered fixtures until they get a
+// test package and an estimate of their own.
+const discoveredEstimate = 60 * time.Minute
+
+// discover returns the examples and test fixtures that have no test package
+// in testDir, which TestAll deploys with a minimal apply, verify and destroy.
+// It follows blueprint-test's discovery: a test fixture covers the example of
+// the same name. A known fixture deployed by a package of another name is
+// covered too.
+func discover(testDir string) ([]fixture, error) {
+	explicit, err := dirs(testDir)
+	if err != nil {
+		return nil, err
+	}
+	testFixtures, err := dirs(filepath.Join(testDir, "..", "fixtures"))
+	if err != nil {
+		return nil, err
+	}
+	examples, err := dirs(filepath.Join(testDir, "..", "..", "examples"))
+	if err != nil {
+		return nil, err
+	}
+
+	discovered := []fixture{}
+	covered := map[string]bool{}
+	for _, f := range fixtures {
+		if f.pkg != "" {
+			covered[f.name] = true
+		}
+	}
+	for _, name := range explicit {
+		covered[name] = true
+	}
+	for _, name := range testFixtures {
+		if !covered[name] {
+			discovered = append(discovered, fixture{name: name, pkg: ".", test: "TestAll/fixtures/" + name, estimate: discoveredEstimate})
+		}
+		covered[name] = true
+	}
+	for _, name := range examples {
+		if !covered[name] {
+			discovered = append(discovered, fixture{name: name, pkg: ".", test: "TestAll/examples/" + name, estimate: discoveredEstimate})
+		}
+	}
+	return discovered, nil
+}
+
+// dirs returns the names of the directories in dir, which may not exist.
+func dirs(dir string) ([]string, error) {
+	entries, err := os.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	names := []string{}
+	for _, entry := range entries {
+		if entry.IsDir() {
+			names = append(names, entry.Name())
+		}
+	}
+	return names, nil
+}
+
+// dir is the test package directory of the fixture.
+func (f fixture) dir() string {
+	if f.pkg != "" {
+		return f.pkg
+	}
+	return "./" + f.name
+}
+
+// runPattern is the go test -run pattern matching the fixture's test and no
+// other, level by level for a subtest.
+func (f fixture) runPattern() string {
+	levels := strings.Split(f.test, "/")
+	for i, level := range levels {
+		levels[i] = "^" + level + "$"
+	}
+	return strings.Join(levels, "/")
+}
+
+// selectFixtures returns the known fixtures with the given names, or all of
+// them when names is empty.
+func selectFixtures(names []string) ([]fixture, error) {
+	if len(names) == 0 {
+		return fixtures, nil
+	}
+	byName := map[string]fixture{}
+	for _, f := range fixtures {
+		byName[f.name] = f
+	}
+	selected := []fixture{}
+	for _, name := range names {
+		f, ok := byName[name]
+		if !ok {
+			return nil, fmt.Errorf("unknown fixture %q", name)
+		}
+		selected = append(selected, f)
+	}
+	return selected, nil
+}
+
+// shard is the fixtures one project runs, one after another. Fixtures in the
+// same project would collide on fixed resource names, so they never overlap.
+type shard struct {
+	project string
+	// pool is false for the setup project, whose ID fixtures read from the
+	// setup outputs.
+	pool     bool
+	fixtures []fixture
+	estimate time.Duration
+}
+
+// assign spreads the fixtures over the setup project and the pool projects.
+// Pinned fixtures go to the setup project, then the others are assigned
+// longest first to whichever project would finish earliest, which keeps the
+// slowest shard, and so the whole run, close to the shortest possible.
+func assign(fixtures []fixture, setupProject string, pool []string) []*shard {
+	shards := []*shard{{project: setupProject}}
+	for _, project := range pool {
+		shards = append(shards, &shard{project: project, pool: true})
+	}
+
+	sorted := append([]fixture{}, fixtures...)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].estimate > sorted[j].estimate })
+	for _, f := range sorted {
+		if f.pinned {
+			shards[0].add(f)
+		}
+	}
+	for _, f := range sorted {
+		if f.pinned {
+			continue
+		}
+		earliest := shards[0]
+		for _, s := range shards[1:] {
+			if s.estimate < earliest.estimate {
+				earliest = s
+			}
+		}
+		earliest.add(f)
+	}
+	return shards
+}
+
+func (s *shard) add(f fixture) {
+	s.fixtures = append(s.fixtures, f)
+	s.estimate += f.estimate
+}
+
+// makespan is the estimated duration of the whole run.
+func makespan(shards []*shard) time.Duration {
+	longest := time.Duration(0)
+	for _, s := range shards {
+		if s.estimate > longest {
+			longest = s.estimate
+		}
+	}
+	return longest
+}
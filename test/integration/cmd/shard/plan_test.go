@@ -0,0 +1,153 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestAssign asserts every fixture is assigned exactly once, pinned fixtures
+// stay in the setup project, and the pool brings the run down to the pinned
+// fixtures' duration.
+func TestAssign(t *testing.T) {
+	assert := assert.New(t)
+	shards := assign(fixtures, "setup", []string{"pool-0", "pool-1", "pool-2"})
+
+	if !assert.Len(shards, 4) {
+		return
+	}
+	assert.Equal("setup", shards[0].project)
+	assert.False(shards[0].pool, "Setup project is marked as a pool project")
+
+	seen := map[string]int{}
+	pinned := time.Duration(0)
+	for _, s := range shards {
+		total := time.Duration(0)
+		for _, f := range s.fixtures {
+			seen[f.name]++
+			total += f.estimate
+			if f.pinned {
+				assert.Equal("setup", s.project, "Pinned fixture %s is not in the setup project", f.name)
+				pinned += f.estimate
+			}
+		}
+		assert.Equal(total, s.estimate, "Estimate of %s is not the sum of its fixtures", s.project)
+	}
+	for _, f := range fixtures {
+		assert.Equal(1, seen[f.name], "Fixture %s is not assigned exactly once", f.name)
+	}
+	assert.Equal(pinned, makespan(shards), "Pool projects are not balanced under the pinned fixtures")
+}
+
+// TestAssignWithoutPool asserts every fixture runs in the setup project when
+// there is no pool.
+func TestAssignWithoutPool(t *testing.T) {
+	assert := assert.New(t)
+	shards := assign(fixtures, "setup", nil)
+
+	if !assert.Len(shards, 1) {
+		return
+	}
+	assert.Len(shards[0].fixtures, len(fixtures))
+	total := time.Duration(0)
+	for _, f := range fixtures {
+		total += f.estimate
+	}
+	assert.Equal(total, makespan(shards))
+}
+
+// TestAssignBalances asserts fixtures are spread longest first, so equal pool
+// projects finish together.
+func TestAssignBalances(t *testing.T) {
+	assert := assert.New(t)
+	balanced := []fixture{
+		{name: "a", estimate: 60 * time.Minute},
+		{name: "b", estimate: 50 * time.Minute},
+		{name: "c", estimate: 40 * time.Minute},
+		{name: "d", estimate: 30 * time.Minute},
+		{name: "e", estimate: 20 * time.Minute},
+		{name: "f", estimate: 10 * time.Minute},
+	}
+	shards := assign(balanced, "setup", []string{"pool-0", "pool-1"})
+
+	assert.Equal(70*time.Minute, makespan(shards))
+	for _, s := range shards {
+		assert.Equal(70*time.Minute, s.estimate, "%s is not balanced", s.project)
+	}
+}
+
+// TestSelectFixtures asserts fixtures are selected by name and unknown names
+// are rejected.
+func TestSelectFixtures(t *testing.T) {
+	assert := assert.New(t)
+
+	all, err := selectFixtures(nil)
+	assert.NoError(err)
+	assert.Equal(fixtures, all)
+
+	some, err := selectFixtures([]string{"cmek", "looker"})
+	if assert.NoError(err) && assert.Len(some, 2) {
+		assert.Equal("TestCMEK", some[0].test)
+		assert.True(some[1].pinned)
+	}
+
+	_, err = selectFixtures([]string{"cmek", "missing"})
+	assert.ErrorContains(err, `unknown fixture "missing"`)
+}
+
+// TestDiscover asserts examples and test fixtures without a test package are
+// discovered as TestAll subtests, a test fixture covers its example, and a
+// known fixture deployed by another package is not discovered.
+func TestDiscover(t *testing.T) {
+	assert := assert.New(t)
+	root := t.TempDir()
+	testDir := filepath.Join(root, "test", "integration")
+	for _, dir := range []string{
+		filepath.Join(testDir, "cmek"),
+		filepath.Join(testDir, "testutils"),
+		filepath.Join(root, "test", "fixtures", "cmek"),
+		filepath.Join(root, "test", "fixtures", "minimal"),
+		filepath.Join(root, "test", "fixtures", "analytics_lakehouse_full"),
+		filepath.Join(root, "examples", "cmek"),
+		filepath.Join(root, "examples", "minimal"),
+		filepath.Join(root, "examples", "simple"),
+	} {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	discovered, err := discover(testDir)
+	if assert.NoError(err) && assert.Len(discovered, 2) {
+		assert.Equal("minimal", discovered[0].name)
+		assert.Equal("TestAll/fixtures/minimal", discovered[0].test)
+		assert.Equal("simple", discovered[1].name)
+		assert.Equal("TestAll/examples/simple", discovered[1].test)
+		assert.Equal(".", discovered[1].dir())
+		assert.Equal("^TestAll$/^examples$/^simple$", discovered[1].runPattern())
+	}
+}
+
+// TestRunPattern asserts a fixture's test is matched exactly.
+func TestRunPattern(t *testing.T) {
+	f := fixture{name: "cmek", test: "TestCMEK"}
+	assert.Equal(t, "./cmek", f.dir())
+	assert.Equal(t, "^TestCMEK$", f.runPattern())
+}
@@ -0,0 +1,136 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Command tfinstall installs the Terraform release a test run is pinned to,
+// so the suite can run under the oldest release the module's required_version
+// allows as well as the latest one. The minimum is read from the root
+// module's versions.tf, which every example must agree with, so the stated
+// constraint and the release it is tested with cannot drift apart. The
+// release archive is checked against its published SHA256 sum.
+//
+// Run it from test/integration, then point TF_BINARY at the binary:
+//
+//	go run ./cmd/tfinstall -version=min -out=/workspace/bin/terraform
+//	go run ./cmd/tfinstall -version=latest -out=/workspace/bin/terraform
+//
+// Without -version it links -out to the terraform on the PATH instead.
+package main
+
+import (
+	"archive/zip"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+// releasesURL is where Terraform releases are published.
+const releasesURL = "https://releases.hashicorp.com/terraform"
+
+func main() {
+	var version, out, module string
+	flag.StringVar(&version, "version", "", "min for the oldest release the module allows, latest, or a release such as 1.5.7.")
+	flag.StringVar(&out, "out", "bin/terraform", "Path to install the binary to.")
+	flag.StringVar(&module, "module", filepath.Join("..", ".."), "Directory of the root module.")
+	flag.Parse()
+
+	if err := os.MkdirAll(filepath.Dir(out), 0o755); err != nil {
+		log.Fatal(err)
+	}
+	if version == "" {
+		path, err := exec.LookPath("terraform")
+		if err != nil {
+			log.Fatal(err)
+		}
+		os.Remove(out)
+		if err := os.Symlink(path, out); err != nil {
+			log.Fatal(err)
+		}
+		log.Printf("linked %s to %s", out, path)
+		return
+	}
+
+	release, err := resolveVersion(version, module)
+	if err != nil {
+		log.Fatalf("resolving Terraform %s: %v", version, err)
+	}
+	if err := install(release, out); err != nil {
+		log.Fatalf("installing Terraform %s: %v", release, err)
+	}
+	log.Printf("installed Terraform %s (%s) to %s", release, version, out)
+}
+
+// install downloads release for this platform and writes its binary to out.
+func install(release, out string) error {
+	archive := fmt.Sprintf("terraform_%s_%s_%s.zip", release, runtime.GOOS, runtime.GOARCH)
+	data, err := download(fmt.Sprintf("%s/%s/%s", releasesURL, release, archive))
+	if err != nil {
+		return err
+	}
+	sums, err := download(fmt.Sprintf("%s/%s/terraform_%s_SHA256SUMS", releasesURL, release, release))
+	if err != nil {
+		return err
+	}
+	sum := sha256.Sum256(data)
+	if !strings.Contains(string(sums), hex.EncodeToString(sum[:])+"  "+archive) {
+		return fmt.Errorf("%s does not match its published SHA256 sum", archive)
+	}
+
+	reader, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return err
+	}
+	for _, f := range reader.File {
+		if f.Name != "terraform" {
+			continue
+		}
+		src, err := f.Open()
+		if err != nil {
+			return err
+		}
+		defer src.Close()
+		os.Remove(out)
+		dst, err := os.OpenFile(out, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0o755)
+		if err != nil {
+			return err
+		}
+		if _, err := io.Copy(dst, src); err != nil {
+			dst.Close()
+			return err
+		}
+		return dst.Close()
+	}
+	return fmt.Errorf("%s has no terraform binary", archive)
+}
+
+func download(url string) ([]byte, error) {
+	resp, err := http.Get(url)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%s returned %s", url, resp.Status)
+	}
+	return io.ReadAll(resp.Body)
+}
@@ -0,0 +1,93 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"path/filepath"
+	"regexp"
+	"sort"
+
+	"github.com/terraform-google-modules/terraform-google-analytics-lakehouse/test/integration/testutils"
+)
+
+// checkpointURL reports the latest Terraform release.
+const checkpointURL = "https://checkpoint-api.hashicorp.com/v1/check/terraform"
+
+var versionPattern = regexp.MustCompile(`^\d+(\.\d+){0,2}$`)
+
+// moduleConstraint returns the required_version the root module at dir and
+// its examples state, which must all be the same.
+func moduleConstraint(dir string) (string, error) {
+	examples, err := filepath.Glob(filepath.Join(dir, "examples", "*", "versions.tf"))
+	if err != nil {
+		return "", err
+	}
+	sort.Strings(examples)
+	root := filepath.Join(dir, "versions.tf")
+	constraint, err := testutils.RequiredVersion(root)
+	if err != nil {
+		return "", err
+	}
+	for _, path := range examples {
+		example, err := testutils.RequiredVersion(path)
+		if err != nil {
+			return "", err
+		}
+		if example != constraint {
+			return "", fmt.Errorf("%s requires Terraform %q but %s requires %q", path, example, root, constraint)
+		}
+	}
+	return constraint, nil
+}
+
+// latestVersion returns the latest Terraform release.
+func latestVersion() (string, error) {
+	resp, err := http.Get(checkpointURL)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("%s returned %s", checkpointURL, resp.Status)
+	}
+	var check struct {
+		CurrentVersion string `json:"current_version"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&check); err != nil {
+		return "", err
+	}
+	return check.CurrentVersion, nil
+}
+
+// resolveVersion resolves min, latest or a release to the release to install.
+func resolveVersion(version, moduleDir string) (string, error) {
+	switch version {
+	case "min":
+		constraint, err := moduleConstraint(moduleDir)
+		if err != nil {
+			return "", err
+		}
+		return testutils.MinimumVersion(constraint)
+	case "latest":
+		return latestVersion()
+	}
+	if !versionPattern.MatchString(version) {
+		return "", fmt.Errorf("-version must be min, latest or a release such as 1.5.7, got %q", version)
+	}
+	return testutils.FullVersion(version), nil
+}
@@ -0,0 +1,56 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/terraform-google-modules/terraform-google-analytics-lakehouse/test/integration/testutils"
+)
+
+// TestMinimumVersion asserts the oldest release a constraint allows is its
+// highest lower bound, padded to a full release.
+func TestMinimumVersion(t *testing.T) {
+	for constraint, want := range map[string]string{
+		">= 1.3":                "1.3.0",
+		">= 1.3, < 2.0":         "1.3.0",
+		"~> 1.5.7":              "1.5.7",
+		">=1.3,>=1.4.2":         "1.4.2",
+		"1.6.0":                 "1.6.0",
+		"< 2.0, >= 1.3, != 1.4": "1.3.0",
+	} {
+		got, err := testutils.MinimumVersion(constraint)
+		if assert.NoError(t, err, constraint) {
+			assert.Equal(t, want, got, constraint)
+		}
+	}
+	for _, constraint := range []string{"< 2.0", "> 1.3", ">= latest", ""} {
+		_, err := testutils.MinimumVersion(constraint)
+		assert.Error(t, err, constraint)
+	}
+}
+
+// TestModuleConstraint asserts the root module and every example state the
+// same required_version, and that it has a minimum the matrix can install.
+func TestModuleConstraint(t *testing.T) {
+	constraint, err := moduleConstraint(filepath.Join("..", "..", "..", ".."))
+	if !assert.NoError(t, err) {
+		return
+	}
+	_, err = testutils.MinimumVersion(constraint)
+	assert.NoError(t, err, "required_version %q", constraint)
+}
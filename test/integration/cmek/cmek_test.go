@@ -0,0 +1,76 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmek
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/GoogleCloudPlatform/cloud-foundation-toolkit/infra/blueprint-test/pkg/gcloud"
+	"github.com/gruntwork-io/terratest/modules/terraform"
+	"github.com/stretchr/testify/assert"
+	"github.com/terraform-google-modules/terraform-google-analytics-lakehouse/test/integration/testutils"
+)
+
+func TestCMEK(t *testing.T) {
+	cmek := testutils.NewExampleTest(t, "", "cmek")
+
+	cmek.Verify(func(assert *assert.Assertions) {
+		projectID := cmek.ProjectID()
+		region := cmek.Region()
+		key := cmek.GetStringOutput("kms_key_name")
+
+		// Assert the workflows could write through the key
+		testutils.WaitForWorkflow(t, projectID, "copy-data")
+		testutils.WaitForWorkflow(t, projectID, "project-setup")
+
+		// Assert the blueprint's dataset defaults to the key; Dataplex creates
+		// the zone datasets with Google-managed encryption
+		testutils.VerifyDatasetEncryption(t, assert, projectID, map[string]string{
+			cmek.GetStringOutput("lakehouse_dataset_id"): key,
+		})
+
+		// Assert every blueprint bucket defaults to the key, and that the
+		// buckets named with the module's suffix are all it reports
+		warehouseBucket := cmek.GetStringOutput("warehouse_bucket")
+		suffix := testutils.RandomSuffix(warehouseBucket)
+		expected := terraform.OutputList(t, cmek.GetTFOptions(), "buckets")
+		buckets := []string{}
+		for _, bucket := range gcloud.Runf(t, "storage buckets list --project=%s", projectID).Array() {
+			name := bucket.Get("name").String()
+			if !strings.HasSuffix(name, "-"+suffix) {
+				continue
+			}
+			buckets = append(buckets, name)
+			assert.Equal(key, bucket.Get("default_kms_key").String(), "%s is not encrypted with the key", name)
+		}
+		assert.ElementsMatch(expected, buckets, "Unexpected blueprint buckets")
+
+		// Assert data written by the Iceberg batch was encrypted with the key
+		objects := gcloud.Runf(t, "storage objects list gs://%s/**", warehouseBucket).Array()
+		assert.NotEmpty(objects, "Warehouse bucket is empty")
+		for _, object := range objects {
+			assert.True(strings.HasPrefix(object.Get("kms_key").String(), key+"/"), "%s is not encrypted with the key", object.Get("name").String())
+		}
+
+		// Assert the Dataproc PHS disks are encrypted with the key
+		clusters := gcloud.Runf(t, "dataproc clusters list --project=%s --region=%s", projectID, region).Array()
+		assert.NotEmpty(clusters, "No Dataproc cluster found")
+		for _, cluster := range clusters {
+			assert.Equal(key, cluster.Get("config.encryptionConfig.gcePdKmsKeyName").String(), "%s disks are not encrypted with the key", cluster.Get("clusterName").String())
+		}
+	})
+	cmek.Test()
+}
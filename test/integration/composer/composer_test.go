@@ -0,0 +1,89 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package composer
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/GoogleCloudPlatform/cloud-foundation-toolkit/infra/blueprint-test/pkg/bq"
+	"github.com/GoogleCloudPlatform/cloud-foundation-toolkit/infra/blueprint-test/pkg/gcloud"
+	"github.com/GoogleCloudPlatform/cloud-foundation-toolkit/infra/blueprint-test/pkg/utils"
+	"github.com/stretchr/testify/assert"
+	"github.com/terraform-google-modules/terraform-google-analytics-lakehouse/test/integration/testutils"
+	"github.com/tidwall/gjson"
+)
+
+// airflowRun runs an Airflow CLI command in the Composer environment and
+// returns its output. The Airflow arguments follow the gcloud flags, so the
+// default --format flag can't be appended.
+func airflowRun(t *testing.T, projectID, region, environment, command string) (string, error) {
+	cmd := fmt.Sprintf("composer environments run %s --project=%s --location=%s %s", environment, projectID, region, command)
+	return gcloud.RunCmdE(t, cmd, gcloud.WithCommonArgs([]string{}))
+}
+
+func TestComposer(t *testing.T) {
+	composer := testutils.NewExampleTest(t, "", "composer")
+
+	composer.Verify(func(assert *assert.Assertions) {
+		projectID := composer.ProjectID()
+		region := composer.Region()
+		environment := composer.GetStringOutput("composer_environment")
+		dag := composer.GetStringOutput("composer_dag")
+
+		// Assert the Composer environment is running
+		env := gcloud.Runf(t, "composer environments describe %s --project=%s --location=%s", environment, projectID, region)
+		assert.Equal("RUNNING", env.Get("state").String(), "Composer environment is not running")
+
+		// Assert Terraform left the workflows for the DAG to run
+		executions := gcloud.Runf(t, "workflows executions list project-setup --project=%s --location=%s", projectID, region).Array()
+		assert.Empty(executions, "project-setup ran before the DAG was triggered")
+
+		// Assert the DAG completes once triggered. The DAG file is picked up
+		// from the bucket asynchronously, so retry the trigger until Airflow
+		// has parsed it.
+		runID := fmt.Sprintf("it-%d", time.Now().UnixNano())
+		trigger := func() (bool, error) {
+			_, err := airflowRun(t, projectID, region, environment, fmt.Sprintf("dags trigger -- %s --run-id=%s", dag, runID))
+			return err != nil, nil
+		}
+		utils.Poll(t, trigger, 20, 30*time.Second)
+
+		state := ""
+		verifyRun := func() (bool, error) {
+			op, err := airflowRun(t, projectID, region, environment, fmt.Sprintf("dags list-runs -- -d %s -o json", dag))
+			// Drop anything gcloud prints ahead of the Airflow JSON output
+			start := strings.Index(op, "[")
+			if err != nil || start < 0 {
+				return true, nil
+			}
+			runs := gjson.Parse(op[start:])
+			state = runs.Get(fmt.Sprintf("#(run_id==%q).state", runID)).String()
+			return state != "success" && state != "failed", nil
+		}
+		utils.Poll(t, verifyRun, 90, time.Minute)
+		assert.Equal("success", state, "DAG run %s did not succeed", runID)
+
+		// Assert the DAG ran both workflows to completion
+		testutils.WaitForWorkflow(t, projectID, "copy-data")
+		testutils.WaitForWorkflow(t, projectID, "project-setup")
+		query := fmt.Sprintf("SELECT count(*) AS count FROM `%s.gcp_lakehouse_ds.agg_events_iceberg`;", projectID)
+		count := bq.Runf(t, "--project_id=%s query --nouse_legacy_sql %s", projectID, query).Get("0.count").Int()
+		assert.Greater(count, int64(0), "agg_events_iceberg is empty")
+	})
+	composer.Test()
+}
@@ -0,0 +1,77 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package datastream
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/GoogleCloudPlatform/cloud-foundation-toolkit/infra/blueprint-test/pkg/bq"
+	"github.com/GoogleCloudPlatform/cloud-foundation-toolkit/infra/blueprint-test/pkg/gcloud"
+	"github.com/GoogleCloudPlatform/cloud-foundation-toolkit/infra/blueprint-test/pkg/utils"
+	"github.com/stretchr/testify/assert"
+	"github.com/terraform-google-modules/terraform-google-analytics-lakehouse/test/integration/testutils"
+	"github.com/tidwall/gjson"
+)
+
+// Number of orders in examples/datastream/sql/orders.sql.
+const sourceOrders = 5
+
+func TestDatastream(t *testing.T) {
+	datastream := testutils.NewExampleTest(t, "", "datastream")
+
+	datastream.Verify(func(assert *assert.Assertions) {
+		projectID := datastream.ProjectID()
+		region := datastream.Region()
+		instance := datastream.GetStringOutput("source_instance")
+		database := datastream.GetStringOutput("source_database")
+		stream := datastream.GetStringOutput("stream_id")
+		table := datastream.GetStringOutput("cdc_table")
+
+		// Assert the stream is running
+		state := ""
+		verifyStream := func() (bool, error) {
+			state = gcloud.Runf(t, "datastream streams describe %s --location=%s --project=%s", stream, region, projectID).Get("state").String()
+			return state != "RUNNING" && state != "FAILED", nil
+		}
+		utils.Poll(t, verifyStream, 20, 30*time.Second)
+		if !assert.Equal("RUNNING", state, "Datastream stream is not running") {
+			return
+		}
+
+		// Assert orders written to the source after the stream started are
+		// replicated into the raw zone
+		gcloud.RunCmd(t, fmt.Sprintf("sql import sql %s %s --database=%s --project=%s --quiet", instance, datastream.GetStringOutput("source_data_uri"), database, projectID))
+		query := fmt.Sprintf("SELECT count(*) AS count, COUNTIF(datastream_metadata.uuid IS NULL) AS untracked FROM `%s.%s`;", projectID, table)
+		count := int64(0)
+		untracked := int64(0)
+		verifyRows := func() (bool, error) {
+			op, err := bq.RunCmdE(t, fmt.Sprintf("--project_id=%s query --nouse_legacy_sql %s", projectID, query))
+			if err != nil {
+				// The table is created with the first change event
+				return true, nil
+			}
+			rows := gjson.Parse(op)
+			count = rows.Get("0.count").Int()
+			untracked = rows.Get("0.untracked").Int()
+			return count < sourceOrders, nil
+		}
+		utils.Poll(t, verifyRows, 30, 30*time.Second)
+		assert.Equal(int64(sourceOrders), count, "Not every source order was replicated into %s", table)
+		assert.Zero(untracked, "Rows in %s have no Datastream metadata", table)
+	})
+	datastream.Test()
+}
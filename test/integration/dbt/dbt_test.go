@@ -0,0 +1,89 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dbt
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/GoogleCloudPlatform/cloud-foundation-toolkit/infra/blueprint-test/pkg/bq"
+	"github.com/GoogleCloudPlatform/cloud-foundation-toolkit/infra/blueprint-test/pkg/gcloud"
+	"github.com/GoogleCloudPlatform/cloud-foundation-toolkit/infra/blueprint-test/pkg/utils"
+	"github.com/stretchr/testify/assert"
+	"github.com/terraform-google-modules/terraform-google-analytics-lakehouse/test/integration/testutils"
+	"github.com/tidwall/gjson"
+)
+
+// The dbt project, relative to this test.
+const dbtProject = "../../../examples/dbt/dbt"
+
+// Marts the dbt project builds.
+var marts = []string{"dim_products", "fct_orders"}
+
+func TestDBT(t *testing.T) {
+	dbt := testutils.NewExampleTest(t, "", "dbt")
+
+	dbt.Verify(func(assert *assert.Assertions) {
+		projectID := dbt.ProjectID()
+		region := dbt.Region()
+		serviceAccount := dbt.GetStringOutput("dbt_service_account")
+		martsDataset := dbt.GetStringOutput("marts_dataset_id")
+		artifacts := dbt.GetStringOutput("dbt_artifacts_bucket")
+
+		// dbt reads the staging tables project-setup publishes
+		testutils.WaitForWorkflow(t, projectID, "project-setup")
+
+		// Assert the dbt build succeeds in Cloud Build
+		build := gcloud.Runf(t, "builds submit %[1]s --config=%[1]s/cloudbuild.yaml --project=%[2]s --region=%[3]s --service-account=projects/%[2]s/serviceAccounts/%[4]s --substitutions=_ARTIFACTS_BUCKET=%[5]s --async", dbtProject, projectID, region, serviceAccount, artifacts)
+		buildID := build.Get("id").String()
+		status := ""
+		verifyBuild := func() (bool, error) {
+			status = gcloud.Runf(t, "builds describe %s --project=%s --region=%s", buildID, projectID, region).Get("status").String()
+			return status == "QUEUED" || status == "PENDING" || status == "WORKING", nil
+		}
+		utils.Poll(t, verifyBuild, 60, 15*time.Second)
+		if !assert.Equal("SUCCESS", status, "dbt build %s did not succeed", buildID) {
+			return
+		}
+
+		// Assert every model ran and every dbt test passed
+		runResults := gjson.Parse(gcloud.RunCmd(t, fmt.Sprintf("storage cat gs://%s/run_results.json", artifacts), gcloud.WithCommonArgs([]string{})))
+		results := runResults.Get("results").Array()
+		assert.NotEmpty(results, "dbt run results are empty")
+		tests := 0
+		for _, result := range results {
+			node := result.Get("unique_id").String()
+			if strings.HasPrefix(node, "test.") {
+				tests++
+				assert.Equal("pass", result.Get("status").String(), "dbt test %s did not pass", node)
+				continue
+			}
+			assert.Equal("success", result.Get("status").String(), "dbt model %s did not build", node)
+		}
+		assert.Greater(tests, 0, "dbt build ran no tests")
+
+		// Assert the marts tables exist and are not empty
+		for _, mart := range marts {
+			table := bq.Runf(t, "--project_id=%s show %s.%s", projectID, martsDataset, mart)
+			assert.Equal("TABLE", table.Get("type").String(), "%s is not a table", mart)
+			query := fmt.Sprintf("SELECT count(*) AS count FROM `%s.%s.%s`;", projectID, martsDataset, mart)
+			count := bq.Runf(t, "--project_id=%s query --nouse_legacy_sql %s", projectID, query).Get("0.count").Int()
+			assert.Greater(count, int64(0), mart)
+		}
+	})
+	dbt.Test()
+}
@@ -15,11 +15,71 @@
 package test
 
 import (
+	"sort"
+	"strings"
 	"testing"
+	"time"
 
+	"github.com/GoogleCloudPlatform/cloud-foundation-toolkit/infra/blueprint-test/pkg/discovery"
 	"github.com/GoogleCloudPlatform/cloud-foundation-toolkit/infra/blueprint-test/pkg/tft"
+	"github.com/stretchr/testify/assert"
+	"github.com/terraform-google-modules/terraform-google-analytics-lakehouse/test/integration/testutils"
 )
 
+// testedElsewhere are the test fixtures deployed by a test package of
+// another name, which TestAll leaves to it.
+var testedElsewhere = map[string]bool{
+	"fixtures/analytics_lakehouse_full": true,
+}
+
+// TestAll deploys every example and test fixture that has no test package
+// of its own, as a subtest named like examples/<name>, so an example is
+// covered from the change that adds it. Each is applied, checked for changes
+// planned after apply and destroyed.
 func TestAll(t *testing.T) {
-	tft.AutoDiscoverAndTest(t)
+	configs := discovery.FindTestConfigs(t, "./")
+	names := []string{}
+	for name := range configs {
+		if !testedElsewhere[name] {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		tfDir := configs[name]
+		t.Run(name, func(t *testing.T) {
+			testDiscovered(t, strings.ReplaceAll(name, "/", "_"), tfDir)
+		})
+	}
+}
+
+func testDiscovered(t *testing.T, fixture, tfDir string) {
+	testutils.ConfigureAuth(t)
+
+	discovered := tft.NewTFBlueprintTest(t, tft.WithTFDir(tfDir), tft.WithRetryableTerraformErrors(testutils.RetryErrors, 60, time.Minute), tft.WithVars(testutils.PoolProjectVars()))
+	timer := testutils.NewStageTimer(t, discovered, fixture)
+	testutils.NewNotifier(t, discovered, fixture, timer)
+
+	discovered.DefineApply(func(assert *assert.Assertions) {
+		timer.Time("apis", func() {
+			testutils.EnableModuleAPIs(t, discovered.GetTFSetupStringOutput("project_id"), discovered.GetTFOptions().TerraformDir)
+		})
+		timer.Time("apply", func() { discovered.DefaultApply(assert) })
+	})
+
+	discovered.DefineVerify(func(assert *assert.Assertions) {
+		// Assert nothing is left to change after apply; an example earns
+		// checks of its own with a test package named after it
+		timer.Time("verify/all", func() { discovered.DefaultVerify(assert) })
+	})
+
+	discovered.DefineTeardown(func(assert *assert.Assertions) {
+		stop := timer.Start("teardown")
+		testutils.WaitForDataprocVMs(t, discovered.GetTFSetupStringOutput("project_id"))
+
+		discovered.DefaultTeardown(assert)
+
+		stop()
+	})
+	discovered.Test()
 }
@@ -0,0 +1,51 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dual_region
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/GoogleCloudPlatform/cloud-foundation-toolkit/infra/blueprint-test/pkg/bq"
+	"github.com/GoogleCloudPlatform/cloud-foundation-toolkit/infra/blueprint-test/pkg/gcloud"
+	"github.com/GoogleCloudPlatform/cloud-foundation-toolkit/infra/blueprint-test/pkg/utils"
+	"github.com/stretchr/testify/assert"
+	"github.com/terraform-google-modules/terraform-google-analytics-lakehouse/test/integration/testutils"
+)
+
+func TestDualRegion(t *testing.T) {
+	dualRegion := testutils.NewExampleTest(t, "", "dual_region")
+
+	dualRegion.Verify(func(assert *assert.Assertions) {
+		projectID := dualRegion.ProjectID()
+		warehouseBucket := dualRegion.GetStringOutput("warehouse_bucket")
+
+		// Assert the warehouse bucket is a turbo-replicated dual-region
+		bucket := gcloud.Runf(t, "storage buckets describe gs://%s --raw --project=%s", warehouseBucket, projectID)
+		assert.Equal("US", bucket.Get("location").String(), "Unexpected warehouse location")
+		assert.Equal("dual-region", bucket.Get("locationType").String(), "Warehouse bucket is not dual-region")
+		assert.ElementsMatch([]string{"US-CENTRAL1", "US-EAST1"}, utils.GetResultStrSlice(bucket.Get("customPlacementConfig.dataLocations").Array()), "Unexpected warehouse data locations")
+		assert.Equal("ASYNC_TURBO", bucket.Get("rpo").String(), "Turbo replication is not enabled on the warehouse bucket")
+
+		// Assert Spark wrote the Iceberg table to the dual-region bucket and
+		// BigQuery in the primary region can read it
+		testutils.WaitForWorkflow(t, projectID, "project-setup")
+		query := fmt.Sprintf("SELECT count(*) AS count FROM `%s.gcp_lakehouse_ds.agg_events_iceberg`;", projectID)
+		count := bq.Runf(t, "--project_id=%s query --nouse_legacy_sql %s", projectID, query).Get("0.count").Int()
+		assert.Greater(count, int64(0), "agg_events_iceberg is empty")
+		assert.NotEmpty(gcloud.Runf(t, "storage objects list gs://%s/warehouse/**", warehouseBucket).Array(), "Warehouse bucket is empty")
+	})
+	dualRegion.Test()
+}
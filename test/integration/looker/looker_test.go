@@ -0,0 +1,123 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package looker
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/GoogleCloudPlatform/cloud-foundation-toolkit/infra/blueprint-test/pkg/bq"
+	"github.com/GoogleCloudPlatform/cloud-foundation-toolkit/infra/blueprint-test/pkg/gcloud"
+	"github.com/GoogleCloudPlatform/cloud-foundation-toolkit/infra/blueprint-test/pkg/utils"
+	"github.com/stretchr/testify/assert"
+	"github.com/terraform-google-modules/terraform-google-analytics-lakehouse/test/integration/testutils"
+	"github.com/tidwall/gjson"
+)
+
+// Name of the Looker connection the test creates to the lakehouse dataset.
+const connection = "lakehouse"
+
+// lookerAPI sends a request to the Looker API and returns the response status
+// and parsed body. The body is form encoded for the login call and JSON
+// otherwise.
+func lookerAPI(t *testing.T, token, method, url, body string) (int, gjson.Result) {
+	req, err := http.NewRequest(method, url, strings.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if token == "" {
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	} else {
+		req.Header.Set("Authorization", "token "+token)
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return resp.StatusCode, gjson.ParseBytes(respBody)
+}
+
+func TestLooker(t *testing.T) {
+	looker := testutils.NewExampleTest(t, "", "looker")
+
+	// The OAuth client can't be created by Terraform, so it is supplied to test/setup
+	if looker.GetTFSetupStringOutput("looker_oauth_client_id") == "" {
+		t.Skip("Looker OAuth client is not configured in test/setup")
+	}
+
+	looker.Verify(func(assert *assert.Assertions) {
+		projectID := looker.ProjectID()
+		region := looker.Region()
+		instance := looker.GetStringOutput("looker_instance")
+		serviceAgent := looker.GetStringOutput("looker_service_agent")
+		dataset := looker.GetStringOutput("lakehouse_dataset_id")
+
+		// Assert the Looker instance is active
+		state := ""
+		verifyInstance := func() (bool, error) {
+			state = gcloud.Runf(t, "looker instances describe %s --project=%s --region=%s", instance, projectID, region).Get("state").String()
+			return state != "ACTIVE" && state != "FAILED", nil
+		}
+		utils.Poll(t, verifyInstance, 30, time.Minute)
+		assert.Equal("ACTIVE", state, "Looker instance is not active")
+
+		// Assert the Looker service agent can read the lakehouse dataset
+		access := bq.Runf(t, "--project_id=%s show %s", projectID, dataset).Get("access")
+		assert.True(access.Get(fmt.Sprintf("#(userByEmail==%q)", serviceAgent)).Exists(), "Looker service agent has no access to %s", dataset)
+
+		// Assert a BigQuery connection using the service agent passes every
+		// Looker connection test. API keys only exist once the instance does.
+		clientID, clientSecret := os.Getenv("LOOKER_API_CLIENT_ID"), os.Getenv("LOOKER_API_CLIENT_SECRET")
+		if clientID == "" || clientSecret == "" {
+			t.Log("Skipping the Looker connection test, LOOKER_API_CLIENT_ID and LOOKER_API_CLIENT_SECRET are not set")
+			return
+		}
+		api := looker.GetStringOutput("looker_uri") + "/api/4.0"
+		status, login := lookerAPI(t, "", "POST", api+"/login", url.Values{"client_id": {clientID}, "client_secret": {clientSecret}}.Encode())
+		if !assert.Equal(http.StatusOK, status, "Looker API login failed: %s", login.Raw) {
+			return
+		}
+		token := login.Get("access_token").String()
+
+		lookerAPI(t, token, "DELETE", api+"/connections/"+connection, "")
+		body := fmt.Sprintf(`{"name": %q, "dialect_name": "bigquery_standard_sql", "host": %q, "database": %q, "uses_application_default_credentials": true}`, connection, projectID, dataset)
+		status, created := lookerAPI(t, token, "POST", api+"/connections", body)
+		if !assert.Equal(http.StatusOK, status, "Could not create the Looker connection: %s", created.Raw) {
+			return
+		}
+		status, results := lookerAPI(t, token, "PUT", api+"/connections/"+connection+"/test", "")
+		if assert.Equal(http.StatusOK, status, "Looker connection test failed: %s", results.Raw) {
+			assert.NotEmpty(results.Array(), "Looker ran no connection tests")
+			for _, result := range results.Array() {
+				assert.Equal("success", result.Get("status").String(), "Looker connection test %q failed: %s", result.Get("name").String(), result.Get("message").String())
+			}
+		}
+		lookerAPI(t, token, "DELETE", api+"/connections/"+connection, "")
+	})
+	looker.Test()
+}
@@ -0,0 +1,268 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package metadata checks the blueprint metadata the Jump Start Solution
+// catalog reads against the root module, without deploying anything.
+package metadata
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/hcl/v2/hclparse"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/stretchr/testify/assert"
+	"github.com/terraform-google-modules/terraform-google-analytics-lakehouse/test/integration/testutils"
+	ctyjson "github.com/zclconf/go-cty/cty/json"
+	"gopkg.in/yaml.v3"
+)
+
+// moduleDir is the root module, relative to this package.
+var moduleDir = filepath.Join("..", "..", "..")
+
+// blueprint is the part of metadata.yaml describing the module's interface.
+type blueprint struct {
+	Spec struct {
+		Info struct {
+			Source struct {
+				Repo string `yaml:"repo"`
+			} `yaml:"source"`
+			ActuationTool struct {
+				Version string `yaml:"version"`
+			} `yaml:"actuationTool"`
+		} `yaml:"info"`
+		Content struct {
+			Examples []struct {
+				Name     string `yaml:"name"`
+				Location string `yaml:"location"`
+			} `yaml:"examples"`
+		} `yaml:"content"`
+		Interfaces struct {
+			Variables []struct {
+				Name         string      `yaml:"name"`
+				Description  string      `yaml:"description"`
+				VarType      string      `yaml:"varType"`
+				DefaultValue interface{} `yaml:"defaultValue"`
+				Required     bool        `yaml:"required"`
+			} `yaml:"variables"`
+			Outputs []struct {
+				Name        string `yaml:"name"`
+				Description string `yaml:"description"`
+			} `yaml:"outputs"`
+		} `yaml:"interfaces"`
+	} `yaml:"spec"`
+}
+
+// display is the part of metadata.display.yaml naming the catalog's inputs.
+type display struct {
+	Spec struct {
+		Info struct {
+			Source struct {
+				Repo string `yaml:"repo"`
+			} `yaml:"source"`
+		} `yaml:"info"`
+		UI struct {
+			Input struct {
+				Variables map[string]struct {
+					Name string `yaml:"name"`
+				} `yaml:"variables"`
+			} `yaml:"input"`
+		} `yaml:"ui"`
+	} `yaml:"spec"`
+}
+
+func readYAML(t *testing.T, name string, out interface{}) {
+	path := filepath.Join(moduleDir, name)
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := yaml.Unmarshal(data, out); err != nil {
+		t.Fatalf("parsing %s: %v", path, err)
+	}
+}
+
+// tfVariable is a variable block of the root module.
+type tfVariable struct {
+	description string
+	// varType is the source of the type expression.
+	varType string
+	// defaultValue is the default as JSON, null when it is null or missing.
+	defaultValue string
+	required     bool
+}
+
+// tfBlocks returns the blocks of a type in a root module file, by name.
+func tfBlocks(t *testing.T, name, blockType string) (map[string]*hclsyntax.Body, []byte) {
+	path := filepath.Join(moduleDir, name)
+	file, diags := hclparse.NewParser().ParseHCLFile(path)
+	if diags.HasErrors() {
+		t.Fatalf("parsing %s: %v", path, diags)
+	}
+	blocks := map[string]*hclsyntax.Body{}
+	for _, block := range file.Body.(*hclsyntax.Body).Blocks {
+		if block.Type == blockType && len(block.Labels) == 1 {
+			blocks[block.Labels[0]] = block.Body
+		}
+	}
+	return blocks, file.Bytes
+}
+
+// stringAttr evaluates a literal string attribute of a block, empty when the
+// block does not set it.
+func stringAttr(t *testing.T, body *hclsyntax.Body, name string) string {
+	attr, ok := body.Attributes[name]
+	if !ok {
+		return ""
+	}
+	value, diags := attr.Expr.Value(nil)
+	if diags.HasErrors() {
+		t.Fatalf("evaluating %s: %v", name, diags)
+	}
+	return value.AsString()
+}
+
+func tfVariables(t *testing.T) map[string]tfVariable {
+	blocks, src := tfBlocks(t, "variables.tf", "variable")
+	variables := map[string]tfVariable{}
+	for name, body := range blocks {
+		v := tfVariable{description: stringAttr(t, body, "description"), defaultValue: "null"}
+		if attr, ok := body.Attributes["type"]; ok {
+			v.varType = string(attr.Expr.Range().SliceBytes(src))
+		}
+		attr, ok := body.Attributes["default"]
+		v.required = !ok
+		if ok {
+			value, diags := attr.Expr.Value(nil)
+			if diags.HasErrors() {
+				t.Fatalf("evaluating the default of %s: %v", name, diags)
+			}
+			if !value.IsNull() {
+				data, err := ctyjson.SimpleJSONValue{Value: value}.MarshalJSON()
+				if err != nil {
+					t.Fatalf("encoding the default of %s: %v", name, err)
+				}
+				v.defaultValue = string(data)
+			}
+		}
+		variables[name] = v
+	}
+	return variables
+}
+
+// withoutSpace strips whitespace, so a type expression matches however the
+// metadata generator wraps it.
+func withoutSpace(s string) string {
+	return strings.Join(strings.Fields(s), "")
+}
+
+// TestVariables asserts metadata.yaml lists exactly the root module's
+// variables, with their types, descriptions, defaults and whether they are
+// required.
+func TestVariables(t *testing.T) {
+	var metadata blueprint
+	readYAML(t, "metadata.yaml", &metadata)
+	variables := tfVariables(t)
+
+	listed := map[string]bool{}
+	for _, v := range metadata.Spec.Interfaces.Variables {
+		listed[v.Name] = true
+		tf, ok := variables[v.Name]
+		if !assert.True(t, ok, "metadata.yaml lists the variable %s, which variables.tf does not declare", v.Name) {
+			continue
+		}
+		assert.Equal(t, tf.description, v.Description, "description of %s", v.Name)
+		assert.Equal(t, withoutSpace(tf.varType), withoutSpace(v.VarType), "varType of %s", v.Name)
+		assert.Equal(t, tf.required, v.Required, "required of %s", v.Name)
+		data, err := json.Marshal(v.DefaultValue)
+		if assert.NoError(t, err, "defaultValue of %s", v.Name) {
+			assert.JSONEq(t, tf.defaultValue, string(data), "defaultValue of %s", v.Name)
+		}
+	}
+	for name := range variables {
+		assert.True(t, listed[name], "metadata.yaml does not list the variable %s", name)
+	}
+}
+
+// TestOutputs asserts metadata.yaml lists exactly the root module's outputs,
+// with their descriptions.
+func TestOutputs(t *testing.T) {
+	var metadata blueprint
+	readYAML(t, "metadata.yaml", &metadata)
+	outputs, _ := tfBlocks(t, "outputs.tf", "output")
+
+	listed := map[string]bool{}
+	for _, o := range metadata.Spec.Interfaces.Outputs {
+		listed[o.Name] = true
+		body, ok := outputs[o.Name]
+		if !assert.True(t, ok, "metadata.yaml lists the output %s, which outputs.tf does not declare", o.Name) {
+			continue
+		}
+		assert.Equal(t, stringAttr(t, body, "description"), o.Description, "description of %s", o.Name)
+	}
+	for name := range outputs {
+		assert.True(t, listed[name], "metadata.yaml does not list the output %s", name)
+	}
+}
+
+// TestDisplayVariables asserts metadata.display.yaml has an input for every
+// variable of the root module and none for variables it no longer declares.
+func TestDisplayVariables(t *testing.T) {
+	var metadata blueprint
+	readYAML(t, "metadata.yaml", &metadata)
+	var ui display
+	readYAML(t, "metadata.display.yaml", &ui)
+	variables := tfVariables(t)
+
+	assert.Equal(t, metadata.Spec.Info.Source.Repo, ui.Spec.Info.Source.Repo, "metadata.display.yaml describes another repository")
+	for key, input := range ui.Spec.UI.Input.Variables {
+		_, ok := variables[key]
+		assert.True(t, ok, "metadata.display.yaml has an input for %s, which variables.tf does not declare", key)
+		assert.Equal(t, key, input.Name, "name of the %s input", key)
+	}
+	for name := range variables {
+		_, ok := ui.Spec.UI.Input.Variables[name]
+		assert.True(t, ok, "metadata.display.yaml has no input for the variable %s", name)
+	}
+}
+
+// TestRequirements asserts metadata.yaml states the Terraform versions the
+// root module allows and lists every example.
+func TestRequirements(t *testing.T) {
+	var metadata blueprint
+	readYAML(t, "metadata.yaml", &metadata)
+
+	constraint, err := testutils.RequiredVersion(filepath.Join(moduleDir, "versions.tf"))
+	if assert.NoError(t, err) {
+		assert.Equal(t, constraint, metadata.Spec.Info.ActuationTool.Version, "actuationTool version")
+	}
+
+	dirs, err := filepath.Glob(filepath.Join(moduleDir, "examples", "*", "main.tf"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	examples := []string{}
+	for _, dir := range dirs {
+		examples = append(examples, filepath.Base(filepath.Dir(dir)))
+	}
+	listed := []string{}
+	for _, e := range metadata.Spec.Content.Examples {
+		listed = append(listed, e.Name)
+		assert.Equal(t, "examples/"+e.Name, e.Location, "location of the %s example", e.Name)
+	}
+	assert.ElementsMatch(t, examples, listed, "examples in metadata.yaml")
+}
@@ -0,0 +1,54 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package policy
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/terraform-google-modules/terraform-google-analytics-lakehouse/test/integration/testutils"
+)
+
+// policyExamples are the examples and fixtures whose plans are checked: the
+// default deployment, the one enabling every optional feature, and the one
+// passing the blueprint a key.
+var policyExamples = []string{
+	filepath.Join("..", "..", "..", "examples", "analytics_lakehouse"),
+	filepath.Join("..", "..", "fixtures", "analytics_lakehouse_full"),
+	filepath.Join("..", "..", "..", "examples", "cmek"),
+}
+
+// TestExamplePolicies plans each of policyExamples with the applied test
+// setup's outputs as variables, like the fixtures are applied, and fails on
+// the policies the plan violates. The examples must be initialized.
+func TestExamplePolicies(t *testing.T) {
+	for _, example := range policyExamples {
+		t.Run(filepath.Base(example), func(t *testing.T) {
+			plan, err := testutils.PlanExample(example, filepath.Join("..", "..", "setup"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			violations, err := evaluate(context.Background(), plan)
+			if !assert.NoError(t, err) {
+				return
+			}
+			for _, v := range violations {
+				t.Errorf("%s policy: %s", v.policy, v.message)
+			}
+		})
+	}
+}
@@ -0,0 +1,87 @@
+# Copyright 2023 Google LLC
+#
+# Licensed under the Apache License, Version 2.0 (the "License");
+# you may not use this file except in compliance with the License.
+# You may obtain a copy of the License at
+#
+#      http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+
+# Denies resources storing data with Google-managed keys when the example
+# passes the blueprint a Cloud KMS key in kms_key_name.
+package lakehouse.cmek
+
+import data.lakehouse.lib
+import future.keywords.contains
+import future.keywords.if
+import future.keywords.in
+
+cmek_required if {
+	some call in input.configuration.root_module.module_calls
+	key := call.expressions.kms_key_name
+	not key.constant_value == null
+	not key.constant_value == ""
+}
+
+# keyed_blocks are the block and attribute that set the key of each resource
+# type storing data.
+keyed_blocks := {
+	"google_storage_bucket": ["encryption", "default_kms_key_name"],
+	"google_bigquery_dataset": ["default_encryption_configuration", "kms_key_name"],
+}
+
+deny contains msg if {
+	cmek_required
+	some rc in lib.planned
+	[block, attr] := keyed_blocks[rc.type]
+	not keyed(rc.change, block, attr)
+	msg := sprintf("%s: %s must set %s to the Cloud KMS key", [rc.address, block, attr])
+}
+
+deny contains msg if {
+	cmek_required
+	some rc in lib.planned
+	rc.type == "google_dataproc_cluster"
+	not dataproc_keyed(rc.change)
+	msg := sprintf("%s: cluster_config must set encryption_config to the Cloud KMS key", [rc.address])
+}
+
+# The key is set in the planned values, or is unknown until apply, as it is
+# when the key is created by the same apply.
+keyed(change, block, attr) if {
+	some b in change.after[block]
+	is_string(b[attr])
+	b[attr] != ""
+}
+
+keyed(change, block, _) if change.after_unknown[block] == true
+
+keyed(change, block, attr) if {
+	some b in change.after_unknown[block]
+	b[attr] == true
+}
+
+dataproc_keyed(change) if {
+	some config in change.after.cluster_config
+	some encryption in config.encryption_config
+	is_string(encryption.kms_key_name)
+	encryption.kms_key_name != ""
+}
+
+dataproc_keyed(change) if change.after_unknown.cluster_config == true
+
+dataproc_keyed(change) if {
+	some config in change.after_unknown.cluster_config
+	config.encryption_config == true
+}
+
+dataproc_keyed(change) if {
+	some config in change.after_unknown.cluster_config
+	some encryption in config.encryption_config
+	encryption.kms_key_name == true
+}
@@ -0,0 +1,61 @@
+# Copyright 2023 Google LLC
+#
+# Licensed under the Apache License, Version 2.0 (the "License");
+# you may not use this file except in compliance with the License.
+# You may obtain a copy of the License at
+#
+#      http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+
+# Denies resources of the blueprint without its solution label, which the
+# label audit and billing reports find them by. Keep labeled_types in sync
+# with labeledResources in testutils/labels.go.
+package lakehouse.labels
+
+import data.lakehouse.lib
+import future.keywords.contains
+import future.keywords.if
+import future.keywords.in
+
+solution_label := "analytics-lakehouse"
+
+labeled_types := {
+	"google_bigquery_dataset",
+	"google_bigquery_table",
+	"google_cloudfunctions2_function",
+	"google_dataplex_asset",
+	"google_dataplex_lake",
+	"google_dataplex_zone",
+	"google_dataproc_cluster",
+	"google_pubsub_subscription",
+	"google_pubsub_topic",
+	"google_storage_bucket",
+	"google_workflows_workflow",
+}
+
+deny contains msg if {
+	some rc in lib.planned
+	lib.in_module(rc)
+	rc.type in labeled_types
+	not labeled(rc.change)
+	msg := sprintf("%s: labels must include %s", [rc.address, solution_label])
+}
+
+# Cloud SQL instances take their labels in settings
+deny contains msg if {
+	some rc in lib.planned
+	lib.in_module(rc)
+	rc.type == "google_sql_database_instance"
+	some settings in rc.change.after.settings
+	not settings.user_labels[solution_label]
+	msg := sprintf("%s: settings.user_labels must include %s", [rc.address, solution_label])
+}
+
+labeled(change) if change.after.labels[solution_label]
+
+labeled(change) if change.after_unknown.labels == true
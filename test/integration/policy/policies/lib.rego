@@ -0,0 +1,33 @@
+# Copyright 2023 Google LLC
+#
+# Licensed under the Apache License, Version 2.0 (the "License");
+# you may not use this file except in compliance with the License.
+# You may obtain a copy of the License at
+#
+#      http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+
+# Rules the policies share. Every policy is a package under lakehouse with a
+# deny set of messages, evaluated against an example's plan as written by
+# terraform show -json.
+package lakehouse.lib
+
+import future.keywords.contains
+import future.keywords.if
+import future.keywords.in
+
+# planned are the resources the plan creates or keeps.
+planned contains rc if {
+	some rc in input.resource_changes
+	rc.mode == "managed"
+	rc.change.after != null
+}
+
+# in_module holds for resources of the blueprint module, rather than of the
+# example around it.
+in_module(rc) if startswith(rc.address, "module.analytics_lakehouse.")
@@ -0,0 +1,74 @@
+# Copyright 2023 Google LLC
+#
+# Licensed under the Apache License, Version 2.0 (the "License");
+# you may not use this file except in compliance with the License.
+# You may obtain a copy of the License at
+#
+#      http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+
+# Denies resources that would give VMs external IPs, or open a database to the
+# internet. The blueprint must deploy where constraints/compute.vmExternalIpAccess
+# denies external IPs.
+package lakehouse.no_public_ips
+
+import data.lakehouse.lib
+import future.keywords.contains
+import future.keywords.if
+import future.keywords.in
+
+# Dataproc's default differs across image versions, so it must be explicit
+deny contains msg if {
+	some rc in lib.planned
+	rc.type == "google_dataproc_cluster"
+	not internal_ip_only(rc.change.after)
+	msg := sprintf("%s: Dataproc VMs must set internal_ip_only", [rc.address])
+}
+
+internal_ip_only(after) if {
+	some config in after.cluster_config
+	some gce in config.gce_cluster_config
+	gce.internal_ip_only == true
+}
+
+deny contains msg if {
+	some rc in lib.planned
+	rc.type in {"google_compute_instance", "google_compute_instance_template"}
+	some nic in rc.change.after.network_interface
+	count(nic.access_config) > 0
+	msg := sprintf("%s: network interfaces must not have an access_config", [rc.address])
+}
+
+deny contains msg if {
+	some rc in lib.planned
+	rc.type in {"google_dataflow_job", "google_dataflow_flex_template_job"}
+	not rc.change.after.ip_configuration == "WORKER_IP_PRIVATE"
+	msg := sprintf("%s: Dataflow workers must set ip_configuration to WORKER_IP_PRIVATE", [rc.address])
+}
+
+deny contains msg if {
+	some rc in lib.planned
+	rc.type == "google_composer_environment"
+	not private_environment(rc.change.after)
+	msg := sprintf("%s: Composer nodes must be private with private_environment_config", [rc.address])
+}
+
+private_environment(after) if {
+	some config in after.config
+	count(config.private_environment_config) > 0
+}
+
+deny contains msg if {
+	some rc in lib.planned
+	rc.type == "google_sql_database_instance"
+	some settings in rc.change.after.settings
+	some ip in settings.ip_configuration
+	some network in ip.authorized_networks
+	network.value == "0.0.0.0/0"
+	msg := sprintf("%s: authorized networks must not include 0.0.0.0/0", [rc.address])
+}
@@ -0,0 +1,91 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package policy checks what examples would deploy against the blueprint's
+// policies before anything is applied, so a violation fails in seconds
+// rather than after a deployment. Plans, as `terraform show -json` output,
+// are evaluated with the Rego policies embedded from policies: no external
+// IPs on VMs, CMEK on every dataset, bucket and cluster when the example
+// passes the blueprint a key, and the solution label on every labelable
+// resource of the blueprint.
+package policy
+
+import (
+	"context"
+	"embed"
+	"encoding/json"
+	"fmt"
+	"path"
+	"sort"
+
+	"github.com/open-policy-agent/opa/rego"
+)
+
+// policies are the Rego policies a plan is checked against, each a package
+// under lakehouse with a deny set of messages.
+//
+//go:embed policies/*.rego
+var policies embed.FS
+
+// violation is a message a policy denied a plan with.
+type violation struct {
+	policy  string
+	message string
+}
+
+// evaluate checks a plan, given as `terraform show -json` output, against the
+// policies and returns their violations sorted by policy and message.
+func evaluate(ctx context.Context, plan []byte) ([]violation, error) {
+	var input interface{}
+	if err := json.Unmarshal(plan, &input); err != nil {
+		return nil, fmt.Errorf("parsing the plan: %v", err)
+	}
+
+	options := []func(*rego.Rego){rego.Query("data.lakehouse[policy].deny[message]")}
+	files, err := policies.ReadDir("policies")
+	if err != nil {
+		return nil, err
+	}
+	for _, f := range files {
+		name := path.Join("policies", f.Name())
+		src, err := policies.ReadFile(name)
+		if err != nil {
+			return nil, err
+		}
+		options = append(options, rego.Module(name, string(src)))
+	}
+	query, err := rego.New(options...).PrepareForEval(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("compiling the policies: %v", err)
+	}
+	results, err := query.Eval(ctx, rego.EvalInput(input))
+	if err != nil {
+		return nil, fmt.Errorf("evaluating the policies: %v", err)
+	}
+
+	violations := []violation{}
+	for _, r := range results {
+		violations = append(violations, violation{
+			policy:  fmt.Sprint(r.Bindings["policy"]),
+			message: fmt.Sprint(r.Bindings["message"]),
+		})
+	}
+	sort.Slice(violations, func(i, j int) bool {
+		if violations[i].policy != violations[j].policy {
+			return violations[i].policy < violations[j].policy
+		}
+		return violations[i].message < violations[j].message
+	})
+	return violations, nil
+}
@@ -0,0 +1,117 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package policy
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// compliantPlan is a trimmed `terraform show -json` plan of the cmek example,
+// whose key is created by the same apply, so it is unknown in some planned
+// values.
+const compliantPlan = `{
+  "configuration": {"root_module": {"module_calls": {
+    "analytics_lakehouse": {"expressions": {"kms_key_name": {"references": ["module.kms.keys[\"lakehouse\"]", "module.kms.keys", "module.kms"]}}}
+  }}},
+  "resource_changes": [
+    {"address": "module.analytics_lakehouse.google_storage_bucket.raw_bucket", "mode": "managed", "type": "google_storage_bucket",
+     "change": {"actions": ["create"], "after": {"labels": {"analytics-lakehouse": "true"}}, "after_unknown": {"encryption": true}}},
+    {"address": "module.analytics_lakehouse.google_bigquery_dataset.gcp_lakehouse_ds[0]", "mode": "managed", "type": "google_bigquery_dataset",
+     "change": {"actions": ["create"], "after": {"labels": {"analytics-lakehouse": "true"}, "default_encryption_configuration": [{"kms_key_name": "projects/p/locations/us-central1/keyRings/k/cryptoKeys/lakehouse"}]}, "after_unknown": {}}},
+    {"address": "module.analytics_lakehouse.google_dataproc_cluster.phs[0]", "mode": "managed", "type": "google_dataproc_cluster",
+     "change": {"actions": ["create"], "after": {"labels": {"analytics-lakehouse": "true"}, "cluster_config": [{"gce_cluster_config": [{"internal_ip_only": true}]}]},
+                "after_unknown": {"cluster_config": [{"encryption_config": true}]}}},
+    {"address": "google_dataflow_flex_template_job.ingest", "mode": "managed", "type": "google_dataflow_flex_template_job",
+     "change": {"actions": ["create"], "after": {"labels": {"analytics-lakehouse": "true"}, "ip_configuration": "WORKER_IP_PRIVATE"}, "after_unknown": {}}},
+    {"address": "module.analytics_lakehouse.google_sql_database_instance.serving[0]", "mode": "managed", "type": "google_sql_database_instance",
+     "change": {"actions": ["create"], "after": {"settings": [{"user_labels": {"analytics-lakehouse": "true"}, "ip_configuration": [{"ipv4_enabled": true, "authorized_networks": []}]}]}, "after_unknown": {}}},
+    {"address": "google_composer_environment.lakehouse", "mode": "managed", "type": "google_composer_environment",
+     "change": {"actions": ["create"], "after": {"config": [{"private_environment_config": [{"enable_private_endpoint": false}]}]}, "after_unknown": {}}},
+    {"address": "module.analytics_lakehouse.google_storage_bucket.phs-temp-bucket", "mode": "managed", "type": "google_storage_bucket",
+     "change": {"actions": ["delete"], "after": null, "after_unknown": {}}},
+    {"address": "module.analytics_lakehouse.data.google_project.project", "mode": "data", "type": "google_project",
+     "change": {"actions": ["read"], "after": {}, "after_unknown": {}}}
+  ]
+}`
+
+// violatingPlan is a trimmed plan breaking every policy.
+const violatingPlan = `{
+  "configuration": {"root_module": {"module_calls": {
+    "analytics_lakehouse": {"expressions": {"kms_key_name": {"constant_value": "projects/p/locations/us-central1/keyRings/k/cryptoKeys/lakehouse"}}}
+  }}},
+  "resource_changes": [
+    {"address": "module.analytics_lakehouse.google_storage_bucket.raw_bucket", "mode": "managed", "type": "google_storage_bucket",
+     "change": {"actions": ["create"], "after": {"labels": {}, "encryption": []}, "after_unknown": {"encryption": []}}},
+    {"address": "module.analytics_lakehouse.google_dataproc_cluster.phs[0]", "mode": "managed", "type": "google_dataproc_cluster",
+     "change": {"actions": ["update"], "after": {"labels": {"analytics-lakehouse": "true"}, "cluster_config": [{"gce_cluster_config": [{"internal_ip_only": false}], "encryption_config": []}]},
+                "after_unknown": {"cluster_config": [{"encryption_config": []}]}}},
+    {"address": "google_dataflow_flex_template_job.ingest", "mode": "managed", "type": "google_dataflow_flex_template_job",
+     "change": {"actions": ["create"], "after": {"labels": {"analytics-lakehouse": "true"}}, "after_unknown": {}}},
+    {"address": "module.analytics_lakehouse.google_sql_database_instance.serving[0]", "mode": "managed", "type": "google_sql_database_instance",
+     "change": {"actions": ["create"], "after": {"settings": [{"user_labels": {}, "ip_configuration": [{"ipv4_enabled": true, "authorized_networks": [{"value": "0.0.0.0/0"}]}]}]}, "after_unknown": {}}},
+    {"address": "google_compute_instance.bastion", "mode": "managed", "type": "google_compute_instance",
+     "change": {"actions": ["create"], "after": {"network_interface": [{"access_config": [{}]}]}, "after_unknown": {}}},
+    {"address": "google_composer_environment.lakehouse", "mode": "managed", "type": "google_composer_environment",
+     "change": {"actions": ["create"], "after": {"config": [{"private_environment_config": []}]}, "after_unknown": {}}}
+  ]
+}`
+
+// TestCompliantPlan asserts a plan following every policy passes, with keys
+// unknown until apply counted as set, and deleted and data resources and
+// resources outside the blueprint left out of the label policy.
+func TestCompliantPlan(t *testing.T) {
+	violations, err := evaluate(context.Background(), []byte(compliantPlan))
+	if assert.NoError(t, err) {
+		assert.Empty(t, violations)
+	}
+}
+
+// TestViolatingPlan asserts every policy denies what it covers.
+func TestViolatingPlan(t *testing.T) {
+	violations, err := evaluate(context.Background(), []byte(violatingPlan))
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, []violation{
+		{"cmek", "module.analytics_lakehouse.google_dataproc_cluster.phs[0]: cluster_config must set encryption_config to the Cloud KMS key"},
+		{"cmek", "module.analytics_lakehouse.google_storage_bucket.raw_bucket: encryption must set default_kms_key_name to the Cloud KMS key"},
+		{"labels", "module.analytics_lakehouse.google_sql_database_instance.serving[0]: settings.user_labels must include analytics-lakehouse"},
+		{"labels", "module.analytics_lakehouse.google_storage_bucket.raw_bucket: labels must include analytics-lakehouse"},
+		{"no_public_ips", "google_composer_environment.lakehouse: Composer nodes must be private with private_environment_config"},
+		{"no_public_ips", "google_compute_instance.bastion: network interfaces must not have an access_config"},
+		{"no_public_ips", "google_dataflow_flex_template_job.ingest: Dataflow workers must set ip_configuration to WORKER_IP_PRIVATE"},
+		{"no_public_ips", "module.analytics_lakehouse.google_dataproc_cluster.phs[0]: Dataproc VMs must set internal_ip_only"},
+		{"no_public_ips", "module.analytics_lakehouse.google_sql_database_instance.serving[0]: authorized networks must not include 0.0.0.0/0"},
+	}, violations)
+}
+
+// TestCMEKNotRequired asserts Google-managed keys pass when the example does
+// not pass the blueprint a key.
+func TestCMEKNotRequired(t *testing.T) {
+	plan := `{
+	  "configuration": {"root_module": {"module_calls": {"analytics_lakehouse": {"expressions": {"kms_key_name": {"constant_value": null}}}}}},
+	  "resource_changes": [
+	    {"address": "module.analytics_lakehouse.google_storage_bucket.raw_bucket", "mode": "managed", "type": "google_storage_bucket",
+	     "change": {"actions": ["create"], "after": {"labels": {"analytics-lakehouse": "true"}}, "after_unknown": {}}}
+	  ]
+	}`
+	violations, err := evaluate(context.Background(), []byte(plan))
+	if assert.NoError(t, err) {
+		assert.Empty(t, violations)
+	}
+}
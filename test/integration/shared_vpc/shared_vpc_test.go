@@ -0,0 +1,53 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package shared_vpc
+
+import (
+	"testing"
+
+	"github.com/GoogleCloudPlatform/cloud-foundation-toolkit/infra/blueprint-test/pkg/gcloud"
+	"github.com/stretchr/testify/assert"
+	"github.com/terraform-google-modules/terraform-google-analytics-lakehouse/test/integration/testutils"
+)
+
+func TestSharedVPC(t *testing.T) {
+	sharedVPC := testutils.NewExampleTest(t, "", "shared_vpc")
+
+	// The host project is only created when enabled in test/setup
+	if sharedVPC.GetTFSetupStringOutput("shared_vpc_subnetwork") == "" {
+		t.Skip("Shared VPC fixture is not enabled in test/setup")
+	}
+
+	sharedVPC.Verify(func(assert *assert.Assertions) {
+		projectID := sharedVPC.ProjectID()
+		region := sharedVPC.Region()
+		subnet := sharedVPC.GetTFSetupStringOutput("shared_vpc_subnetwork")
+
+		assert.Equal(subnet, sharedVPC.GetStringOutput("dataproc_subnetwork"), "Module is not using the shared subnet")
+
+		// Assert no network was created in the service project
+		assert.Empty(gcloud.Runf(t, "compute networks list --project=%s", projectID).Array(), "Service project has its own network")
+
+		// Assert the serverless Spark batch could start on the shared subnet
+		testutils.WaitForWorkflow(t, projectID, "project-setup")
+
+		// Assert the PHS and serverless Spark batches run on the shared subnet
+		testutils.VerifyDataprocSubnet(t, assert, projectID, region, subnet)
+
+		// Assert the PHS has no external IP on the shared subnet
+		testutils.VerifyNoExternalIPs(t, assert, projectID)
+	})
+	sharedVPC.Test()
+}
@@ -0,0 +1,74 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package simple_example
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/GoogleCloudPlatform/cloud-foundation-toolkit/infra/blueprint-test/pkg/bq"
+	"github.com/GoogleCloudPlatform/cloud-foundation-toolkit/infra/blueprint-test/pkg/gcloud"
+	"github.com/stretchr/testify/assert"
+	"github.com/terraform-google-modules/terraform-google-analytics-lakehouse/test/integration/testutils"
+)
+
+// smokeBudget is how long the smoke test may take from apply to the end of
+// teardown, so it stays a quick signal ahead of the full suite.
+const smokeBudget = 15 * time.Minute
+
+// TestSimpleExample deploys the smallest footprint of the blueprint: the
+// thelook tables only, without Dataproc. It checks the data lands and the
+// lakehouse view can be queried, within smokeBudget.
+func TestSimpleExample(t *testing.T) {
+	simple := testutils.NewExampleTest(t, "", "simple_example")
+
+	var start time.Time
+	simple.BeforeApply(func(assert *assert.Assertions) {
+		start = time.Now()
+	})
+
+	simple.Verify(func(assert *assert.Assertions) {
+		projectID := simple.ProjectID()
+		region := simple.Region()
+		tablesBucket := simple.GetStringOutput("tables_bucket")
+
+		testutils.WaitForWorkflow(t, projectID, "copy-data")
+		testutils.WaitForWorkflow(t, projectID, "project-setup")
+
+		// Assert only the thelook tables were copied
+		assert.NotEmpty(gcloud.Runf(t, "storage objects list gs://%s/thelook_ecommerce/**", tablesBucket).Array(), "thelook tables were not copied")
+		assert.Empty(gcloud.Runf(t, "storage objects list gs://%s/new-york-taxi-trips/**", tablesBucket).Array(), "Taxi trips were copied")
+
+		// Assert nothing ran on Dataproc; batches of fixtures that used the
+		// project before are kept, so only this deployment's are listed
+		assert.Empty(gcloud.Runf(t, "dataproc clusters list --project=%s --region=%s", projectID, region).Array(), "A Dataproc cluster was created")
+		batches := gcloud.Runf(t, "dataproc batches list --project=%s --region=%s --filter=createTime>=%s", projectID, region, start.UTC().Format(time.RFC3339)).Array()
+		assert.Empty(batches, "A Spark batch was run")
+
+		// Assert the lakehouse view reads the tables Dataplex discovered
+		query := fmt.Sprintf("SELECT count(*) AS count FROM `%s.%s.view_ecommerce`;", projectID, simple.GetStringOutput("lakehouse_dataset_id"))
+		count := bq.Runf(t, "--project_id=%s query --nouse_legacy_sql %s", projectID, query).Get("0.count").Int()
+		assert.Greater(count, int64(0), "view_ecommerce is empty")
+	})
+
+	simple.AfterTeardown(func(assert *assert.Assertions) {
+		if !start.IsZero() {
+			elapsed := time.Since(start)
+			assert.LessOrEqual(elapsed, smokeBudget, "The smoke test took %s, over its %s budget", elapsed.Round(time.Second), smokeBudget)
+		}
+	})
+	simple.Test()
+}
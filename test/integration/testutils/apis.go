@@ -0,0 +1,224 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package testutils
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/GoogleCloudPlatform/cloud-foundation-toolkit/infra/blueprint-test/pkg/gcloud"
+	"github.com/GoogleCloudPlatform/cloud-foundation-toolkit/infra/blueprint-test/pkg/utils"
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclparse"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/zclconf/go-cty/cty"
+)
+
+// moduleMain and moduleVariables are the root module's main.tf and
+// variables.tf, relative to a fixture directory.
+var (
+	moduleMain      = filepath.Join("..", "..", "..", "main.tf")
+	moduleVariables = filepath.Join("..", "..", "..", "variables.tf")
+)
+
+// probeHosts are the endpoints of services not served at their own name. An
+// empty host is a service with no endpoint of its own, which is not probed.
+var probeHosts = map[string]string{
+	"cloudapis.googleapis.com":   "",
+	"storage-api.googleapis.com": "storage.googleapis.com",
+}
+
+// ModuleAPIs returns the APIs the root module's project-services module
+// activates when deployed by the configuration in tfDir, as listed in its
+// main.tf. The APIs of optional features are gated on their flags, which are
+// evaluated with the inputs moduleInputs finds. A flag only known at apply,
+// such as one set from a variable of the configuration, counts as set.
+func ModuleAPIs(t *testing.T, tfDir string) []string {
+	body := parseBody(t, moduleMain)
+	var expr hclsyntax.Expression
+	for _, block := range body.Blocks {
+		if block.Type == "module" && len(block.Labels) > 0 && block.Labels[0] == "project-services" {
+			if attr, ok := block.Body.Attributes["activate_apis"]; ok {
+				expr = attr.Expr
+			}
+		}
+	}
+	if expr == nil {
+		t.Fatalf("%s has no project-services module with activate_apis", moduleMain)
+	}
+
+	// Each argument of concat is evaluated on its own, so a gate that is
+	// unknown only adds its APIs
+	parts := []hclsyntax.Expression{expr}
+	if call, ok := expr.(*hclsyntax.FunctionCallExpr); ok && call.Name == "concat" {
+		parts = call.Args
+	}
+	ctx := &hcl.EvalContext{Variables: map[string]cty.Value{"var": cty.ObjectVal(moduleInputs(t, tfDir))}}
+	apis := []string{}
+	for _, part := range parts {
+		value, diags := part.Value(ctx)
+		if cond, ok := part.(*hclsyntax.ConditionalExpr); ok && !diags.HasErrors() && !value.IsWhollyKnown() {
+			value, diags = cond.TrueResult.Value(ctx)
+		}
+		if diags.HasErrors() {
+			t.Fatalf("evaluating activate_apis in %s: %v", moduleMain, diags)
+		}
+		for _, v := range value.AsValueSlice() {
+			apis = append(apis, v.AsString())
+		}
+	}
+	return apis
+}
+
+// moduleInputs returns the root module's variables as the configuration in
+// tfDir sets them: the literal values it passes the module, the defaults of
+// the variables it leaves out, and unknown values for the rest.
+func moduleInputs(t *testing.T, tfDir string) map[string]cty.Value {
+	inputs := map[string]cty.Value{}
+	for _, block := range parseBody(t, moduleVariables).Blocks {
+		if block.Type != "variable" || len(block.Labels) == 0 {
+			continue
+		}
+		inputs[block.Labels[0]] = cty.DynamicVal
+		if attr, ok := block.Body.Attributes["default"]; ok {
+			if value, diags := attr.Expr.Value(nil); !diags.HasErrors() {
+				inputs[block.Labels[0]] = value
+			}
+		}
+	}
+
+	root, err := filepath.Abs(filepath.Dir(moduleMain))
+	if err != nil {
+		t.Fatal(err)
+	}
+	files, err := filepath.Glob(filepath.Join(tfDir, "*.tf"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, file := range files {
+		for _, block := range parseBody(t, file).Blocks {
+			if block.Type != "module" {
+				continue
+			}
+			source, ok := block.Body.Attributes["source"]
+			if !ok {
+				continue
+			}
+			value, diags := source.Expr.Value(nil)
+			if diags.HasErrors() || value.Type() != cty.String {
+				continue
+			}
+			dir, err := filepath.Abs(filepath.Join(tfDir, value.AsString()))
+			if err != nil || dir != root {
+				continue
+			}
+			for name, attr := range block.Body.Attributes {
+				if _, ok := inputs[name]; !ok {
+					continue
+				}
+				value, diags := attr.Expr.Value(nil)
+				if diags.HasErrors() {
+					value = cty.DynamicVal
+				}
+				inputs[name] = value
+			}
+		}
+	}
+	return inputs
+}
+
+// parseBody parses a Terraform configuration file.
+func parseBody(t *testing.T, path string) *hclsyntax.Body {
+	file, diags := hclparse.NewParser().ParseHCLFile(path)
+	if diags.HasErrors() {
+		t.Fatalf("parsing %s: %v", path, diags)
+	}
+	return file.Body.(*hclsyntax.Body)
+}
+
+// EnableModuleAPIs enables the APIs the root module activates when deployed
+// by the configuration in tfDir in projectID ahead of apply, and waits until each of them serves calls for the project.
+// An API is usable some time after it reports being enabled, and until then
+// calls fail with SERVICE_DISABLED, so probing replaces waiting a fixed time.
+func EnableModuleAPIs(t *testing.T, projectID, tfDir string) {
+	apis := ModuleAPIs(t, tfDir)
+	// services enable takes at most 20 services at a time
+	for i := 0; i < len(apis); i += 20 {
+		end := i + 20
+		if end > len(apis) {
+			end = len(apis)
+		}
+		gcloud.Runf(t, "services enable %s --project %s", strings.Join(apis[i:end], " "), projectID)
+	}
+
+	pending := apis
+	probe := func() (bool, error) {
+		token := strings.TrimSpace(gcloud.RunCmd(t, "auth print-access-token", gcloud.WithCommonArgs([]string{})))
+		next := []string{}
+		for _, api := range pending {
+			ready, err := probeAPI(api, projectID, token)
+			if err != nil {
+				t.Logf("not probing %s: %v", api, err)
+				continue
+			}
+			if !ready {
+				next = append(next, api)
+			}
+		}
+		pending = next
+		if len(pending) > 0 {
+			t.Logf("waiting for %s to serve %s", strings.Join(pending, ", "), projectID)
+			return true, nil
+		}
+		return false, nil
+	}
+	utils.Poll(t, probe, 60, 10*time.Second)
+}
+
+// probeAPI calls the root of an API's endpoint on behalf of projectID. Any
+// answer but SERVICE_DISABLED, even one rejecting the request, means the API
+// accepts calls for the project.
+func probeAPI(api, projectID, token string) (bool, error) {
+	host := api
+	if h, ok := probeHosts[api]; ok {
+		if h == "" {
+			return true, nil
+		}
+		host = h
+	}
+	req, err := http.NewRequest("GET", "https://"+host+"/", nil)
+	if err != nil {
+		return false, err
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	req.Header.Set("X-Goog-User-Project", projectID)
+
+	client := &http.Client{Timeout: 30 * time.Second}
+	resp, err := client.Do(req)
+	if err != nil {
+		return false, fmt.Errorf("calling %s: %v", host, err)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return false, err
+	}
+	return resp.StatusCode != http.StatusForbidden || !strings.Contains(string(body), "SERVICE_DISABLED"), nil
+}
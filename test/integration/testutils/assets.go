@@ -0,0 +1,90 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package testutils
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/GoogleCloudPlatform/cloud-foundation-toolkit/infra/blueprint-test/pkg/golden"
+	"github.com/stretchr/testify/assert"
+	"github.com/tidwall/gjson"
+)
+
+// derivedAssetTypes are created by services on behalf of labeled resources,
+// such as the VMs of a Dataproc cluster or Dataflow job and the Cloud Run
+// service of a function, and carry their labels. How many there are depends
+// on autoscaling, so they are left out of asset manifests.
+var derivedAssetTypes = map[string]bool{
+	"compute.googleapis.com/Disk":          true,
+	"compute.googleapis.com/Instance":      true,
+	"compute.googleapis.com/InstanceGroup": true,
+	"run.googleapis.com/Revision":          true,
+	"run.googleapis.com/Service":           true,
+}
+
+// AssetCounts returns how many resources of each asset type in projectID
+// carry all of labels, as found by Cloud Asset Inventory.
+func AssetCounts(t *testing.T, projectID string, labels map[string]string) (map[string]int, error) {
+	keys := []string{}
+	for k := range labels {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	terms := []string{}
+	for _, k := range keys {
+		terms = append(terms, fmt.Sprintf("labels.%s:%s", k, labels[k]))
+	}
+
+	counts := map[string]int{}
+	params := url.Values{"query": {strings.Join(terms, " AND ")}, "pageSize": {"500"}}
+	for {
+		page, err := getAPI(t, fmt.Sprintf("https://cloudasset.googleapis.com/v1/projects/%s:searchAllResources?%s", projectID, params.Encode()))
+		if err != nil {
+			return nil, err
+		}
+		for _, r := range page.Get("results").Array() {
+			if assetType := r.Get("assetType").String(); !derivedAssetTypes[assetType] {
+				counts[assetType]++
+			}
+		}
+		token := page.Get("nextPageToken").String()
+		if token == "" {
+			return counts, nil
+		}
+		params.Set("pageToken", token)
+	}
+}
+
+// VerifyAssetGolden asserts the resources carrying labels in projectID are
+// of the types and counts in the fixture's golden manifest, testdata/name,
+// so resources a change adds or drops show up in review. Run with
+// UPDATE_GOLDEN=true to update the manifest.
+func VerifyAssetGolden(t *testing.T, assert *assert.Assertions, projectID string, labels map[string]string, name string) {
+	counts, err := AssetCounts(t, projectID, labels)
+	if !assert.NoError(err, "Searching the labeled assets failed") {
+		return
+	}
+	data, err := json.MarshalIndent(map[string]interface{}{"assets": counts}, "", "  ")
+	if err != nil {
+		t.Fatal(err)
+	}
+	g := golden.NewOrUpdate(t, string(data), golden.WithFileName(name))
+	g.JSONEq(assert, gjson.ParseBytes(data), "assets")
+}
@@ -0,0 +1,84 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package testutils
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	"github.com/GoogleCloudPlatform/cloud-foundation-toolkit/infra/blueprint-test/pkg/gcloud"
+	"github.com/tidwall/gjson"
+	"google.golang.org/api/impersonate"
+	"google.golang.org/api/option"
+)
+
+const (
+	// credentialsEnvVar may point at an external account credential
+	// configuration for workload identity federation, such as the one
+	// written by google-github-actions/auth.
+	credentialsEnvVar = "GOOGLE_APPLICATION_CREDENTIALS"
+	// impersonateEnvVar is the service account the Terraform provider
+	// impersonates; gcloud and bq are pointed at the same account.
+	impersonateEnvVar       = "GOOGLE_IMPERSONATE_SERVICE_ACCOUNT"
+	gcloudImpersonateEnvVar = "CLOUDSDK_AUTH_IMPERSONATE_SERVICE_ACCOUNT"
+)
+
+// ConfigureAuth lets the suite run without a service account key. When
+// GOOGLE_APPLICATION_CREDENTIALS is an external account configuration,
+// gcloud is logged in with it, and when GOOGLE_IMPERSONATE_SERVICE_ACCOUNT
+// is set, gcloud and bq calls made by the verifiers impersonate that account
+// like Terraform does. It must be called before any gcloud or bq call.
+func ConfigureAuth(t *testing.T) {
+	if path := os.Getenv(credentialsEnvVar); path != "" {
+		config, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatalf("reading %s: %v", credentialsEnvVar, err)
+		}
+		if gjson.GetBytes(config, "type").String() == "external_account" {
+			gcloud.RunCmd(t, "auth login --quiet --cred-file="+path, gcloud.WithCommonArgs([]string{}))
+		}
+	}
+	if sa := os.Getenv(impersonateEnvVar); sa != "" && os.Getenv(gcloudImpersonateEnvVar) == "" {
+		t.Setenv(gcloudImpersonateEnvVar, sa)
+	}
+}
+
+// ActiveAccount returns the identity the verifiers' gcloud and bq calls act
+// as, which is the impersonated service account when one is configured.
+func ActiveAccount(t *testing.T) string {
+	if sa := os.Getenv(gcloudImpersonateEnvVar); sa != "" {
+		return sa
+	}
+	return gcloud.Runf(t, "config get-value account").String()
+}
+
+// ClientOptions returns options that make Google Cloud Go clients act as the
+// same identity as the gcloud and bq calls. Without impersonation configured
+// the clients use Application Default Credentials.
+func ClientOptions(ctx context.Context, t *testing.T) []option.ClientOption {
+	sa := os.Getenv(gcloudImpersonateEnvVar)
+	if sa == "" {
+		return nil
+	}
+	ts, err := impersonate.CredentialsTokenSource(ctx, impersonate.CredentialsConfig{
+		TargetPrincipal: sa,
+		Scopes:          []string{"https://www.googleapis.com/auth/cloud-platform"},
+	})
+	if err != nil {
+		t.Fatalf("impersonating %s: %v", sa, err)
+	}
+	return []option.ClientOption{option.WithTokenSource(ts)}
+}
@@ -0,0 +1,69 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package testutils
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/gruntwork-io/terratest/modules/terraform"
+	"github.com/tidwall/gjson"
+)
+
+// TerraformBinaryEnvVar selects the binary fixtures are deployed with:
+// terraform, the default, or tofu to run them under OpenTofu. A path to
+// either binary also works.
+const TerraformBinaryEnvVar = "TF_BINARY"
+
+// Fixtures run Terraform through Terratest, which runs its default executable
+// unless told otherwise.
+func init() {
+	if binary := os.Getenv(TerraformBinaryEnvVar); binary != "" {
+		terraform.DefaultExecutable = binary
+	}
+}
+
+// TerraformBinary returns the binary fixtures are deployed with, for commands
+// the tests run themselves.
+func TerraformBinary() string {
+	return terraform.DefaultExecutable
+}
+
+// BinaryName returns the name of TerraformBinary, such as terraform or tofu.
+func BinaryName() string {
+	return strings.TrimSuffix(filepath.Base(TerraformBinary()), ".exe")
+}
+
+var (
+	versionOnce   sync.Once
+	binaryVersion string
+)
+
+// BinaryVersion returns the version of TerraformBinary, such as 1.6.0.
+func BinaryVersion() string {
+	versionOnce.Do(func() {
+		binaryVersion = "unknown"
+		out, err := exec.Command(TerraformBinary(), "version", "-json").Output()
+		if err != nil {
+			return
+		}
+		// OpenTofu reports its version under the same key
+		binaryVersion = gjson.GetBytes(out, "terraform_version").String()
+	})
+	return binaryVersion
+}
@@ -0,0 +1,135 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package testutils
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/GoogleCloudPlatform/cloud-foundation-toolkit/infra/blueprint-test/pkg/gcloud"
+	"github.com/GoogleCloudPlatform/cloud-foundation-toolkit/infra/blueprint-test/pkg/tft"
+	"github.com/stretchr/testify/assert"
+)
+
+// ExampleTest is the blueprint test of an example or fixture, with the
+// stages they share defined: apply enables the module's APIs first, verify
+// runs the default verify, and teardown waits for the Dataproc VMs before
+// destroying. Each stage is timed and reported under the test's name.
+type ExampleTest struct {
+	*tft.TFBlueprintTest
+	Timer *StageTimer
+
+	beforeApply   func(assert *assert.Assertions)
+	afterTeardown func(assert *assert.Assertions)
+}
+
+// NewExampleTest returns the test of the example or fixture in dir, or of
+// the example named after the test's package when dir is empty. It deploys
+// into the pool project when one is assigned, and retries RetryErrors.
+func NewExampleTest(t *testing.T, dir, name string) *ExampleTest {
+	ConfigureAuth(t)
+
+	var bpt *tft.TFBlueprintTest
+	if dir == "" {
+		bpt = tft.NewTFBlueprintTest(t, tft.WithRetryableTerraformErrors(RetryErrors, 60, time.Minute), tft.WithVars(PoolProjectVars()))
+	} else {
+		bpt = tft.NewTFBlueprintTest(t, tft.WithTFDir(dir), tft.WithRetryableTerraformErrors(RetryErrors, 60, time.Minute), tft.WithVars(PoolProjectVars()))
+	}
+	e := &ExampleTest{TFBlueprintTest: bpt, Timer: NewStageTimer(t, bpt, name)}
+	NewNotifier(t, bpt, name, e.Timer)
+
+	bpt.DefineApply(func(assert *assert.Assertions) {
+		e.Timer.Time("apis", func() { EnableModuleAPIs(t, e.ProjectID(), bpt.GetTFOptions().TerraformDir) })
+		if e.beforeApply != nil {
+			e.beforeApply(assert)
+		}
+		e.Timer.Time("apply", func() { bpt.DefaultApply(assert) })
+	})
+	bpt.DefineTeardown(func(assert *assert.Assertions) {
+		stop := e.Timer.Start("teardown")
+		WaitForDataprocVMs(t, e.ProjectID())
+		bpt.DefaultTeardown(assert)
+		if e.afterTeardown != nil {
+			e.afterTeardown(assert)
+		}
+		stop()
+	})
+	return e
+}
+
+// ProjectID returns the project the test deploys into.
+func (e *ExampleTest) ProjectID() string {
+	return e.GetTFSetupStringOutput("project_id")
+}
+
+// Region returns the region the test deploys into.
+func (e *ExampleTest) Region() string {
+	return e.GetTFSetupStringOutput("region")
+}
+
+// BeforeApply sets what to do once the APIs are enabled, before apply, such
+// as creating resources the example is given.
+func (e *ExampleTest) BeforeApply(fn func(assert *assert.Assertions)) {
+	e.beforeApply = fn
+}
+
+// Verify sets the example's own assertions, run after the default verify.
+func (e *ExampleTest) Verify(fn func(assert *assert.Assertions)) {
+	e.DefineVerify(func(assert *assert.Assertions) {
+		stop := e.Timer.Start("verify/all")
+		e.DefaultVerify(assert)
+		fn(assert)
+		stop()
+	})
+}
+
+// AfterTeardown sets what to check or clean up once the example is
+// destroyed.
+func (e *ExampleTest) AfterTeardown(fn func(assert *assert.Assertions)) {
+	e.afterTeardown = fn
+}
+
+// NewProviderBoundaryTest returns the test of a provider boundary fixture,
+// which pins the google and google-beta releases verifyProviders expects,
+// so a provider release changing a default the module relies on fails the
+// fixture rather than users.
+func NewProviderBoundaryTest(t *testing.T, fixture string, verifyProviders func(t *testing.T, assert *assert.Assertions, tfDir string)) *ExampleTest {
+	tfDir := filepath.Join("..", "..", "fixtures", fixture)
+	boundary := NewExampleTest(t, tfDir, fixture)
+
+	// The default verify asserts the providers' defaults leave nothing to
+	// change after apply
+	boundary.Verify(func(assert *assert.Assertions) {
+		projectID := boundary.ProjectID()
+		region := boundary.Region()
+
+		// Assert init selected the pinned google and google-beta releases
+		verifyProviders(t, assert, tfDir)
+
+		// Assert the workflows succeed, running the Spark batches and loading
+		// the tables through the resources the providers created
+		WaitForWorkflow(t, projectID, "copy-data")
+		WaitForWorkflow(t, projectID, "project-setup")
+
+		// Assert the Persistent History Server came up with the providers'
+		// Dataproc defaults, and project-setup stopped it
+		clusters := gcloud.Runf(t, "dataproc clusters list --project=%s --region=%s", projectID, region).Array()
+		if assert.Len(clusters, 1, "Unexpected number of Dataproc clusters") {
+			assert.Equal("TERMINATED", clusters[0].Get("status.state").String(), "PHS is not in a stopped state")
+		}
+	})
+	return boundary
+}
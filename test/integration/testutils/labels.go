@@ -0,0 +1,163 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package testutils
+
+import (
+	"fmt"
+	"net/url"
+	"os/exec"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/tidwall/gjson"
+)
+
+// labeledResources are the labelable resource types the module creates, with
+// the Cloud Asset Inventory type and full resource name of each. Resources
+// services create on the module's behalf, such as Dataproc staging buckets
+// and the datasets of Dataplex zones, are not in Terraform state, so they
+// are not audited.
+var labeledResources = map[string]struct {
+	assetType string
+	name      func(r gjson.Result) string
+}{
+	"google_bigquery_dataset": {"bigquery.googleapis.com/Dataset", func(r gjson.Result) string {
+		return "//bigquery.googleapis.com/" + r.Get("id").String()
+	}},
+	"google_bigquery_table": {"bigquery.googleapis.com/Table", func(r gjson.Result) string {
+		return "//bigquery.googleapis.com/" + r.Get("id").String()
+	}},
+	"google_cloudfunctions2_function": {"cloudfunctions.googleapis.com/Function", func(r gjson.Result) string {
+		return "//cloudfunctions.googleapis.com/" + r.Get("id").String()
+	}},
+	"google_dataplex_asset": {"dataplex.googleapis.com/Asset", func(r gjson.Result) string {
+		return "//dataplex.googleapis.com/" + r.Get("id").String()
+	}},
+	"google_dataplex_lake": {"dataplex.googleapis.com/Lake", func(r gjson.Result) string {
+		return "//dataplex.googleapis.com/" + r.Get("id").String()
+	}},
+	"google_dataplex_zone": {"dataplex.googleapis.com/Zone", func(r gjson.Result) string {
+		return "//dataplex.googleapis.com/" + r.Get("id").String()
+	}},
+	"google_dataproc_cluster": {"dataproc.googleapis.com/Cluster", func(r gjson.Result) string {
+		return fmt.Sprintf("//dataproc.googleapis.com/projects/%s/regions/%s/clusters/%s", r.Get("project"), r.Get("region"), r.Get("name"))
+	}},
+	"google_pubsub_subscription": {"pubsub.googleapis.com/Subscription", func(r gjson.Result) string {
+		return "//pubsub.googleapis.com/" + r.Get("id").String()
+	}},
+	"google_pubsub_topic": {"pubsub.googleapis.com/Topic", func(r gjson.Result) string {
+		return "//pubsub.googleapis.com/" + r.Get("id").String()
+	}},
+	"google_sql_database_instance": {"sqladmin.googleapis.com/Instance", func(r gjson.Result) string {
+		return fmt.Sprintf("//cloudsql.googleapis.com/projects/%s/instances/%s", r.Get("project"), r.Get("name"))
+	}},
+	"google_storage_bucket": {"storage.googleapis.com/Bucket", func(r gjson.Result) string {
+		return "//storage.googleapis.com/" + r.Get("name").String()
+	}},
+	"google_workflows_workflow": {"workflows.googleapis.com/Workflow", func(r gjson.Result) string {
+		return "//workflows.googleapis.com/" + r.Get("id").String()
+	}},
+}
+
+// StateResources returns the managed resources in the Terraform state of
+// tfDir, by address, with their attribute values.
+func StateResources(tfDir string) (map[string]gjson.Result, error) {
+	out, err := exec.Command(TerraformBinary(), "-chdir="+tfDir, "show", "-json").Output()
+	if err != nil {
+		return nil, fmt.Errorf("reading the state of %s: %v", tfDir, err)
+	}
+	resources := map[string]gjson.Result{}
+	var walk func(module gjson.Result)
+	walk = func(module gjson.Result) {
+		for _, r := range module.Get("resources").Array() {
+			if r.Get("mode").String() == "managed" {
+				resources[r.Get("address").String()] = r
+			}
+		}
+		for _, child := range module.Get("child_modules").Array() {
+			walk(child)
+		}
+	}
+	walk(gjson.GetBytes(out, "values.root_module"))
+	return resources, nil
+}
+
+// VerifyRunLabels asserts every labelable resource in the Terraform state of
+// tfDir carries each of labels in Cloud Asset Inventory. Cleanup of leftover
+// resources and the cost of a run are found by these labels, so a resource
+// missing them is neither swept nor costed.
+func VerifyRunLabels(t *testing.T, assert *assert.Assertions, tfDir, projectID string, labels map[string]string) {
+	resources, err := StateResources(tfDir)
+	if !assert.NoError(err, "Listing the resources created this run failed") {
+		return
+	}
+
+	types := map[string]bool{}
+	for _, r := range resources {
+		if lr, ok := labeledResources[r.Get("type").String()]; ok {
+			types[lr.assetType] = true
+		}
+	}
+	assetTypes := []string{}
+	for assetType := range types {
+		assetTypes = append(assetTypes, assetType)
+	}
+	sort.Strings(assetTypes)
+
+	assets := map[string]gjson.Result{}
+	params := url.Values{"assetTypes": assetTypes, "pageSize": {"500"}}
+	for {
+		page, err := getAPI(t, fmt.Sprintf("https://cloudasset.googleapis.com/v1/projects/%s:searchAllResources?%s", projectID, params.Encode()))
+		if !assert.NoError(err, "Searching the project's assets failed") {
+			return
+		}
+		for _, r := range page.Get("results").Array() {
+			assets[r.Get("name").String()] = r.Get("labels")
+		}
+		token := page.Get("nextPageToken").String()
+		if token == "" {
+			break
+		}
+		params.Set("pageToken", token)
+	}
+
+	addresses := []string{}
+	for address := range resources {
+		addresses = append(addresses, address)
+	}
+	sort.Strings(addresses)
+	for _, address := range addresses {
+		r := resources[address]
+		lr, ok := labeledResources[r.Get("type").String()]
+		if !ok {
+			continue
+		}
+		name := lr.name(r.Get("values"))
+		got, ok := assets[name]
+		if !assert.True(ok, "%s (%s) is not in Cloud Asset Inventory", address, name) {
+			continue
+		}
+		missing := []string{}
+		for k, v := range labels {
+			if got.Get(gjson.Escape(k)).String() != v {
+				missing = append(missing, k+"="+v)
+			}
+		}
+		sort.Strings(missing)
+		assert.Empty(missing, "%s lacks the labels %s", address, strings.Join(missing, ", "))
+	}
+}
@@ -0,0 +1,149 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package testutils
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/exec"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/GoogleCloudPlatform/cloud-foundation-toolkit/infra/blueprint-test/pkg/gcloud"
+	"github.com/GoogleCloudPlatform/cloud-foundation-toolkit/infra/blueprint-test/pkg/tft"
+)
+
+// stageMetricType is the custom metric test/setup describes and charts.
+const stageMetricType = "custom.googleapis.com/lakehouse_test/stage_duration"
+
+// commitEnvVar holds the commit under test. Outside Cloud Build the commit
+// checked out in the working directory is used.
+const commitEnvVar = "COMMIT_SHA"
+
+// StageTimer writes how long the stages of a fixture take to Cloud
+// Monitoring in the setup's main project, labeled by fixture and commit.
+// Metrics are best effort: failing to write one is logged and never fails
+// the test.
+type StageTimer struct {
+	t       *testing.T
+	project string
+	fixture string
+	commit  string
+	current string
+	failed  string
+}
+
+// NewStageTimer returns a StageTimer for the fixture deployed by bpt.
+func NewStageTimer(t *testing.T, bpt *tft.TFBlueprintTest, fixture string) *StageTimer {
+	return &StageTimer{
+		t:       t,
+		project: bpt.GetTFSetupStringOutput("stage_metrics_project_id"),
+		fixture: fixture,
+		commit:  commit(),
+	}
+}
+
+func commit() string {
+	if sha := os.Getenv(commitEnvVar); sha != "" {
+		return sha
+	}
+	out, err := exec.Command("git", "rev-parse", "HEAD").Output()
+	if err != nil {
+		return "unknown"
+	}
+	return strings.TrimSpace(string(out))
+}
+
+// Start starts timing stage and returns a function that stops the timer and
+// writes the duration. Stages that fail the test never call it, so only
+// completed stages are charted.
+func (s *StageTimer) Start(stage string) func() {
+	start := time.Now()
+	s.current = stage
+	return func() {
+		if s.t.Failed() && s.failed == "" {
+			s.failed = stage
+		}
+		if err := s.write(stage, time.Since(start)); err != nil {
+			s.t.Logf("writing the %s duration of %s: %v", stage, s.fixture, err)
+		}
+	}
+}
+
+// FailedStage returns the first stage that ended with the test failed, or the
+// stage still running when the test stopped.
+func (s *StageTimer) FailedStage() string {
+	if s.failed != "" {
+		return s.failed
+	}
+	return s.current
+}
+
+// Time runs fn as stage and writes how long it took.
+func (s *StageTimer) Time(stage string, fn func()) {
+	stop := s.Start(stage)
+	fn()
+	stop()
+}
+
+// write writes one point of the stage duration metric.
+func (s *StageTimer) write(stage string, d time.Duration) error {
+	point := map[string]interface{}{
+		"interval": map[string]string{"endTime": time.Now().UTC().Format(time.RFC3339Nano)},
+		"value":    map[string]float64{"doubleValue": d.Seconds()},
+	}
+	series := map[string]interface{}{
+		"metric": map[string]interface{}{
+			"type":   stageMetricType,
+			"labels": map[string]string{"fixture": s.fixture, "stage": stage, "commit": s.commit, "binary": BinaryName()},
+		},
+		"resource": map[string]interface{}{
+			"type":   "global",
+			"labels": map[string]string{"project_id": s.project},
+		},
+		"points": []interface{}{point},
+	}
+	body, err := json.Marshal(map[string]interface{}{"timeSeries": []interface{}{series}})
+	if err != nil {
+		return err
+	}
+
+	url := fmt.Sprintf("https://monitoring.googleapis.com/v3/projects/%s/timeSeries", s.project)
+	req, err := http.NewRequest("POST", url, strings.NewReader(string(body)))
+	if err != nil {
+		return err
+	}
+	token, err := gcloud.RunCmdE(s.t, "auth print-access-token", gcloud.WithCommonArgs([]string{}))
+	if err != nil {
+		return fmt.Errorf("getting an access token: %w", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+strings.TrimSpace(token))
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		respBody, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("%s returned %d: %s", url, resp.StatusCode, respBody)
+	}
+	return nil
+}
@@ -0,0 +1,159 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package testutils
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/GoogleCloudPlatform/cloud-foundation-toolkit/infra/blueprint-test/pkg/tft"
+)
+
+// NotifyWebhookEnvVar holds the Slack or Google Chat incoming webhook failures
+// are posted to. Nothing is posted when it is unset.
+const NotifyWebhookEnvVar = "NOTIFY_WEBHOOK_URL"
+
+// runStageEnvVar is the stage `cft test run --stage` runs, used when the
+// failure happened outside a timed stage.
+const runStageEnvVar = "RUN_STAGE"
+
+// Notifier posts a summary of the failure to a webhook when a fixture's test
+// fails, so maintainers hear about breakage without watching the builds. The
+// summary names the stage and the first failed check, links to the resource
+// the check covers and lists the run labels. Posting is best effort: failing
+// to post is logged and never changes the test result.
+type Notifier struct {
+	webhook string
+	fixture string
+	project string
+	timer   *StageTimer
+	report  *Report
+}
+
+// NewNotifier returns a Notifier for the fixture deployed by bpt, which posts
+// when t finishes failed. The stage is taken from timer.
+func NewNotifier(t *testing.T, bpt *tft.TFBlueprintTest, fixture string, timer *StageTimer) *Notifier {
+	n := &Notifier{
+		webhook: os.Getenv(NotifyWebhookEnvVar),
+		fixture: fixture,
+		project: bpt.GetTFSetupStringOutput("project_id"),
+		timer:   timer,
+	}
+	if n.webhook == "" {
+		return n
+	}
+	t.Cleanup(func() {
+		if !t.Failed() {
+			return
+		}
+		if err := n.post(n.summary()); err != nil {
+			t.Logf("posting the failure of %s: %v", fixture, err)
+		}
+	})
+	return n
+}
+
+// Watch adds the first failed check of report to the summary.
+func (n *Notifier) Watch(report *Report) {
+	n.report = report
+}
+
+// summary formats the failure with the link syntax Slack and Google Chat
+// share, so the same message works for both.
+func (n *Notifier) summary() string {
+	stage := n.timer.FailedStage()
+	if stage == "" {
+		stage = os.Getenv(runStageEnvVar)
+	}
+	if stage == "" {
+		stage = "unknown"
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "*%s* integration test failed in stage `%s`\n", n.fixture, stage)
+
+	link := Link{Text: "Project " + n.project, URL: consoleURL("home/dashboard", url.Values{"project": {n.project}})}
+	if n.report != nil {
+		if c := n.report.FirstFailure(); c != nil {
+			fmt.Fprintf(&b, "Check: %s (%s)\n", c.Name, c.Status)
+			if len(c.Failures) > 0 {
+				fmt.Fprintf(&b, "> %s\n", failureLine(c.Failures[0]))
+			}
+			if len(c.Links) > 0 {
+				link = c.Links[0]
+			}
+		}
+	}
+	fmt.Fprintf(&b, "Resource: <%s|%s>\n", link.URL, link.Text)
+
+	labels := []string{}
+	for k, v := range RunLabels() {
+		labels = append(labels, k+"="+v)
+	}
+	sort.Strings(labels)
+	if len(labels) > 0 {
+		fmt.Fprintf(&b, "Labels: %s\n", strings.Join(labels, ", "))
+	}
+	fmt.Fprintf(&b, "Commit: %s", n.timer.commit)
+	return b.String()
+}
+
+// failureLine returns the message of an assertion failure, or its error when
+// it has none, leaving out the trace and details testify adds.
+func failureLine(failure string) string {
+	fields := map[string]string{}
+	for _, l := range strings.Split(failure, "\n") {
+		if name, value, ok := strings.Cut(strings.TrimSpace(l), ":"); ok && (name == "Error" || name == "Messages") {
+			fields[name] = strings.TrimSpace(value)
+		}
+	}
+	for _, name := range []string{"Messages", "Error"} {
+		if fields[name] != "" {
+			return fields[name]
+		}
+	}
+	return strings.SplitN(failure, "\n", 2)[0]
+}
+
+func (n *Notifier) post(text string) error {
+	body, err := json.Marshal(map[string]string{"text": text})
+	if err != nil {
+		return err
+	}
+	client := &http.Client{Timeout: 30 * time.Second}
+	resp, err := client.Post(n.webhook, "application/json", bytes.NewReader(body))
+	if err != nil {
+		// Leave the webhook URL, which is a credential, out of the logs
+		if uerr, ok := err.(*url.Error); ok {
+			return uerr.Err
+		}
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		respBody, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("webhook returned %d: %s", resp.StatusCode, respBody)
+	}
+	return nil
+}
@@ -0,0 +1,66 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package testutils
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+)
+
+// PlanExample plans the initialized example in dir with the outputs of the
+// applied test setup in setup as TF_VAR_ variables, like the fixtures are
+// applied, and returns the plan as `terraform show -json` output.
+func PlanExample(dir, setup string) ([]byte, error) {
+	output, err := exec.Command(TerraformBinary(), "-chdir="+setup, "output", "-json").Output()
+	if err != nil {
+		return nil, fmt.Errorf("reading the setup outputs: %v", err)
+	}
+	var outputs map[string]struct {
+		Value json.RawMessage `json:"value"`
+	}
+	if err := json.Unmarshal(output, &outputs); err != nil {
+		return nil, fmt.Errorf("parsing the setup outputs: %v", err)
+	}
+	env := os.Environ()
+	for name, o := range outputs {
+		value := string(o.Value)
+		var s string
+		if json.Unmarshal(o.Value, &s) == nil {
+			value = s
+		}
+		env = append(env, fmt.Sprintf("TF_VAR_%s=%s", name, value))
+	}
+
+	plan := filepath.Join(os.TempDir(), "plan-"+filepath.Base(dir)+".tfplan")
+	defer os.Remove(plan)
+	cmd := exec.Command(TerraformBinary(), "-chdir="+dir, "plan", "-input=false", "-lock=false", "-out="+plan)
+	cmd.Env = env
+	cmd.Stdout = os.Stderr
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("planning %s: %v", dir, err)
+	}
+	show := exec.Command(TerraformBinary(), "-chdir="+dir, "show", "-json", plan)
+	show.Env = env
+	show.Stderr = os.Stderr
+	data, err := show.Output()
+	if err != nil {
+		return nil, fmt.Errorf("showing the plan of %s: %v", dir, err)
+	}
+	return data, nil
+}
@@ -0,0 +1,152 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package testutils
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/GoogleCloudPlatform/cloud-foundation-toolkit/infra/blueprint-test/pkg/gcloud"
+	"github.com/tidwall/gjson"
+)
+
+// QuotaPreflightEnvVar sets what CheckQuotas does when quota is short: fail
+// the test, which is the default, skip it, or "off" to not check at all.
+const QuotaPreflightEnvVar = "QUOTA_PREFLIGHT"
+
+// SlotsQuota is the Quota metric of the BigQuery slots reservations in a
+// region can hold.
+const SlotsQuota = "BIGQUERY_SLOTS"
+
+// Quota is an amount of a regional quota a fixture needs free before it is
+// applied. Metric is a Compute Engine regional quota metric, such as CPUS or
+// IN_USE_ADDRESSES, which Dataproc clusters and serverless batches also draw
+// on, or SlotsQuota.
+type Quota struct {
+	Metric string
+	Amount float64
+}
+
+// CheckQuotas stops the test before apply if the project has less than the
+// quotas free in region, rather than letting apply retry until it times out.
+// A quota whose limit cannot be found is logged and not checked.
+func CheckQuotas(t *testing.T, projectID, region string, quotas []Quota) {
+	mode := os.Getenv(QuotaPreflightEnvVar)
+	if mode == "off" {
+		return
+	}
+
+	compute := map[string]gjson.Result{}
+	for _, q := range gcloud.Runf(t, "compute regions describe %s --project %s", region, projectID).Get("quotas").Array() {
+		compute[q.Get("metric").String()] = q
+	}
+
+	short := []string{}
+	for _, q := range quotas {
+		var limit, usage float64
+		if q.Metric == SlotsQuota {
+			var err error
+			if limit, usage, err = slotQuota(t, projectID, region); err != nil {
+				t.Logf("not checking the %s quota: %v", q.Metric, err)
+				continue
+			}
+		} else {
+			c, ok := compute[q.Metric]
+			if !ok {
+				t.Logf("not checking the %s quota: %s has no such quota", q.Metric, region)
+				continue
+			}
+			limit, usage = c.Get("limit").Float(), c.Get("usage").Float()
+		}
+		if free := limit - usage; free < q.Amount {
+			short = append(short, fmt.Sprintf("%s: %g needed, %g free (limit %g, used %g)", q.Metric, q.Amount, free, limit, usage))
+		}
+	}
+	if len(short) == 0 {
+		return
+	}
+
+	msg := fmt.Sprintf("insufficient quota in %s of %s; request an increase or free these before applying:\n  %s", region, projectID, strings.Join(short, "\n  "))
+	if mode == "skip" {
+		t.Skip(msg)
+	}
+	t.Fatal(msg)
+}
+
+// slotQuota returns the region's BigQuery slot limit, the smallest of the
+// non-rate slot quotas the Cloud Quotas API reports for BigQuery
+// Reservations, and the slots the project's reservations there can use.
+func slotQuota(t *testing.T, projectID, region string) (float64, float64, error) {
+	infos, err := getAPI(t, fmt.Sprintf("https://cloudquotas.googleapis.com/v1/projects/%s/locations/global/services/bigqueryreservation.googleapis.com/quotaInfos", projectID))
+	if err != nil {
+		return 0, 0, err
+	}
+	limit := math.Inf(1)
+	for _, info := range infos.Get("quotaInfos").Array() {
+		if !strings.Contains(strings.ToLower(info.Get("metric").String()), "slots") || info.Get("refreshInterval").String() != "" {
+			continue
+		}
+		for _, d := range info.Get("dimensionsInfos").Array() {
+			if r := d.Get("dimensions.region").String(); r != "" && r != region {
+				continue
+			}
+			if v := d.Get("details.value").Float(); v >= 0 && v < limit {
+				limit = v
+			}
+		}
+	}
+	if math.IsInf(limit, 1) {
+		return 0, 0, fmt.Errorf("no slot quota found for %s", region)
+	}
+
+	reservations, err := getAPI(t, fmt.Sprintf("https://bigqueryreservation.googleapis.com/v1/projects/%s/locations/%s/reservations", projectID, region))
+	if err != nil {
+		return 0, 0, err
+	}
+	usage := 0.0
+	for _, r := range reservations.Get("reservations").Array() {
+		usage += r.Get("slotCapacity").Float() + r.Get("autoscale.maxSlots").Float()
+	}
+	return limit, usage, nil
+}
+
+// getAPI calls a Google API with the credentials gcloud uses.
+func getAPI(t *testing.T, url string) (gjson.Result, error) {
+	req, err := http.NewRequest("GET", url, nil)
+	if err != nil {
+		return gjson.Result{}, err
+	}
+	token := strings.TrimSpace(gcloud.RunCmd(t, "auth print-access-token", gcloud.WithCommonArgs([]string{})))
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return gjson.Result{}, err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return gjson.Result{}, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return gjson.Result{}, fmt.Errorf("%s returned %d: %s", url, resp.StatusCode, body)
+	}
+	return gjson.ParseBytes(body), nil
+}
@@ -0,0 +1,342 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package testutils
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html/template"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// ReportDirEnvVar names the directory verification reports are written to.
+// It defaults to test/integration/reports.
+const ReportDirEnvVar = "REPORT_DIR"
+
+// Check statuses. A check is aborted when it stops the test, for example when
+// a command it runs fails, so the checks after it never ran.
+const (
+	CheckPassed  = "passed"
+	CheckFailed  = "failed"
+	CheckAborted = "aborted"
+)
+
+// Link is a console page relevant to a check.
+type Link struct {
+	Text string
+	URL  string
+}
+
+// Assertions are what a check asserts with. The alias lets checks be written
+// inside Define functions, whose assert parameter hides the assert package.
+type Assertions = assert.Assertions
+
+// CheckResult is the outcome of one check in a Report.
+type CheckResult struct {
+	Name     string
+	Status   string
+	Duration time.Duration
+	Failures []string
+	Links    []Link
+}
+
+// Divergence is a check whose status under TerraformBinary differs from its
+// status in the fixture's last run under terraform.
+type Divergence struct {
+	Name      string
+	Terraform string
+	Binary    string
+}
+
+// Report records the checks of a fixture's verification and writes them as
+// an HTML page when the test finishes, with links to the console pages to
+// look at when a check fails.
+type Report struct {
+	t       *testing.T
+	fixture string
+	project string
+	started time.Time
+	checks  []CheckResult
+}
+
+// NewReport returns a Report for the fixture deployed to projectID, written
+// to <fixture>.html in the report directory when t finishes, along with the
+// check statuses in <fixture>.json. Runs under another binary than terraform
+// write <fixture>_<binary>.html instead, and report the checks whose status
+// differs from the terraform run's <fixture>.json, if there is one.
+func NewReport(t *testing.T, fixture, projectID string) *Report {
+	r := &Report{t: t, fixture: fixture, project: projectID, started: time.Now()}
+	t.Cleanup(func() {
+		path, err := r.write()
+		if err != nil {
+			t.Logf("writing the verification report of %s: %v", fixture, err)
+			return
+		}
+		t.Logf("verification report of %s written to %s", fixture, path)
+	})
+	return r
+}
+
+// reportDir returns the directory reports are written to.
+func reportDir() string {
+	if dir := os.Getenv(ReportDirEnvVar); dir != "" {
+		return dir
+	}
+	return filepath.Join("..", "reports")
+}
+
+// name returns the file name of the report, without extension.
+func (r *Report) name() string {
+	if BinaryName() == "terraform" {
+		return r.fixture
+	}
+	return r.fixture + "_" + BinaryName()
+}
+
+// divergences compares the checks with the terraform run's, returning nil
+// when this is the terraform run or there is no terraform run to compare with.
+func (r *Report) divergences(dir string) ([]Divergence, error) {
+	if BinaryName() == "terraform" {
+		return nil, nil
+	}
+	data, err := os.ReadFile(filepath.Join(dir, r.fixture+".json"))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	baseline := []CheckResult{}
+	if err := json.Unmarshal(data, &baseline); err != nil {
+		return nil, err
+	}
+	statuses := map[string]string{}
+	for _, c := range r.checks {
+		statuses[c.Name] = c.Status
+	}
+	divergences := []Divergence{}
+	for _, c := range baseline {
+		status, ok := statuses[c.Name]
+		if !ok {
+			status = "not run"
+		}
+		delete(statuses, c.Name)
+		if status != c.Status {
+			divergences = append(divergences, Divergence{c.Name, c.Status, status})
+		}
+	}
+	for _, c := range r.checks {
+		if status, ok := statuses[c.Name]; ok {
+			divergences = append(divergences, Divergence{c.Name, "not run", status})
+		}
+	}
+	return divergences, nil
+}
+
+// FirstFailure returns the first check that failed or was aborted, or nil.
+func (r *Report) FirstFailure() *CheckResult {
+	for i, c := range r.checks {
+		if c.Status != CheckPassed {
+			return &r.checks[i]
+		}
+	}
+	return nil
+}
+
+// Check runs fn as the check name and records whether its assertions passed
+// and how long it took. Failed assertions also fail the test, as they would
+// with the test's own Assertions.
+func (r *Report) Check(name string, links []Link, fn func(assert *Assertions)) {
+	rec := &recorder{t: r.t}
+	start := time.Now()
+	status := CheckAborted
+	defer func() {
+		r.checks = append(r.checks, CheckResult{
+			Name:     name,
+			Status:   status,
+			Duration: time.Since(start).Round(time.Second),
+			Failures: rec.failures,
+			Links:    links,
+		})
+	}()
+
+	fn(assert.New(rec))
+
+	status = CheckPassed
+	if len(rec.failures) > 0 {
+		status = CheckFailed
+	}
+}
+
+// recorder forwards assertion failures to the test and keeps their messages
+// for the report.
+type recorder struct {
+	t        *testing.T
+	failures []string
+}
+
+func (r *recorder) Errorf(format string, args ...interface{}) {
+	r.t.Helper()
+	r.failures = append(r.failures, strings.TrimSpace(fmt.Sprintf(format, args...)))
+	r.t.Errorf(format, args...)
+}
+
+func (r *recorder) Helper() {
+	r.t.Helper()
+}
+
+var reportTemplate = template.Must(template.New("report").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>{{.Fixture}} verification</title>
+<style>
+body { font-family: sans-serif; margin: 2em; }
+table { border-collapse: collapse; width: 100%; }
+th, td { border-bottom: 1px solid #ddd; padding: 0.4em; text-align: left; vertical-align: top; }
+pre { margin: 0; white-space: pre-wrap; font-size: 0.85em; }
+.passed { color: #188038; }
+.failed, .aborted { color: #d93025; font-weight: bold; }
+</style>
+</head>
+<body>
+<h1>{{.Fixture}} verification</h1>
+<p>Project <a href="{{.ProjectURL}}">{{.Project}}</a>, deployed with {{.Binary}} {{.Version}}, started {{.Started.Format "2006-01-02 15:04:05 MST"}}:
+{{.Passed}} of {{len .Checks}} checks passed.</p>
+{{if .Divergences}}<h2>Divergence from terraform</h2>
+<table>
+<tr><th>Check</th><th>terraform</th><th>{{.Binary}}</th></tr>
+{{range .Divergences}}<tr><td>{{.Name}}</td><td class="{{.Terraform}}">{{.Terraform}}</td><td class="{{.Binary}}">{{.Binary}}</td></tr>
+{{end}}</table>
+<h2>Checks</h2>
+{{end}}
+<table>
+<tr><th>Check</th><th>Status</th><th>Duration</th><th>Console</th></tr>
+{{range .Checks}}<tr>
+<td>{{.Name}}{{range .Failures}}<pre>{{.}}</pre>{{end}}</td>
+<td class="{{.Status}}">{{.Status}}</td>
+<td>{{.Duration}}</td>
+<td>{{range .Links}}<a href="{{.URL}}">{{.Text}}</a><br>{{end}}</td>
+</tr>
+{{end}}</table>
+</body>
+</html>
+`))
+
+// write writes the report and returns its path.
+func (r *Report) write() (string, error) {
+	dir := reportDir()
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return "", err
+	}
+	divergences, err := r.divergences(dir)
+	if err != nil {
+		r.t.Logf("comparing %s with its terraform run: %v", r.fixture, err)
+	}
+	for _, d := range divergences {
+		r.t.Logf("%s diverges from terraform: %q %s under terraform, %s under %s", r.fixture, d.Name, d.Terraform, d.Binary, BinaryName())
+	}
+	statuses, err := json.MarshalIndent(r.checks, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	if err := os.WriteFile(filepath.Join(dir, r.name()+".json"), statuses, 0o644); err != nil {
+		return "", err
+	}
+	path := filepath.Join(dir, r.name()+".html")
+	f, err := os.Create(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	passed := 0
+	for _, c := range r.checks {
+		if c.Status == CheckPassed {
+			passed++
+		}
+	}
+	err = reportTemplate.Execute(f, struct {
+		Fixture     string
+		Project     string
+		ProjectURL  string
+		Binary      string
+		Version     string
+		Started     time.Time
+		Passed      int
+		Checks      []CheckResult
+		Divergences []Divergence
+	}{r.fixture, r.project, consoleURL("home/dashboard", url.Values{"project": {r.project}}), BinaryName(), BinaryVersion(), r.started, passed, r.checks, divergences})
+	return path, err
+}
+
+func consoleURL(path string, query url.Values) string {
+	if len(query) == 0 {
+		return "https://console.cloud.google.com/" + path
+	}
+	return "https://console.cloud.google.com/" + path + "?" + query.Encode()
+}
+
+// WorkflowLink links to the executions of a workflow.
+func WorkflowLink(projectID, region, workflow string) Link {
+	return Link{
+		Text: "Workflow " + workflow,
+		URL:  consoleURL(fmt.Sprintf("workflows/workflow/%s/%s/executions", region, workflow), url.Values{"project": {projectID}}),
+	}
+}
+
+// TableLink links to a BigQuery table, given as dataset.table.
+func TableLink(projectID, table string) Link {
+	dataset, name, _ := strings.Cut(table, ".")
+	return Link{
+		Text: "Table " + table,
+		URL: consoleURL("bigquery", url.Values{
+			"project": {projectID},
+			"ws":      {fmt.Sprintf("!1m5!1m4!4m3!1s%s!2s%s!3s%s", projectID, dataset, name)},
+		}),
+	}
+}
+
+// DataprocClusterLink links to a Dataproc cluster.
+func DataprocClusterLink(projectID, region, cluster string) Link {
+	return Link{
+		Text: "Dataproc cluster " + cluster,
+		URL:  consoleURL("dataproc/clusters/"+cluster, url.Values{"project": {projectID}, "region": {region}}),
+	}
+}
+
+// DataprocBatchesLink links to the serverless Spark batches of a region.
+func DataprocBatchesLink(projectID, region string) Link {
+	return Link{
+		Text: "Dataproc batches",
+		URL:  consoleURL("dataproc/batches", url.Values{"project": {projectID}, "region": {region}}),
+	}
+}
+
+// BucketLink links to a Cloud Storage bucket.
+func BucketLink(bucket string) Link {
+	return Link{
+		Text: "Bucket " + bucket,
+		URL:  consoleURL("storage/browser/"+bucket, nil),
+	}
+}
@@ -0,0 +1,248 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package testutils
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"path"
+	"strings"
+	"testing"
+	"time"
+
+	compute "cloud.google.com/go/compute/apiv1"
+	"cloud.google.com/go/compute/apiv1/computepb"
+	"github.com/tidwall/gjson"
+	"google.golang.org/api/iterator"
+)
+
+// dataprocVMTimeout bounds how long WaitForDataprocVMs waits on the
+// operations removing the Dataproc VMs.
+const dataprocVMTimeout = 60 * time.Minute
+
+// dataprocPollInterval is how often a Dataproc operation is read, as
+// Dataproc has no method that waits on one.
+const dataprocPollInterval = 5 * time.Second
+
+// Labels Dataproc puts on the VMs of its clusters and serverless batches.
+const (
+	dataprocClusterLabel  = "goog-dataproc-cluster-name"
+	dataprocBatchLabel    = "goog-dataproc-batch-id"
+	dataprocLocationLabel = "goog-dataproc-location"
+)
+
+// dataprocVM is a compute instance Dataproc created for a cluster or a
+// serverless batch.
+type dataprocVM struct {
+	name, zone string
+	id         uint64
+	// Either cluster or batch is set, with the region both are in.
+	cluster, batch, location string
+}
+
+func (vm dataprocVM) owner() string {
+	if vm.batch != "" {
+		return "batch " + vm.batch
+	}
+	return "cluster " + vm.cluster
+}
+
+// WaitForDataprocVMs waits until the Dataproc VMs on their way out of the
+// project are deleted, so they do not block network teardown. Rather than
+// counting instances, it waits on the operations doing the work: the
+// running Dataproc operations of each VM's batch or cluster, such as a
+// cluster delete, then the VM's own delete operation. It returns as soon as
+// they are done, and fails the test with the operation that failed and why,
+// or with the one still running after dataprocVMTimeout. VMs of clusters
+// with nothing in flight, such as the Persistent History Server, are left
+// for Terraform to delete.
+func WaitForDataprocVMs(t *testing.T, projectID string) {
+	ctx, cancel := context.WithTimeout(context.Background(), dataprocVMTimeout)
+	defer cancel()
+
+	instances, err := compute.NewInstancesRESTClient(ctx, ClientOptions(ctx, t)...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer instances.Close()
+	operations, err := compute.NewZoneOperationsRESTClient(ctx, ClientOptions(ctx, t)...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer operations.Close()
+
+	// Operations already waited on, by name, shared by the VMs of a batch or
+	// cluster
+	waited := map[string]bool{}
+	for {
+		vms, err := listDataprocVMs(ctx, instances, projectID)
+		if err != nil {
+			t.Fatalf("listing the Dataproc VMs in %s: %v", projectID, err)
+		}
+		undeleted := []string{}
+		for _, vm := range vms {
+			if err := waitForDataprocOperations(ctx, t, projectID, vm, waited); err != nil {
+				t.Fatal(err)
+			}
+			op, err := deleteOperation(ctx, operations, projectID, vm)
+			if err != nil {
+				t.Fatalf("finding the operation deleting VM %s of %s: %v", vm.name, vm.owner(), err)
+			}
+			if op == nil {
+				// Dataproc deletes a finished batch's VMs shortly after it ends
+				if vm.batch != "" {
+					undeleted = append(undeleted, fmt.Sprintf("VM %s of %s", vm.name, vm.owner()))
+				}
+				continue
+			}
+			if err := waitForDeletion(ctx, operations, projectID, vm, op); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if len(undeleted) == 0 {
+			return
+		}
+		select {
+		case <-ctx.Done():
+			t.Fatalf("No delete operation was started within %s for %s, although their batches finished", dataprocVMTimeout, strings.Join(undeleted, ", "))
+		case <-time.After(dataprocPollInterval):
+		}
+	}
+}
+
+// listDataprocVMs returns the compute instances in the project created by
+// Dataproc.
+func listDataprocVMs(ctx context.Context, instances *compute.InstancesClient, projectID string) ([]dataprocVM, error) {
+	vms := []dataprocVM{}
+	it := instances.AggregatedList(ctx, &computepb.AggregatedListInstancesRequest{Project: projectID})
+	for {
+		pair, err := it.Next()
+		if err == iterator.Done {
+			return vms, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		for _, instance := range pair.Value.GetInstances() {
+			labels := instance.GetLabels()
+			vm := dataprocVM{
+				name:     instance.GetName(),
+				zone:     path.Base(instance.GetZone()),
+				id:       instance.GetId(),
+				cluster:  labels[dataprocClusterLabel],
+				batch:    labels[dataprocBatchLabel],
+				location: labels[dataprocLocationLabel],
+			}
+			if vm.cluster != "" || vm.batch != "" {
+				vms = append(vms, vm)
+			}
+		}
+	}
+}
+
+// waitForDataprocOperations waits on the Dataproc operations running on a
+// VM's batch or cluster. A batch's operation ends with the batch, failed or
+// not, after which Dataproc deletes its VMs. A failed cluster operation
+// fails the test, as the cluster's VMs would not be deleted.
+func waitForDataprocOperations(ctx context.Context, t *testing.T, projectID string, vm dataprocVM, waited map[string]bool) error {
+	names := []string{}
+	if vm.batch != "" {
+		batch, err := getAPI(t, fmt.Sprintf("https://dataproc.googleapis.com/v1/projects/%s/locations/%s/batches/%s", projectID, vm.location, vm.batch))
+		if err != nil {
+			return fmt.Errorf("reading %s: %v", vm.owner(), err)
+		}
+		if name := batch.Get("operation").String(); name != "" {
+			names = append(names, name)
+		}
+	} else {
+		filter := url.Values{"filter": {fmt.Sprintf("status.state = ACTIVE AND clusterName = %s", vm.cluster)}}
+		ops, err := getAPI(t, fmt.Sprintf("https://dataproc.googleapis.com/v1/projects/%s/regions/%s/operations?%s", projectID, vm.location, filter.Encode()))
+		if err != nil {
+			return fmt.Errorf("listing the running operations of %s: %v", vm.owner(), err)
+		}
+		for _, op := range ops.Get("operations").Array() {
+			names = append(names, op.Get("name").String())
+		}
+	}
+
+	for _, name := range names {
+		if waited[name] {
+			continue
+		}
+		op, err := waitForDataprocOperation(ctx, t, name, vm)
+		if err != nil {
+			return err
+		}
+		waited[name] = true
+		if msg := op.Get("error.message").String(); msg != "" && vm.batch == "" {
+			return fmt.Errorf("Dataproc %s operation %s of %s failed: %s", op.Get("metadata.operationType"), name, vm.owner(), msg)
+		}
+	}
+	return nil
+}
+
+// waitForDataprocOperation reads a Dataproc operation until it is done.
+func waitForDataprocOperation(ctx context.Context, t *testing.T, name string, vm dataprocVM) (gjson.Result, error) {
+	for {
+		op, err := getAPI(t, "https://dataproc.googleapis.com/v1/"+name)
+		if err != nil {
+			return gjson.Result{}, fmt.Errorf("reading Dataproc operation %s of %s: %v", name, vm.owner(), err)
+		}
+		if op.Get("done").Bool() {
+			return op, nil
+		}
+		select {
+		case <-ctx.Done():
+			return gjson.Result{}, fmt.Errorf("Dataproc %s operation %s of %s is still running after %s: %s", op.Get("metadata.operationType"), name, vm.owner(), dataprocVMTimeout, op.Get("metadata.description"))
+		case <-time.After(dataprocPollInterval):
+		}
+	}
+}
+
+// deleteOperation returns the operation deleting a VM, or nil if none was
+// started.
+func deleteOperation(ctx context.Context, operations *compute.ZoneOperationsClient, projectID string, vm dataprocVM) (*computepb.Operation, error) {
+	filter := fmt.Sprintf(`(targetId = %d) AND (operationType = "delete")`, vm.id)
+	op, err := operations.List(ctx, &computepb.ListZoneOperationsRequest{Project: projectID, Zone: vm.zone, Filter: &filter}).Next()
+	if err == iterator.Done {
+		return nil, nil
+	}
+	return op, err
+}
+
+// waitForDeletion waits on the operation deleting a VM, and returns why it
+// failed if it did.
+func waitForDeletion(ctx context.Context, operations *compute.ZoneOperationsClient, projectID string, vm dataprocVM, op *computepb.Operation) error {
+	for op.GetStatus() != computepb.Operation_DONE {
+		// Wait returns when the operation is done, or after two minutes
+		next, err := operations.Wait(ctx, &computepb.WaitZoneOperationRequest{Project: projectID, Zone: vm.zone, Operation: op.GetName()})
+		if ctx.Err() != nil {
+			return fmt.Errorf("operation %s deleting VM %s of %s is still running after %s", op.GetName(), vm.name, vm.owner(), dataprocVMTimeout)
+		}
+		if err != nil {
+			return fmt.Errorf("waiting on operation %s deleting VM %s of %s: %v", op.GetName(), vm.name, vm.owner(), err)
+		}
+		op = next
+	}
+	if errs := op.GetError().GetErrors(); len(errs) > 0 {
+		reasons := []string{}
+		for _, e := range errs {
+			reasons = append(reasons, fmt.Sprintf("%s: %s", e.GetCode(), e.GetMessage()))
+		}
+		return fmt.Errorf("operation %s deleting VM %s of %s failed with %s: %s", op.GetName(), vm.name, vm.owner(), op.GetHttpErrorMessage(), strings.Join(reasons, "; "))
+	}
+	return nil
+}
@@ -0,0 +1,200 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package testutils holds helpers shared by the integration test fixtures.
+package testutils
+
+import (
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/GoogleCloudPlatform/cloud-foundation-toolkit/infra/blueprint-test/pkg/bq"
+	"github.com/GoogleCloudPlatform/cloud-foundation-toolkit/infra/blueprint-test/pkg/gcloud"
+	"github.com/GoogleCloudPlatform/cloud-foundation-toolkit/infra/blueprint-test/pkg/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+// RetryErrors are retried if encountered while applying a fixture.
+var RetryErrors = map[string]string{
+	".*does not have enough resources available to fulfill the request.  Try a different zone,.*": "Compute zone resources currently unavailable.",
+	".*Error 400: The subnetwork resource*":                                                       "Subnet is eventually drained",
+}
+
+// PoolProjectEnvVar overrides the setup's project_id output, as read with
+// GetTFSetupStringOutput, with a pool project assigned by cmd/shard.
+const PoolProjectEnvVar = "CFT_SETUP_project_id"
+
+// PoolProjectVars returns the Terraform variables that deploy a fixture into
+// the pool project set in PoolProjectEnvVar, or nil to deploy into the setup
+// project. Setup outputs are passed to fixtures as TF_VAR_ environment
+// variables, which the override does not reach, so fixtures that can run in
+// a pool project pass these with tft.WithVars.
+func PoolProjectVars() map[string]interface{} {
+	project := os.Getenv(PoolProjectEnvVar)
+	if project == "" {
+		return nil
+	}
+	return map[string]interface{}{"project_id": project}
+}
+
+// RunIDEnvVar identifies a CI run, such as its Cloud Build ID. Resources
+// deployed by the run are labeled with it, so their cost can be found in the
+// billing export afterwards.
+const RunIDEnvVar = "RUN_ID"
+
+// Labels every resource deployed by a run carries: the blueprint's solution
+// label, the environment, set to "ci", and the run ID.
+const (
+	SolutionLabel    = "analytics-lakehouse"
+	EnvironmentLabel = "environment"
+	RunLabel         = "lakehouse-test-run"
+)
+
+// RunLabels returns the labels resources deployed by the run carry, or nil
+// when RunIDEnvVar is unset so the example's defaults apply.
+func RunLabels() map[string]string {
+	id := strings.ToLower(os.Getenv(RunIDEnvVar))
+	if id == "" {
+		return nil
+	}
+	return map[string]string{SolutionLabel: "true", EnvironmentLabel: "ci", RunLabel: id}
+}
+
+// RandomSuffix returns the random_id suffix the module appends to resource
+// names, taken from one of its bucket names.
+func RandomSuffix(bucket string) string {
+	return bucket[strings.LastIndex(bucket, "-")+1:]
+}
+
+// WaitForWorkflow polls until the latest execution of workflow succeeds,
+// failing the test if it failed.
+func WaitForWorkflow(t *testing.T, projectID, workflow string) {
+	verifyWorkflow := func() (bool, error) {
+		executions := gcloud.Runf(t, "workflows executions list %s --project %s --sort-by=startTime", workflow, projectID)
+		state := executions.Get("0.state").String()
+		if state == "FAILED" {
+			id := executions.Get("0.name")
+			gcloud.Runf(t, "workflows executions describe %s", id)
+			t.FailNow()
+		}
+		if state == "SUCCEEDED" {
+			return false, nil
+		}
+		return true, nil
+	}
+	utils.Poll(t, verifyWorkflow, 150, 5*time.Second)
+}
+
+// VerifyDatasetEncryption asserts every dataset in the project uses the
+// default KMS key expected maps it to, or Google-managed encryption if it is
+// not listed. An empty key also means Google-managed encryption.
+func VerifyDatasetEncryption(t *testing.T, assert *assert.Assertions, projectID string, expected map[string]string) {
+	datasets := []string{}
+	for _, ds := range bq.Runf(t, "--project_id=%s ls", projectID).Array() {
+		datasets = append(datasets, ds.Get("datasetReference.datasetId").String())
+	}
+	for dataset := range expected {
+		assert.Contains(datasets, dataset, "Dataset %s not found", dataset)
+	}
+
+	for _, dataset := range datasets {
+		key := expected[dataset]
+		got := bq.Runf(t, "--project_id=%s show %s", projectID, dataset).Get("defaultEncryptionConfiguration.kmsKeyName").String()
+		if key == "" {
+			assert.Empty(got, "%s is expected to use Google-managed encryption", dataset)
+		} else {
+			assert.Equal(key, got, "%s is not encrypted with %s", dataset, key)
+		}
+	}
+}
+
+// VerifyNoExternalIPs asserts no compute instance in the project, including
+// Dataproc cluster and serverless batch VMs, has an external IP access config.
+// The blueprint must deploy where constraints/compute.vmExternalIpAccess
+// denies all external IPs.
+func VerifyNoExternalIPs(t *testing.T, assert *assert.Assertions, projectID string) {
+	instances := gcloud.Runf(t, "compute instances list --project %s", projectID).Array()
+	assert.NotEmpty(instances, "No compute instances found")
+	for _, instance := range instances {
+		for _, nic := range instance.Get("networkInterfaces").Array() {
+			assert.Empty(nic.Get("accessConfigs").Array(), "%s has an external IP on %s", instance.Get("name").String(), nic.Get("name").String())
+		}
+	}
+}
+
+// resourcePath trims a Compute self link to its projects/... path, since the
+// APIs return subnet URIs with different host prefixes.
+func resourcePath(uri string) string {
+	if i := strings.Index(uri, "projects/"); i >= 0 {
+		return uri[i:]
+	}
+	return uri
+}
+
+// VerifyDataprocSubnet asserts the Dataproc clusters and serverless Spark
+// batches in the project all run on subnet, given as a self link.
+func VerifyDataprocSubnet(t *testing.T, assert *assert.Assertions, projectID, region, subnet string) {
+	subnet = resourcePath(subnet)
+
+	clusters := gcloud.Runf(t, "dataproc clusters list --project=%s --region=%s", projectID, region).Array()
+	assert.NotEmpty(clusters, "No Dataproc cluster found")
+	for _, cluster := range clusters {
+		assert.Equal(subnet, resourcePath(cluster.Get("config.gceClusterConfig.subnetworkUri").String()), "%s is not on %s", cluster.Get("clusterName").String(), subnet)
+	}
+
+	batches := gcloud.Runf(t, "dataproc batches list --project=%s --region=%s", projectID, region).Array()
+	assert.NotEmpty(batches, "No Dataproc batch found")
+	for _, batch := range batches {
+		assert.Equal(subnet, resourcePath(batch.Get("environmentConfig.executionConfig.subnetworkUri").String()), "%s is not on %s", batch.Get("name").String(), subnet)
+	}
+}
+
+// Range and addresses of the restricted.googleapis.com VIP.
+const restrictedAPIRange = "199.36.153.4/30"
+
+var restrictedAPIAddresses = []string{"199.36.153.4", "199.36.153.5", "199.36.153.6", "199.36.153.7"}
+
+// VerifyRestrictedAPIAccess asserts Google APIs are routed to the
+// restricted.googleapis.com VIP from network. A missing record or route
+// otherwise only shows up as API calls silently leaving through the public
+// endpoints, or as timeouts.
+func VerifyRestrictedAPIAccess(t *testing.T, assert *assert.Assertions, projectID, network string) {
+	zone := gcloud.Runf(t, "dns managed-zones describe googleapis --project=%s", projectID)
+	assert.Equal("googleapis.com.", zone.Get("dnsName").String(), "Zone googleapis does not serve googleapis.com")
+	assert.Equal("private", zone.Get("visibility").String(), "Zone googleapis is not private")
+	assert.True(strings.HasSuffix(zone.Get("privateVisibilityConfig.networks.0.networkUrl").String(), "/global/networks/"+network), "Zone googleapis is not visible to %s", network)
+
+	records := map[string][]string{}
+	for _, record := range gcloud.Runf(t, "dns record-sets list --zone=googleapis --project=%s", projectID).Array() {
+		records[record.Get("type").String()+" "+record.Get("name").String()] = utils.GetResultStrSlice(record.Get("rrdatas").Array())
+	}
+	if a, ok := records["A restricted.googleapis.com."]; assert.True(ok, "Zone googleapis is missing the A record for restricted.googleapis.com.") {
+		assert.ElementsMatch(restrictedAPIAddresses, a, "restricted.googleapis.com. does not resolve to the restricted VIP")
+	}
+	if cname, ok := records["CNAME *.googleapis.com."]; assert.True(ok, "Zone googleapis is missing the *.googleapis.com. CNAME, so API hostnames resolve to public endpoints") {
+		assert.Equal([]string{"restricted.googleapis.com."}, cname, "*.googleapis.com. is not aliased to restricted.googleapis.com.")
+	}
+
+	routes := gcloud.Runf(t, "compute routes list --project=%s", projectID).Array()
+	found := false
+	for _, route := range routes {
+		if route.Get("destRange").String() == restrictedAPIRange && strings.HasSuffix(route.Get("network").String(), "/global/networks/"+network) {
+			found = true
+			assert.True(strings.HasSuffix(route.Get("nextHopGateway").String(), "/global/gateways/default-internet-gateway"), "Route %s to the restricted VIP does not use the default internet gateway", route.Get("name").String())
+		}
+	}
+	assert.True(found, "%s has no route to the restricted VIP %s", network, restrictedAPIRange)
+}
@@ -0,0 +1,302 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package testutils
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/hcl/v2/hclparse"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/stretchr/testify/assert"
+	"github.com/tidwall/gjson"
+)
+
+// ModuleVersions is the root module's versions.tf, relative to a fixture
+// directory.
+var ModuleVersions = filepath.Join("..", "..", "..", "versions.tf")
+
+var versionPattern = regexp.MustCompile(`^\d+(\.\d+){0,2}$`)
+
+// terraformBlock returns the terraform block of a versions.tf.
+func terraformBlock(path string) (*hclsyntax.Body, error) {
+	file, diags := hclparse.NewParser().ParseHCLFile(path)
+	if diags.HasErrors() {
+		return nil, fmt.Errorf("parsing %s: %v", path, diags)
+	}
+	for _, block := range file.Body.(*hclsyntax.Body).Blocks {
+		if block.Type == "terraform" {
+			return block.Body, nil
+		}
+	}
+	return nil, fmt.Errorf("%s has no terraform block", path)
+}
+
+// RequiredVersion returns the required_version of a versions.tf.
+func RequiredVersion(path string) (string, error) {
+	body, err := terraformBlock(path)
+	if err != nil {
+		return "", err
+	}
+	attr, ok := body.Attributes["required_version"]
+	if !ok {
+		return "", fmt.Errorf("%s has no required_version", path)
+	}
+	value, diags := attr.Expr.Value(nil)
+	if diags.HasErrors() {
+		return "", fmt.Errorf("evaluating required_version in %s: %v", path, diags)
+	}
+	return value.AsString(), nil
+}
+
+// ProviderConstraint returns the version constraint a versions.tf requires
+// of a provider, such as google.
+func ProviderConstraint(path, provider string) (string, error) {
+	body, err := terraformBlock(path)
+	if err != nil {
+		return "", err
+	}
+	for _, block := range body.Blocks {
+		if block.Type != "required_providers" {
+			continue
+		}
+		attr, ok := block.Body.Attributes[provider]
+		if !ok {
+			break
+		}
+		value, diags := attr.Expr.Value(nil)
+		if diags.HasErrors() {
+			return "", fmt.Errorf("evaluating the %s provider in %s: %v", provider, path, diags)
+		}
+		return value.GetAttr("version").AsString(), nil
+	}
+	return "", fmt.Errorf("%s does not require the %s provider", path, provider)
+}
+
+// constraintTerm is one operator and version of a version constraint.
+type constraintTerm struct {
+	op      string
+	version string
+}
+
+func parseConstraint(constraint string) ([]constraintTerm, error) {
+	terms := []constraintTerm{}
+	for _, term := range strings.Split(constraint, ",") {
+		term = strings.TrimSpace(term)
+		op := strings.TrimRight(term, "0123456789. ")
+		version := strings.TrimSpace(strings.TrimPrefix(term, op))
+		op = strings.TrimSpace(op)
+		if !versionPattern.MatchString(version) {
+			return nil, fmt.Errorf("cannot parse %q in the constraint %q", term, constraint)
+		}
+		switch op {
+		case "":
+			op = "="
+		case ">=", ">", "<", "<=", "=", "!=", "~>":
+		default:
+			return nil, fmt.Errorf("cannot parse %q in the constraint %q", term, constraint)
+		}
+		terms = append(terms, constraintTerm{op, version})
+	}
+	return terms, nil
+}
+
+// MinimumVersion returns the oldest release a version constraint allows,
+// such as 1.3.0 for ">= 1.3, < 2.0". The constraint must have an inclusive
+// lower bound.
+func MinimumVersion(constraint string) (string, error) {
+	terms, err := parseConstraint(constraint)
+	if err != nil {
+		return "", err
+	}
+	minimum := ""
+	for _, term := range terms {
+		switch term.op {
+		case ">":
+			return "", fmt.Errorf("the constraint %q has an exclusive lower bound; use >=", constraint)
+		case "<", "<=", "!=":
+			continue
+		}
+		version := FullVersion(term.version)
+		if minimum == "" || CompareVersions(version, minimum) > 0 {
+			minimum = version
+		}
+	}
+	if minimum == "" {
+		return "", fmt.Errorf("the constraint %q has no lower bound", constraint)
+	}
+	return minimum, nil
+}
+
+// AllowsVersion reports whether a version constraint allows a release.
+// Prereleases are never allowed.
+func AllowsVersion(constraint, version string) (bool, error) {
+	terms, err := parseConstraint(constraint)
+	if err != nil {
+		return false, err
+	}
+	if !versionPattern.MatchString(version) {
+		return false, nil
+	}
+	version = FullVersion(version)
+	for _, term := range terms {
+		c := CompareVersions(version, FullVersion(term.version))
+		allowed := true
+		switch term.op {
+		case ">=":
+			allowed = c >= 0
+		case ">":
+			allowed = c > 0
+		case "<":
+			allowed = c < 0
+		case "<=":
+			allowed = c <= 0
+		case "=":
+			allowed = c == 0
+		case "!=":
+			allowed = c != 0
+		case "~>":
+			// ~> 1.2.3 allows 1.2.x from 1.2.3 on, ~> 1.2 allows 1.x from 1.2 on
+			parts := strings.Split(term.version, ".")
+			if len(parts) == 1 {
+				parts = append(parts, "0")
+			}
+			prefix := strings.Join(parts[:len(parts)-1], ".") + "."
+			allowed = c >= 0 && strings.HasPrefix(version, prefix)
+		}
+		if !allowed {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// FullVersion pads a version to major.minor.patch.
+func FullVersion(version string) string {
+	for strings.Count(version, ".") < 2 {
+		version += ".0"
+	}
+	return version
+}
+
+// CompareVersions compares two major.minor.patch versions like
+// strings.Compare.
+func CompareVersions(a, b string) int {
+	as, bs := strings.Split(a, "."), strings.Split(b, ".")
+	for i := range as {
+		var x, y int
+		fmt.Sscan(as[i], &x)
+		fmt.Sscan(bs[i], &y)
+		if x != y {
+			if x < y {
+				return -1
+			}
+			return 1
+		}
+	}
+	return 0
+}
+
+// LatestProviderVersion returns the latest release of a hashicorp provider
+// that a version constraint allows, from the Terraform Registry.
+func LatestProviderVersion(t *testing.T, provider, constraint string) string {
+	url := "https://registry.terraform.io/v1/providers/hashicorp/" + provider + "/versions"
+	resp, err := http.Get(url)
+	if err != nil {
+		t.Fatalf("listing the %s provider releases: %v", provider, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("%s returned %s", url, resp.Status)
+	}
+	var releases struct {
+		Versions []struct {
+			Version string `json:"version"`
+		} `json:"versions"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&releases); err != nil {
+		t.Fatalf("parsing the %s provider releases: %v", provider, err)
+	}
+	latest := ""
+	for _, release := range releases.Versions {
+		allowed, err := AllowsVersion(constraint, release.Version)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if allowed && (latest == "" || CompareVersions(release.Version, latest) > 0) {
+			latest = release.Version
+		}
+	}
+	if latest == "" {
+		t.Fatalf("no %s provider release satisfies %q", provider, constraint)
+	}
+	return latest
+}
+
+// ProviderSelections returns the provider releases an initialized
+// configuration selected, by provider name, such as google.
+func ProviderSelections(t *testing.T, tfDir string) map[string]string {
+	out, err := exec.Command(TerraformBinary(), "-chdir="+tfDir, "version", "-json").Output()
+	if err != nil {
+		t.Fatalf("reading the providers selected in %s: %v", tfDir, err)
+	}
+	selections := map[string]string{}
+	gjson.GetBytes(out, "provider_selections").ForEach(func(source, version gjson.Result) bool {
+		selections[source.String()[strings.LastIndex(source.String(), "/")+1:]] = version.String()
+		return true
+	})
+	return selections
+}
+
+// boundaryProviders are the providers the provider boundary fixtures pin.
+var boundaryProviders = []string{"google", "google-beta"}
+
+// VerifyMinimumProviders asserts the configuration in tfDir was initialized
+// with the oldest google and google-beta releases the root module allows.
+func VerifyMinimumProviders(t *testing.T, assert *assert.Assertions, tfDir string) {
+	verifyProviders(t, assert, tfDir, "oldest", func(provider, constraint string) string {
+		minimum, err := MinimumVersion(constraint)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return minimum
+	})
+}
+
+// VerifyLatestProviders asserts the configuration in tfDir was initialized
+// with the latest google and google-beta releases the root module allows, so
+// a stale lock file or a dependency capping them lower fails the fixture.
+func VerifyLatestProviders(t *testing.T, assert *assert.Assertions, tfDir string) {
+	verifyProviders(t, assert, tfDir, "latest", func(provider, constraint string) string {
+		return LatestProviderVersion(t, provider, constraint)
+	})
+}
+
+func verifyProviders(t *testing.T, assert *assert.Assertions, tfDir, boundary string, want func(provider, constraint string) string) {
+	selections := ProviderSelections(t, tfDir)
+	for _, provider := range boundaryProviders {
+		constraint, err := ProviderConstraint(ModuleVersions, provider)
+		if err != nil {
+			t.Fatal(err)
+		}
+		assert.Equal(want(provider, constraint), selections[provider], "%s is not the %s %s release %q allows", tfDir, boundary, provider, constraint)
+	}
+}
@@ -34,6 +34,25 @@ resource "google_project_iam_member" "int_test" {
   member  = "serviceAccount:${google_service_account.int_test.email}"
 }
 
+resource "google_project_iam_member" "int_test_pool" {
+  for_each = { for pair in setproduct(range(var.project_pool_size), local.int_required_roles) : "${pair[0]}/${pair[1]}" => pair }
+
+  project = module.pool_project[each.value[0]].project_id
+  role    = each.value[1]
+  member  = "serviceAccount:${google_service_account.int_test.email}"
+}
+
+resource "google_billing_account_iam_member" "int_test_budgets" {
+  count = var.enable_budget_fixture ? 1 : 0
+
+  billing_account_id = var.billing_account
+  role               = "roles/billing.costsManager"
+  member             = "serviceAccount:${google_service_account.int_test.email}"
+}
+
+# Not needed when the suite authenticates with workload identity federation.
 resource "google_service_account_key" "int_test" {
+  count = var.create_ci_sa_key ? 1 : 0
+
   service_account_id = google_service_account.int_test.id
 }
@@ -14,6 +14,26 @@
  * limitations under the License.
  */
 
+locals {
+  project_apis = [
+    "accesscontextmanager.googleapis.com",
+    "cloudasset.googleapis.com",
+    "cloudbilling.googleapis.com",
+    "cloudkms.googleapis.com",
+    "cloudquotas.googleapis.com",
+    "cloudresourcemanager.googleapis.com",
+    "bigquery.googleapis.com",
+    "bigquerystorage.googleapis.com",
+    "bigqueryconnection.googleapis.com",
+    "bigqueryreservation.googleapis.com",
+    "compute.googleapis.com",
+    "serviceusage.googleapis.com",
+    "iam.googleapis.com",
+    "monitoring.googleapis.com",
+    "securitycenter.googleapis.com",
+  ]
+}
+
 module "project" {
   source  = "terraform-google-modules/project-factory/google"
   version = "~> 14.0"
@@ -25,15 +45,27 @@ module "project" {
   billing_account         = var.billing_account
   default_service_account = "keep"
 
-  activate_apis = [
-    "cloudkms.googleapis.com",
-    "cloudresourcemanager.googleapis.com",
-    "bigquery.googleapis.com",
-    "bigquerystorage.googleapis.com",
-    "bigqueryconnection.googleapis.com",
-    "serviceusage.googleapis.com",
-    "iam.googleapis.com",
-  ]
+  activate_apis = local.project_apis
+}
+
+# Optional pool of seed projects the shard coordinator in
+# test/integration/cmd/shard deploys fixtures into in parallel. Pool projects
+# get neither the org policies nor the VPC Service Controls perimeter, so
+# fixtures that assert those stay in the main project.
+module "pool_project" {
+  count = var.project_pool_size
+
+  source  = "terraform-google-modules/project-factory/google"
+  version = "~> 14.0"
+
+  name                    = "ci-lakehouse-pool-${count.index}"
+  random_project_id       = "true"
+  org_id                  = var.org_id
+  folder_id               = var.folder_id
+  billing_account         = var.billing_account
+  default_service_account = "keep"
+
+  activate_apis = local.project_apis
 }
 
 module "kms_keyring" {
@@ -53,3 +85,9 @@ module "kms_keyring" {
 data "google_bigquery_default_service_account" "initialize_encryption_account" {
   project = module.project.project_id
 }
+
+data "google_bigquery_default_service_account" "initialize_pool_encryption_account" {
+  count = var.project_pool_size
+
+  project = module.pool_project[count.index].project_id
+}
@@ -0,0 +1,84 @@
+/**
+ * Copyright 2023 Google LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+# Fixtures write how long apply, each verification group and teardown take
+# into the main project, including fixtures sharded into pool projects, so
+# the dashboard shows deploy-time regressions creeping in across commits.
+resource "google_monitoring_metric_descriptor" "stage_duration" {
+  project      = module.project.project_id
+  type         = "custom.googleapis.com/lakehouse_test/stage_duration"
+  display_name = "Integration test stage duration"
+  description  = "Seconds an integration test fixture spent in a stage."
+  metric_kind  = "GAUGE"
+  value_type   = "DOUBLE"
+  unit         = "s"
+
+  labels {
+    key         = "fixture"
+    description = "The test fixture, named after its example."
+  }
+  labels {
+    key         = "stage"
+    description = "apply, teardown, or verify/<group> for a verification group."
+  }
+  labels {
+    key         = "commit"
+    description = "The commit under test."
+  }
+  labels {
+    key         = "binary"
+    description = "The binary the fixture was deployed with, terraform or tofu."
+  }
+}
+
+resource "google_monitoring_dashboard" "stage_duration" {
+  project = module.project.project_id
+  dashboard_json = jsonencode({
+    displayName = "Lakehouse integration test stages"
+    mosaicLayout = {
+      columns = 12
+      tiles = [for i, stage in ["apply", "verify", "teardown"] : {
+        xPos   = 0
+        yPos   = i * 4
+        width  = 12
+        height = 4
+        widget = {
+          title = stage == "verify" ? "verify duration by fixture and group" : "${stage} duration by fixture"
+          xyChart = {
+            dataSets = [{
+              plotType = "LINE"
+              timeSeriesQuery = {
+                timeSeriesFilter = {
+                  filter = "metric.type=\"${google_monitoring_metric_descriptor.stage_duration.type}\" resource.type=\"global\" ${stage == "verify" ? "metric.label.stage=starts_with(\"verify/\")" : "metric.label.stage=\"${stage}\""}"
+                  aggregation = {
+                    alignmentPeriod    = "86400s"
+                    perSeriesAligner   = "ALIGN_MEAN"
+                    crossSeriesReducer = "REDUCE_MEAN"
+                    groupByFields      = stage == "verify" ? ["metric.label.fixture", "metric.label.stage"] : ["metric.label.fixture"]
+                  }
+                }
+              }
+            }]
+            yAxis = {
+              label = "seconds"
+              scale = "LINEAR"
+            }
+          }
+        }
+      }]
+    }
+  })
+}
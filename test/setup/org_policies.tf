@@ -0,0 +1,50 @@
+/**
+ * Copyright 2023 Google LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+# Deploy the blueprint under the restrictive constraints customer
+# foundations commonly enforce.
+locals {
+  enforced_boolean_constraints = [
+    "compute.requireOsLogin",
+    "compute.requireShieldedVm",
+    "iam.disableServiceAccountKeyCreation",
+  ]
+}
+
+# The CI key is created first so the test can still authenticate.
+resource "google_project_organization_policy" "boolean_constraints" {
+  for_each = toset(local.enforced_boolean_constraints)
+
+  project    = module.project.project_id
+  constraint = each.key
+
+  boolean_policy {
+    enforced = true
+  }
+
+  depends_on = [google_service_account_key.int_test]
+}
+
+resource "google_project_organization_policy" "vm_external_ip_access" {
+  project    = module.project.project_id
+  constraint = "compute.vmExternalIpAccess"
+
+  list_policy {
+    deny {
+      all = true
+    }
+  }
+}
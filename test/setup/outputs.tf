@@ -19,7 +19,7 @@ output "project_id" {
 }
 
 output "sa_key" {
-  value     = google_service_account_key.int_test.private_key
+  value     = var.create_ci_sa_key ? google_service_account_key.int_test[0].private_key : ""
   sensitive = true
 }
 
@@ -30,3 +30,80 @@ output "kms_keys" {
 output "region" {
   value = var.region
 }
+
+output "vpc_sc_dry_run_perimeter" {
+  value = var.vpc_sc_access_policy_id == "" ? "" : google_access_context_manager_service_perimeter.dry_run[0].name
+}
+
+output "scc_findings_gate" {
+  value = var.enable_scc_findings_gate
+}
+
+output "forecast_max_mape" {
+  value = var.forecast_max_mape
+}
+
+output "load_test_concurrency" {
+  value = var.load_test_concurrency
+}
+
+output "load_test_slo_seconds" {
+  value = var.load_test_slo_seconds
+}
+
+output "dashboard_benchmark_minutes" {
+  value = var.dashboard_benchmark_minutes
+}
+
+output "dashboard_benchmark_viewers" {
+  value = var.dashboard_benchmark_viewers
+}
+
+output "dashboard_p95_seconds" {
+  value = var.dashboard_p95_seconds
+}
+
+output "raw_data_format" {
+  value = var.raw_data_format
+}
+
+output "shared_vpc_host_project_id" {
+  value = var.enable_shared_vpc_fixture ? module.shared_vpc_host[0].project_id : ""
+}
+
+output "shared_vpc_subnetwork" {
+  value = var.enable_shared_vpc_fixture ? google_compute_subnetwork.shared[0].self_link : ""
+}
+
+output "looker_oauth_client_id" {
+  value = var.looker_oauth_client_id
+}
+
+output "looker_oauth_client_secret" {
+  value     = var.looker_oauth_client_secret
+  sensitive = true
+}
+
+output "project_pool" {
+  value = module.pool_project[*].project_id
+}
+
+output "stage_metrics_project_id" {
+  value = google_monitoring_metric_descriptor.stage_duration.project
+}
+
+output "billing_export_table" {
+  value = var.billing_export_table
+}
+
+output "run_cost_threshold" {
+  value = var.run_cost_threshold
+}
+
+output "budget_billing_account" {
+  value = var.enable_budget_fixture ? var.billing_account : ""
+}
+
+output "budget_alert_email" {
+  value = var.enable_budget_fixture ? "lakehouse-ci-budget@example.com" : ""
+}
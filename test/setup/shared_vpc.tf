@@ -0,0 +1,100 @@
+/**
+ * Copyright 2023 Google LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+# Optional Shared VPC host project for the shared_vpc example. Enabling the
+# host needs Compute Shared VPC Admin on the folder.
+module "shared_vpc_host" {
+  count = var.enable_shared_vpc_fixture ? 1 : 0
+
+  source  = "terraform-google-modules/project-factory/google"
+  version = "~> 14.0"
+
+  name              = "ci-lakehouse-host"
+  random_project_id = "true"
+  org_id            = var.org_id
+  folder_id         = var.folder_id
+  billing_account   = var.billing_account
+
+  activate_apis = [
+    "compute.googleapis.com",
+  ]
+}
+
+resource "google_compute_shared_vpc_host_project" "host" {
+  count = var.enable_shared_vpc_fixture ? 1 : 0
+
+  project = module.shared_vpc_host[0].project_id
+}
+
+resource "google_compute_shared_vpc_service_project" "lakehouse" {
+  count = var.enable_shared_vpc_fixture ? 1 : 0
+
+  host_project    = google_compute_shared_vpc_host_project.host[0].project
+  service_project = module.project.project_id
+}
+
+resource "google_compute_network" "shared" {
+  count = var.enable_shared_vpc_fixture ? 1 : 0
+
+  project                 = module.shared_vpc_host[0].project_id
+  name                    = "vpc-lakehouse-shared"
+  auto_create_subnetworks = false
+}
+
+resource "google_compute_subnetwork" "shared" {
+  count = var.enable_shared_vpc_fixture ? 1 : 0
+
+  project                  = module.shared_vpc_host[0].project_id
+  name                     = "lakehouse-shared-subnet"
+  ip_cidr_range            = "10.4.0.0/16"
+  region                   = var.region
+  network                  = google_compute_network.shared[0].id
+  private_ip_google_access = true
+}
+
+resource "google_compute_firewall" "shared_internal" {
+  count = var.enable_shared_vpc_fixture ? 1 : 0
+
+  project = module.shared_vpc_host[0].project_id
+  name    = "lakehouse-shared-internal"
+  network = google_compute_network.shared[0].id
+
+  allow {
+    protocol = "icmp"
+  }
+
+  allow {
+    protocol = "tcp"
+  }
+
+  allow {
+    protocol = "udp"
+  }
+  source_ranges = [google_compute_subnetwork.shared[0].ip_cidr_range]
+}
+
+# Lets the CI account read the subnet and grant the lakehouse's Dataproc
+# service agents Compute Network User on it.
+resource "google_project_iam_member" "shared_vpc_int_test" {
+  for_each = toset(var.enable_shared_vpc_fixture ? [
+    "roles/compute.networkViewer",
+    "roles/compute.securityAdmin",
+  ] : [])
+
+  project = module.shared_vpc_host[0].project_id
+  role    = each.key
+  member  = "serviceAccount:${google_service_account.int_test.email}"
+}
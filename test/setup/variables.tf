@@ -30,3 +30,106 @@ variable "region" {
   description = "Google Cloud Region"
   default     = "us-central1"
 }
+
+variable "vpc_sc_access_policy_id" {
+  type        = string
+  description = "Access Context Manager policy ID. When set, the test project is placed in a dry-run VPC Service Controls perimeter and the test asserts no violations are logged. The CI account needs roles/accesscontextmanager.policyEditor on the policy."
+  default     = ""
+}
+
+variable "enable_scc_findings_gate" {
+  type        = bool
+  description = "Whether the integration test fails on HIGH or CRITICAL Security Command Center misconfiguration findings in the test project. Security Command Center must be active on the organization."
+  default     = false
+}
+
+variable "create_ci_sa_key" {
+  type        = bool
+  description = "Whether to create a key for the CI service account. Disable when the suite authenticates with workload identity federation and impersonation instead."
+  default     = true
+}
+
+variable "enable_shared_vpc_fixture" {
+  type        = bool
+  description = "Whether to create a Shared VPC host project with a subnet for the shared_vpc example. The setup account needs roles/compute.xpnAdmin on the folder."
+  default     = false
+}
+
+variable "forecast_max_mape" {
+  type        = number
+  description = "Highest mean absolute percentage error the integration test accepts from the taxi trips forecast on its December holdout."
+  default     = 25
+}
+
+variable "load_test_concurrency" {
+  type        = number
+  description = "Number of analyst-style queries the integration test runs concurrently against the lakehouse. The load test is skipped when 0."
+  default     = 0
+}
+
+variable "load_test_slo_seconds" {
+  type        = number
+  description = "Longest time in seconds any query of the load test may take."
+  default     = 60
+}
+
+variable "dashboard_benchmark_minutes" {
+  type        = number
+  description = "Minutes the integration test replays the Looker Studio dashboard's queries for, as dashboard_benchmark_viewers viewers refreshing it back to back. The benchmark is skipped when 0."
+  default     = 0
+}
+
+variable "dashboard_benchmark_viewers" {
+  type        = number
+  description = "Number of dashboard viewers the benchmark simulates at once."
+  default     = 5
+}
+
+variable "dashboard_p95_seconds" {
+  type        = number
+  description = "Longest 95th percentile latency in seconds of the dashboard queries during the benchmark."
+  default     = 5
+}
+
+variable "raw_data_format" {
+  type        = string
+  description = "File format the analytics_lakehouse example and fixture write the raw thelook tables in, one of PARQUET, CSV or JSON."
+  default     = "PARQUET"
+}
+
+variable "looker_oauth_client_id" {
+  type        = string
+  description = "The client ID of an OAuth client in the test project for the looker example. The looker test is skipped when unset."
+  default     = ""
+}
+
+variable "looker_oauth_client_secret" {
+  type        = string
+  description = "The client secret of the OAuth client for the looker example."
+  default     = ""
+  sensitive   = true
+}
+
+variable "project_pool_size" {
+  type        = number
+  description = "Number of additional seed projects to create for the shard coordinator, which deploys the fixtures that do not need the main project's org policies or perimeter into them in parallel."
+  default     = 0
+}
+
+variable "billing_export_table" {
+  type        = string
+  description = "The detailed or standard Cloud Billing export table, as project.dataset.table, to check the cost of a CI run in. The check is skipped when unset."
+  default     = ""
+}
+
+variable "run_cost_threshold" {
+  type        = number
+  description = "Largest cost in USD, after credits, the resources labeled with a CI run may incur."
+  default     = 100
+}
+
+variable "enable_budget_fixture" {
+  type        = bool
+  description = "Whether the analytics_lakehouse_full fixture creates a billing budget on billing_account. Grants the CI service account Billing Account Costs Manager on it, which requires billing account administration."
+  default     = false
+}
@@ -0,0 +1,68 @@
+/**
+ * Copyright 2023 Google LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+# Services the blueprint calls that VPC Service Controls can restrict.
+locals {
+  vpc_sc_restricted_services = [
+    "aiplatform.googleapis.com",
+    "analyticshub.googleapis.com",
+    "artifactregistry.googleapis.com",
+    "biglake.googleapis.com",
+    "bigquery.googleapis.com",
+    "bigquerydatapolicy.googleapis.com",
+    "bigquerydatatransfer.googleapis.com",
+    "bigqueryreservation.googleapis.com",
+    "cloudbuild.googleapis.com",
+    "cloudfunctions.googleapis.com",
+    "cloudscheduler.googleapis.com",
+    "composer.googleapis.com",
+    "compute.googleapis.com",
+    "datacatalog.googleapis.com",
+    "dataflow.googleapis.com",
+    "dataform.googleapis.com",
+    "datalineage.googleapis.com",
+    "dataplex.googleapis.com",
+    "dataproc.googleapis.com",
+    "datastream.googleapis.com",
+    "dlp.googleapis.com",
+    "firestore.googleapis.com",
+    "iam.googleapis.com",
+    "logging.googleapis.com",
+    "looker.googleapis.com",
+    "monitoring.googleapis.com",
+    "pubsub.googleapis.com",
+    "run.googleapis.com",
+    "sqladmin.googleapis.com",
+    "storage.googleapis.com",
+    "workflows.googleapis.com",
+  ]
+}
+
+# Dry-run perimeter around the test project. Nothing is blocked; violations
+# are only logged, which the integration test checks for.
+resource "google_access_context_manager_service_perimeter" "dry_run" {
+  count = var.vpc_sc_access_policy_id == "" ? 0 : 1
+
+  parent = "accessPolicies/${var.vpc_sc_access_policy_id}"
+  name   = "accessPolicies/${var.vpc_sc_access_policy_id}/servicePerimeters/ci_lakehouse_${replace(module.project.project_id, "-", "_")}"
+  title  = "ci_lakehouse_${replace(module.project.project_id, "-", "_")}"
+
+  use_explicit_dry_run_spec = true
+  spec {
+    resources           = ["projects/${module.project.project_number}"]
+    restricted_services = local.vpc_sc_restricted_services
+  }
+}
@@ -42,6 +42,12 @@ variable "enable_apis" {
   default     = true
 }
 
+variable "execute_workflows" {
+  type        = bool
+  description = "Whether Terraform starts the copy-data and project-setup workflows on apply. Set to false to run them from another orchestrator, such as the Composer DAG in examples/composer."
+  default     = true
+}
+
 variable "force_destroy" {
   type        = string
   description = "Whether or not to protect GCS resources from deletion when solution is modified or changed."
@@ -59,3 +65,369 @@ variable "public_data_bucket" {
   description = "Public Data bucket for access"
   default     = "data-analytics-demos"
 }
+
+variable "sample_datasets" {
+  type        = list(string)
+  description = "Sample datasets the copy-data workflow copies from the public data bucket, of thelook_ecommerce, new_york_taxi_trips, textocr_images and ga4_images. The thelook tables are always copied. Image inference needs textocr_images, and forecasting and the glossary need new_york_taxi_trips."
+  default     = ["thelook_ecommerce", "new_york_taxi_trips", "textocr_images", "ga4_images"]
+
+  validation {
+    condition     = contains(var.sample_datasets, "thelook_ecommerce") && alltrue([for dataset in var.sample_datasets : contains(["thelook_ecommerce", "new_york_taxi_trips", "textocr_images", "ga4_images"], dataset)])
+    error_message = "The sample_datasets must include thelook_ecommerce, and may include new_york_taxi_trips, textocr_images and ga4_images."
+  }
+}
+
+variable "enable_dataproc" {
+  type        = bool
+  description = "Whether to create the Dataproc Persistent History Server, and have the project-setup workflow create the Iceberg tables with a serverless Spark batch. Delta Lake, Iceberg maintenance and the Firestore and serving exports, which read agg_events_iceberg, require it."
+  default     = true
+}
+
+variable "enable_data_attributes" {
+  type        = bool
+  description = "Whether to create Dataplex data attributes (sensitivity, domain) and bind them to the lakehouse zone entities."
+  default     = false
+}
+
+variable "enable_aspect_types" {
+  type        = bool
+  description = "Whether to create a Dataplex Catalog data-freshness aspect type and attach it to the staging table entries."
+  default     = false
+}
+
+variable "enable_glossary" {
+  type        = bool
+  description = "Whether to create a Dataplex business glossary with Orders, Events, and Taxi Trips terms linked to their tables."
+  default     = false
+}
+
+variable "warehouse_dual_region" {
+  type        = list(string)
+  description = "Pair of regions in the same continent, one of them `region`, to store the Iceberg warehouse bucket in as a dual-region for high availability, for example `[\"us-central1\", \"us-east1\"]`. The bucket is regional when empty."
+  default     = []
+
+  validation {
+    condition     = length(var.warehouse_dual_region) == 0 || length(var.warehouse_dual_region) == 2
+    error_message = "The warehouse_dual_region must be empty or list exactly two regions."
+  }
+
+  validation {
+    condition     = length(distinct([for region in var.warehouse_dual_region : split("-", region)[0]])) <= 1 && alltrue([for region in var.warehouse_dual_region : contains(["us", "europe", "asia"], split("-", region)[0])])
+    error_message = "The warehouse_dual_region must list two regions in the same one of the us, europe or asia continents."
+  }
+}
+
+variable "warehouse_turbo_replication" {
+  type        = bool
+  description = "Whether to enable turbo replication on the dual-region Iceberg warehouse bucket. Only applies with `warehouse_dual_region`."
+  default     = false
+}
+
+variable "create_lakehouse_dataset" {
+  type        = bool
+  description = "Whether to create the gcp_lakehouse_ds BigQuery dataset. Set to false to reuse an existing gcp_lakehouse_ds dataset in the project, in `region`, which the blueprint then neither modifies nor deletes."
+  default     = true
+}
+
+variable "tables_bucket_name" {
+  type        = string
+  description = "Name of an existing bucket in the project, in `region`, to copy the sample tables to instead of creating one. The blueprint neither modifies nor deletes it, but leaves the copied tables in it on destroy."
+  default     = null
+}
+
+variable "warehouse_bucket_name" {
+  type        = string
+  description = "Name of an existing bucket in the project to use as the Iceberg warehouse instead of creating one. The blueprint neither modifies nor deletes it, but leaves the warehouse data in it on destroy. `warehouse_dual_region` and `warehouse_turbo_replication` do not apply to it."
+  default     = null
+}
+
+variable "kms_key_name" {
+  type        = string
+  description = "Cloud KMS key, in the same location as `region`, used to encrypt the BigQuery dataset, Cloud Storage buckets, and Dataproc cluster disks. Google-managed encryption is used when null."
+  default     = null
+}
+
+variable "network_self_link" {
+  type        = string
+  description = "Self link of an existing network in the project that contains `subnetwork_self_link`, set together with it. Cloud NAT and Private Service Connect attach to it when enabled."
+  default     = null
+}
+
+variable "subnetwork_self_link" {
+  type        = string
+  description = "Self link of an existing subnet in the project, in `region`, to run Dataproc on instead of creating a network. Set together with `network_self_link`. The subnet needs Private Google Access and a firewall rule allowing internal traffic."
+  default     = null
+}
+
+variable "shared_vpc_host_project_id" {
+  type        = string
+  description = "Shared VPC host project owning `shared_vpc_subnetwork`, where the blueprint grants the Dataproc service agents Compute Network User on the subnet. Defaults to the project in the `shared_vpc_subnetwork` self link."
+  default     = null
+}
+
+variable "shared_vpc_subnetwork" {
+  type        = string
+  description = "Self link of a Shared VPC subnet, in `region`, to run Dataproc on instead of creating a network in the project. The subnet needs Private Google Access and a firewall rule allowing internal traffic."
+  default     = null
+}
+
+variable "enable_nat" {
+  type        = bool
+  description = "Whether to create a Cloud Router and Cloud NAT so Dataproc nodes and serverless Spark batches, which have no external IPs, can reach the internet, for example to install PyPI packages. Not created with Shared VPC, where the host project owns egress."
+  default     = false
+}
+
+variable "enable_vpc_connector" {
+  type        = bool
+  description = "Whether to create a Serverless VPC Access connector on the lakehouse network, so serverless integrations such as Cloud Functions egress privately through it. Not created with Shared VPC."
+  default     = false
+}
+
+variable "enable_private_service_connect" {
+  type        = bool
+  description = "Whether to create a Private Service Connect endpoint for Google APIs and a private googleapis.com DNS zone, so Dataproc reaches Google APIs without leaving the network. Not created with Shared VPC, where the host project owns DNS."
+  default     = false
+}
+
+variable "enable_restricted_api_access" {
+  type        = bool
+  description = "Whether to route Google APIs through the restricted.googleapis.com VIP, which only serves APIs supported by VPC Service Controls, with a private googleapis.com DNS zone. Ignored when `enable_private_service_connect` is set or with Shared VPC."
+  default     = false
+}
+
+variable "enable_data_access_audit_logs" {
+  type        = bool
+  description = "Whether to enable Data Access audit logs (DATA_READ and DATA_WRITE) for BigQuery and Cloud Storage in the project."
+  default     = false
+}
+
+variable "enable_log_sink" {
+  type        = bool
+  description = "Whether to route Workflows and Dataproc logs into a lakehouse operations BigQuery dataset."
+  default     = false
+}
+
+variable "enable_conditional_access" {
+  type        = bool
+  description = "Whether to grant the marketing user time-bound read access scoped to the lakehouse dataset through an IAM condition."
+  default     = false
+}
+
+variable "enable_access_layer" {
+  type        = bool
+  description = "Whether to create an access-layer dataset of curated views over the staging tables, authorized on the staging dataset, and a consumer service account that can only query those views."
+  default     = false
+}
+
+variable "raw_data_format" {
+  type        = string
+  description = "File format the copy-data workflow writes the thelook tables to the tables bucket in, one of PARQUET, CSV or JSON. Parquet files are copied as published; CSV and JSON files are exported from them with BigQuery, and Dataplex discovery infers their schema."
+  default     = "PARQUET"
+
+  validation {
+    condition     = contains(["PARQUET", "CSV", "JSON"], var.raw_data_format)
+    error_message = "The raw_data_format must be one of PARQUET, CSV or JSON."
+  }
+}
+
+variable "enable_dataflow_load" {
+  type        = bool
+  description = "Whether the project-setup workflow also loads the distribution centers into the lakehouse dataset with a Dataflow flex template job, transforming the rows on the way in."
+  default     = false
+}
+
+variable "enable_transfer_load" {
+  type        = bool
+  description = "Whether to create a BigQuery Data Transfer Service config that loads the raw thelook orders files from the tables bucket into the lakehouse dataset once a day, without the workflows. Only applies when raw_data_format is PARQUET."
+  default     = false
+}
+
+variable "enable_dataform" {
+  type        = bool
+  description = "Whether to create a Dataform repository whose SQLX models build a curated dataset from the staging tables. The project-setup workflow compiles and invokes the models."
+  default     = false
+}
+
+variable "bi_engine_reservation_gb" {
+  type        = number
+  description = "Size in GiB of a BI Engine reservation that accelerates dashboard queries over the curated tables. 0 creates no reservation. Requires enable_dataform."
+  default     = 0
+}
+
+variable "enable_analytics_hub" {
+  type        = bool
+  description = "Whether to publish the curated dataset through an Analytics Hub exchange and listing, with a subscriber service account allowed to subscribe to it. Requires enable_dataform."
+  default     = false
+}
+
+variable "enable_image_inference" {
+  type        = bool
+  description = "Whether the project-setup workflow creates an object table over the TextOCR images and a Gemini remote model, and stores ML.GENERATE_TEXT descriptions for a sample of the images."
+  default     = false
+}
+
+variable "enable_vector_search" {
+  type        = bool
+  description = "Whether the project-setup workflow embeds the thelook products with a Vertex AI embedding model, creates a vector index over the embeddings and a similar_products search function."
+  default     = false
+}
+
+variable "enable_firestore_export" {
+  type        = bool
+  description = "Whether to create a Firestore database and a firestore-export workflow that writes the top 100 users by event count from agg_events_iceberg as documents for low-latency lookups. The workflow runs on demand, after project-setup has built the table."
+  default     = false
+}
+
+variable "enable_forecasting" {
+  type        = bool
+  description = "Whether the project-setup workflow trains an ARIMA_PLUS model that forecasts hourly New York taxi pickups, holding out December 2022 for evaluation."
+  default     = false
+}
+
+variable "enable_materialized_views" {
+  type        = bool
+  description = "Whether the project-setup workflow copies the thelook orders and users into native tables and creates an auto-refreshing materialized view over their join."
+  default     = false
+}
+
+variable "enable_search_index" {
+  type        = bool
+  description = "Whether the project-setup workflow copies the thelook events into a native thelook_events table with a search index over its string columns, for SEARCH() point lookups. With enable_slot_reservation, index management also runs on the reservation so the small sample table is indexed."
+  default     = false
+}
+
+variable "enable_snapshots" {
+  type        = bool
+  description = "Whether the project-setup workflow snapshots the thelook orders into a gcp_lakehouse_snapshots dataset, with a restore_snapshot procedure that clones a snapshot back into a table."
+  default     = false
+}
+
+variable "enable_notebook" {
+  type        = bool
+  description = "Whether to upload a sample lakehouse notebook to the provisioning bucket and have the project-setup workflow create a Colab Enterprise runtime template to execute it on."
+  default     = false
+}
+
+variable "enable_serving_export" {
+  type        = bool
+  description = "Whether to create a small Cloud SQL for MySQL instance and a serving-export workflow that copies the agg_events_iceberg aggregate into it for application serving. The workflow runs on demand, after project-setup has built the table."
+  default     = false
+}
+
+variable "enable_remote_function" {
+  type        = bool
+  description = "Whether to deploy a Cloud Function and create the score_event BigQuery remote function that calls it from SQL. The function runs on the Serverless VPC Access connector when enable_vpc_connector is set."
+  default     = false
+}
+
+variable "enable_scheduled_queries" {
+  type        = bool
+  description = "Whether to create a BigQuery scheduled query that merges the orders into a daily_order_aggregates table once a day."
+  default     = false
+}
+
+variable "enable_slot_reservation" {
+  type        = bool
+  description = "Whether to create an Enterprise edition slot reservation and assign the project's query jobs to it, for predictable capacity-based pricing instead of on-demand billing."
+  default     = false
+}
+
+variable "reservation_baseline_slots" {
+  type        = number
+  description = "Baseline slots of the reservation created by enable_slot_reservation, billed while the reservation exists. Must be a multiple of 50."
+  default     = 0
+}
+
+variable "reservation_autoscale_max_slots" {
+  type        = number
+  description = "Slots the reservation created by enable_slot_reservation can autoscale by above its baseline, billed only while in use. Must be a multiple of 50."
+  default     = 100
+}
+
+variable "enable_streaming" {
+  type        = bool
+  description = "Whether to create a Pub/Sub topic with a BigQuery subscription that streams events into an events_stream table in the raw zone."
+  default     = false
+}
+
+variable "enable_continuous_query" {
+  type        = bool
+  description = "Whether the project-setup workflow starts a BigQuery continuous query that keeps a per-minute event aggregate up to date from the streaming table. Requires enable_streaming, and creates a 50 slot Enterprise edition reservation for CONTINUOUS jobs, which is billed while it exists."
+  default     = false
+}
+
+variable "enable_delta_lake" {
+  type        = bool
+  description = "Whether the project-setup workflow also writes the event aggregate as a Delta Lake table in the warehouse bucket, with a symlink manifest, and creates an agg_events_delta BigLake table over it alongside agg_events_iceberg. Requires enable_dataproc."
+  default     = false
+}
+
+variable "enable_dlp_scan" {
+  type        = bool
+  description = "Whether to create Sensitive Data Protection (DLP) job triggers that inspect native copies of the thelook users and events for personal data weekly, saving findings into a dlp_findings table in the lakehouse dataset. The project-setup workflow creates the copies and runs the first scan."
+  default     = false
+}
+
+variable "enable_dlp_deidentify" {
+  type        = bool
+  description = "Whether to create a Sensitive Data Protection (DLP) de-identify template, and have the project-setup workflow write a copy of the thelook users into a thelook_users_deidentified table with the names tokenized and the email and street address masked."
+  default     = false
+}
+
+variable "enable_iceberg_maintenance" {
+  type        = bool
+  description = "Whether to create an iceberg-maintenance workflow, executed by Cloud Scheduler, that compacts the data files of agg_events_iceberg and expires all but its current snapshot with a serverless Spark batch."
+  default     = false
+}
+
+variable "iceberg_maintenance_schedule" {
+  type        = string
+  description = "Cron schedule, in UTC, on which Cloud Scheduler executes the iceberg-maintenance workflow created by enable_iceberg_maintenance."
+  default     = "0 3 * * 0"
+}
+
+variable "enable_retention" {
+  type        = bool
+  description = "Whether to create a data-retention workflow, executed daily by Cloud Scheduler, that moves the orders older than `retention_days` from a day-partitioned thelook_orders_hot table to a Coldline archive bucket, queryable through a gcp_lakehouse_archive dataset."
+  default     = false
+}
+
+variable "retention_days" {
+  type        = number
+  description = "Age in days after which the data-retention workflow created by enable_retention moves order partitions to the archive bucket."
+  default     = 365
+
+  validation {
+    condition     = var.retention_days > 0
+    error_message = "The retention_days must be greater than 0."
+  }
+}
+
+variable "budget_billing_account" {
+  type        = string
+  description = "ID of the billing account to create a monthly budget on, scoped to the project and to resources labeled analytics-lakehouse. No budget is created when empty."
+  default     = ""
+}
+
+variable "budget_amount" {
+  type        = number
+  description = "Monthly amount in USD of the budget created with budget_billing_account. Alerts are sent at 50%, 90% and 100% of it, and when spend is forecasted to exceed it."
+  default     = 1000
+
+  validation {
+    condition     = var.budget_amount > 0
+    error_message = "The budget_amount must be greater than 0."
+  }
+}
+
+variable "budget_alert_emails" {
+  type        = list(string)
+  description = "Email addresses the budget alerts are sent to through Cloud Monitoring notification channels. When empty, the billing account's administrators and users are alerted instead."
+  default     = []
+}
+
+variable "resource_tags" {
+  type        = map(string)
+  description = "Secure tags, as key/value short names, to create in the project and bind to the project and lakehouse buckets for policy targeting."
+  default     = {}
+}
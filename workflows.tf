@@ -31,21 +31,18 @@ resource "google_service_account" "workflows_sa" {
   depends_on = [google_project_service_identity.workflows]
 }
 
+# The workflows service account only orchestrates: it starts Dataproc batches
+# as the data-plane service account and manages Dataplex metadata, but owns
+# no data writes outside the lakehouse dataset's views.
 resource "google_project_iam_member" "workflows_sa_roles" {
-  for_each = toset([
-    "roles/workflows.admin",
-    "roles/bigquery.dataOwner",
-    "roles/storage.admin",
-    "roles/bigquery.resourceAdmin",
-    "roles/iam.serviceAccountTokenCreator",
-    "roles/iam.serviceAccountUser",
-    "roles/bigquery.connectionAdmin",
-    "roles/bigquery.jobUser",
+  for_each = toset(concat([
+    "roles/workflows.viewer",
     "roles/logging.logWriter",
-    "roles/dataproc.admin",
-    "roles/bigquery.admin",
-    "roles/dataplex.admin"
-  ])
+    "roles/dataproc.editor",
+    "roles/dataplex.admin",
+    "roles/bigquery.jobUser",
+    "roles/bigquery.metadataViewer",
+  ], var.enable_dataflow_load ? ["roles/dataflow.developer"] : [], var.enable_notebook ? ["roles/aiplatform.notebookRuntimeAdmin"] : [], var.enable_iceberg_maintenance || var.enable_retention ? ["roles/workflows.invoker"] : [], var.enable_dlp_scan ? ["roles/dlp.jobsEditor", "roles/dlp.jobTriggersReader", "roles/dlp.inspectTemplatesReader"] : []))
 
   project = module.project-services.project_id
   role    = each.key
@@ -56,7 +53,23 @@ resource "google_project_iam_member" "workflows_sa_roles" {
   ]
 }
 
+# Allow the workflows service account to create the lakehouse views
+resource "google_bigquery_dataset_iam_member" "workflows_sa_views" {
+  project    = module.project-services.project_id
+  dataset_id = local.lakehouse_dataset_id
+  role       = "roles/bigquery.dataEditor"
+  member     = "serviceAccount:${google_service_account.workflows_sa.email}"
+}
+
+# Allow the workflows service account to run Dataproc batches as the data-plane service account
+resource "google_service_account_iam_member" "workflows_sa_dataproc_user" {
+  service_account_id = google_service_account.dataproc_service_account.name
+  role               = "roles/iam.serviceAccountUser"
+  member             = "serviceAccount:${google_service_account.workflows_sa.email}"
+}
+
 # Workflow to copy data from prod GCS bucket to private buckets
+# This workflow writes data, so it runs as the data-plane service account.
 # NOTE: google_storage_bucket.<bucket>.name omits the `gs://` prefix.
 # You can use google_storage_bucket.<bucket>.url to include the prefix.
 resource "google_workflows_workflow" "copy_data" {
@@ -64,16 +77,19 @@ resource "google_workflows_workflow" "copy_data" {
   project         = module.project-services.project_id
   region          = var.region
   description     = "Copies data and performs project setup"
-  service_account = google_service_account.workflows_sa.email
+  service_account = google_service_account.dataproc_service_account.email
+  labels          = var.labels
   source_contents = templatefile("${path.module}/src/yaml/copy-data.yaml", {
     public_data_bucket    = var.public_data_bucket,
     textocr_images_bucket = google_storage_bucket.textocr_images_bucket.name,
     ga4_images_bucket     = google_storage_bucket.ga4_images_bucket.name,
-    tables_bucket         = google_storage_bucket.tables_bucket.name,
+    tables_bucket         = local.tables_bucket,
     dataplex_bucket       = google_storage_bucket.dataplex_bucket.name,
     images_zone_name      = google_dataplex_zone.gcp_primary_raw.name,
     tables_zone_name      = google_dataplex_zone.gcp_primary_staging.name,
-    lake_name             = google_dataplex_lake.gcp_primary.name
+    lake_name             = google_dataplex_lake.gcp_primary.name,
+    raw_data_format       = var.raw_data_format
+    sample_datasets       = jsonencode(var.sample_datasets)
   })
 
   depends_on = [
@@ -83,6 +99,11 @@ resource "google_workflows_workflow" "copy_data" {
 
 }
 
+# Delta Lake counterpart of the Iceberg table, written by the same batch
+locals {
+  delta_lake_uri = "gs://${local.warehouse_bucket}/delta/agg_events_delta"
+}
+
 # Workflow to set up project resources
 # Note: google_storage_bucket.<bucket>.name omits the `gs://` prefix.
 # You can use google_storage_bucket.<bucket>.url to include the prefix.
@@ -92,16 +113,56 @@ resource "google_workflows_workflow" "project_setup" {
   region          = var.region
   description     = "Copies data and performs project setup"
   service_account = google_service_account.workflows_sa.email
+  labels          = var.labels
   source_contents = templatefile("${path.module}/src/yaml/project-setup.yaml", {
     data_analyst_user         = google_service_account.data_analyst_user.email,
     marketing_user            = google_service_account.marketing_user.email,
     dataproc_service_account  = google_service_account.dataproc_service_account.email,
+    subnetwork                = local.subnetwork,
+    dataproc_network_tag      = local.dataproc_network_tag,
     provisioner_bucket        = google_storage_bucket.provisioning_bucket.name,
-    warehouse_bucket          = google_storage_bucket.warehouse_bucket.name,
-    temp_bucket               = google_storage_bucket.warehouse_bucket.name,
+    warehouse_bucket          = local.warehouse_bucket,
+    temp_bucket               = local.warehouse_bucket,
     dataplex_asset_tables_id  = "projects/${module.project-services.project_id}/locations/${var.region}/lakes/gcp-primary-lake/zones/gcp-primary-staging/assets/gcp-primary-tables"
     dataplex_asset_textocr_id = "projects/${module.project-services.project_id}/locations/${var.region}/lakes/gcp-primary-lake/zones/gcp-primary-raw/assets/gcp-primary-textocr"
     dataplex_asset_ga4_id     = "projects/${module.project-services.project_id}/locations/${var.region}/lakes/gcp-primary-lake/zones/gcp-primary-raw/assets/gcp-primary-ga4-obfuscated-sample-ecommerce"
+    enable_data_attributes    = var.enable_data_attributes
+    enable_aspect_types       = var.enable_aspect_types
+    enable_glossary           = var.enable_glossary
+    enable_access_layer       = var.enable_access_layer
+    enable_dataflow_load      = var.enable_dataflow_load
+    enable_dataform           = var.enable_dataform
+    dataform_repository       = var.enable_dataform ? google_dataform_repository.lakehouse[0].id : ""
+    dataform_files            = jsonencode(local.dataform_files)
+    enable_continuous_query   = local.enable_continuous_query
+    continuous_query          = jsonencode(file("${path.module}/src/sql/continuous_query.sql"))
+    enable_remote_function    = var.enable_remote_function
+    remote_function_ddl       = jsonencode(local.remote_function_ddl)
+    enable_image_inference    = var.enable_image_inference
+    image_inference_sql       = jsonencode(local.image_inference_sql)
+    enable_vector_search      = var.enable_vector_search
+    vector_search_sql         = jsonencode(local.vector_search_sql)
+    enable_forecasting        = var.enable_forecasting
+    forecast_sql              = jsonencode(file("${path.module}/src/sql/taxi_forecast.sql"))
+    enable_materialized_views = var.enable_materialized_views
+    materialized_views_sql    = jsonencode(file("${path.module}/src/sql/materialized_views.sql"))
+    enable_search_index       = var.enable_search_index
+    search_index_sql          = jsonencode(file("${path.module}/src/sql/search_index.sql"))
+    enable_snapshots          = var.enable_snapshots
+    table_snapshot_sql        = jsonencode(file("${path.module}/src/sql/table_snapshot.sql"))
+    enable_dlp_scan           = var.enable_dlp_scan
+    dlp_scan_sql              = jsonencode(file("${path.module}/src/sql/dlp_scan.sql"))
+    dlp_job_triggers          = jsonencode([for trigger in google_data_loss_prevention_job_trigger.lakehouse : trigger.id])
+    enable_dlp_deidentify     = var.enable_dlp_deidentify
+    dlp_deidentify_template   = var.enable_dlp_deidentify ? google_data_loss_prevention_deidentify_template.users[0].id : ""
+    dlp_users_table           = "${local.lakehouse_dataset_id}.${local.dlp_deidentified_users_table}"
+    enable_dataproc           = var.enable_dataproc
+    enable_delta_lake         = local.enable_delta_lake
+    delta_lake_uri            = local.delta_lake_uri
+    delta_lake_sql            = jsonencode(templatefile("${path.module}/src/sql/delta_lake.sql", { region = var.region, delta_lake_uri = local.delta_lake_uri }))
+    spark_packages            = join(",", concat(["org.apache.iceberg:iceberg-spark-runtime-3.3_2.13:1.2.1"], local.enable_delta_lake ? ["io.delta:delta-core_2.13:2.3.0"] : []))
+    enable_notebook           = var.enable_notebook
+    notebook_runtime_template = local.notebook_runtime_template_id
   })
   # Note: using the asset_id values below in project_setup config threw an IAM error when executing. Unsure why.
   # dataplex_asset_tables_id  = google_dataplex_asset.gcp_primary_tables.id,
@@ -109,7 +170,25 @@ resource "google_workflows_workflow" "project_setup" {
   # dataplex_asset_ga4_id     = google_dataplex_asset.gcp_primary_ga4_obfuscated_sample_ecommerce.id
   depends_on = [
     google_project_iam_member.workflows_sa_roles,
-    google_project_iam_member.dataproc_sa_roles
+    google_project_iam_member.dataproc_sa_roles,
+    google_bigquery_dataset_iam_member.workflows_sa_views,
+    google_bigquery_dataset_iam_member.workflows_sa_access_views,
+    google_service_account_iam_member.workflows_sa_dataproc_user,
+    google_compute_subnetwork_iam_member.shared_vpc_network_users,
+    google_dataform_repository_iam_member.workflows_sa_editor,
+    google_project_iam_member.dataform_job_user,
+    google_bigquery_dataset_iam_member.dataform_staging_viewer,
+    google_bigquery_dataset_iam_member.dataform_curated_editor,
+    google_bigquery_reservation_assignment.continuous,
+    google_bigquery_table.events_by_source,
+    google_bigquery_table_iam_member.workflows_sa_stream_reader,
+    google_bigquery_connection_iam_member.workflows_sa_function_connection,
+    google_cloud_run_service_iam_member.function_invoker,
+    google_project_iam_member.vertex_connection_user,
+    google_bigquery_connection_iam_member.workflows_sa_inference_connections,
+    google_bigquery_dataset_iam_member.workflows_sa_snapshots,
+    google_bigquery_reservation_assignment.background,
+    google_storage_bucket_object.deidentify_users_file
   ]
 
 }
@@ -121,6 +200,8 @@ data "google_client_config" "current" {
 
 # # execute the copy data workflow
 data "http" "call_workflows_copy_data" {
+  count = var.execute_workflows ? 1 : 0
+
   url    = "https://workflowexecutions.googleapis.com/v1/projects/${module.project-services.project_id}/locations/${var.region}/workflows/${google_workflows_workflow.copy_data.name}/executions"
   method = "POST"
   request_headers = {
@@ -129,7 +210,9 @@ data "http" "call_workflows_copy_data" {
   depends_on = [
     google_storage_bucket.textocr_images_bucket,
     google_storage_bucket.ga4_images_bucket,
-    google_storage_bucket.tables_bucket
+    google_storage_bucket.tables_bucket,
+    google_project_iam_audit_config.data_access,
+    google_bigquery_dataset_iam_member.ops_sink_writer
   ]
 }
 
@@ -143,6 +226,8 @@ resource "time_sleep" "wait_after_copy_data" {
 
 # execute the other project setup workflow
 data "http" "call_workflows_project_setup" {
+  count = var.execute_workflows ? 1 : 0
+
   url    = "https://workflowexecutions.googleapis.com/v1/projects/${module.project-services.project_id}/locations/${var.region}/workflows/${google_workflows_workflow.project_setup.name}/executions"
   method = "POST"
   request_headers = {
@@ -154,13 +239,13 @@ data "http" "call_workflows_project_setup" {
     google_dataplex_asset.gcp_primary_ga4_obfuscated_sample_ecommerce,
     google_dataplex_asset.gcp_primary_tables,
     google_dataplex_asset.gcp_primary_textocr,
-    google_project_iam_member.connectionPermissionGrant,
-    google_project_iam_member.connectionPermissionGrant,
+    google_storage_bucket_iam_member.connectionPermissionGrant,
     google_project_iam_member.dataproc_sa_roles,
     google_service_account.dataproc_service_account,
     # google_storage_bucket.temp_bucket,
     google_storage_bucket.provisioning_bucket,
     google_storage_bucket.warehouse_bucket,
+    google_storage_bucket_object.dataflow_files,
     time_sleep.wait_after_copy_data
   ]
 }
@@ -180,7 +265,9 @@ resource "time_sleep" "wait_after_all_workflows" {
 # Stop the PHS cluster after creation since it costs too much.
 # tflint-ignore: terraform_unused_declarations
 data "http" "call_stop_cluster" {
-  url    = "https://dataproc.googleapis.com/v1/projects/${module.project-services.project_id}/regions/${var.region}/clusters/${google_dataproc_cluster.phs.name}:stop"
+  count = var.enable_dataproc ? 1 : 0
+
+  url    = "https://dataproc.googleapis.com/v1/projects/${module.project-services.project_id}/regions/${var.region}/clusters/${google_dataproc_cluster.phs[0].name}:stop"
   method = "POST"
   request_headers = {
     Accept = "application/json"